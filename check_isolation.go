@@ -0,0 +1,419 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/topology"
+	"github.com/urfave/cli"
+)
+
+var checkIsolationCommand = cli.Command{
+	Name:  "check-isolation",
+	Usage: "check whether the CPUs of a container are isolated",
+	ArgsUsage: `<container-id>|<bundle>
+
+Where "<container-id>" is the name of an existing container, and "<bundle>"
+is the path to a bundle directory containing a config.json file.
+
+The following checks are performed:
+
+  isolated   the requested cpuset CPUs are isolated (isolcpus=)
+  nohz_full  the requested cpuset CPUs are in adaptive-tick mode (nohz_full=)
+  siblings   the requested cpuset CPUs do not share cores with other CPUs
+             (hardware threads)
+  exclusive  the requested cpuset CPUs are not used by an exclusive cpuset
+             of another container
+  rt-budget  there is enough real-time bandwidth for the requested budget
+
+The command fails if any check fails.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: `select one of: ` + formatOptions,
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		format := context.String("format")
+		if format != "table" && format != "json" {
+			return errors.New("invalid format option")
+		}
+		t, err := getIsolationTarget(context.GlobalString("root"), context.Args().First())
+		if err != nil {
+			return err
+		}
+		res := checkIsolation(context.GlobalString("root"), t)
+
+		switch format {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
+			fmt.Fprint(w, "CHECK\tSTATUS\tMESSAGE\n")
+			for _, c := range res.Checks {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, c.Status, c.Message)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		case "json":
+			if err := json.NewEncoder(os.Stdout).Encode(res); err != nil {
+				return err
+			}
+		}
+		if !res.Passed {
+			return errors.New("isolation check failed")
+		}
+		return nil
+	},
+}
+
+// isolationTarget is what is being checked by check-isolation.
+type isolationTarget struct {
+	// id is the container ID, or empty for a bundle.
+	id        string
+	cpus      string
+	rtRuntime int64
+	rtPeriod  uint64
+	// cpuCgroup is the cgroup v1 cpu controller directory of the
+	// container, which may not exist.
+	cpuCgroup string
+}
+
+type isolationCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // "pass", "fail", or "skip".
+	Message string `json:"message,omitempty"`
+}
+
+type isolationResult struct {
+	CPUs   string           `json:"cpus"`
+	Passed bool             `json:"passed"`
+	Checks []isolationCheck `json:"checks"`
+}
+
+func getIsolationTarget(root, arg string) (*isolationTarget, error) {
+	if c, err := libcontainer.Load(root, arg); err == nil {
+		r := c.Config().Cgroups.Resources
+		t := &isolationTarget{
+			id:        arg,
+			cpus:      r.CpusetCpus,
+			rtRuntime: r.CpuRtRuntime,
+			rtPeriod:  r.CpuRtPeriod,
+		}
+		if state, err := c.State(); err == nil {
+			t.cpuCgroup = state.CgroupPaths["cpu"]
+		}
+		return t, nil
+	} else if !errors.Is(err, libcontainer.ErrNotExist) && !errors.Is(err, libcontainer.ErrInvalidID) {
+		return nil, err
+	}
+
+	if _, err := os.Stat(filepath.Join(arg, specConfig)); err != nil {
+		return nil, fmt.Errorf("%s is neither a container nor a bundle", arg)
+	}
+	spec, err := loadSpec(filepath.Join(arg, specConfig))
+	if err != nil {
+		return nil, err
+	}
+	t := &isolationTarget{}
+	if spec.Linux == nil {
+		return t, nil
+	}
+	if r := spec.Linux.Resources; r != nil && r.CPU != nil {
+		t.cpus = r.CPU.Cpus
+		if r.CPU.RealtimeRuntime != nil {
+			t.rtRuntime = *r.CPU.RealtimeRuntime
+		}
+		if r.CPU.RealtimePeriod != nil {
+			t.rtPeriod = *r.CPU.RealtimePeriod
+		}
+	}
+	// Only a cgroupfs path can be resolved without creating the container.
+	if p := spec.Linux.CgroupsPath; filepath.IsAbs(p) && !cgroups.IsCgroup2UnifiedMode() {
+		if mnt, err := cgroups.FindCgroupMountpoint("", "cpu"); err == nil {
+			t.cpuCgroup = filepath.Join(mnt, p)
+		}
+	}
+	return t, nil
+}
+
+func checkIsolation(root string, t *isolationTarget) *isolationResult {
+	res := &isolationResult{CPUs: t.cpus, Passed: true}
+	cpus, err := cgroups.ParseCPUList(t.cpus)
+	if err != nil {
+		res.Checks = append(res.Checks, isolationCheck{Name: "cpus", Status: "fail", Message: err.Error()})
+		res.Passed = false
+		return res
+	}
+	for _, check := range []isolationCheck{
+		checkCPUsIn(cpus, "isolated", "/sys/devices/system/cpu/isolated"),
+		checkCPUsIn(cpus, "nohz_full", "/sys/devices/system/cpu/nohz_full"),
+		checkSiblings(cpus),
+		checkExclusiveCPUs(root, t.id, cpus),
+		checkRtBudget(t),
+	} {
+		if check.Status == "fail" {
+			res.Passed = false
+		}
+		res.Checks = append(res.Checks, check)
+	}
+	return res
+}
+
+// checkCPUsIn checks that cpus are all in the list of CPUs in file.
+func checkCPUsIn(cpus []int, name, file string) isolationCheck {
+	c := isolationCheck{Name: name, Status: "fail"}
+	if len(cpus) == 0 {
+		c.Message = "no cpuset cpus requested"
+		return c
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		c.Message = err.Error()
+		return c
+	}
+	list, err := cgroups.ParseCPUList(string(data))
+	if err != nil {
+		c.Message = err.Error()
+		return c
+	}
+	set := make(map[int]struct{}, len(list))
+	for _, cpu := range list {
+		set[cpu] = struct{}{}
+	}
+	var missing []int
+	for _, cpu := range cpus {
+		if _, ok := set[cpu]; !ok {
+			missing = append(missing, cpu)
+		}
+	}
+	if len(missing) > 0 {
+		c.Message = fmt.Sprintf("cpus %s are not in %s (%q)", cgroups.FormatCPUList(missing), name, cgroups.FormatCPUList(list))
+		return c
+	}
+	c.Status = "pass"
+	return c
+}
+
+// checkSiblings checks that cpus are whole cores, that is, they do not share
+// a core with CPUs which may be used by other workloads.
+func checkSiblings(cpus []int) isolationCheck {
+	c := isolationCheck{Name: "siblings", Status: "fail"}
+	if len(cpus) == 0 {
+		c.Status, c.Message = "skip", "no cpuset cpus requested"
+		return c
+	}
+	topo, err := topology.Discover()
+	if err != nil {
+		c.Message = err.Error()
+		return c
+	}
+	if foreign := topo.ForeignSiblings(cpus); len(foreign) > 0 {
+		c.Message = fmt.Sprintf("cpus share cores with cpus %s", foreign)
+		return c
+	}
+	c.Status = "pass"
+	return c
+}
+
+// exclusiveCPUs returns the CPUs which are exclusively used by the cgroup at
+// path (a cpuset cgroup v1 directory, or a cgroup v2 directory), if any.
+func exclusiveCPUs(path string) ([]int, error) {
+	if !cgroups.IsCgroup2UnifiedMode() {
+		if excl, err := cgroups.ReadFile(path, "cpuset.cpu_exclusive"); err != nil || strings.TrimSpace(excl) != "1" {
+			return nil, err
+		}
+		cpus, err := cgroups.ReadFile(path, "cpuset.cpus")
+		if err != nil {
+			return nil, err
+		}
+		return cgroups.ParseCPUList(cpus)
+	}
+	// A partition root (or an isolated partition) has its effective CPUs
+	// used exclusively.
+	if part, err := cgroups.ReadFile(path, "cpuset.cpus.partition"); err == nil {
+		if p := strings.Fields(part); len(p) > 0 && (p[0] == "root" || p[0] == "isolated") {
+			cpus, err := cgroups.ReadFile(path, "cpuset.cpus.effective")
+			if err != nil {
+				return nil, err
+			}
+			return cgroups.ParseCPUList(cpus)
+		}
+	}
+	cpus, err := cgroups.ReadFile(path, "cpuset.cpus.exclusive")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return nil, err
+	}
+	return cgroups.ParseCPUList(cpus)
+}
+
+// checkExclusiveCPUs checks that none of cpus are used by an exclusive
+// cpuset of another running container.
+func checkExclusiveCPUs(root, id string, cpus []int) isolationCheck {
+	c := isolationCheck{Name: "exclusive", Status: "skip"}
+	if len(cpus) == 0 {
+		c.Message = "no cpuset cpus requested"
+		return c
+	}
+	set := make(map[int]struct{}, len(cpus))
+	for _, cpu := range cpus {
+		set[cpu] = struct{}{}
+	}
+	list, err := os.ReadDir(root)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		c.Status, c.Message = "fail", err.Error()
+		return c
+	}
+	var conflicts []string
+	for _, item := range list {
+		if !item.IsDir() || item.Name() == id {
+			continue
+		}
+		ct, err := libcontainer.Load(root, item.Name())
+		if err != nil {
+			continue
+		}
+		if status, err := ct.Status(); err != nil || status == libcontainer.Stopped {
+			continue
+		}
+		state, err := ct.State()
+		if err != nil {
+			continue
+		}
+		path := state.CgroupPaths["cpuset"]
+		if cgroups.IsCgroup2UnifiedMode() {
+			path = state.CgroupPaths[""]
+		}
+		if path == "" {
+			continue
+		}
+		excl, err := exclusiveCPUs(path)
+		if err != nil {
+			c.Status, c.Message = "fail", fmt.Sprintf("container %s: %v", item.Name(), err)
+			return c
+		}
+		var overlap []int
+		for _, cpu := range excl {
+			if _, ok := set[cpu]; ok {
+				overlap = append(overlap, cpu)
+			}
+		}
+		if len(overlap) > 0 {
+			conflicts = append(conflicts, item.Name()+" ("+cgroups.FormatCPUList(overlap)+")")
+		}
+	}
+	if len(conflicts) > 0 {
+		c.Status, c.Message = "fail", "cpus are used exclusively by "+strings.Join(conflicts, ", ")
+		return c
+	}
+	c.Status = "pass"
+	return c
+}
+
+// rtBandwidth returns the real-time bandwidth of the cgroup v1 cpu
+// controller directory, as a fraction of the CPU time, or -1 if unlimited.
+func rtBandwidth(path string) (float64, error) {
+	runtime, err := readInt(path, "cpu.rt_runtime_us")
+	if err != nil {
+		return 0, err
+	}
+	if runtime < 0 {
+		return -1, nil
+	}
+	period, err := readInt(path, "cpu.rt_period_us")
+	if err != nil {
+		return 0, err
+	}
+	if period <= 0 {
+		return 0, fmt.Errorf("%s: invalid cpu.rt_period_us %d", path, period)
+	}
+	return float64(runtime) / float64(period), nil
+}
+
+func readInt(dir, file string) (int64, error) {
+	s, err := cgroups.ReadFile(dir, file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+}
+
+// checkRtBudget checks that the real-time budget of the container fits in
+// the real-time bandwidth of its parent cgroup which is not used by the
+// other child cgroups.
+func checkRtBudget(t *isolationTarget) isolationCheck {
+	c := isolationCheck{Name: "rt-budget", Status: "fail"}
+	if t.rtRuntime == 0 {
+		c.Status, c.Message = "skip", "no real-time budget requested"
+		return c
+	}
+	if cgroups.IsCgroup2UnifiedMode() {
+		c.Message = "real-time group scheduling is not supported with cgroup v2"
+		return c
+	}
+	if t.rtRuntime < 0 {
+		c.Message = "unlimited real-time runtime requested"
+		return c
+	}
+	parent, err := cgroups.FindCgroupMountpoint("", "cpu")
+	if err != nil {
+		c.Message = err.Error()
+		return c
+	}
+	if t.cpuCgroup != "" {
+		parent = filepath.Dir(t.cpuCgroup)
+	}
+	period := int64(t.rtPeriod)
+	if period == 0 {
+		if period, err = readInt(parent, "cpu.rt_period_us"); err != nil {
+			c.Message = err.Error()
+			return c
+		}
+	}
+	need := float64(t.rtRuntime) / float64(period)
+
+	avail, err := rtBandwidth(parent)
+	if err != nil {
+		c.Message = err.Error()
+		return c
+	}
+	if avail >= 0 {
+		children, err := os.ReadDir(parent)
+		if err != nil {
+			c.Message = err.Error()
+			return c
+		}
+		for _, child := range children {
+			dir := filepath.Join(parent, child.Name())
+			if !child.IsDir() || dir == t.cpuCgroup {
+				continue
+			}
+			used, err := rtBandwidth(dir)
+			if err != nil {
+				c.Message = err.Error()
+				return c
+			}
+			avail -= used
+		}
+		if need > avail {
+			c.Message = fmt.Sprintf("requested %.2f%% of CPU time, only %.2f%% available in %s", need*100, max(avail, 0)*100, parent)
+			return c
+		}
+	}
+	c.Status, c.Message = "pass", fmt.Sprintf("requested %.2f%% of CPU time", need*100)
+	return c
+}
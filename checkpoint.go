@@ -11,6 +11,7 @@ import (
 	criu "github.com/checkpoint-restore/go-criu/v6/rpc"
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/userns"
+	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -31,6 +32,8 @@ checkpointed.`,
 		cli.StringFlag{Name: "parent-path", Value: "", Usage: "path for previous criu image files in pre-dump"},
 		cli.BoolFlag{Name: "leave-running", Usage: "leave the process running after checkpointing"},
 		cli.BoolFlag{Name: "tcp-established", Usage: "allow open tcp connections"},
+		cli.BoolFlag{Name: "tcp-close", Usage: "don't dump the state of open tcp connections"},
+		cli.BoolFlag{Name: "tcp-skip-in-flight", Usage: "skip in-flight tcp connections"},
 		cli.BoolFlag{Name: "ext-unix-sk", Usage: "allow external unix sockets"},
 		cli.BoolFlag{Name: "shell-job", Usage: "allow shell jobs"},
 		cli.BoolFlag{Name: "lazy-pages", Usage: "use userfaultfd to lazily restore memory pages"},
@@ -41,6 +44,7 @@ checkpointed.`,
 		cli.StringFlag{Name: "manage-cgroups-mode", Value: "", Usage: "cgroups mode: soft|full|strict|ignore (default: soft)"},
 		cli.StringSliceFlag{Name: "empty-ns", Usage: "create a namespace, but don't restore its properties"},
 		cli.BoolFlag{Name: "auto-dedup", Usage: "enable auto deduplication of memory images"},
+		cli.BoolFlag{Name: "feature-check", Usage: "only check that criu supports what is needed to checkpoint the container with the given options"},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
@@ -66,6 +70,14 @@ checkpointed.`,
 		if err != nil {
 			return err
 		}
+		_, annotations := utils.Annotations(container.Config().Labels)
+		if err := setCriuAnnotationDefaults(context, options, annotations); err != nil {
+			return err
+		}
+
+		if context.Bool("feature-check") {
+			return criuFeatureCheck(container, options)
+		}
 
 		err = container.Checkpoint(options)
 		if err == nil && !(options.LeaveRunning || options.PreDump) {
@@ -78,14 +90,39 @@ checkpointed.`,
 	},
 }
 
+// criuFeatureCheck reports what won't work when checkpointing the container
+// with the given options, and fails if the checkpoint is expected to fail.
+func criuFeatureCheck(container *libcontainer.Container, options *libcontainer.CriuOpts) error {
+	check, err := container.CheckCriu(options)
+	if err != nil {
+		return err
+	}
+	if check.Version != 0 {
+		fmt.Printf("criu version: %s\n", libcontainer.FormatCriuVersion(check.Version))
+	}
+	for _, u := range check.Unsupported {
+		fmt.Printf("unsupported: %s\n", u)
+	}
+	for _, w := range check.Warnings {
+		fmt.Printf("warning: %s\n", w)
+	}
+	if !check.OK() {
+		return errors.New("the container can't be checkpointed with these options")
+	}
+	return nil
+}
+
 func prepareImagePaths(context *cli.Context) (string, string, error) {
 	imagePath := context.String("image-path")
 	if imagePath == "" {
 		imagePath = getDefaultImagePath()
 	}
 
-	if err := os.MkdirAll(imagePath, 0o600); err != nil {
-		return "", "", err
+	// Nothing is written by a feature check.
+	if !context.Bool("feature-check") {
+		if err := os.MkdirAll(imagePath, 0o600); err != nil {
+			return "", "", err
+		}
 	}
 
 	parentPath := context.String("parent-path")
@@ -122,6 +159,8 @@ func criuOptions(context *cli.Context) (*libcontainer.CriuOpts, error) {
 		ParentImage:             parentPath,
 		LeaveRunning:            context.Bool("leave-running"),
 		TcpEstablished:          context.Bool("tcp-established"),
+		TcpClose:                context.Bool("tcp-close"),
+		TcpSkipInFlight:         context.Bool("tcp-skip-in-flight"),
 		ExternalUnixConnections: context.Bool("ext-unix-sk"),
 		ShellJob:                context.Bool("shell-job"),
 		FileLocks:               context.Bool("file-locks"),
@@ -187,3 +226,31 @@ func criuOptions(context *cli.Context) (*libcontainer.CriuOpts, error) {
 
 	return opts, nil
 }
+
+// criuAnnotationOptions are the boolean CRIU options which can have
+// per-container defaults set using annotations.
+var criuAnnotationOptions = []struct {
+	annotation, flag string
+	opt              func(*libcontainer.CriuOpts) *bool
+}{
+	{"org.criu.tcp-established", "tcp-established", func(o *libcontainer.CriuOpts) *bool { return &o.TcpEstablished }},
+	{"org.criu.tcp-close", "tcp-close", func(o *libcontainer.CriuOpts) *bool { return &o.TcpClose }},
+	{"org.criu.tcp-skip-in-flight", "tcp-skip-in-flight", func(o *libcontainer.CriuOpts) *bool { return &o.TcpSkipInFlight }},
+}
+
+// setCriuAnnotationDefaults sets the CRIU options not specified on the
+// command line from the container annotations.
+func setCriuAnnotationDefaults(context *cli.Context, opts *libcontainer.CriuOpts, annotations map[string]string) error {
+	for _, o := range criuAnnotationOptions {
+		v, ok := annotations[o.annotation]
+		if !ok || context.IsSet(o.flag) {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid annotation %s=%s: %w", o.annotation, v, err)
+		}
+		*o.opt(opts) = b
+	}
+	return nil
+}
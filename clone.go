@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	criu "github.com/checkpoint-restore/go-criu/v6/rpc"
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/utils"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+)
+
+var cloneCommand = cli.Command{
+	Name:  "clone",
+	Usage: "start a copy of a running container",
+	ArgsUsage: `<container-id> <new-id>
+
+Where "<container-id>" is the name of the running container instance to be
+cloned, and "<new-id>" is the name for the new container instance.`,
+	Description: `The clone command checkpoints a running container, leaving it running, and
+immediately restores the checkpoint as a new container, e.g. for a fast
+scale-out of a warmed-up service.
+
+The new container is restored from the bundle of the cloned container, or
+from the one given with --bundle. Its cgroup is named after <new-id>, and it
+gets a new network namespace, which is to be set up by the prestart and
+createRuntime hooks of the spec, run with the state of the new container.
+Established TCP connections are restored closed in the new container.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "bundle, b",
+			Value: "",
+			Usage: "path to the root of the bundle directory of the new container, defaults to the bundle of the cloned container",
+		},
+		cli.StringFlag{
+			Name:  "image-path",
+			Value: "",
+			Usage: "path for the criu image files, defaults to a temporary directory removed once the new container is restored",
+		},
+		cli.StringFlag{
+			Name:  "work-path",
+			Value: "",
+			Usage: "path for saving work files and logs",
+		},
+		cli.StringFlag{
+			Name:  "hostname",
+			Value: "",
+			Usage: "set the hostname of the new container",
+		},
+		cli.StringFlag{
+			Name:  "cgroup-root",
+			Value: "",
+			Usage: "create the new container cgroup under this parent cgroup (or, with systemd, slice) instead of the one in the spec",
+		},
+		cli.StringFlag{
+			Name:  "console-socket",
+			Value: "",
+			Usage: "path to an AF_UNIX socket which will receive a file descriptor referencing the master end of the console's pseudoterminal",
+		},
+		cli.BoolFlag{
+			Name:  "detach, d",
+			Usage: "detach from the new container's process",
+		},
+		cli.StringFlag{
+			Name:  "pid-file",
+			Value: "",
+			Usage: "specify the file to write the process id of the new container to",
+		},
+		cli.BoolFlag{
+			Name:  "ext-unix-sk",
+			Usage: "allow external unix sockets",
+		},
+		cli.BoolFlag{
+			Name:  "shell-job",
+			Usage: "allow shell jobs",
+		},
+		cli.BoolFlag{
+			Name:  "file-locks",
+			Usage: "handle file locks",
+		},
+		cli.BoolFlag{
+			Name:  "no-subreaper",
+			Usage: "disable the use of the subreaper used to reap reparented processes",
+		},
+		cli.BoolFlag{
+			Name:  "no-pivot",
+			Usage: "do not use pivot root to jail process inside rootfs.  This should be used whenever the rootfs is on top of a ramdisk",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 2, exactArgs); err != nil {
+			return err
+		}
+		status, err := cloneContainer(context)
+		if err != nil {
+			return fmt.Errorf("runc clone failed: %w", err)
+		}
+		// exit with the container's exit status so any external supervisor is
+		// notified of the exit with the correct exit status.
+		os.Exit(status)
+		return nil
+	},
+}
+
+func cloneContainer(context *cli.Context) (int, error) {
+	id := context.Args().Get(1)
+	if id == "" {
+		return -1, errEmptyID
+	}
+	if err := revisePidFile(context); err != nil {
+		return -1, err
+	}
+	src, err := getContainer(context)
+	if err != nil {
+		return -1, err
+	}
+	status, err := src.Status()
+	if err != nil {
+		return -1, err
+	}
+	if status == libcontainer.Created || status == libcontainer.Stopped {
+		return -1, fmt.Errorf("container cannot be cloned in %s state", status)
+	}
+	root := context.GlobalString("root")
+	if _, err := libcontainer.Load(root, id); err == nil {
+		return -1, libcontainer.ErrExist
+	} else if !errors.Is(err, libcontainer.ErrNotExist) {
+		return -1, err
+	}
+
+	srcConfig := src.Config()
+	bundle := context.String("bundle")
+	if bundle == "" {
+		var ok bool
+		bundle, ok = utils.SearchLabels(srcConfig.Labels, "bundle")
+		if !ok {
+			return -1, errors.New("bundle not found in labels")
+		}
+	}
+	if err := os.Chdir(bundle); err != nil {
+		return -1, err
+	}
+	spec, err := loadSpec(specConfig)
+	if err != nil {
+		return -1, err
+	}
+	if err := setupCloneSpec(context, spec, &srcConfig, id); err != nil {
+		return -1, err
+	}
+
+	imagePath := context.String("image-path")
+	if imagePath == "" {
+		imagePath, err = os.MkdirTemp("", "runc-clone-")
+		if err != nil {
+			return -1, err
+		}
+		defer os.RemoveAll(imagePath)
+	} else if err := os.MkdirAll(imagePath, 0o600); err != nil {
+		return -1, err
+	}
+	opts := &libcontainer.CriuOpts{
+		ImagesDirectory:         imagePath,
+		WorkDirectory:           context.String("work-path"),
+		LeaveRunning:            true,
+		TcpClose:                true,
+		ExternalUnixConnections: context.Bool("ext-unix-sk"),
+		ShellJob:                context.Bool("shell-job"),
+		FileLocks:               context.Bool("file-locks"),
+		// The new container has its own cgroup, so the cgroups
+		// recorded in the images are not to be restored.
+		ManageCgroupsMode: criu.CriuCgMode_IGNORE,
+		// runc doesn't manage network devices and their configuration.
+		EmptyNs:  unix.CLONE_NEWNET,
+		Hostname: context.String("hostname"),
+	}
+	if err := src.Checkpoint(opts); err != nil {
+		return -1, fmt.Errorf("checkpoint of %s: %w", src.ID(), err)
+	}
+
+	container, err := createContainer(context, id, spec)
+	if err != nil {
+		return -1, err
+	}
+	r := &runner{
+		enableSubreaper: !context.Bool("no-subreaper"),
+		shouldDestroy:   true,
+		container:       container,
+		consoleSocket:   context.String("console-socket"),
+		detach:          context.Bool("detach"),
+		pidFile:         context.String("pid-file"),
+		action:          CT_ACT_RESTORE,
+		criuOpts:        opts,
+		init:            true,
+	}
+	return r.run(spec.Process)
+}
+
+// setupCloneSpec adjusts the spec of the new container with the given id,
+// cloned from a container with the given config, so that the two don't share
+// a cgroup or a network namespace.
+func setupCloneSpec(context *cli.Context, spec *specs.Spec, srcConfig *configs.Config, id string) error {
+	if spec.Linux == nil {
+		return errors.New("linux section must not be empty")
+	}
+	spec.Linux.CgroupsPath = cloneCgroupsPath(spec.Linux.CgroupsPath, id, context.GlobalBool("systemd-cgroup"))
+
+	var hasUTS bool
+	for _, ns := range spec.Linux.Namespaces {
+		switch ns.Type {
+		case specs.NetworkNamespace:
+			if ns.Path != "" && ns.Path == srcConfig.Namespaces.PathOf(configs.NEWNET) {
+				return fmt.Errorf("the new container would share the network namespace %s, use a bundle with another one", ns.Path)
+			}
+		case specs.UTSNamespace:
+			hasUTS = ns.Path == ""
+		}
+	}
+	if hostname := context.String("hostname"); hostname != "" {
+		if !hasUTS {
+			return errors.New("unable to set the hostname without a private UTS namespace")
+		}
+		spec.Hostname = hostname
+	}
+	return nil
+}
+
+// cloneCgroupsPath returns the cgroups path of a container cloned from one
+// with the given path: its last element (or, with systemd, its name) is
+// replaced with the id of the new container. An empty path is kept, as the
+// default one is already derived from the container id.
+func cloneCgroupsPath(path, id string, systemd bool) string {
+	if path == "" {
+		return ""
+	}
+	if systemd {
+		parts := strings.Split(path, ":")
+		if len(parts) != 3 {
+			// Invalid, left for specconv to report.
+			return path
+		}
+		parts[2] = id
+		return strings.Join(parts, ":")
+	}
+	return filepath.Join(filepath.Dir(path), id)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var cpuRescanCommand = cli.Command{
+	Name:  "cpu-rescan",
+	Usage: "re-assert the cpusets and real-time budgets of containers after a CPU hotplug",
+	ArgsUsage: `[<container-id>...]
+
+Where "<container-id>" is the name for the instance of the container. If no
+container is given, all the running containers are rescanned.`,
+	Description: `The cpu-rescan command restores the cpusets and real-time budgets of
+containers after CPUs go offline and online again. With cgroup v1, the kernel
+drops the CPUs going offline from all the cpusets, but does not add them back
+once they are online, so the containers would otherwise be left running on
+fewer CPUs than requested.
+
+It is meant to be run on the CPU online events, e.g. by the udev rule:
+
+  SUBSYSTEM=="cpu", ACTION=="online", RUN+="/usr/bin/runc cpu-rescan"`,
+	Action: func(context *cli.Context) error {
+		root := context.GlobalString("root")
+		ids := context.Args()
+		all := len(ids) == 0
+		if all {
+			list, err := getContainers(context)
+			if err != nil {
+				return err
+			}
+			for _, c := range list {
+				ids = append(ids, c.ID)
+			}
+		}
+		var failed int
+		for _, id := range ids {
+			container, err := libcontainer.Load(root, id)
+			if err == nil {
+				err = container.RescanCPUs()
+			}
+			if err != nil {
+				if all && errors.Is(err, libcontainer.ErrNotRunning) {
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "container %s: %v\n", id, err)
+				failed++
+				continue
+			}
+			logrus.Debugf("rescanned the cpus of container %s", id)
+		}
+		if failed > 0 {
+			return fmt.Errorf("unable to rescan the cpus of %d container(s)", failed)
+		}
+		return nil
+	},
+}
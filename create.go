@@ -51,10 +51,34 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 			Name:  "no-new-keyring",
 			Usage: "do not create a new session keyring for the container.  This will cause the container to inherit the calling processes session key",
 		},
+		cli.BoolFlag{
+			Name:  "adjust-rt-bandwidth",
+			Usage: "raise the global real-time bandwidth (kernel.sched_rt_runtime_us) if the requested real-time runtime does not fit in it",
+		},
+		cli.BoolFlag{
+			Name:  "thaw-frozen-ancestors",
+			Usage: "thaw the ancestors of the container cgroup if they are frozen, rather than failing",
+		},
+		cli.BoolFlag{
+			Name:  "start-frozen",
+			Usage: "keep the container init frozen until start, once the cpuset and real-time budget are verified",
+		},
 		cli.IntFlag{
 			Name:  "preserve-fds",
 			Usage: "Pass N additional file descriptors to the container (stdio + $LISTEN_FDS + N in total)",
 		},
+		cli.StringSliceFlag{
+			Name:  "env-file",
+			Usage: "read more environment variables for the container process from a file (KEY=VALUE lines; can be specified multiple times)",
+		},
+		cli.IntSliceFlag{
+			Name:  "env-fd",
+			Usage: "read more environment variables for the container process from file descriptor FD, as with --env-file, and close it (can be specified multiple times)",
+		},
+		cli.StringSliceFlag{
+			Name:  "listen-fd",
+			Usage: "pass file descriptor FD to the container as a socket activation fd named NAME (NAME=FD; can be specified multiple times)",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
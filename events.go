@@ -5,16 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
+	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/opencontainers/runc/types"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
 )
 
 var eventsCommand = cli.Command{
@@ -28,6 +32,11 @@ information is displayed once every 5 seconds.`,
 	Flags: []cli.Flag{
 		cli.DurationFlag{Name: "interval", Value: 5 * time.Second, Usage: "set the stats collection interval"},
 		cli.BoolFlag{Name: "stats", Usage: "display the container's stats then exit"},
+		cli.BoolFlag{Name: "rates", Usage: "add the rates of change of the counters (such as cpu usage percentage) to the stats"},
+		cli.BoolFlag{Name: "reset-peaks", Usage: "reset the peak memory usage of the container first, so that the stats report the peak since then"},
+		cli.Float64Flag{Name: "fork-rate-limit", Usage: "briefly freeze the container whenever its number of tasks grows faster than this many per second"},
+		cli.DurationFlag{Name: "fork-check-interval", Value: libcontainer.DefaultForkCheckInterval, Usage: "set the interval over which the growth rate of the number of tasks is measured"},
+		cli.DurationFlag{Name: "fork-freeze-time", Value: libcontainer.DefaultForkFreezeTime, Usage: "set how long the container is frozen for when --fork-rate-limit is exceeded"},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
@@ -48,11 +57,27 @@ information is displayed once every 5 seconds.`,
 		if status == libcontainer.Stopped {
 			return fmt.Errorf("container with id %s is not running", container.ID())
 		}
+		forkRate := context.Float64("fork-rate-limit")
+		if forkRate < 0 {
+			return errors.New("fork rate limit must not be negative")
+		}
+		if forkRate > 0 && context.Bool("stats") {
+			return errors.New("--fork-rate-limit can not be used with --stats")
+		}
+		if context.Bool("reset-peaks") {
+			if err := container.ResetMemoryPeak(); err != nil {
+				return fmt.Errorf("unable to reset peak memory usage: %w", err)
+			}
+		}
 		var (
-			stats  = make(chan *libcontainer.Stats, 1)
+			stats  = make(chan *statsSample, 1)
 			events = make(chan *types.Event, 1024)
 			group  = &sync.WaitGroup{}
+			rates  *rateCalculator
 		)
+		if context.Bool("rates") {
+			rates = &rateCalculator{}
+		}
 		group.Add(1)
 		go func() {
 			defer group.Done()
@@ -64,18 +89,30 @@ information is displayed once every 5 seconds.`,
 			}
 		}()
 		if context.Bool("stats") {
-			s, err := container.Stats()
+			s, err := getStatsSample(container, rates != nil)
 			if err != nil {
 				return err
 			}
-			events <- &types.Event{Type: "stats", ID: container.ID(), Data: convertLibcontainerStats(s)}
+			if rates != nil {
+				// Rates need two samples.
+				rates.update(s)
+				time.Sleep(duration)
+				if s, err = getStatsSample(container, true); err != nil {
+					return err
+				}
+				rates.update(s)
+			}
+			events <- s.event
 			close(events)
 			group.Wait()
 			return nil
 		}
+		if timings := container.BootstrapTimings(); len(timings) > 0 {
+			events <- newEvent(container, "bootstrap", convertBootstrapTimings(timings))
+		}
 		go func() {
 			for range time.Tick(context.Duration("interval")) {
-				s, err := container.Stats()
+				s, err := getStatsSample(container, rates != nil)
 				if err != nil {
 					logrus.Error(err)
 					continue
@@ -87,6 +124,31 @@ information is displayed once every 5 seconds.`,
 		if err != nil {
 			return err
 		}
+		var (
+			stopPolicer = make(chan struct{})
+			policer     sync.WaitGroup
+		)
+		if forkRate > 0 {
+			policy := libcontainer.ForkPolicy{
+				MaxRate:    forkRate,
+				Interval:   context.Duration("fork-check-interval"),
+				FreezeTime: context.Duration("fork-freeze-time"),
+			}
+			policer.Add(1)
+			go func() {
+				defer policer.Done()
+				err := container.PoliceForks(policy, stopPolicer, func(t libcontainer.ForkThrottle) {
+					events <- newEvent(container, "fork-throttle", &types.ForkThrottle{
+						Rate:   t.Rate,
+						Tasks:  t.Tasks,
+						Frozen: uint64(t.Frozen.Nanoseconds()),
+					})
+				})
+				if err != nil {
+					logrus.Errorf("fork rate limiting: %v", err)
+				}
+			}()
+		}
 		for {
 			select {
 			case _, ok := <-n:
@@ -94,14 +156,19 @@ information is displayed once every 5 seconds.`,
 					// this means an oom event was received, if it is !ok then
 					// the channel was closed because the container stopped and
 					// the cgroups no longer exist.
-					events <- &types.Event{Type: "oom", ID: container.ID()}
+					events <- newEvent(container, "oom", nil)
 				} else {
 					n = nil
 				}
 			case s := <-stats:
-				events <- &types.Event{Type: "stats", ID: container.ID(), Data: convertLibcontainerStats(s)}
+				if rates != nil {
+					rates.update(s)
+				}
+				events <- s.event
 			}
 			if n == nil {
+				close(stopPolicer)
+				policer.Wait()
 				close(events)
 				break
 			}
@@ -111,6 +178,146 @@ information is displayed once every 5 seconds.`,
 	},
 }
 
+// statsSample is a stats event, along with the data which is only needed to
+// calculate the rates.
+type statsSample struct {
+	event *types.Event
+	// rtUsage is the CPU time consumed by the real-time threads, in
+	// nanoseconds.
+	rtUsage uint64
+}
+
+func getStatsSample(container *libcontainer.Container, withRates bool) (*statsSample, error) {
+	ls, err := container.Stats()
+	if err != nil {
+		return nil, err
+	}
+	s := &statsSample{event: newEvent(container, "stats", convertLibcontainerStats(ls))}
+	if withRates {
+		if s.rtUsage, err = realtimeUsage(container); err != nil {
+			logrus.Warnf("unable to get real-time cpu usage: %v", err)
+		}
+	}
+	return s, nil
+}
+
+// realtimeUsage returns the total CPU time consumed by the real-time
+// (SCHED_FIFO and SCHED_RR) threads of the container. Since the kernel does
+// not account for it per cgroup, the time is summed over the threads which
+// currently exist, so the time of the exited threads is not included.
+func realtimeUsage(container *libcontainer.Container) (uint64, error) {
+	pids, err := container.Processes()
+	if err != nil {
+		return 0, err
+	}
+	var total uint64
+	for _, pid := range pids {
+		taskDir := "/proc/" + strconv.Itoa(pid) + "/task"
+		tasks, err := os.ReadDir(taskDir)
+		if err != nil {
+			// The process has exited.
+			continue
+		}
+		for _, task := range tasks {
+			tid, err := strconv.Atoi(task.Name())
+			if err != nil {
+				continue
+			}
+			attr, err := unix.SchedGetAttr(tid, 0)
+			if err != nil || (attr.Policy != unix.SCHED_FIFO && attr.Policy != unix.SCHED_RR) {
+				continue
+			}
+			// The first field of schedstat is the time spent on the cpu.
+			data, err := os.ReadFile(taskDir + "/" + task.Name() + "/schedstat")
+			if err != nil {
+				continue
+			}
+			runtime, _, _ := strings.Cut(string(data), " ")
+			if v, err := strconv.ParseUint(runtime, 10, 64); err == nil {
+				total += v
+			}
+		}
+	}
+	return total, nil
+}
+
+// rateCalculator fills in the rates of the stats samples, using the
+// previous sample.
+type rateCalculator struct {
+	prev *statsSample
+}
+
+func (r *rateCalculator) update(s *statsSample) {
+	prev := r.prev
+	r.prev = s
+	if prev == nil {
+		return
+	}
+	cur, ok := s.event.Data.(*types.Stats)
+	if !ok || cur == nil {
+		return
+	}
+	old, ok := prev.event.Data.(*types.Stats)
+	if !ok || old == nil || s.event.Monotonic <= prev.event.Monotonic {
+		return
+	}
+	interval := uint64(s.event.Monotonic - prev.event.Monotonic)
+	// Calculates the rate, per second, of a counter. The counters may go
+	// backwards (e.g. when threads exit), which is reported as 0.
+	perSec := func(cur, old uint64) float64 {
+		if cur < old {
+			return 0
+		}
+		return float64(cur-old) * float64(time.Second) / float64(interval)
+	}
+	// Calculates the percentage of a CPU time counter (in nanoseconds).
+	percent := func(cur, old uint64) float64 {
+		return perSec(cur, old) * 100 / float64(time.Second)
+	}
+	cur.Rates = &types.Rates{
+		Interval: interval,
+		CPU: types.CpuRates{
+			Usage:            percent(cur.CPU.Usage.Total, old.CPU.Usage.Total),
+			Kernel:           percent(cur.CPU.Usage.Kernel, old.CPU.Usage.Kernel),
+			User:             percent(cur.CPU.Usage.User, old.CPU.Usage.User),
+			ThrottledPeriods: perSec(cur.CPU.Throttling.ThrottledPeriods, old.CPU.Throttling.ThrottledPeriods),
+			ThrottledTime:    perSec(cur.CPU.Throttling.ThrottledTime, old.CPU.Throttling.ThrottledTime),
+			Realtime:         perSec(s.rtUsage, prev.rtUsage),
+		},
+	}
+}
+
+// newEvent returns a new event of the given type for the container, stamped
+// with the current wall clock and monotonic time.
+func newEvent(container *libcontainer.Container, typ string, data interface{}) *types.Event {
+	_, annotations := utils.Annotations(container.Config().Labels)
+	e := &types.Event{
+		SchemaVersion: types.EventSchemaVersion,
+		Type:          typ,
+		ID:            container.ID(),
+		Timestamp:     time.Now(),
+		Annotations:   annotations,
+		Data:          data,
+	}
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err == nil {
+		e.Monotonic = ts.Nano()
+	}
+	return e
+}
+
+func convertBootstrapTimings(timings []libcontainer.BootstrapPhase) []types.BootstrapPhase {
+	phases := make([]types.BootstrapPhase, 0, len(timings))
+	for _, t := range timings {
+		phases = append(phases, types.BootstrapPhase{
+			Name:     t.Name,
+			Start:    t.Start,
+			Duration: uint64(t.Duration.Nanoseconds()),
+		})
+	}
+	return phases
+}
+
 func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	cg := ls.CgroupStats
 	if cg == nil {
@@ -129,7 +336,21 @@ func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	s.CPU.Throttling.Periods = cg.CpuStats.ThrottlingData.Periods
 	s.CPU.Throttling.ThrottledPeriods = cg.CpuStats.ThrottlingData.ThrottledPeriods
 	s.CPU.Throttling.ThrottledTime = cg.CpuStats.ThrottlingData.ThrottledTime
+	s.CPU.Burst = types.Burst(cg.CpuStats.BurstData)
 	s.CPU.PSI = cg.CpuStats.PSI
+	if rt := cg.CpuStats.RtBandwidth; rt != nil {
+		s.CPU.Realtime = &types.CpuRealtime{Runtime: rt.Runtime, Period: rt.Period}
+	}
+	for _, h := range cg.CpuStats.RtHeadroom {
+		s.CPU.RealtimeHeadroom = append(s.CPU.RealtimeHeadroom, types.CpuRealtimeHeadroom{
+			Path:      h.Path,
+			Runtime:   h.Runtime,
+			Period:    h.Period,
+			Allocated: h.Allocated,
+			Free:      h.Free,
+		})
+	}
+	s.CPU.Idle = cg.CpuStats.Idle
 
 	s.CPUSet = types.CPUSet(cg.CPUSetStats)
 
@@ -140,6 +361,7 @@ func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	s.Memory.Usage = convertMemoryEntry(cg.MemoryStats.Usage)
 	s.Memory.Raw = cg.MemoryStats.Stats
 	s.Memory.PSI = cg.MemoryStats.PSI
+	s.Memory.NUMA = cg.MemoryStats.NUMAStat
 
 	s.Blkio.IoServiceBytesRecursive = convertBlkioEntry(cg.BlkioStats.IoServiceBytesRecursive)
 	s.Blkio.IoServicedRecursive = convertBlkioEntry(cg.BlkioStats.IoServicedRecursive)
@@ -176,6 +398,9 @@ func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	}
 
 	s.NetworkInterfaces = ls.Interfaces
+	if ls.ExecCgroupStats != nil {
+		s.Exec = convertLibcontainerStats(&libcontainer.Stats{CgroupStats: ls.ExecCgroupStats})
+	}
 	return &s
 }
 
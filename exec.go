@@ -45,6 +45,14 @@ following will output a list of processes running in the container:
 			Name:  "env, e",
 			Usage: "set environment variables",
 		},
+		cli.StringSliceFlag{
+			Name:  "env-file",
+			Usage: "read environment variables from a file (KEY=VALUE lines; can be specified multiple times)",
+		},
+		cli.IntSliceFlag{
+			Name:  "env-fd",
+			Usage: "read environment variables from file descriptor FD, as with --env-file, and close it (can be specified multiple times)",
+		},
 		cli.BoolFlag{
 			Name:  "tty, t",
 			Usage: "allocate a pseudo-TTY",
@@ -95,10 +103,18 @@ following will output a list of processes running in the container:
 			Name:  "cgroup",
 			Usage: "run the process in an (existing) sub-cgroup(s). Format is [<controller>:]<cgroup>.",
 		},
+		cli.BoolFlag{
+			Name:  "sub-cgroup",
+			Usage: "run the process in the real-time sub-cgroup of the container",
+		},
 		cli.BoolFlag{
 			Name:  "ignore-paused",
 			Usage: "allow exec in a paused container",
 		},
+		cli.DurationFlag{
+			Name:  "timer-slack",
+			Usage: "set the timer slack of the process (such as 1us), instead of the container's one",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, minArgs); err != nil {
@@ -170,6 +186,10 @@ func execProcess(context *cli.Context) (int, error) {
 	if !ok {
 		return -1, errors.New("bundle not found in labels")
 	}
+	envFiles, envFds, err := getEnvSources(context)
+	if err != nil {
+		return -1, err
+	}
 	p, err := getProcess(context, bundle)
 	if err != nil {
 		return -1, err
@@ -179,6 +199,10 @@ func execProcess(context *cli.Context) (int, error) {
 	if err != nil {
 		return -1, err
 	}
+	timerSlack := context.Duration("timer-slack")
+	if timerSlack < 0 || (context.IsSet("timer-slack") && timerSlack == 0) {
+		return -1, errors.New("--timer-slack must be greater than 0")
+	}
 
 	r := &runner{
 		enableSubreaper: false,
@@ -192,6 +216,10 @@ func execProcess(context *cli.Context) (int, error) {
 		init:            false,
 		preserveFDs:     context.Int("preserve-fds"),
 		subCgroupPaths:  cgPaths,
+		rtSubCgroup:     context.Bool("sub-cgroup"),
+		timerSlack:      uint64(timerSlack.Nanoseconds()),
+		envFiles:        envFiles,
+		envFds:          envFds,
 	}
 	return r.run(p)
 }
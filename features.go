@@ -3,11 +3,14 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/opencontainers/runc/libcontainer/capabilities"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/seccomp"
 	"github.com/opencontainers/runc/libcontainer/specconv"
+	"github.com/opencontainers/runc/libcontainer/topology"
 	runcfeatures "github.com/opencontainers/runc/types/features"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-spec/specs-go/features"
@@ -21,12 +24,31 @@ var featuresCommand = cli.Command{
 	Description: `Show the enabled features.
    The result is parsable as a JSON.
    See https://github.com/opencontainers/runtime-spec/blob/main/features.md for the type definition.
+
+   With --topology, the CPU topology of the host (cores, caches, NUMA nodes,
+   and isolated CPUs) is shown instead.
 `,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "topology",
+			Usage: "show the CPU topology of the host",
+		},
+	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 0, exactArgs); err != nil {
 			return err
 		}
 
+		if context.Bool("topology") {
+			topo, err := topology.Discover()
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(context.App.Writer)
+			enc.SetIndent("", "    ")
+			return enc.Encode(topo)
+		}
+
 		t := true
 
 		feat := features.Features{
@@ -36,6 +58,7 @@ var featuresCommand = cli.Command{
 				runcfeatures.AnnotationRuncVersion:           version,
 				runcfeatures.AnnotationRuncCommit:            gitCommit,
 				runcfeatures.AnnotationRuncCheckpointEnabled: "true",
+				runcfeatures.AnnotationRuncResourcePolicies:  strings.Join(cgroups.ResourcePolicies(), ","),
 			},
 			Hooks:        configs.KnownHookNames(),
 			MountOptions: specconv.KnownMountOptions(),
@@ -68,6 +91,11 @@ var featuresCommand = cli.Command{
 				"bundle",
 				"org.systemd.property.", // prefix form
 				"org.criu.config",
+				"org.opencontainers.runc.network.interfaces",
+				"org.opencontainers.runc.network.routes",
+				"org.opencontainers.runc.userns.auto",
+				"org.opencontainers.runc.exe-protection",
+				"org.opencontainers.runc.intelrdt.pseudo-lock",
 			},
 		}
 
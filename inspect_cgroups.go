@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/urfave/cli"
+)
+
+var inspectCgroupsCommand = cli.Command{
+	Name:  "inspect-cgroups",
+	Usage: "dump the cgroup interface files of a container",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container.`,
+	Description: `The inspect-cgroups command outputs, as a single JSON document, the contents
+of all the interface files of the cgroups of a container (of every controller,
+for cgroup v1) and of their descendants, such as the sub-cgroups created by
+runc. With --ancestors, the files of the ancestor cgroups, up to the root of
+each hierarchy, are also included.
+
+This is meant to be attached to bug reports, in particular those about
+real-time scheduling, in which the settings of the parent cgroups matter.
+Write-only files are skipped, and the errors reading the other ones are
+reported along with the contents.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "ancestors, a",
+			Usage: "also dump the files of the ancestor cgroups",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		state, err := container.State()
+		if err != nil {
+			return err
+		}
+		inspection, err := inspectCgroups(state.CgroupPaths, context.Bool("ancestors"))
+		if err != nil {
+			return err
+		}
+		inspection.ID = container.ID()
+		data, err := json.MarshalIndent(inspection, "", "  ")
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(data)
+		return nil
+	},
+}
+
+// cgroupsInspection is the output of runc inspect-cgroups.
+type cgroupsInspection struct {
+	ID string `json:"id"`
+	// Version is the cgroup version of the host (1 for hybrid hosts).
+	Version int `json:"cgroup_version"`
+	// Cgroups are the container cgroups and their descendants.
+	Cgroups []cgroupDump `json:"cgroups"`
+	// Ancestors are the ancestors of the container cgroups, if requested.
+	Ancestors []cgroupDump `json:"ancestors,omitempty"`
+}
+
+type cgroupDump struct {
+	Path string `json:"path"`
+	// Controllers are the cgroup v1 controllers of the hierarchy.
+	Controllers []string `json:"controllers,omitempty"`
+	// Files are the contents of the readable files, by name.
+	Files map[string]string `json:"files"`
+	// Errors are the errors reading the files, by name.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// inspectCgroups dumps the cgroups with the given paths, by controller (as
+// in the container state), along with their descendants and, if ancestors is
+// set, their ancestors.
+func inspectCgroups(paths map[string]string, ancestors bool) (*cgroupsInspection, error) {
+	// With cgroup v1, several controllers can share a hierarchy.
+	controllers := make(map[string][]string)
+	for name, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, ok := controllers[path]; !ok {
+			controllers[path] = nil
+		}
+		if name != "" {
+			controllers[path] = append(controllers[path], name)
+		}
+	}
+	if len(controllers) == 0 {
+		return nil, errors.New("the container has no cgroups")
+	}
+	dirs := make([]string, 0, len(controllers))
+	for path := range controllers {
+		dirs = append(dirs, path)
+		sort.Strings(controllers[path])
+	}
+	sort.Strings(dirs)
+
+	inspection := &cgroupsInspection{Version: 1}
+	if cgroups.IsCgroup2UnifiedMode() {
+		inspection.Version = 2
+	}
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				inspection.Cgroups = append(inspection.Cgroups, dumpCgroup(path, controllers[dir]))
+				seen[path] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("container cgroup %s: %w", dir, err)
+		}
+	}
+	if !ancestors {
+		return inspection, nil
+	}
+	for _, dir := range dirs {
+		for parent := filepath.Dir(dir); !seen[parent] && isCgroup(parent); parent = filepath.Dir(parent) {
+			inspection.Ancestors = append(inspection.Ancestors, dumpCgroup(parent, controllers[dir]))
+			seen[parent] = true
+		}
+	}
+	return inspection, nil
+}
+
+// isCgroup returns whether dir is a cgroup (rather than, for cgroup v1, the
+// directory in which the hierarchies are mounted).
+func isCgroup(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "cgroup.procs"))
+	return err == nil
+}
+
+// dumpCgroup reads all the readable files of the cgroup dir.
+func dumpCgroup(dir string, controllers []string) cgroupDump {
+	dump := cgroupDump{Path: dir, Controllers: controllers, Files: make(map[string]string)}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		dump.Errors = map[string]string{".": err.Error()}
+		return dump
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.Mode().Perm()&0o444 == 0 {
+			// Write-only, such as cgroup.event_control.
+			continue
+		}
+		data, err := cgroups.ReadFile(dir, e.Name())
+		if err != nil {
+			if dump.Errors == nil {
+				dump.Errors = make(map[string]string)
+			}
+			dump.Errors[e.Name()] = err.Error()
+			continue
+		}
+		dump.Files[e.Name()] = data
+	}
+	return dump
+}
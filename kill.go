@@ -13,7 +13,7 @@ import (
 
 var killCommand = cli.Command{
 	Name:  "kill",
-	Usage: "kill sends the specified signal (default: SIGTERM) to the container's init process",
+	Usage: "kill sends the specified signal (default: the container's stop signal, or SIGTERM) to the container's init process",
 	ArgsUsage: `<container-id> [signal]
 
 Where "<container-id>" is the name for the instance of the container and
@@ -23,12 +23,21 @@ EXAMPLE:
 For example, if the container id is "ubuntu01" the following will send a "KILL"
 signal to the init process of the "ubuntu01" container:
 
-       # runc kill ubuntu01 KILL`,
+       # runc kill ubuntu01 KILL
+
+Without a signal, the container's stop signal (set with the
+org.opencontainers.runc.stop-signal annotation) is sent, or SIGTERM. The
+prestop hooks of the container (set with the
+org.opencontainers.runc.hooks.prestop annotation) are run before the stop
+signal is sent.
+
+With --all, the signal is sent to all processes inside the container. The
+container's cgroup is frozen while the signal is being sent, so that every
+process receives it exactly once.`,
 	Flags: []cli.Flag{
 		cli.BoolFlag{
-			Name:   "all, a",
-			Usage:  "(obsoleted, do not use)",
-			Hidden: true,
+			Name:  "all, a",
+			Usage: "send the specified signal to all processes inside the container",
 		},
 	},
 	Action: func(context *cli.Context) error {
@@ -43,16 +52,18 @@ signal to the init process of the "ubuntu01" container:
 			return err
 		}
 
-		sigstr := context.Args().Get(1)
-		if sigstr == "" {
-			sigstr = "SIGTERM"
+		signal := container.StopSignal()
+		if sigstr := context.Args().Get(1); sigstr != "" {
+			signal, err = parseSignal(sigstr)
+			if err != nil {
+				return err
+			}
 		}
-
-		signal, err := parseSignal(sigstr)
-		if err != nil {
-			return err
+		if signal == container.StopSignal() {
+			err = container.Stop(context.Bool("all"))
+		} else {
+			err = container.Signal(signal, context.Bool("all"))
 		}
-		err = container.Signal(signal)
 		if errors.Is(err, libcontainer.ErrNotRunning) && context.Bool("all") {
 			err = nil
 		}
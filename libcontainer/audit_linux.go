@@ -0,0 +1,200 @@
+package libcontainer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// auditRecord is a line of the audit log.
+type auditRecord struct {
+	Time      time.Time `json:"time"`
+	Container string    `json:"container"`
+	// Op is the operation which the step is part of: "create", "exec", or
+	// "update".
+	Op   string `json:"op"`
+	Pid  int    `json:"pid,omitempty"`
+	Step string `json:"step"`
+	Data any    `json:"data"`
+}
+
+type auditLSM struct {
+	AppArmorProfile string `json:"apparmor_profile,omitempty"`
+	ProcessLabel    string `json:"process_label,omitempty"`
+	MountLabel      string `json:"mount_label,omitempty"`
+}
+
+type auditSeccomp struct {
+	// SHA256 is the hash of the JSON-encoded seccomp profile, as stored in
+	// the container state.
+	SHA256 string `json:"sha256"`
+	Rules  int    `json:"rules"`
+}
+
+type auditPaths struct {
+	Masked   []string `json:"masked"`
+	Readonly []string `json:"readonly"`
+}
+
+type auditCgroupWrite struct {
+	Path string `json:"path"`
+	Data string `json:"data"`
+}
+
+// auditLog records the security-relevant setup steps of an operation on a
+// container (see configs.Config.AuditLog). Each record is written as a
+// single line using a single write, so that the records of concurrent runc
+// instances don't get mixed up.
+type auditLog struct {
+	f         *os.File
+	container string
+	op        string
+
+	mu  sync.Mutex
+	err error // The first write error.
+}
+
+// openAuditLog opens the audit log of the container config, if it has one,
+// for the given operation. A nil *auditLog, which records nothing, is
+// returned if there is no audit log.
+func openAuditLog(config *configs.Config, id, op string) (*auditLog, error) {
+	if config.AuditLog == "" {
+		return nil, nil
+	}
+	var f *os.File
+	if fdStr, ok := strings.CutPrefix(config.AuditLog, "fd:"); ok {
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit log file descriptor %q", fdStr)
+		}
+		// Use a copy, which can be closed.
+		newFd, err := unix.FcntlInt(uintptr(fd), unix.F_DUPFD_CLOEXEC, 3)
+		if err != nil {
+			return nil, fmt.Errorf("audit log file descriptor %d: %w", fd, err)
+		}
+		f = os.NewFile(uintptr(newFd), config.AuditLog)
+	} else {
+		var err error
+		f, err = os.OpenFile(config.AuditLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open the audit log: %w", err)
+		}
+	}
+	return &auditLog{f: f, container: id, op: op}, nil
+}
+
+func (a *auditLog) close() {
+	if a != nil {
+		a.f.Close()
+	}
+}
+
+// record writes a record of a step. Write errors are kept, to be returned
+// by Err.
+func (a *auditLog) record(pid int, step string, data any) {
+	if a == nil {
+		return
+	}
+	line, err := json.Marshal(auditRecord{
+		Time:      time.Now().UTC(),
+		Container: a.container,
+		Op:        a.op,
+		Pid:       pid,
+		Step:      step,
+		Data:      data,
+	})
+	if err == nil {
+		_, err = a.f.Write(append(line, '\n'))
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil && a.err == nil {
+		a.err = fmt.Errorf("unable to write the audit log: %w", err)
+	}
+}
+
+// recordCgroupWrite records a cgroup file write, as reported by
+// cgroups.RecordWrites.
+func (a *auditLog) recordCgroupWrite(path, data string) {
+	a.record(0, "cgroup-write", auditCgroupWrite{Path: path, Data: data})
+}
+
+// recordProcess records the security settings applied to a container
+// process with the given pid. For the init process, the masked paths and the
+// device rules of the container are also recorded.
+func (a *auditLog) recordProcess(config *configs.Config, p *Process, pid int) {
+	if a == nil {
+		return
+	}
+	caps := config.Capabilities
+	lsm := auditLSM{
+		AppArmorProfile: config.AppArmorProfile,
+		ProcessLabel:    config.ProcessLabel,
+		MountLabel:      config.MountLabel,
+	}
+	noNewPrivileges := config.NoNewPrivileges
+	if !p.Init {
+		if p.Capabilities != nil {
+			caps = p.Capabilities
+		}
+		if p.AppArmorProfile != "" {
+			lsm.AppArmorProfile = p.AppArmorProfile
+		}
+		if p.Label != "" {
+			lsm.ProcessLabel = p.Label
+		}
+		if p.NoNewPrivileges != nil {
+			noNewPrivileges = *p.NoNewPrivileges
+		}
+	}
+	a.record(pid, "capabilities", caps)
+	a.record(pid, "no-new-privileges", noNewPrivileges)
+	a.record(pid, "lsm", lsm)
+	if config.Seccomp != nil {
+		a.record(pid, "seccomp", seccompAudit(config.Seccomp))
+	} else {
+		a.record(pid, "seccomp", nil)
+	}
+	if !p.Init {
+		return
+	}
+	a.record(pid, "paths", auditPaths{Masked: config.MaskPaths, Readonly: config.ReadonlyPaths})
+	if config.Cgroups != nil && config.Cgroups.Resources != nil {
+		rules := make([]string, 0, len(config.Cgroups.Resources.Devices))
+		for _, rule := range config.Cgroups.Resources.Devices {
+			action := "deny "
+			if rule.Allow {
+				action = "allow "
+			}
+			rules = append(rules, action+rule.CgroupString())
+		}
+		a.record(pid, "devices", rules)
+	}
+}
+
+// Err returns the first error which occurred while writing the records.
+func (a *auditLog) Err() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+func seccompAudit(s *configs.Seccomp) auditSeccomp {
+	// Marshaling a configs.Seccomp does not fail.
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return auditSeccomp{SHA256: hex.EncodeToString(sum[:]), Rules: len(s.Syscalls)}
+}
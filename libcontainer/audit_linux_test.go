@@ -0,0 +1,125 @@
+package libcontainer
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/devices"
+)
+
+func readAuditLog(t *testing.T, path string) []auditRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []auditRecord
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var r auditRecord
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			t.Fatalf("invalid record %q: %v", s.Text(), err)
+		}
+		records = append(records, r)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	config := &configs.Config{
+		AuditLog:        path,
+		AppArmorProfile: "container",
+		NoNewPrivileges: true,
+		Capabilities:    &configs.Capabilities{Bounding: []string{"CAP_KILL"}},
+		Seccomp:         &configs.Seccomp{DefaultAction: configs.Allow},
+		MaskPaths:       []string{"/proc/kcore"},
+		Cgroups: &configs.Cgroup{Resources: &configs.Resources{
+			Devices: []*devices.Rule{{Type: devices.CharDevice, Major: 1, Minor: 3, Permissions: "rwm", Allow: true}},
+		}},
+	}
+
+	audit, err := openAuditLog(config, "ct", "create")
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.recordCgroupWrite("/sys/fs/cgroup/ct/pids.max", "10")
+	audit.recordProcess(config, &Process{Init: true}, 100)
+	audit.close()
+	noNewPrivs := false
+	audit, err = openAuditLog(config, "ct", "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.recordProcess(config, &Process{AppArmorProfile: "unconfined", NoNewPrivileges: &noNewPrivs}, 200)
+	audit.close()
+	if err := audit.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := readAuditLog(t, path)
+	var steps []string
+	for _, r := range records {
+		if r.Container != "ct" {
+			t.Errorf("unexpected container in %+v", r)
+		}
+		steps = append(steps, r.Op+":"+r.Step)
+		data, _ := json.Marshal(r.Data)
+		switch r.Op + ":" + r.Step {
+		case "create:cgroup-write":
+			if string(data) != `{"data":"10","path":"/sys/fs/cgroup/ct/pids.max"}` {
+				t.Errorf("unexpected cgroup write %s", data)
+			}
+		case "create:devices":
+			if string(data) != `["allow c 1:3 rwm"]` {
+				t.Errorf("unexpected device rules %s", data)
+			}
+		case "create:lsm":
+			if string(data) != `{"apparmor_profile":"container"}` {
+				t.Errorf("unexpected lsm %s", data)
+			}
+		case "exec:lsm":
+			if string(data) != `{"apparmor_profile":"unconfined"}` {
+				t.Errorf("unexpected exec lsm %s", data)
+			}
+		case "exec:no-new-privileges":
+			if string(data) != "false" {
+				t.Errorf("unexpected exec no-new-privileges %s", data)
+			}
+		}
+	}
+	want := []string{
+		"create:cgroup-write",
+		"create:capabilities", "create:no-new-privileges", "create:lsm", "create:seccomp", "create:paths", "create:devices",
+		"exec:capabilities", "exec:no-new-privileges", "exec:lsm", "exec:seccomp",
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("expected steps %v, got %v", want, steps)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Fatalf("expected steps %v, got %v", want, steps)
+		}
+	}
+}
+
+func TestAuditLogNone(t *testing.T) {
+	audit, err := openAuditLog(&configs.Config{}, "ct", "create")
+	if err != nil || audit != nil {
+		t.Fatalf("expected no audit log, got %v, %v", audit, err)
+	}
+	// A nil audit log records nothing.
+	audit.recordProcess(&configs.Config{}, &Process{Init: true}, 1)
+	audit.close()
+	if err := audit.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
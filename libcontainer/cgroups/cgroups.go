@@ -2,6 +2,7 @@ package cgroups
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
 )
@@ -18,8 +19,56 @@ var (
 	// manage devices.
 	DevicesSetV1 func(path string, r *configs.Resources) error
 	DevicesSetV2 func(path string, r *configs.Resources) error
+
+	// ErrNetRateUnsupported is an error returned when a cgroup manager
+	// is not configured to set network rate limits.
+	ErrNetRateUnsupported = errors.New("cgroup manager is not configured to set network rate limits")
+
+	// NetRateSetV2 is a function to set network rate limits for cgroup v2.
+	// Unless [github.com/opencontainers/runc/libcontainer/cgroups/netrate]
+	// package is imported, it is set to nil, so cgroup managers can't
+	// manage network rate limits.
+	NetRateSetV2 func(path string, r *configs.Resources) error
+
+	// ErrKillUnsupported is returned by Manager.Kill when cgroup.kill is
+	// not available, i.e. on cgroup v1 without the unified hierarchy, or
+	// on kernels older than 5.14.
+	ErrKillUnsupported = errors.New("cgroup.kill is not supported")
+
+	// The errors below are wrapped by the errors returned from cgroup
+	// managers and the functions of this package, so callers can use
+	// errors.Is to tell the kind of failure. See also ErrCgroupReadOnly.
+
+	// ErrControllerUnavailable means a resource can't be set because its
+	// controller is not available (i.e. not mounted on cgroup v1, or not
+	// enabled on cgroup v2). This is permanent, unless the host
+	// configuration is changed.
+	ErrControllerUnavailable = errors.New("cgroup controller not available")
+
+	// ErrBudgetExceeded means a resource does not fit in what is left of a
+	// budget shared with other cgroups, such as the real-time bandwidth.
+	// This may be retried once other cgroups release some of it.
+	ErrBudgetExceeded = errors.New("resource budget exceeded")
+
+	// ErrParseFile means a cgroup file has unexpected contents (see
+	// [github.com/opencontainers/runc/libcontainer/cgroups/fscommon.ParseError]).
+	ErrParseFile = errors.New("unable to parse cgroup file")
 )
 
+// ControllerUnavailableError is returned when a controller is not available.
+// It matches ErrControllerUnavailable.
+type ControllerUnavailableError struct {
+	Controller string
+}
+
+func (e *ControllerUnavailableError) Error() string {
+	return fmt.Sprintf("controller %q not available", e.Controller)
+}
+
+func (e *ControllerUnavailableError) Is(target error) bool {
+	return target == ErrControllerUnavailable
+}
+
 type Manager interface {
 	// Apply creates a cgroup, if not yet created, and adds a process
 	// with the specified pid into that cgroup.  A special value of -1
@@ -46,11 +95,27 @@ type Manager interface {
 	// For cgroupv2, the argument is unused and can be empty.
 	Path(string) string
 
+	// ControllerPath returns the path of the cgroup in which the given
+	// controller is available, or an empty string if it is not. For cgroup
+	// v2, the freezer and devices controllers are always available, as
+	// they are implemented by the core and by eBPF. The name "" refers to
+	// the cgroup v2 hierarchy: the unified one or, on hybrid hosts, the
+	// hybrid one.
+	//
+	// Unlike Path, its result does not depend on the cgroup version (and,
+	// for cgroup v2, tells whether the controller is enabled), so callers
+	// don't need to special-case it.
+	ControllerPath(name string) string
+
 	// Set sets cgroup resources parameters/limits. If the argument is nil,
 	// the resources specified during Manager creation (or the previous call
 	// to Set) are used.
 	Set(r *configs.Resources) error
 
+	// Diff returns the changes of the cgroup files which Set(r) would make,
+	// without making them (see DiffSet).
+	Diff(r *configs.Resources) ([]FileChange, error)
+
 	// GetPaths returns cgroup path(s) to save in a state file in order to
 	// restore later.
 	//
@@ -72,4 +137,26 @@ type Manager interface {
 
 	// OOMKillCount reports OOM kill count for the cgroup.
 	OOMKillCount() (uint64, error)
+
+	// Kill kills all the processes in the cgroup and its sub-cgroups at
+	// once, using cgroup.kill. This is not racy against processes forking,
+	// unlike signalling the PIDs one by one. It returns ErrKillUnsupported
+	// if cgroup.kill is not available.
+	Kill() error
+
+	// GetEffectiveCpus returns the CPUs the processes in the cgroup can
+	// actually run on, i.e. the requested ones restricted by the ancestor
+	// cgroups, in the cpuset list format (e.g. "0-3,7"). It returns an
+	// error matching ErrControllerUnavailable if the cpuset controller is
+	// not available.
+	GetEffectiveCpus() (string, error)
+
+	// GetEffectiveMems is like GetEffectiveCpus, for the memory nodes.
+	GetEffectiveMems() (string, error)
+
+	// ResetMemoryPeak resets the peak memory usage of the cgroup (as
+	// reported by GetStats) to its current usage. With cgroup v2, this
+	// requires Linux 6.12, and the reset is only seen by the stats of
+	// this Manager, not by other readers.
+	ResetMemoryPeak() error
 }
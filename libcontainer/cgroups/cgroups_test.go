@@ -1,6 +1,9 @@
 package cgroups
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"testing"
 )
 
@@ -19,3 +22,24 @@ func TestParseCgroups(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestErrorKinds(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		kind error
+	}{
+		{err: NewNotFoundError("cpu"), kind: ErrControllerUnavailable},
+		{err: fmt.Errorf("set: %w", &ControllerUnavailableError{Controller: "io"}), kind: ErrControllerUnavailable},
+	} {
+		if !errors.Is(tc.err, tc.kind) {
+			t.Errorf("%v: expected to match %v", tc.err, tc.kind)
+		}
+	}
+
+	if err := (&ControllerUnavailableError{Controller: "io"}).Error(); err != `controller "io" not available` {
+		t.Errorf("unexpected error message: %s", err)
+	}
+	if errors.Is(os.ErrNotExist, ErrControllerUnavailable) {
+		t.Error("ENOENT must not match ErrControllerUnavailable")
+	}
+}
@@ -0,0 +1,122 @@
+package cgroups
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrCgroupReadOnly is returned when a cgroup hierarchy is mounted read-only,
+// which usually means runc is running inside a container without cgroup
+// delegation.
+var ErrCgroupReadOnly = errors.New("cgroup filesystem is read-only (running inside a container without cgroup delegation?)")
+
+// isCgroupFs tells whether dir is on a cgroup (v1 or v2) filesystem.
+// A non-existing dir is reported as not being on a cgroup filesystem.
+func isCgroupFs(dir string) bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return false
+	}
+	return st.Type == unix.CGROUP_SUPER_MAGIC || st.Type == unix.CGROUP2_SUPER_MAGIC
+}
+
+// DelegationBoundary returns the topmost cgroup directory, among path and
+// its ancestors, which the current process is allowed to modify. This is the
+// root of the subtree delegated to us, which, when runc is running inside
+// another container, is usually below the cgroup mount point, and so settings
+// must not be propagated above it. Note that path itself may not exist yet.
+//
+// If the closest existing directory is not writable, an error is returned,
+// which is ErrCgroupReadOnly if the cgroup filesystem is mounted read-only.
+func DelegationBoundary(path string) (string, error) {
+	dir := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no cgroup directory found for %s", path)
+		}
+		dir = parent
+	}
+	if !isCgroupFs(dir) {
+		return "", fmt.Errorf("%s is not on a cgroup filesystem", dir)
+	}
+	if err := unix.Faccessat(unix.AT_FDCWD, dir, unix.W_OK, unix.AT_EACCESS); err != nil {
+		if errors.Is(err, unix.EROFS) {
+			return "", fmt.Errorf("%s: %w", dir, ErrCgroupReadOnly)
+		}
+		return "", &os.PathError{Op: "access", Path: dir, Err: err}
+	}
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir || !isCgroupFs(parent) ||
+			unix.Faccessat(unix.AT_FDCWD, parent, unix.W_OK, unix.AT_EACCESS) != nil {
+			return dir, nil
+		}
+		dir = parent
+	}
+}
+
+// v1DelegateFiles are the files of a cgroup v1 directory which must be owned
+// by the delegatee, in addition to the directory itself, for it to be able to
+// manage the processes and sub-cgroups of the cgroup.
+var v1DelegateFiles = []string{"cgroup.procs", "tasks", "cgroup.clone_children", "notify_on_release"}
+
+// DelegateFiles returns the files of a cgroup v2 directory which must be
+// owned by the delegatee. The kernel exposes the list of them in
+// /sys/kernel/cgroup/delegate. If the file is not present (Linux < 4.15),
+// the initial values mentioned in cgroups(7) are used.
+func DelegateFiles() ([]string, error) {
+	const cgroupDelegateFile = "/sys/kernel/cgroup/delegate"
+
+	f, err := os.Open(cgroupDelegateFile)
+	if err != nil {
+		return []string{"cgroup.procs", "cgroup.subtree_control", "cgroup.threads"}, nil
+	}
+	defer f.Close()
+
+	filesToChown := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		filesToChown = append(filesToChown, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", cgroupDelegateFile, err)
+	}
+
+	return filesToChown, nil
+}
+
+// Delegate makes the cgroup dir, and the files needed to manage it, owned by
+// uid, the same way systemd does it for units with Delegate=yes. This allows
+// the user to create sub-cgroups and move processes between them.
+func Delegate(dir string, uid int) error {
+	files := v1DelegateFiles
+	if IsCgroup2UnifiedMode() {
+		var err error
+		if files, err = DelegateFiles(); err != nil {
+			return err
+		}
+	}
+	// The directory itself must be chowned.
+	if err := os.Chown(dir, uid, -1); err != nil {
+		return err
+	}
+	for _, file := range files {
+		err := os.Chown(filepath.Join(dir, file), uid, -1)
+		// Some files might not be present.
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cgroups
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDelegationBoundary(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("Test requires root.")
+	}
+	mnt := "/sys/fs/cgroup"
+	if !IsCgroup2UnifiedMode() {
+		mnt = "/sys/fs/cgroup/pids"
+	}
+	if !isCgroupFs(mnt) {
+		t.Skipf("%s is not a cgroup mount", mnt)
+	}
+	dir := filepath.Join(mnt, "test-delegation-boundary")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(dir)
+
+	for _, path := range []string{mnt, dir, filepath.Join(dir, "a/b/c")} {
+		b, err := DelegationBoundary(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if b != mnt {
+			t.Errorf("%s: expected boundary %s, got %s", path, mnt, b)
+		}
+	}
+
+	if _, err := DelegationBoundary(t.TempDir()); err == nil {
+		t.Error("expected an error for a non-cgroup directory")
+	} else if errors.Is(err, ErrCgroupReadOnly) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package cgroups
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// FileChange is a change of a cgroup file which setting cgroup resources
+// would make.
+type FileChange struct {
+	Path string `json:"path"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// dryRun records the file writes instead of doing them.
+type dryRun struct {
+	mu      sync.Mutex
+	changes []FileChange
+	written map[string]string // The last value written to a file.
+}
+
+var (
+	// diffMu serializes DiffSet calls.
+	diffMu sync.Mutex
+	// curDryRun is the dryRun of the running DiffSet, if any.
+	curDryRun atomic.Pointer[dryRun]
+)
+
+// DiffSet calls set, which is supposed to set the cgroup resources r (e.g.
+// a Manager's Set), with the file writes recorded rather than done, and
+// returns the writes which would change the file contents, in order. The
+// device rules, the freezer state and the network rate limits of r are
+// ignored, as setting these involves more than file writes.
+//
+// The contents are compared as strings, so writing a value the kernel
+// normalizes (e.g. -1 for no memory limit on cgroup v1), or a line of a
+// file having one line per device (e.g. io.max), may be reported as a change
+// even if it would change nothing. Since the writes are recorded process-wide,
+// no cgroup files must be written by other goroutines while DiffSet runs.
+func DiffSet(r *configs.Resources, set func(*configs.Resources) error) ([]FileChange, error) {
+	if r == nil {
+		return nil, nil
+	}
+	dr := *r
+	dr.SkipDevices = true
+	dr.Freezer = configs.Undefined
+	dr.NetRate = nil
+
+	diffMu.Lock()
+	defer diffMu.Unlock()
+	d := &dryRun{written: make(map[string]string)}
+	curDryRun.Store(d)
+	defer curDryRun.Store(nil)
+	if err := set(&dr); err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	for _, c := range d.changes {
+		if !sameContents(c.Old, c.New) {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// sameContents returns whether writing data to a file having the contents
+// cur would change nothing.
+func sameContents(cur, data string) bool {
+	if cur == data {
+		return true
+	}
+	for _, line := range strings.Split(cur, "\n") {
+		if strings.TrimSpace(line) == data {
+			return true
+		}
+	}
+	return false
+}
+
+// write records a write of data to the file at path p. The current contents
+// of the file are obtained using read, unless it was already written to.
+func (d *dryRun) write(p, data string, read func() (string, error)) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	old, ok := d.written[p]
+	if !ok {
+		var err error
+		if old, err = read(); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// The write would fail.
+				return err
+			}
+			// Not readable (e.g. devices.allow).
+			old = ""
+		}
+	}
+	d.written[p] = data
+	d.changes = append(d.changes, FileChange{
+		Path: p,
+		Old:  strings.TrimSpace(old),
+		New:  strings.TrimSpace(data),
+	})
+	return nil
+}
+
+// read returns the last data written to the file at path p, if any.
+func (d *dryRun) read(p string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	data, ok := d.written[p]
+	return data, ok
+}
+
+// dryRunWrite records a write to a cgroup file if DiffSet is running,
+// returning false otherwise.
+func dryRunWrite(dir, file, data string) (bool, error) {
+	d := curDryRun.Load()
+	if d == nil || dir == "" {
+		return false, nil
+	}
+	return true, d.write(path.Join(dir, utils.CleanPath(file)), data, func() (string, error) {
+		return readFile(dir, file)
+	})
+}
+
+// dryRunRead returns the last data written to a cgroup file if DiffSet is
+// running and it was written to.
+func dryRunRead(dir, file string) (string, bool) {
+	d := curDryRun.Load()
+	if d == nil || dir == "" {
+		return "", false
+	}
+	return d.read(path.Join(dir, utils.CleanPath(file)))
+}
@@ -0,0 +1,74 @@
+package cgroups
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestDiffSet(t *testing.T) {
+	TestMode = true
+	defer func() { TestMode = false }()
+
+	dir := t.TempDir()
+	for file, data := range map[string]string{
+		"pids.max":   "max\n",
+		"cpu.weight": "100\n",
+		"io.max":     "8:0 rbps=max wbps=max riops=max wiops=max\n8:16 rbps=1000 wbps=max riops=max wiops=max\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &configs.Resources{PidsLimit: 10, Freezer: configs.Frozen}
+	changes, err := DiffSet(r, func(r *configs.Resources) error {
+		if !r.SkipDevices || r.Freezer != configs.Undefined {
+			t.Errorf("expected devices and freezer to be skipped, got %+v", r)
+		}
+		for _, w := range []struct{ file, data string }{
+			{"pids.max", "10"},
+			{"cpu.weight", "100"},
+			{"cpu.weight", "200"},
+			{"io.max", "8:16 rbps=1000 wbps=max riops=max wiops=max"},
+			{"io.max", "8:0 rbps=1000"},
+		} {
+			if err := WriteFile(dir, w.file, w.data); err != nil {
+				return err
+			}
+		}
+		// The written value must be read back.
+		if data, err := ReadFile(dir, "pids.max"); err != nil || data != "10" {
+			t.Errorf("expected to read back 10, got %q, %v", data, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []FileChange{
+		{Path: filepath.Join(dir, "pids.max"), Old: "max", New: "10"},
+		{Path: filepath.Join(dir, "cpu.weight"), Old: "100", New: "200"},
+		{Path: filepath.Join(dir, "io.max"), Old: "8:16 rbps=1000 wbps=max riops=max wiops=max", New: "8:0 rbps=1000"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %+v, got %+v", expected, changes)
+	}
+
+	// Nothing must have been written.
+	if data, err := ReadFile(dir, "pids.max"); err != nil || data != "max\n" {
+		t.Errorf("expected pids.max to be unchanged, got %q, %v", data, err)
+	}
+
+	// A write to a missing file fails.
+	_, err = DiffSet(r, func(_ *configs.Resources) error {
+		return WriteFile(dir, "cpu.latency.nice", "0")
+	})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected ENOENT, got %v", err)
+	}
+}
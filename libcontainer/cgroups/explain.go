@@ -0,0 +1,155 @@
+package cgroups
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The kernel rejects the cgroup v1 bandwidth writes which do not fit in the
+// hierarchy with a bare EINVAL (or EBUSY). The functions below compute, from
+// the neighbouring cgroups, why a write was rejected, so that it can be
+// added to the error. They return an empty string if no explanation is
+// found (e.g. the write was rejected for a different reason).
+
+// rtUsage returns the real-time runtime used by the children of dir, other
+// than skip, scaled to period.
+func rtUsage(dir, skip string, period uint64) (int64, error) {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var used int64
+	for _, ent := range ents {
+		if !ent.IsDir() || ent.Name() == skip {
+			continue
+		}
+		runtime, childPeriod, err := readBandwidth(filepath.Join(dir, ent.Name()), "cpu.rt_runtime_us", "cpu.rt_period_us")
+		if err != nil {
+			return 0, err
+		}
+		if runtime > 0 && childPeriod > 0 {
+			used += rtRuntimeFor(runtime, childPeriod, period)
+		}
+	}
+	return used, nil
+}
+
+// GetRtHeadroom returns the real-time bandwidth which is left unallocated
+// at each level of the cgroup v1 cpu hierarchy, from dir (e.g. a container)
+// up to the root (e.g. through the pod and kubepods cgroups), so that it can
+// be told whether, and where, more real-time tasks would fit.
+func GetRtHeadroom(dir string) ([]RtHeadroom, error) {
+	var levels []RtHeadroom
+	for ; ; dir = filepath.Dir(dir) {
+		runtime, period, err := readBandwidth(dir, "cpu.rt_runtime_us", "cpu.rt_period_us")
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && len(levels) > 0 {
+				// Past the root of the hierarchy.
+				break
+			}
+			return nil, err
+		}
+		level := RtHeadroom{Path: dir, RtBandwidth: RtBandwidth{Runtime: runtime, Period: period}, Free: -1}
+		if period > 0 {
+			if level.Allocated, err = rtUsage(dir, "", period); err != nil {
+				return nil, err
+			}
+			if runtime >= 0 {
+				level.Free = max(runtime-level.Allocated, 0)
+			}
+		}
+		levels = append(levels, level)
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	return levels, nil
+}
+
+// ExplainRtBandwidth explains why the kernel rejected setting the real-time
+// runtime and period of the cgroup v1 directory dir. A zero
+// period means the current period of dir. If cpus is not empty, it is the
+// cpuset the bandwidth applies to, and is mentioned in the explanation.
+func ExplainRtBandwidth(dir string, runtime int64, period uint64, cpus string) string {
+	curRuntime, curPeriod, err := readBandwidth(dir, "cpu.rt_runtime_us", "cpu.rt_period_us")
+	if err != nil {
+		return ""
+	}
+	if runtime == 0 {
+		runtime = curRuntime
+	}
+	if period == 0 {
+		period = curPeriod
+	}
+	if runtime < 0 || period == 0 {
+		return ""
+	}
+	if cpus != "" {
+		cpus = " across cpus " + cpus
+	}
+
+	// The runtime of a cgroup can't be lower than the sum of that of
+	// its children.
+	if used, err := rtUsage(dir, "", period); err == nil && used > runtime {
+		return fmt.Sprintf("requested %dus per %dus but the sub-cgroups of %s use %dus%s",
+			runtime, period, dir, used, cpus)
+	}
+
+	// Nor can the sum of the runtime of the children of a cgroup be
+	// larger than its runtime.
+	parent := filepath.Dir(dir)
+	parentRuntime, parentPeriod, err := readBandwidth(parent, "cpu.rt_runtime_us", "cpu.rt_period_us")
+	if err != nil || parentRuntime < 0 || parentPeriod == 0 {
+		return ""
+	}
+	used, err := rtUsage(parent, filepath.Base(dir), parentPeriod)
+	if err != nil {
+		return ""
+	}
+	need := rtRuntimeFor(runtime, period, parentPeriod)
+	free := max(parentRuntime-used, 0)
+	if need <= free {
+		return ""
+	}
+	return fmt.Sprintf("requested %dus per %dus but %s has only %dus per %dus free%s",
+		need, parentPeriod, parent, free, parentPeriod, cpus)
+}
+
+// ExplainCfsQuota explains why the kernel rejected (with EINVAL) setting
+// the CFS quota and period of the cgroup v1 directory dir. A zero period
+// means the current period of dir.
+func ExplainCfsQuota(dir string, quota int64, period uint64) string {
+	if quota <= 0 {
+		return ""
+	}
+	if period == 0 {
+		data, err := ReadFile(dir, "cpu.cfs_period_us")
+		if err != nil {
+			return ""
+		}
+		if period, err = strconv.ParseUint(strings.TrimSpace(data), 10, 64); err != nil || period == 0 {
+			return ""
+		}
+	}
+	// The quota of a cgroup v1 can't be larger than that of its closest
+	// limited ancestor.
+	for parent := filepath.Dir(dir); parent != dir; dir, parent = parent, filepath.Dir(parent) {
+		parentQuota, parentPeriod, err := readBandwidth(parent, "cpu.cfs_quota_us", "cpu.cfs_period_us")
+		if err != nil || parentPeriod == 0 {
+			return ""
+		}
+		if parentQuota < 0 {
+			continue
+		}
+		if rtRuntimeFor(quota, period, parentPeriod) <= parentQuota {
+			return ""
+		}
+		return fmt.Sprintf("requested quota %dus per %dus exceeds the quota of %s (%dus per %dus)",
+			quota, period, parent, parentQuota, parentPeriod)
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeBandwidth(t *testing.T, dir, kind, runtime, period string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"rt": "cpu.rt_runtime_us", "cfs": "cpu.cfs_quota_us"}
+	for file, val := range map[string]string{files[kind]: runtime, "cpu." + kind + "_period_us": period} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(val+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExplainRtBandwidth(t *testing.T) {
+	TestMode = true
+	defer func() { TestMode = false }()
+
+	root := t.TempDir()
+	parent := filepath.Join(root, "parent")
+	dir := filepath.Join(parent, "ct")
+	writeBandwidth(t, parent, "rt", "500000", "1000000")
+	writeBandwidth(t, filepath.Join(parent, "other"), "rt", "20000", "100000")
+	writeBandwidth(t, dir, "rt", "0", "1000000")
+	writeBandwidth(t, filepath.Join(dir, "workload"), "rt", "100000", "1000000")
+
+	// Fits in the 300000us free in the parent.
+	if why := ExplainRtBandwidth(dir, 300000, 0, ""); why != "" {
+		t.Errorf("unexpected explanation: %s", why)
+	}
+
+	expected := "requested 800000us per 1000000us but " + parent + " has only 300000us per 1000000us free across cpus 2-3"
+	if why := ExplainRtBandwidth(dir, 800000, 0, "2-3"); why != expected {
+		t.Errorf("expected %q, got %q", expected, why)
+	}
+
+	// Lower than the runtime of the sub-cgroups.
+	expected = "requested 50000us per 1000000us but the sub-cgroups of " + dir + " use 100000us"
+	if why := ExplainRtBandwidth(dir, 50000, 0, ""); why != expected {
+		t.Errorf("expected %q, got %q", expected, why)
+	}
+}
+
+func TestExplainCfsQuota(t *testing.T) {
+	TestMode = true
+	defer func() { TestMode = false }()
+
+	root := t.TempDir()
+	writeBandwidth(t, root, "cfs", "-1", "100000")
+	parent := filepath.Join(root, "parent")
+	writeBandwidth(t, parent, "cfs", "200000", "100000")
+	unlimited := filepath.Join(parent, "unlimited")
+	writeBandwidth(t, unlimited, "cfs", "-1", "100000")
+	dir := filepath.Join(unlimited, "ct")
+	writeBandwidth(t, dir, "cfs", "-1", "100000")
+
+	if why := ExplainCfsQuota(dir, 150000, 0); why != "" {
+		t.Errorf("unexpected explanation: %s", why)
+	}
+	expected := "requested quota 30000us per 10000us exceeds the quota of " + parent + " (200000us per 100000us)"
+	if why := ExplainCfsQuota(dir, 30000, 10000); why != expected {
+		t.Errorf("expected %q, got %q", expected, why)
+	}
+}
+
+func TestGetRtHeadroom(t *testing.T) {
+	TestMode = true
+	defer func() { TestMode = false }()
+
+	root := filepath.Join(t.TempDir(), "cpu")
+	pod := filepath.Join(root, "kubepods", "pod")
+	ct := filepath.Join(pod, "ct")
+	for _, c := range []struct {
+		dir             string
+		runtime, period string
+	}{
+		{root, "950000", "1000000"},
+		{filepath.Join(root, "kubepods"), "500000", "1000000"},
+		{pod, "100000", "500000"},
+		{ct, "50000", "500000"},
+		{filepath.Join(pod, "other"), "20000", "100000"},
+	} {
+		writeBandwidth(t, c.dir, "rt", c.runtime, c.period)
+	}
+
+	levels, err := GetRtHeadroom(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []RtHeadroom{
+		{Path: ct, RtBandwidth: RtBandwidth{Runtime: 50000, Period: 500000}, Allocated: 0, Free: 50000},
+		{Path: pod, RtBandwidth: RtBandwidth{Runtime: 100000, Period: 500000}, Allocated: 150000, Free: 0},
+		{Path: filepath.Join(root, "kubepods"), RtBandwidth: RtBandwidth{Runtime: 500000, Period: 1000000}, Allocated: 200000, Free: 300000},
+		{Path: root, RtBandwidth: RtBandwidth{Runtime: 950000, Period: 1000000}, Allocated: 500000, Free: 450000},
+	}
+	if !reflect.DeepEqual(levels, expected) {
+		t.Errorf("expected %+v, got %+v", expected, levels)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/sirupsen/logrus"
@@ -25,12 +26,23 @@ func OpenFile(dir, file string, flags int) (*os.File, error) {
 	if dir == "" {
 		return nil, fmt.Errorf("no directory specified for %s", file)
 	}
-	return openFile(dir, file, flags)
+	fd, err := openFile(dir, file, flags)
+	if errors.Is(err, unix.EROFS) {
+		err = fmt.Errorf("%w: %w", err, ErrCgroupReadOnly)
+	}
+	return fd, err
 }
 
 // ReadFile reads data from a cgroup file in dir.
 // It is supposed to be used for cgroup files only.
 func ReadFile(dir, file string) (string, error) {
+	if data, ok := dryRunRead(dir, file); ok {
+		return data, nil
+	}
+	return readFile(dir, file)
+}
+
+func readFile(dir, file string) (string, error) {
 	fd, err := OpenFile(dir, file, unix.O_RDONLY)
 	if err != nil {
 		return "", err
@@ -45,6 +57,9 @@ func ReadFile(dir, file string) (string, error) {
 // WriteFile writes data to a cgroup file in dir.
 // It is supposed to be used for cgroup files only.
 func WriteFile(dir, file, data string) error {
+	if ok, err := dryRunWrite(dir, file, data); ok {
+		return err
+	}
 	fd, err := OpenFile(dir, file, unix.O_WRONLY)
 	if err != nil {
 		return err
@@ -54,12 +69,16 @@ func WriteFile(dir, file, data string) error {
 		// Having data in the error message helps in debugging.
 		return fmt.Errorf("failed to write %q: %w", data, err)
 	}
+	recordWrite(dir, file, data)
 	return nil
 }
 
 // WriteFileByLine is the same as WriteFile, except if data contains newlines,
 // it is written line by line.
 func WriteFileByLine(dir, file, data string) error {
+	if ok, err := dryRunWrite(dir, file, data); ok {
+		return err
+	}
 	i := strings.Index(data, "\n")
 	if i == -1 {
 		return WriteFile(dir, file, data)
@@ -88,9 +107,32 @@ func WriteFileByLine(dir, file, data string) error {
 		start += i + 1
 		i = strings.Index(data[start:], "\n")
 	}
+	recordWrite(dir, file, data)
 	return nil
 }
 
+// writeRecorder is the function set by RecordWrites, if any.
+var writeRecorder atomic.Pointer[func(path, data string)]
+
+// RecordWrites makes fn be called with the path and the data of every
+// successful cgroup file write done using WriteFile or WriteFileByLine,
+// until the returned function is called. The writes are recorded
+// process-wide, so they may include the writes done for other containers,
+// while the changes made by systemd on behalf of the systemd cgroup managers
+// are not seen.
+func RecordWrites(fn func(path, data string)) (stop func()) {
+	writeRecorder.Store(&fn)
+	return func() {
+		writeRecorder.CompareAndSwap(&fn, nil)
+	}
+}
+
+func recordWrite(dir, file, data string) {
+	if fn := writeRecorder.Load(); fn != nil {
+		(*fn)(path.Join(dir, utils.CleanPath(file)), data)
+	}
+}
+
 const (
 	cgroupfsDir    = "/sys/fs/cgroup"
 	cgroupfsPrefix = cgroupfsDir + "/"
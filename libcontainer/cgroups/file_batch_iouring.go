@@ -0,0 +1,357 @@
+//go:build runc_iouring
+
+package cgroups
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// The parts of the io_uring ABI used here (see include/uapi/linux/io_uring.h).
+const (
+	ioringOpClose   = 19
+	ioringOpWrite   = 23
+	ioringOpOpenat2 = 28
+
+	iosqeFixedFile = 1 << 0
+	iosqeIOLink    = 1 << 2
+
+	ioringEnterGetevents = 1 << 0
+	ioringRegisterFiles  = 2
+
+	// ioringFeatLinkedFile means the fixed file of a linked request is
+	// looked up when the request is executed, rather than submitted, so
+	// that it can be opened by the previous request (since Linux 5.17).
+	ioringFeatLinkedFile = 1 << 12
+
+	ioringOffSQRing = 0
+	ioringOffCQRing = 0x8000000
+	ioringOffSQEs   = 0x10000000
+
+	// ioringEntries is the size of the submission queue. The batches which
+	// need more entries are submitted in parts.
+	ioringEntries = 128
+)
+
+type ioSQRingOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type ioCQRingOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type ioUringParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFd uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  ioSQRingOffsets
+	cqOff                                                                  ioCQRingOffsets
+}
+
+type ioUringSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	opFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	fileIndex   uint32
+	addr3       uint64
+	_           uint64
+}
+
+type ioUringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// ioUring is a minimal io_uring instance, with a single fixed file slot,
+// into which the cgroup files are opened one after another.
+type ioUring struct {
+	fd                     int
+	sqRing, cqRing, sqMem  []byte
+	sqHead, sqTail, cqHead *uint32
+	cqTail                 *uint32
+	sqMask, cqMask         uint32
+	sqArray                []uint32
+	sqes                   []ioUringSQE
+	cqes                   []ioUringCQE
+}
+
+func newIOUring() (_ *ioUring, retErr error) {
+	var p ioUringParams
+	fd, _, errno := unix.Syscall(unix.SYS_IO_URING_SETUP, ioringEntries, uintptr(unsafe.Pointer(&p)), 0)
+	if errno != 0 {
+		return nil, os.NewSyscallError("io_uring_setup", errno)
+	}
+	r := &ioUring{fd: int(fd)}
+	defer func() {
+		if retErr != nil {
+			r.close()
+		}
+	}()
+	if p.features&ioringFeatLinkedFile == 0 {
+		return nil, fmt.Errorf("io_uring: linked file feature not supported (features %#x)", p.features)
+	}
+
+	const prot, flags = unix.PROT_READ | unix.PROT_WRITE, unix.MAP_SHARED | unix.MAP_POPULATE
+	var err error
+	r.sqRing, err = unix.Mmap(r.fd, ioringOffSQRing, int(p.sqOff.array+p.sqEntries*4), prot, flags)
+	if err != nil {
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	r.cqRing, err = unix.Mmap(r.fd, ioringOffCQRing, int(p.cqOff.cqes+p.cqEntries*uint32(unsafe.Sizeof(ioUringCQE{}))), prot, flags)
+	if err != nil {
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	r.sqMem, err = unix.Mmap(r.fd, ioringOffSQEs, int(p.sqEntries*uint32(unsafe.Sizeof(ioUringSQE{}))), prot, flags)
+	if err != nil {
+		return nil, os.NewSyscallError("mmap", err)
+	}
+
+	r.sqHead = (*uint32)(unsafe.Pointer(&r.sqRing[p.sqOff.head]))
+	r.sqTail = (*uint32)(unsafe.Pointer(&r.sqRing[p.sqOff.tail]))
+	r.sqMask = *(*uint32)(unsafe.Pointer(&r.sqRing[p.sqOff.ringMask]))
+	r.sqArray = unsafe.Slice((*uint32)(unsafe.Pointer(&r.sqRing[p.sqOff.array])), p.sqEntries)
+	r.sqes = unsafe.Slice((*ioUringSQE)(unsafe.Pointer(&r.sqMem[0])), p.sqEntries)
+	r.cqHead = (*uint32)(unsafe.Pointer(&r.cqRing[p.cqOff.head]))
+	r.cqTail = (*uint32)(unsafe.Pointer(&r.cqRing[p.cqOff.tail]))
+	r.cqMask = *(*uint32)(unsafe.Pointer(&r.cqRing[p.cqOff.ringMask]))
+	r.cqes = unsafe.Slice((*ioUringCQE)(unsafe.Pointer(&r.cqRing[p.cqOff.cqes])), p.cqEntries)
+
+	// Register a single empty fixed file slot.
+	files := []int32{-1}
+	_, _, errno = unix.Syscall6(unix.SYS_IO_URING_REGISTER, uintptr(r.fd), ioringRegisterFiles,
+		uintptr(unsafe.Pointer(&files[0])), uintptr(len(files)), 0, 0)
+	if errno != 0 {
+		return nil, os.NewSyscallError("io_uring_register", errno)
+	}
+	return r, nil
+}
+
+func (r *ioUring) close() {
+	for _, m := range [][]byte{r.sqRing, r.cqRing, r.sqMem} {
+		if m != nil {
+			_ = unix.Munmap(m)
+		}
+	}
+	unix.Close(r.fd)
+}
+
+// submit submits the sqes and waits for them to complete, returning their
+// results in the same order.
+func (r *ioUring) submit(sqes []ioUringSQE) ([]int32, error) {
+	n := uint32(len(sqes))
+	tail := *r.sqTail
+	for i := range sqes {
+		idx := (tail + uint32(i)) & r.sqMask
+		sqes[i].userData = uint64(i)
+		r.sqes[idx] = sqes[i]
+		r.sqArray[idx] = idx
+	}
+	tail += n
+	atomic.StoreUint32(r.sqTail, tail)
+
+	cqHead := *r.cqHead
+	for {
+		toSubmit := tail - atomic.LoadUint32(r.sqHead)
+		ready := atomic.LoadUint32(r.cqTail) - cqHead
+		if toSubmit == 0 && ready >= n {
+			break
+		}
+		_, _, errno := unix.Syscall6(unix.SYS_IO_URING_ENTER, uintptr(r.fd),
+			uintptr(toSubmit), uintptr(n-ready), ioringEnterGetevents, 0, 0)
+		if errno != 0 && errno != unix.EINTR {
+			return nil, os.NewSyscallError("io_uring_enter", errno)
+		}
+	}
+
+	res := make([]int32, n)
+	for i := uint32(0); i < n; i++ {
+		cqe := &r.cqes[(cqHead+i)&r.cqMask]
+		res[cqe.userData] = cqe.res
+	}
+	atomic.StoreUint32(r.cqHead, cqHead+n)
+	return res, nil
+}
+
+var (
+	ring     *ioUring
+	ringErr  error
+	ringOnce sync.Once
+	ringMu   sync.Mutex
+)
+
+// batchOp describes an io_uring request done for a cgroup file write.
+type batchOp struct {
+	write int    // Index of the write.
+	op    uint8  // The io_uring opcode.
+	line  []byte // The data written by ioringOpWrite.
+}
+
+func flushBatch(writes []batchWrite) (int, error) {
+	if TestMode || prepareOpenat2() != nil {
+		return flushBatchSeq(writes)
+	}
+	ringOnce.Do(func() {
+		ring, ringErr = newIOUring()
+		if ringErr != nil {
+			logrus.Debugf("falling back to sequential cgroup writes: %v", ringErr)
+		}
+	})
+	if ringErr != nil {
+		return flushBatchSeq(writes)
+	}
+	ringMu.Lock()
+	defer ringMu.Unlock()
+
+	// O_CLOEXEC makes no sense for (and is rejected with) a fixed file.
+	how := &unix.OpenHow{
+		Resolve: resolveFlags,
+		Flags:   unix.O_WRONLY,
+	}
+	paths := make([]string, len(writes))
+	relPaths := make([]*byte, len(writes))
+	for i, w := range writes {
+		paths[i] = path.Join(w.dir, utils.CleanPath(w.file))
+		rel := strings.TrimPrefix(paths[i], cgroupfsPrefix)
+		if w.dir == "" || len(rel) == len(paths[i]) {
+			// Let WriteFile deal with it.
+			return flushBatchSeq(writes)
+		}
+		p, err := unix.BytePtrFromString(rel)
+		if err != nil {
+			return i, &os.PathError{Op: "openat2", Path: paths[i], Err: err}
+		}
+		relPaths[i] = p
+	}
+
+	var ops []batchOp
+	var sqes []ioUringSQE
+	submit := func() (int, error) {
+		if len(sqes) == 0 {
+			return 0, nil
+		}
+		sqes[len(sqes)-1].flags &^= iosqeIOLink
+		res, err := ring.submit(sqes)
+		runtime.KeepAlive(relPaths)
+		runtime.KeepAlive(ops)
+		runtime.KeepAlive(how)
+		if err != nil {
+			return ops[0].write, err
+		}
+		defer func() { ops, sqes = ops[:0], sqes[:0] }()
+		for i, op := range ops {
+			r := res[i]
+			if r >= 0 && (op.op != ioringOpWrite || int(r) == len(op.line)) {
+				continue
+			}
+			// The requests following the failed one are canceled.
+			p := paths[op.write]
+			switch op.op {
+			case ioringOpOpenat2:
+				return op.write, &os.PathError{Op: "openat2", Path: p, Err: unix.Errno(-r)}
+			case ioringOpWrite:
+				ring.closeFile()
+				err := io.ErrShortWrite
+				if r < 0 {
+					err = unix.Errno(-r)
+				}
+				return op.write, fmt.Errorf("failed to write %q: %w", op.line, &os.PathError{Op: "write", Path: p, Err: err})
+			default:
+				return op.write, &os.PathError{Op: "close", Path: p, Err: unix.Errno(-r)}
+			}
+		}
+		return 0, nil
+	}
+
+	for i, w := range writes {
+		lines := splitLines(w.data)
+		if len(lines)+2 > ioringEntries {
+			// Too many lines, do it the usual way.
+			if idx, err := submit(); err != nil {
+				return idx, err
+			}
+			if err := WriteFileByLine(w.dir, w.file, w.data); err != nil {
+				return i, err
+			}
+			continue
+		}
+		if len(sqes)+len(lines)+2 > ioringEntries {
+			if idx, err := submit(); err != nil {
+				return idx, err
+			}
+		}
+		ops = append(ops, batchOp{write: i, op: ioringOpOpenat2})
+		sqes = append(sqes, ioUringSQE{
+			opcode:    ioringOpOpenat2,
+			flags:     iosqeIOLink,
+			fd:        int32(cgroupRootHandle.Fd()),
+			addr:      uint64(uintptr(unsafe.Pointer(relPaths[i]))),
+			len:       uint32(unsafe.Sizeof(*how)),
+			off:       uint64(uintptr(unsafe.Pointer(how))),
+			fileIndex: 1, // Slot 0.
+		})
+		for _, line := range lines {
+			b := []byte(line)
+			var addr uint64
+			if len(b) > 0 {
+				addr = uint64(uintptr(unsafe.Pointer(&b[0])))
+			}
+			ops = append(ops, batchOp{write: i, op: ioringOpWrite, line: b})
+			sqes = append(sqes, ioUringSQE{
+				opcode: ioringOpWrite,
+				flags:  iosqeFixedFile | iosqeIOLink,
+				addr:   addr,
+				len:    uint32(len(b)),
+				off:    ^uint64(0), // Use the file position.
+			})
+		}
+		ops = append(ops, batchOp{write: i, op: ioringOpClose})
+		sqes = append(sqes, ioUringSQE{
+			opcode:    ioringOpClose,
+			flags:     iosqeIOLink,
+			fileIndex: 1,
+		})
+	}
+	return submit()
+}
+
+// closeFile closes the file in the fixed file slot, which is left open if a
+// write to it fails.
+func (r *ioUring) closeFile() {
+	_, _ = r.submit([]ioUringSQE{{opcode: ioringOpClose, fileIndex: 1}})
+}
+
+// splitLines splits data the same way as WriteFileByLine does.
+func splitLines(data string) []string {
+	var lines []string
+	for {
+		i := strings.IndexByte(data, '\n')
+		if i == -1 {
+			return append(lines, data)
+		}
+		lines = append(lines, data[:i+1])
+		data = data[i+1:]
+	}
+}
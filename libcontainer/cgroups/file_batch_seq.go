@@ -0,0 +1,7 @@
+//go:build !runc_iouring
+
+package cgroups
+
+func flushBatch(writes []batchWrite) (int, error) {
+	return flushBatchSeq(writes)
+}
@@ -0,0 +1,98 @@
+package cgroups
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// batchTestCgroup creates a temporary cgroup, returning its path, and the
+// name of a file in it which accepts numbers.
+func batchTestCgroup(tb testing.TB) (string, string) {
+	tb.Helper()
+	if os.Geteuid() != 0 {
+		tb.Skip("requires root")
+	}
+	mnt, file := "/sys/fs/cgroup/pids", "pids.max"
+	if IsCgroup2UnifiedMode() {
+		mnt, file = "/sys/fs/cgroup", "cgroup.max.descendants"
+	}
+	dir, err := os.MkdirTemp(mnt, "runc-test-batch-")
+	if err != nil {
+		tb.Skip(err)
+	}
+	tb.Cleanup(func() { _ = os.Remove(dir) })
+	return dir, file
+}
+
+func TestWriteBatch(t *testing.T) {
+	dir, file := batchTestCgroup(t)
+	check := func(exp string) {
+		t.Helper()
+		val, err := ReadFile(dir, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(val) != exp {
+			t.Fatalf("expected %s, got %s", exp, val)
+		}
+	}
+
+	var b WriteBatch
+	for i := 1; i <= 100; i++ {
+		b.Add(dir, file, strconv.Itoa(i))
+	}
+	if idx, err := b.Flush(); err != nil {
+		t.Fatalf("write %d: %v", idx, err)
+	}
+	if b.Len() != 0 {
+		t.Fatalf("expected empty batch after Flush, got %d writes", b.Len())
+	}
+	check("100")
+
+	// The writes after the failed one must not be done.
+	b.Add(dir, file, "20")
+	b.Add(dir, file, "foo")
+	b.Add(dir, file, "30")
+	idx, err := b.Flush()
+	if err == nil || idx != 1 {
+		t.Fatalf("expected error at write 1, got %d, %v", idx, err)
+	}
+	check("20")
+
+	b.Add(dir, file, "40")
+	b.Add(dir, "no-such-file", "1")
+	idx, err = b.Flush()
+	if !errors.Is(err, os.ErrNotExist) || idx != 1 {
+		t.Fatalf("expected ENOENT at write 1, got %d, %v", idx, err)
+	}
+	check("40")
+}
+
+const benchmarkWrites = 32
+
+func BenchmarkWriteFileSeq(b *testing.B) {
+	dir, file := batchTestCgroup(b)
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < benchmarkWrites; j++ {
+			if err := WriteFile(dir, file, "max"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkWriteBatch(b *testing.B) {
+	dir, file := batchTestCgroup(b)
+	var batch WriteBatch
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < benchmarkWrites; j++ {
+			batch.Add(dir, file, "max")
+		}
+		if _, err := batch.Flush(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
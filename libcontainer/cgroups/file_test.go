@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -95,3 +96,40 @@ func BenchmarkWriteFile(b *testing.B) {
 		}
 	}
 }
+
+func TestRecordWrites(t *testing.T) {
+	TestMode = true
+	defer func() { TestMode = false }()
+
+	dir := t.TempDir()
+	for _, file := range []string{"pids.max", "devices.allow"} {
+		if err := os.WriteFile(filepath.Join(dir, file), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var writes []string
+	stop := RecordWrites(func(path, data string) {
+		writes = append(writes, path+"="+data)
+	})
+	if err := WriteFile(dir, "pids.max", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileByLine(dir, "devices.allow", "c 1:3 rwm\nc 1:5 rwm"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(filepath.Join(dir, "nonexistent"), "pids.max", "1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	stop()
+	if err := WriteFile(dir, "pids.max", "20"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		dir + "/pids.max=10",
+		dir + "/devices.allow=c 1:3 rwm\nc 1:5 rwm",
+	}
+	if !reflect.DeepEqual(writes, want) {
+		t.Errorf("expected writes %q, got %q", want, writes)
+	}
+}
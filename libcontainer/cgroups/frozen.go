@@ -0,0 +1,64 @@
+package cgroups
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FrozenAncestorError is returned by CheckFrozenAncestors when an ancestor of
+// a cgroup is frozen, in which case the processes added to the cgroup are
+// frozen as well.
+type FrozenAncestorError struct {
+	// Path is the path of the frozen ancestor cgroup.
+	Path string
+}
+
+func (e *FrozenAncestorError) Error() string {
+	return "ancestor cgroup " + e.Path + " is frozen"
+}
+
+// CheckFrozenAncestors checks that no ancestor of the cgroup at path (which
+// does not need to exist yet) is frozen, e.g. by systemd or by hand. The
+// path is either a cgroup v2 path, or a cgroup v1 freezer controller one.
+// If a frozen ancestor is found, a *FrozenAncestorError naming the closest
+// one is returned, unless thaw is set, in which case the frozen ancestors
+// are thawed instead.
+func CheckFrozenAncestors(path string, thaw bool) error {
+	if path == "" {
+		return nil
+	}
+	stateFile, frozenVal, thawedVal := "freezer.self_freezing", "1", "THAWED"
+	thawFile := "freezer.state"
+	if IsCgroup2UnifiedMode() {
+		stateFile, frozenVal, thawedVal = "cgroup.freeze", "1", "0"
+		thawFile = stateFile
+	}
+	for dir := filepath.Dir(path); dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		state, err := ReadFile(dir, stateFile)
+		if err != nil {
+			// The root cgroup can't be frozen, and has no such file.
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if strings.TrimSpace(state) != frozenVal {
+			continue
+		}
+		if !thaw {
+			return &FrozenAncestorError{Path: dir}
+		}
+		if err := WriteFile(dir, thawFile, thawedVal); err != nil {
+			return err
+		}
+		logrus.Warnf("thawed frozen ancestor cgroup %s", dir)
+	}
+	return nil
+}
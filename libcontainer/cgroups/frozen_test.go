@@ -0,0 +1,56 @@
+package cgroups
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckFrozenAncestors(t *testing.T) {
+	TestMode = true
+	defer func() { TestMode = false }()
+
+	file, frozen, thawed := "freezer.self_freezing", "1", "0"
+	if IsCgroup2UnifiedMode() {
+		file, frozen, thawed = "cgroup.freeze", "1", "0"
+	}
+	root := t.TempDir()
+	parent := filepath.Join(root, "parent")
+	child := filepath.Join(parent, "child")
+	if err := os.MkdirAll(child, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for dir, val := range map[string]string{parent: frozen, child: thawed} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(val+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The container cgroup itself does not exist yet.
+	path := filepath.Join(child, "ct")
+
+	var frozenErr *FrozenAncestorError
+	err := CheckFrozenAncestors(path, false)
+	if !errors.As(err, &frozenErr) {
+		t.Fatalf("expected a FrozenAncestorError, got %v", err)
+	}
+	if frozenErr.Path != parent {
+		t.Fatalf("expected %s to be frozen, got %s", parent, frozenErr.Path)
+	}
+
+	if err := CheckFrozenAncestors(path, true); err != nil {
+		t.Fatal(err)
+	}
+	thawFile := "freezer.state"
+	if IsCgroup2UnifiedMode() {
+		thawFile = "cgroup.freeze"
+	}
+	data, err := os.ReadFile(filepath.Join(parent, thawFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := strings.TrimSpace(string(data)); state != "THAWED" && state != "0" {
+		t.Fatalf("expected %s to be thawed, got %q", parent, state)
+	}
+}
@@ -688,6 +688,51 @@ func TestThrottleRecursiveBlkioStats(t *testing.T) {
 	expectBlkioStatsEquals(t, expectedStats, actualStats.BlkioStats)
 }
 
+func TestThrottleRecursiveBlkioStatsDiscard(t *testing.T) {
+	// Since Linux 4.19, the stats include discards.
+	path := tempDir(t, "blkio")
+	writeFileContents(t, path, map[string]string{
+		"blkio.throttle.io_service_bytes_recursive": `8:0 Read 4096
+8:0 Write 8192
+8:0 Sync 8192
+8:0 Async 4096
+8:0 Discard 1024
+8:0 Total 13312
+Total 13312`,
+		"blkio.throttle.io_serviced_recursive": `8:0 Read 1
+8:0 Write 2
+8:0 Sync 2
+8:0 Async 1
+8:0 Discard 1
+8:0 Total 4
+Total 4`,
+	})
+
+	blkio := &BlkioGroup{}
+	actualStats := *cgroups.NewStats()
+	err := blkio.GetStats(path, &actualStats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedStats := cgroups.BlkioStats{}
+	appendBlkioStatEntry(&expectedStats.IoServiceBytesRecursive, 8, 0, 4096, "Read")
+	appendBlkioStatEntry(&expectedStats.IoServiceBytesRecursive, 8, 0, 8192, "Write")
+	appendBlkioStatEntry(&expectedStats.IoServiceBytesRecursive, 8, 0, 8192, "Sync")
+	appendBlkioStatEntry(&expectedStats.IoServiceBytesRecursive, 8, 0, 4096, "Async")
+	appendBlkioStatEntry(&expectedStats.IoServiceBytesRecursive, 8, 0, 1024, "Discard")
+	appendBlkioStatEntry(&expectedStats.IoServiceBytesRecursive, 8, 0, 13312, "Total")
+
+	appendBlkioStatEntry(&expectedStats.IoServicedRecursive, 8, 0, 1, "Read")
+	appendBlkioStatEntry(&expectedStats.IoServicedRecursive, 8, 0, 2, "Write")
+	appendBlkioStatEntry(&expectedStats.IoServicedRecursive, 8, 0, 2, "Sync")
+	appendBlkioStatEntry(&expectedStats.IoServicedRecursive, 8, 0, 1, "Async")
+	appendBlkioStatEntry(&expectedStats.IoServicedRecursive, 8, 0, 1, "Discard")
+	appendBlkioStatEntry(&expectedStats.IoServicedRecursive, 8, 0, 4, "Total")
+
+	expectBlkioStatsEquals(t, expectedStats, actualStats.BlkioStats)
+}
+
 func TestThrottleBlkioStats(t *testing.T) {
 	path := tempDir(t, "blkio")
 	writeFileContents(t, path, map[string]string{
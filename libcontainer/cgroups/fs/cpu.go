@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/cgroups/fscommon"
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -20,9 +23,23 @@ func (s *CpuGroup) Name() string {
 }
 
 func (s *CpuGroup) Apply(path string, r *configs.Resources, pid int) error {
-	if err := os.MkdirAll(path, 0o755); err != nil {
+	created, err := mkdirAll(path)
+	if err != nil {
 		return err
 	}
+	// A new cgroup v1 has no real-time runtime, so give the parents
+	// created above the real-time budget of the container, for it to fit
+	// in (e.g. when a container is restored under a new parent).
+	if r.CpuRtRuntime > 0 {
+		for _, dir := range created {
+			if dir == path {
+				break
+			}
+			if err := s.SetRtSched(dir, r); err != nil {
+				return err
+			}
+		}
+	}
 	// We should set the real-Time group scheduling settings before moving
 	// in the process because if the process is already in SCHED_RR mode
 	// and no RT bandwidth is set, adding it will fail.
@@ -31,7 +48,34 @@ func (s *CpuGroup) Apply(path string, r *configs.Resources, pid int) error {
 	}
 	// Since we are not using apply(), we need to place the pid
 	// into the procs file.
-	return cgroups.WriteCgroupProc(path, pid)
+	return cgroups.WriteCgroupProcRetry(path, pid, r.GetAttachRetry())
+}
+
+// mkdirAll is like os.MkdirAll, but also returns the directories it created,
+// the outermost first.
+func mkdirAll(path string) ([]string, error) {
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		_, err := os.Stat(dir)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) || dir == filepath.Dir(dir) {
+			return nil, err
+		}
+		missing = append(missing, dir)
+	}
+	created := make([]string, 0, len(missing))
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0o755); err != nil {
+			if errors.Is(err, os.ErrExist) {
+				continue
+			}
+			return nil, err
+		}
+		created = append(created, missing[i])
+	}
+	return created, nil
 }
 
 func (s *CpuGroup) SetRtSched(path string, r *configs.Resources) error {
@@ -53,11 +97,11 @@ func (s *CpuGroup) SetRtSched(path string, r *configs.Resources) error {
 	}
 	if r.CpuRtRuntime != 0 {
 		if err := cgroups.WriteFile(path, "cpu.rt_runtime_us", strconv.FormatInt(r.CpuRtRuntime, 10)); err != nil {
-			return err
+			return rtWriteError(path, r, err)
 		}
 		if period != "" {
 			if err := cgroups.WriteFile(path, "cpu.rt_period_us", period); err != nil {
-				return err
+				return rtWriteError(path, r, err)
 			}
 		}
 	}
@@ -126,11 +170,11 @@ func (s *CpuGroup) Set(path string, r *configs.Resources) error {
 	}
 	if r.CpuQuota != 0 {
 		if err := cgroups.WriteFile(path, "cpu.cfs_quota_us", strconv.FormatInt(r.CpuQuota, 10)); err != nil {
-			return err
+			return cfsQuotaError(path, r, err)
 		}
 		if period != "" {
 			if err := cgroups.WriteFile(path, "cpu.cfs_period_us", period); err != nil {
-				return err
+				return cfsQuotaError(path, r, err)
 			}
 		}
 		if burst != "" {
@@ -147,10 +191,65 @@ func (s *CpuGroup) Set(path string, r *configs.Resources) error {
 		}
 	}
 
+	if r.CpuLatencyNice != nil {
+		if err := setLatencyNice(path, *r.CpuLatencyNice); err != nil {
+			return err
+		}
+	}
+
 	return s.SetRtSched(path, r)
 }
 
+// rtWriteError wraps the error of a real-time bandwidth write, explaining
+// why it was rejected if the bandwidth does not fit in that of the parent
+// cgroup (minus that of the siblings), or is lower than that of the
+// sub-cgroups.
+func rtWriteError(path string, r *configs.Resources, err error) error {
+	if !errors.Is(err, unix.EINVAL) && !errors.Is(err, unix.EBUSY) {
+		return err
+	}
+	if why := cgroups.ExplainRtBandwidth(path, r.CpuRtRuntime, r.CpuRtPeriod, r.CpusetCpus); why != "" {
+		return fmt.Errorf("%w (%s): %w", err, why, cgroups.ErrBudgetExceeded)
+	}
+	// EBUSY means the runtime can't be lowered as there are
+	// real-time tasks in the cgroup.
+	if errors.Is(err, unix.EBUSY) {
+		return fmt.Errorf("%w: %w", err, cgroups.ErrBudgetExceeded)
+	}
+	return err
+}
+
+// cfsQuotaError wraps the error of a CFS quota write, explaining why it
+// was rejected if the quota exceeds that of an ancestor cgroup.
+func cfsQuotaError(path string, r *configs.Resources, err error) error {
+	if !errors.Is(err, unix.EINVAL) {
+		return err
+	}
+	if why := cgroups.ExplainCfsQuota(path, r.CpuQuota, r.CpuPeriod); why != "" {
+		return fmt.Errorf("%w (%s)", err, why)
+	}
+	return err
+}
+
+// setLatencyNice sets the latency nice value of the cgroup, if supported by
+// the kernel.
+func setLatencyNice(path string, nice int64) error {
+	err := cgroups.WriteFile(path, "cpu.latency.nice", strconv.FormatInt(nice, 10))
+	if errors.Is(err, os.ErrNotExist) {
+		logrus.Warnf("cpu.latency.nice is not supported by the kernel, ignoring latency nice value %d", nice)
+		return nil
+	}
+	return err
+}
+
 func (s *CpuGroup) GetStats(path string, stats *cgroups.Stats) error {
+	if err := getRtBandwidth(path, stats); err != nil {
+		return err
+	}
+	if err := getIdle(path, stats); err != nil {
+		return err
+	}
+
 	const file = "cpu.stat"
 	f, err := cgroups.OpenFile(path, file, os.O_RDONLY)
 	if err != nil {
@@ -176,7 +275,44 @@ func (s *CpuGroup) GetStats(path string, stats *cgroups.Stats) error {
 
 		case "throttled_time":
 			stats.CpuStats.ThrottlingData.ThrottledTime = v
+
+		case "nr_bursts":
+			stats.CpuStats.BurstData.BurstsPeriods = v
+
+		case "burst_time":
+			stats.CpuStats.BurstData.BurstTime = v
 		}
 	}
 	return nil
 }
+
+func getRtBandwidth(path string, stats *cgroups.Stats) error {
+	runtime, err := fscommon.GetCgroupParamInt(path, "cpu.rt_runtime_us")
+	if err != nil {
+		// No RT group scheduling (CONFIG_RT_GROUP_SCHED is not set).
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	period, err := fscommon.GetCgroupParamUint(path, "cpu.rt_period_us")
+	if err != nil {
+		return err
+	}
+	stats.CpuStats.RtBandwidth = &cgroups.RtBandwidth{Runtime: runtime, Period: period}
+	stats.CpuStats.RtHeadroom, err = cgroups.GetRtHeadroom(path)
+	return err
+}
+
+func getIdle(path string, stats *cgroups.Stats) error {
+	idle, err := fscommon.GetCgroupParamInt(path, "cpu.idle")
+	if err != nil {
+		// cpu.idle is only available since Linux 5.15.
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	stats.CpuStats.Idle = &idle
+	return nil
+}
@@ -1,7 +1,10 @@
 package fs
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -116,6 +119,34 @@ func TestCpuSetBandWidth(t *testing.T) {
 	}
 }
 
+func TestCpuSetLatencyNice(t *testing.T) {
+	path := tempDir(t, "cpu")
+
+	nice := int64(-5)
+	r := &configs.Resources{
+		CpuLatencyNice: &nice,
+	}
+	cpu := &CpuGroup{}
+	// No cpu.latency.nice file, so it should be ignored.
+	if err := cpu.Set(path, r); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFileContents(t, path, map[string]string{
+		"cpu.latency.nice": "0",
+	})
+	if err := cpu.Set(path, r); err != nil {
+		t.Fatal(err)
+	}
+	value, err := fscommon.GetCgroupParamInt(path, "cpu.latency.nice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != nice {
+		t.Fatalf("expected cpu.latency.nice %d, got %d", nice, value)
+	}
+}
+
 func TestCpuStats(t *testing.T) {
 	path := tempDir(t, "cpu")
 
@@ -147,6 +178,58 @@ func TestCpuStats(t *testing.T) {
 	expectThrottlingDataEquals(t, expectedStats, actualStats.CpuStats.ThrottlingData)
 }
 
+func TestCpuStatsBurst(t *testing.T) {
+	path := tempDir(t, "cpu")
+	writeFileContents(t, path, map[string]string{
+		"cpu.stat": "nr_periods 2000\nnr_throttled 200\nthrottled_time 42\nnr_bursts 20\nburst_time 5000\n",
+	})
+
+	cpu := &CpuGroup{}
+	actualStats := *cgroups.NewStats()
+	if err := cpu.GetStats(path, &actualStats); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := cgroups.BurstData{BurstsPeriods: 20, BurstTime: 5000}
+	if actualStats.CpuStats.BurstData != expected {
+		t.Errorf("expected burst data %+v, got %+v", expected, actualStats.CpuStats.BurstData)
+	}
+}
+
+func TestCpuStatsRtBandwidth(t *testing.T) {
+	path := tempDir(t, "cpu")
+	writeFileContents(t, path, map[string]string{
+		"cpu.rt_runtime_us": "-1",
+		"cpu.rt_period_us":  "1000000",
+	})
+
+	cpu := &CpuGroup{}
+	actualStats := *cgroups.NewStats()
+	if err := cpu.GetStats(path, &actualStats); err != nil {
+		t.Fatal(err)
+	}
+	rt := actualStats.CpuStats.RtBandwidth
+	if rt == nil || rt.Runtime != -1 || rt.Period != 1000000 {
+		t.Fatalf("unexpected rt bandwidth: %+v", rt)
+	}
+}
+
+func TestCpuStatsIdle(t *testing.T) {
+	path := tempDir(t, "cpu")
+	writeFileContents(t, path, map[string]string{
+		"cpu.idle": "1",
+	})
+
+	cpu := &CpuGroup{}
+	actualStats := *cgroups.NewStats()
+	if err := cpu.GetStats(path, &actualStats); err != nil {
+		t.Fatal(err)
+	}
+	if idle := actualStats.CpuStats.Idle; idle == nil || *idle != 1 {
+		t.Fatalf("expected idle 1, got %v", idle)
+	}
+}
+
 func TestNoCpuStatFile(t *testing.T) {
 	path := tempDir(t, "cpu")
 
@@ -225,3 +308,32 @@ func TestCpuSetRtSchedAtApply(t *testing.T) {
 		t.Fatal("Got the wrong value, set cgroup.procs failed.")
 	}
 }
+
+func TestCpuSetRtSchedAtApplyNewParent(t *testing.T) {
+	root := tempDir(t, "cpu")
+	parent := filepath.Join(root, "pod")
+	path := filepath.Join(parent, "ctr")
+
+	r := &configs.Resources{
+		CpuRtRuntime: 5000,
+		CpuRtPeriod:  7000,
+	}
+	if err := (&CpuGroup{}).Apply(path, r, 1234); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new parent is given the budget of the container.
+	for _, dir := range []string{parent, path} {
+		rtRuntime, err := fscommon.GetCgroupParamUint(dir, "cpu.rt_runtime_us")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rtRuntime != 5000 {
+			t.Errorf("%s: expected cpu.rt_runtime_us 5000, got %d", dir, rtRuntime)
+		}
+	}
+	// But not the existing one.
+	if _, err := os.Stat(filepath.Join(root, "cpu.rt_runtime_us")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected cpu.rt_runtime_us not to be set in %s", root)
+	}
+}
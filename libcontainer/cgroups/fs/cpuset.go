@@ -2,6 +2,7 @@ package fs
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -138,6 +139,16 @@ func (s *CpusetGroup) GetStats(path string, stats *cgroups.Stats) error {
 		return err
 	}
 
+	stats.CPUSetStats.EffectiveCPUs, err = getCpusetStat(path, "cpuset.effective_cpus")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	stats.CPUSetStats.EffectiveMems, err = getCpusetStat(path, "cpuset.effective_mems")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
 	return nil
 }
 
@@ -168,7 +179,7 @@ func (s *CpusetGroup) ApplyDir(dir string, r *configs.Resources, pid int) error
 	}
 	// Since we are not using apply(), we need to place the pid
 	// into the procs file.
-	return cgroups.WriteCgroupProc(dir, pid)
+	return cgroups.WriteCgroupProcRetry(dir, pid, r.GetAttachRetry())
 }
 
 func getCpusetSubsystemSettings(parent string) (cpus, mems string, err error) {
@@ -184,7 +195,9 @@ func getCpusetSubsystemSettings(parent string) (cpus, mems string, err error) {
 // cpusetEnsureParent makes sure that the parent directories of current
 // are created and populated with the proper cpus and mems files copied
 // from their respective parent. It does that recursively, starting from
-// the top of the cpuset hierarchy (i.e. cpuset cgroup mount point).
+// the top of the cpuset hierarchy (i.e. cpuset cgroup mount point), or
+// from the top of the subtree delegated to us, if runc is running inside
+// another container.
 func cpusetEnsureParent(current string) error {
 	var st unix.Statfs_t
 
@@ -199,8 +212,12 @@ func cpusetEnsureParent(current string) error {
 		return &os.PathError{Op: "statfs", Path: parent, Err: err}
 	}
 
-	if err := cpusetEnsureParent(parent); err != nil {
-		return err
+	// Do not go above the delegation boundary, as the cgroups there are
+	// managed by someone else.
+	if err != nil || unix.Faccessat(unix.AT_FDCWD, parent, unix.W_OK, unix.AT_EACCESS) == nil {
+		if err := cpusetEnsureParent(parent); err != nil {
+			return err
+		}
 	}
 	if err := os.Mkdir(current, 0o755); err != nil && !os.IsExist(err) {
 		return err
@@ -208,6 +225,123 @@ func cpusetEnsureParent(current string) error {
 	return cpusetCopyIfNeeded(current, parent)
 }
 
+// RestoreCpuset re-asserts the cpus and mems of the cpuset directory dir
+// after a CPU (or memory node) hotplug: the kernel removes the CPUs going
+// offline from all the cpusets, but does not add them back once they are
+// online again. The requested CPUs which are online (those of the root
+// cpuset) are added back to the ancestors of dir, and dir is set to them.
+// The cpus and mems set are returned; empty cpus or mems are left alone.
+func RestoreCpuset(dir, cpus, mems string) (newCpus, newMems string, _ error) {
+	var ancestors []string
+	for p := filepath.Dir(dir); ; p = filepath.Dir(p) {
+		if _, err := os.Stat(filepath.Join(p, "cpuset.cpus")); err != nil {
+			break
+		}
+		ancestors = append(ancestors, p)
+		if p == filepath.Dir(p) {
+			break
+		}
+	}
+	if len(ancestors) == 0 {
+		return "", "", fmt.Errorf("%s is not a cpuset cgroup", dir)
+	}
+	root := ancestors[len(ancestors)-1]
+	for _, f := range []struct {
+		file, list string
+		res        *string
+	}{
+		{"cpuset.cpus", cpus, &newCpus},
+		{"cpuset.mems", mems, &newMems},
+	} {
+		if f.list == "" {
+			continue
+		}
+		requested, err := cgroups.ParseCPUList(f.list)
+		if err != nil {
+			return "", "", err
+		}
+		online, err := readCpusetList(root, f.file)
+		if err != nil {
+			return "", "", err
+		}
+		want := intersectLists(requested, online)
+		if len(want) == 0 {
+			return "", "", fmt.Errorf("none of the requested %s %s are online", f.file, f.list)
+		}
+		// From the outermost ancestor below the root (which can't be
+		// written to) down to the parent of dir.
+		for i := len(ancestors) - 2; i >= 0; i-- {
+			cur, err := readCpusetList(ancestors[i], f.file)
+			if err != nil {
+				return "", "", err
+			}
+			if merged := mergeLists(cur, want); len(merged) != len(cur) {
+				if err := cgroups.WriteFile(ancestors[i], f.file, cgroups.FormatCPUList(merged)); err != nil {
+					return "", "", err
+				}
+			}
+		}
+		*f.res = cgroups.FormatCPUList(want)
+		if err := cgroups.WriteFile(dir, f.file, *f.res); err != nil {
+			return "", "", err
+		}
+	}
+	return newCpus, newMems, nil
+}
+
+func readCpusetList(dir, file string) ([]int, error) {
+	data, err := cgroups.ReadFile(dir, file)
+	if err != nil {
+		return nil, err
+	}
+	list, err := cgroups.ParseCPUList(data)
+	if err != nil {
+		return nil, &parseError{Path: dir, File: file, Err: err}
+	}
+	return list, nil
+}
+
+// intersectLists returns the elements of the sorted list a which are in the
+// sorted list b.
+func intersectLists(a, b []int) []int {
+	var res []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			res = append(res, a[i])
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+// mergeLists returns the sorted union of the sorted lists a and b.
+func mergeLists(a, b []int) []int {
+	res := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			res = append(res, a[i])
+			i++
+		case a[i] > b[j]:
+			res = append(res, b[j])
+			j++
+		default:
+			res = append(res, a[i])
+			i++
+			j++
+		}
+	}
+	res = append(res, a[i:]...)
+	return append(res, b[j:]...)
+}
+
 // cpusetCopyIfNeeded copies the cpuset.cpus and cpuset.mems from the parent
 // directory to the current directory if the file's contents are 0
 func cpusetCopyIfNeeded(current, parent string) error {
@@ -243,3 +377,21 @@ func (s *CpusetGroup) ensureCpusAndMems(path string, r *configs.Resources) error
 	}
 	return cpusetCopyIfNeeded(path, filepath.Dir(path))
 }
+
+// GetEffectiveCpus returns the effective CPUs of the cpuset cgroup at path.
+func GetEffectiveCpus(path string) (string, error) {
+	return getEffective(path, "cpuset.effective_cpus")
+}
+
+// GetEffectiveMems returns the effective memory nodes of the cpuset cgroup
+// at path.
+func GetEffectiveMems(path string) (string, error) {
+	return getEffective(path, "cpuset.effective_mems")
+}
+
+func getEffective(path, file string) (string, error) {
+	if path == "" {
+		return "", &cgroups.ControllerUnavailableError{Controller: "cpuset"}
+	}
+	return fscommon.GetCgroupParamString(path, file)
+}
@@ -1,7 +1,11 @@
 package fs
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
@@ -240,3 +244,64 @@ func TestCPUSetStatsMissingFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestCPUSetGetEffective(t *testing.T) {
+	path := tempDir(t, "cpuset")
+	writeFileContents(t, path, map[string]string{
+		"cpuset.effective_cpus": "0-3,7\n",
+		"cpuset.effective_mems": "0\n",
+	})
+
+	cpus, err := GetEffectiveCpus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpus != "0-3,7" {
+		t.Errorf("expected effective cpus 0-3,7, got %q", cpus)
+	}
+	mems, err := GetEffectiveMems(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mems != "0" {
+		t.Errorf("expected effective mems 0, got %q", mems)
+	}
+
+	if _, err := GetEffectiveCpus(""); !errors.Is(err, cgroups.ErrControllerUnavailable) {
+		t.Errorf("expected ErrControllerUnavailable, got %v", err)
+	}
+}
+
+func TestRestoreCpuset(t *testing.T) {
+	root := tempDir(t, "cpuset")
+	parent := filepath.Join(root, "pod")
+	dir := filepath.Join(parent, "ct")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// CPUs 2-3 were offlined, and 2 is back online.
+	writeFileContents(t, root, map[string]string{"cpuset.cpus": "0-2\n", "cpuset.mems": "0\n"})
+	writeFileContents(t, parent, map[string]string{"cpuset.cpus": "0-1\n", "cpuset.mems": "0\n"})
+	writeFileContents(t, dir, map[string]string{"cpuset.cpus": "1\n", "cpuset.mems": "0\n"})
+
+	cpus, mems, err := RestoreCpuset(dir, "1-3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpus != "1-2" || mems != "" {
+		t.Errorf("expected cpus 1-2 and no mems, got %q and %q", cpus, mems)
+	}
+	for d, expected := range map[string]string{root: "0-2\n", parent: "0-2", dir: "1-2"} {
+		value, err := fscommon.GetCgroupParamString(d, "cpuset.cpus")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != strings.TrimSpace(expected) {
+			t.Errorf("%s: expected cpus %q, got %q", d, strings.TrimSpace(expected), value)
+		}
+	}
+
+	if _, _, err := RestoreCpuset(dir, "3", ""); err == nil {
+		t.Error("expected an error for offline cpus, got nil")
+	}
+}
@@ -21,7 +21,7 @@ func (s *DevicesGroup) Apply(path string, r *configs.Resources, pid int) error {
 		return errSubsystemDoesNotExist
 	}
 
-	return apply(path, pid)
+	return apply(path, r, pid)
 }
 
 func (s *DevicesGroup) Set(path string, r *configs.Resources) error {
@@ -19,8 +19,8 @@ func (s *FreezerGroup) Name() string {
 	return "freezer"
 }
 
-func (s *FreezerGroup) Apply(path string, _ *configs.Resources, pid int) error {
-	return apply(path, pid)
+func (s *FreezerGroup) Apply(path string, r *configs.Resources, pid int) error {
+	return apply(path, r, pid)
 }
 
 func (s *FreezerGroup) Set(path string, r *configs.Resources) (Err error) {
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"sync"
 
+	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
@@ -111,6 +113,11 @@ func (m *Manager) Apply(pid int) (err error) {
 
 	c := m.cgroups
 
+	policy, r, err := cgroups.PreApplyPolicy(c, m.paths)
+	if err != nil {
+		return err
+	}
+
 	for _, sys := range subsystems {
 		name := sys.Name()
 		p, ok := m.paths[name]
@@ -118,7 +125,22 @@ func (m *Manager) Apply(pid int) (err error) {
 			continue
 		}
 
-		if err := sys.Apply(p, c.Resources, pid); err != nil {
+		if c.Adopt {
+			// The cgroup is created by an external manager, so only
+			// check it exists and add the process to it.
+			if _, err := os.Stat(p); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return fmt.Errorf("adopted cgroup %s does not exist", p)
+				}
+				return err
+			}
+			if err := cgroups.WriteCgroupProcRetry(p, pid, c.Resources.GetAttachRetry()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := sys.Apply(p, r, pid); err != nil {
 			// In the case of rootless (including euid=0 in userns), where an
 			// explicit cgroup path hasn't been set, we don't bail on error in
 			// case of permission problems here, but do delete the path from
@@ -134,15 +156,91 @@ func (m *Manager) Apply(pid int) (err error) {
 			}
 			return err
 		}
-
+		if c.OwnerUID != nil {
+			if err := cgroups.Delegate(p, *c.OwnerUID); err != nil {
+				return err
+			}
+		}
 	}
-	return nil
+	return cgroups.PostApplyPolicy(c, policy, m.paths, r, pid)
 }
 
 func (m *Manager) Destroy() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return cgroups.RemovePaths(m.paths)
+	if m.cgroups.Adopt {
+		// The cgroup is owned by an external manager.
+		return nil
+	}
+	return removePaths(m.paths)
+}
+
+// removePaths removes the cgroup paths, by subsystem, killing the processes
+// left in them and in their sub-cgroups (see [cgroups.RemovePathKill]). The
+// freezer hierarchy goes first, so that the processes are frozen while they
+// are being killed; once they are gone, the other hierarchies are empty too.
+// The removed paths are deleted from paths.
+func removePaths(paths map[string]string) error {
+	var errs []error
+	remove := func(name string, kill func(string) error) {
+		if err := cgroups.RemovePathKill(paths[name], kill); err != nil {
+			errs = append(errs, err)
+			return
+		}
+		delete(paths, name)
+	}
+	if _, ok := paths["freezer"]; ok {
+		remove("freezer", killFrozen)
+	}
+	for name := range paths {
+		switch name {
+		case "freezer":
+		case "":
+			// The unified hierarchy, in the hybrid mode.
+			remove(name, killUnified)
+		default:
+			remove(name, cgroups.KillProcs)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("unable to remove cgroup paths: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// killFrozen kills the processes in the freezer cgroup path, freezing it
+// first so that they can't fork while being killed.
+func killFrozen(path string) error {
+	if inCgroup(path) {
+		// Can't freeze ourselves.
+		return cgroups.KillProcs(path)
+	}
+	if err := (&FreezerGroup{}).Set(path, &configs.Resources{Freezer: configs.Frozen}); err != nil {
+		logrus.Debugf("unable to freeze %s: %v", path, err)
+	}
+	err := cgroups.KillProcs(path)
+	// Killing a frozen process does nothing until it's thawed.
+	if terr := cgroups.WriteFile(path, "freezer.state", string(configs.Thawed)); err == nil {
+		err = terr
+	}
+	return err
+}
+
+// killUnified kills the processes in the cgroup v2 path, using cgroup.kill
+// if available.
+func killUnified(path string) error {
+	if !inCgroup(path) {
+		if err := cgroups.Kill(path); !errors.Is(err, cgroups.ErrKillUnsupported) {
+			return err
+		}
+	}
+	return cgroups.KillProcs(path)
+}
+
+// inCgroup returns whether the current process is in the cgroup path.
+func inCgroup(path string) bool {
+	pids, _ := cgroups.GetPids(path)
+	return slices.Contains(pids, os.Getpid())
 }
 
 func (m *Manager) Path(subsys string) string {
@@ -151,6 +249,12 @@ func (m *Manager) Path(subsys string) string {
 	return m.paths[subsys]
 }
 
+// ControllerPath implements [cgroups.Manager.ControllerPath]. The paths are
+// already by controller, including the hybrid one ("").
+func (m *Manager) ControllerPath(name string) string {
+	return m.Path(name)
+}
+
 func (m *Manager) GetStats() (*cgroups.Stats, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -191,7 +295,7 @@ func (m *Manager) Set(r *configs.Resources) error {
 			if path == "" {
 				// We never created a path for this cgroup, so we cannot set
 				// limits for it (though we have already tried at this point).
-				return fmt.Errorf("cannot set %s limit: container could not join or create cgroup", sys.Name())
+				return fmt.Errorf("cannot set %s limit: container could not join or create cgroup: %w", sys.Name(), cgroups.ErrControllerUnavailable)
 			}
 			return err
 		}
@@ -200,6 +304,10 @@ func (m *Manager) Set(r *configs.Resources) error {
 	return nil
 }
 
+func (m *Manager) Diff(r *configs.Resources) ([]cgroups.FileChange, error) {
+	return cgroups.DiffSet(r, m.Set)
+}
+
 // Freeze toggles the container's freezer cgroup depending on the state
 // provided
 func (m *Manager) Freeze(state configs.FreezerState) error {
@@ -250,10 +358,28 @@ func (m *Manager) Exists() bool {
 	return cgroups.PathExists(m.Path("devices"))
 }
 
+func (m *Manager) ResetMemoryPeak() error {
+	return ResetMemoryPeak(m.Path("memory"))
+}
+
 func OOMKillCount(path string) (uint64, error) {
 	return fscommon.GetValueByKey(path, "memory.oom_control", "oom_kill")
 }
 
+// Kill kills all the container processes using cgroup.kill, which is only
+// available for the unified hierarchy in the hybrid mode.
+func (m *Manager) Kill() error {
+	return cgroups.Kill(m.Path(""))
+}
+
+func (m *Manager) GetEffectiveCpus() (string, error) {
+	return GetEffectiveCpus(m.Path("cpuset"))
+}
+
+func (m *Manager) GetEffectiveMems() (string, error) {
+	return GetEffectiveMems(m.Path("cpuset"))
+}
+
 func (m *Manager) OOMKillCount() (uint64, error) {
 	c, err := OOMKillCount(m.Path("memory"))
 	// Ignore ENOENT when rootless as it couldn't create cgroup.
@@ -1,6 +1,11 @@
 package fs
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
@@ -48,3 +53,61 @@ func BenchmarkGetStats(b *testing.B) {
 		b.Fatalf("stats: %+v", st)
 	}
 }
+
+func TestDestroyPopulated(t *testing.T) {
+	if cgroups.IsCgroup2UnifiedMode() {
+		t.Skip("cgroup v2 is not supported")
+	}
+	if os.Geteuid() != 0 {
+		t.Skip("Test requires root.")
+	}
+	cgroups.TestMode = false
+	defer func() {
+		cgroups.TestMode = true
+	}()
+
+	cg := &configs.Cgroup{
+		Path:      "/runc-test-destroy-populated",
+		Resources: &configs.Resources{},
+	}
+	m, err := NewManager(cg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Apply(-1); err != nil {
+		t.Fatal(err)
+	}
+	// A nested sub-cgroup with a process in it, in every hierarchy
+	// except cpuset, which would need its cpus and mems set.
+	cmd := exec.Command("sleep", "1000")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	for name, path := range m.GetPaths() {
+		if name == "cpuset" {
+			continue
+		}
+		sub := filepath.Join(path, "a", "b")
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := cgroups.WriteCgroupProc(sub, cmd.Process.Pid); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	for name, path := range m.GetPaths() {
+		t.Errorf("%s: %s is left", name, path)
+	}
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/stat")
+	if err == nil && !strings.Contains(string(data), ") Z") {
+		t.Errorf("process %d is still running", cmd.Process.Pid)
+	}
+}
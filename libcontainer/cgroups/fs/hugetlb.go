@@ -16,8 +16,8 @@ func (s *HugetlbGroup) Name() string {
 	return "hugetlb"
 }
 
-func (s *HugetlbGroup) Apply(path string, _ *configs.Resources, pid int) error {
-	return apply(path, pid)
+func (s *HugetlbGroup) Apply(path string, r *configs.Resources, pid int) error {
+	return apply(path, r, pid)
 }
 
 func (s *HugetlbGroup) Set(path string, r *configs.Resources) error {
@@ -30,8 +30,8 @@ func (s *MemoryGroup) Name() string {
 	return "memory"
 }
 
-func (s *MemoryGroup) Apply(path string, _ *configs.Resources, pid int) error {
-	return apply(path, pid)
+func (s *MemoryGroup) Apply(path string, r *configs.Resources, pid int) error {
+	return apply(path, r, pid)
 }
 
 func setMemory(path string, val int64) error {
@@ -356,3 +356,20 @@ func getNUMAField(stats *cgroups.PageUsageByNUMA, name string) *cgroups.PageStat
 	}
 	return nil
 }
+
+// ResetMemoryPeak resets the peak memory usage of the cgroup v1 at path, and
+// its peak memory+swap usage if swap is accounted, to the current usage.
+func ResetMemoryPeak(path string) error {
+	if path == "" {
+		return &cgroups.ControllerUnavailableError{Controller: "memory"}
+	}
+	if err := cgroups.WriteFile(path, cgroupMemoryMaxUsage, "0"); err != nil {
+		return err
+	}
+	err := cgroups.WriteFile(path, "memory.memsw.max_usage_in_bytes", "0")
+	if errors.Is(err, os.ErrNotExist) {
+		// No swap accounting.
+		return nil
+	}
+	return err
+}
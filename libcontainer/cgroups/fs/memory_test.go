@@ -505,3 +505,28 @@ func TestWithoutNumaStat(t *testing.T) {
 	}
 	expectPageUsageByNUMAEquals(t, cgroups.PageUsageByNUMA{}, actualStats)
 }
+
+func TestResetMemoryPeak(t *testing.T) {
+	path := tempDir(t, "memory")
+	writeFileContents(t, path, map[string]string{
+		"memory.max_usage_in_bytes":       memoryMaxUsageContents,
+		"memory.memsw.max_usage_in_bytes": memoryMaxUsageContents,
+	})
+
+	if err := ResetMemoryPeak(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"memory.max_usage_in_bytes", "memory.memsw.max_usage_in_bytes"} {
+		value, err := fscommon.GetCgroupParamUint(path, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != 0 {
+			t.Errorf("expected %s to be reset, got %d", file, value)
+		}
+	}
+
+	if err := ResetMemoryPeak(""); err == nil {
+		t.Error("expected an error without the memory controller, got nil")
+	}
+}
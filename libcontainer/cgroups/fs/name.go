@@ -14,10 +14,10 @@ func (s *NameGroup) Name() string {
 	return s.GroupName
 }
 
-func (s *NameGroup) Apply(path string, _ *configs.Resources, pid int) error {
+func (s *NameGroup) Apply(path string, r *configs.Resources, pid int) error {
 	if s.Join {
 		// Ignore errors if the named cgroup does not exist.
-		_ = apply(path, pid)
+		_ = apply(path, r, pid)
 	}
 	return nil
 }
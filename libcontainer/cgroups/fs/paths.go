@@ -175,12 +175,12 @@ func subsysPath(root, inner, subsystem string) (string, error) {
 	return filepath.Join(parentPath, inner), nil
 }
 
-func apply(path string, pid int) error {
+func apply(path string, r *configs.Resources, pid int) error {
 	if path == "" {
 		return nil
 	}
 	if err := os.MkdirAll(path, 0o755); err != nil {
 		return err
 	}
-	return cgroups.WriteCgroupProc(path, pid)
+	return cgroups.WriteCgroupProcRetry(path, pid, r.GetAttachRetry())
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
@@ -14,7 +15,7 @@ import (
 )
 
 func isCpuSet(r *configs.Resources) bool {
-	return r.CpuWeight != 0 || r.CpuQuota != 0 || r.CpuPeriod != 0 || r.CPUIdle != nil || r.CpuBurst != nil
+	return r.CpuWeight != 0 || r.CpuQuota != 0 || r.CpuPeriod != 0 || r.CPUIdle != nil || r.CpuBurst != nil || r.CpuLatencyNice != nil
 }
 
 func setCpu(dirPath string, r *configs.Resources) error {
@@ -28,6 +29,15 @@ func setCpu(dirPath string, r *configs.Resources) error {
 		}
 	}
 
+	if r.CpuLatencyNice != nil {
+		err := cgroups.WriteFile(dirPath, "cpu.latency.nice", strconv.FormatInt(*r.CpuLatencyNice, 10))
+		if errors.Is(err, os.ErrNotExist) {
+			logrus.Warnf("cpu.latency.nice is not supported by the kernel, ignoring latency nice value %d", *r.CpuLatencyNice)
+		} else if err != nil {
+			return err
+		}
+	}
+
 	// NOTE: .CpuShares is not used here. Conversion is the caller's responsibility.
 	if r.CpuWeight != 0 {
 		if err := cgroups.WriteFile(dirPath, "cpu.weight", strconv.FormatUint(r.CpuWeight, 10)); err != nil {
@@ -109,10 +119,26 @@ func statCpu(dirPath string, stats *cgroups.Stats) error {
 
 		case "throttled_usec":
 			stats.CpuStats.ThrottlingData.ThrottledTime = v * 1000
+
+		case "nr_bursts":
+			stats.CpuStats.BurstData.BurstsPeriods = v
+
+		case "burst_usec":
+			stats.CpuStats.BurstData.BurstTime = v * 1000
 		}
 	}
 	if err := sc.Err(); err != nil {
 		return &parseError{Path: dirPath, File: file, Err: err}
 	}
+
+	idle, err := fscommon.GetCgroupParamInt(dirPath, "cpu.idle")
+	if err != nil {
+		// cpu.idle is only available since Linux 5.15.
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	stats.CpuStats.Idle = &idle
 	return nil
 }
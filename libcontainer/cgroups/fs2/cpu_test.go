@@ -0,0 +1,47 @@
+package fs2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+const exampleCpuStatData = `usage_usec 1000000
+user_usec 600000
+system_usec 400000
+core_sched.force_idle_usec 0
+nr_periods 100
+nr_throttled 10
+throttled_usec 20000
+nr_bursts 5
+burst_usec 3000`
+
+func TestStatCpu(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+	fakeCgroupDir := t.TempDir()
+	statPath := filepath.Join(fakeCgroupDir, "cpu.stat")
+	if err := os.WriteFile(statPath, []byte(exampleCpuStatData), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gotStats := cgroups.NewStats()
+	if err := statCpu(fakeCgroupDir, gotStats); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := gotStats.CpuStats.CpuUsage
+	if usage.TotalUsage != 1000000000 || usage.UsageInUsermode != 600000000 || usage.UsageInKernelmode != 400000000 {
+		t.Errorf("unexpected cpu usage: %+v", usage)
+	}
+	expectedThrottling := cgroups.ThrottlingData{Periods: 100, ThrottledPeriods: 10, ThrottledTime: 20000000}
+	if gotStats.CpuStats.ThrottlingData != expectedThrottling {
+		t.Errorf("expected throttling data %+v, got %+v", expectedThrottling, gotStats.CpuStats.ThrottlingData)
+	}
+	expectedBurst := cgroups.BurstData{BurstsPeriods: 5, BurstTime: 3000000}
+	if gotStats.CpuStats.BurstData != expectedBurst {
+		t.Errorf("expected burst data %+v, got %+v", expectedBurst, gotStats.CpuStats.BurstData)
+	}
+}
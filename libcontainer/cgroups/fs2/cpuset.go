@@ -26,3 +26,30 @@ func setCpuset(dirPath string, r *configs.Resources) error {
 	}
 	return nil
 }
+
+func statCpuset(dirPath string, stats *cgroups.Stats) error {
+	for _, f := range []struct {
+		file string
+		list *[]uint16
+	}{
+		{"cpuset.cpus", &stats.CPUSetStats.CPUs},
+		{"cpuset.mems", &stats.CPUSetStats.Mems},
+		{"cpuset.cpus.effective", &stats.CPUSetStats.EffectiveCPUs},
+		{"cpuset.mems.effective", &stats.CPUSetStats.EffectiveMems},
+	} {
+		data, err := cgroups.ReadFile(dirPath, f.file)
+		if err != nil {
+			return err
+		}
+		// The format of the memory node lists is the same.
+		list, err := cgroups.ParseCPUList(data)
+		if err != nil {
+			return &parseError{Path: dirPath, File: f.file, Err: err}
+		}
+		*f.list = make([]uint16, 0, len(list))
+		for _, n := range list {
+			*f.list = append(*f.list, uint16(n))
+		}
+	}
+	return nil
+}
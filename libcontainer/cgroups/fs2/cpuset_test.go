@@ -0,0 +1,40 @@
+package fs2
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+func TestStatCpuset(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+	fakeCgroupDir := t.TempDir()
+	for file, data := range map[string]string{
+		"cpuset.cpus":           "\n",
+		"cpuset.mems":           "1\n",
+		"cpuset.cpus.effective": "0-2,5\n",
+		"cpuset.mems.effective": "1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(fakeCgroupDir, file), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gotStats := cgroups.NewStats()
+	if err := statCpuset(fakeCgroupDir, gotStats); err != nil {
+		t.Fatal(err)
+	}
+	expected := cgroups.CPUSetStats{
+		CPUs:          []uint16{},
+		Mems:          []uint16{1},
+		EffectiveCPUs: []uint16{0, 1, 2, 5},
+		EffectiveMems: []uint16{1},
+	}
+	if !reflect.DeepEqual(gotStats.CPUSetStats, expected) {
+		t.Errorf("expected %+v, got %+v", expected, gotStats.CPUSetStats)
+	}
+}
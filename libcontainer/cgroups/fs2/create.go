@@ -1,11 +1,15 @@
 package fs2
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
 )
@@ -61,6 +65,45 @@ func needAnyControllers(r *configs.Resources) (bool, error) {
 	return false, nil
 }
 
+// checkAdoptedCgroup checks that the cgroup at path, created by an external
+// manager, exists and has the controllers needed for r enabled.
+func checkAdoptedCgroup(path string, r *configs.Resources) error {
+	content, err := cgroups.ReadFile(path, "cgroup.controllers")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("adopted cgroup %s does not exist", path)
+		}
+		return err
+	}
+	if r == nil {
+		return nil
+	}
+	avail := make(map[string]struct{})
+	for _, ctr := range strings.Fields(content) {
+		avail[ctr] = struct{}{}
+	}
+	var missing []string
+	for _, c := range []struct {
+		name string
+		need bool
+	}{
+		{"pids", isPidsSet(r)},
+		{"memory", isMemorySet(r)},
+		{"io", isIoSet(r)},
+		{"cpu", isCpuSet(r)},
+		{"cpuset", isCpusetSet(r)},
+		{"hugetlb", isHugeTlbSet(r)},
+	} {
+		if _, ok := avail[c.name]; c.need && !ok {
+			missing = append(missing, c.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("adopted cgroup %s does not have the required controllers enabled: %s", path, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 // containsDomainController returns whether the current config contains domain controller or not.
 // Refer to: http://man7.org/linux/man-pages/man7/cgroups.7.html
 // As at Linux 4.19, the following controllers are threaded: cpu, perf_event, and pids.
@@ -86,6 +129,18 @@ func CreateCgroupPath(path string, c *configs.Cgroup) (Err error) {
 	ctrs := strings.Fields(content)
 	res := "+" + strings.Join(ctrs, " +")
 
+	// When running inside another container, only a subtree of the
+	// hierarchy may be delegated to us. Leave the cgroups above it (which
+	// belong to the outer manager) alone.
+	boundary, err := cgroups.DelegationBoundary(path)
+	if err != nil {
+		if errors.Is(err, cgroups.ErrCgroupReadOnly) {
+			return err
+		}
+		// Rootless, or no permissions; errors are caught later.
+		boundary = UnifiedMountpoint
+	}
+
 	elements := strings.Split(path, "/")
 	elements = elements[3:]
 	current := "/sys/fs"
@@ -135,8 +190,15 @@ func CreateCgroupPath(path string, c *configs.Cgroup) (Err error) {
 			}
 		}
 		// enable all supported controllers
-		if i < len(elements)-1 {
+		if i < len(elements)-1 && len(current) >= len(boundary) {
 			if err := cgroups.WriteFile(current, cgStCtlFile, res); err != nil {
+				if errors.Is(err, unix.EBUSY) {
+					// A cgroup with processes in it can not have domain
+					// controllers enabled for its children, which is
+					// typically the case for the root of a nested
+					// container's cgroup namespace.
+					logrus.Warnf("unable to enable controllers in %s, as it has processes in it; move them into a child cgroup", current)
+				}
 				// try write one by one
 				allCtrs := strings.Split(res, " ")
 				for _, ctr := range allCtrs {
@@ -0,0 +1,43 @@
+package fs2
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestCheckAdoptedCgroup(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+	fakeCgroupDir := t.TempDir()
+
+	if err := checkAdoptedCgroup(filepath.Join(fakeCgroupDir, "missing"), nil); err == nil {
+		t.Fatal("expected error for a non-existent cgroup")
+	}
+
+	if err := os.WriteFile(filepath.Join(fakeCgroupDir, "cgroup.controllers"), []byte("cpu memory pids\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &configs.Resources{
+		Memory:    1 << 20,
+		CpuWeight: 100,
+		PidsLimit: 100,
+	}
+	if err := checkAdoptedCgroup(fakeCgroupDir, r); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	r.CpusetCpus = "0-1"
+	r.BlkioWeight = 100
+	err := checkAdoptedCgroup(fakeCgroupDir, r)
+	if err == nil {
+		t.Fatal("expected error for missing controllers")
+	}
+	if !strings.Contains(err.Error(), "io, cpuset") {
+		t.Errorf("expected io and cpuset to be reported missing, got %v", err)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/cgroups/fscommon"
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -20,6 +23,9 @@ type Manager struct {
 	// controllers is content of "cgroup.controllers" file.
 	// excludes pseudo-controllers ("devices" and "freezer").
 	controllers map[string]struct{}
+	// peak is the memory.peak file the peak memory usage was reset
+	// through (see ResetMemoryPeak), or nil.
+	peak *os.File
 }
 
 // NewManager creates a manager for cgroup v2 unified hierarchy.
@@ -63,6 +69,17 @@ func (m *Manager) getControllers() error {
 }
 
 func (m *Manager) Apply(pid int) error {
+	if m.config.Adopt {
+		if err := checkAdoptedCgroup(m.dirPath, m.config.Resources); err != nil {
+			return err
+		}
+		return cgroups.WriteCgroupProcRetry(m.dirPath, pid, m.config.Resources.GetAttachRetry())
+	}
+	paths := map[string]string{"": m.dirPath}
+	policy, r, err := cgroups.PreApplyPolicy(m.config, paths)
+	if err != nil {
+		return err
+	}
 	if err := CreateCgroupPath(m.dirPath, m.config); err != nil {
 		// Related tests:
 		// - "runc create (no limits + no cgrouppath + no permission) succeeds"
@@ -78,10 +95,15 @@ func (m *Manager) Apply(pid int) error {
 		}
 		return err
 	}
-	if err := cgroups.WriteCgroupProc(m.dirPath, pid); err != nil {
+	if m.config.OwnerUID != nil {
+		if err := cgroups.Delegate(m.dirPath, *m.config.OwnerUID); err != nil {
+			return err
+		}
+	}
+	if err := cgroups.WriteCgroupProcRetry(m.dirPath, pid, r.GetAttachRetry()); err != nil {
 		return err
 	}
-	return nil
+	return cgroups.PostApplyPolicy(m.config, policy, paths, r, pid)
 }
 
 func (m *Manager) GetPids() ([]int, error) {
@@ -105,6 +127,11 @@ func (m *Manager) GetStats() (*cgroups.Stats, error) {
 	if err := statMemory(m.dirPath, st); err != nil && !os.IsNotExist(err) {
 		errs = append(errs, err)
 	}
+	if m.peak != nil {
+		if err := statMemoryPeak(m.peak, st); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	// io (since kernel 4.5)
 	if err := statIo(m.dirPath, st); err != nil && !os.IsNotExist(err) {
 		errs = append(errs, err)
@@ -125,6 +152,14 @@ func (m *Manager) GetStats() (*cgroups.Stats, error) {
 	if st.BlkioStats.PSI, err = statPSI(m.dirPath, "io.pressure"); err != nil {
 		errs = append(errs, err)
 	}
+	// cpuset (since kernel 5.0)
+	if err := statCpuset(m.dirPath, st); err != nil && !os.IsNotExist(err) {
+		errs = append(errs, err)
+	}
+	// memory.numa_stat (since kernel 5.0)
+	if err := statMemoryNUMA(m.dirPath, st); err != nil && !os.IsNotExist(err) {
+		errs = append(errs, err)
+	}
 	// hugetlb (since kernel 5.6)
 	if err := statHugeTlb(m.dirPath, st); err != nil && !os.IsNotExist(err) {
 		errs = append(errs, err)
@@ -155,35 +190,96 @@ func (m *Manager) Freeze(state configs.FreezerState) error {
 }
 
 func (m *Manager) Destroy() error {
-	return cgroups.RemovePath(m.dirPath)
+	if m.config.Adopt {
+		// The cgroup is owned by an external manager.
+		return nil
+	}
+	return cgroups.RemovePathKill(m.dirPath, kill)
+}
+
+// kill kills the processes in the cgroup dirPath, using cgroup.kill if
+// available, or else freezing the cgroup while signalling them.
+func kill(dirPath string) error {
+	pids, err := cgroups.GetPids(dirPath)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(pids, os.Getpid()) {
+		// Can't kill or freeze ourselves.
+		return cgroups.KillProcs(dirPath)
+	}
+	if err := cgroups.Kill(dirPath); !errors.Is(err, cgroups.ErrKillUnsupported) {
+		return err
+	}
+	if err := setFreezer(dirPath, configs.Frozen); err != nil {
+		logrus.Debugf("unable to freeze %s: %v", dirPath, err)
+	}
+	err = cgroups.KillProcs(dirPath)
+	if terr := setFreezer(dirPath, configs.Thawed); err == nil {
+		err = terr
+	}
+	return err
 }
 
 func (m *Manager) Path(_ string) string {
 	return m.dirPath
 }
 
+// ControllerPath implements [cgroups.Manager.ControllerPath]: it returns
+// the cgroup path if the controller is enabled for it (as listed in
+// cgroup.controllers), or is one of the implicit ones.
+func (m *Manager) ControllerPath(name string) string {
+	switch name {
+	case "", "freezer", "devices":
+		return m.dirPath
+	}
+	// Not using m.controllers, which may be stale.
+	data, err := cgroups.ReadFile(m.dirPath, "cgroup.controllers")
+	if err != nil {
+		return ""
+	}
+	for _, c := range strings.Fields(data) {
+		if c == name {
+			return m.dirPath
+		}
+	}
+	return ""
+}
+
 func (m *Manager) Set(r *configs.Resources) error {
 	if r == nil {
 		return nil
 	}
+	if err := m.set(r); err != nil {
+		return err
+	}
+	m.config.Resources = r
+	return nil
+}
+
+func (m *Manager) Diff(r *configs.Resources) ([]cgroups.FileChange, error) {
+	return cgroups.DiffSet(r, m.set)
+}
+
+func (m *Manager) set(r *configs.Resources) error {
 	if err := m.getControllers(); err != nil {
 		return err
 	}
 	// pids (since kernel 4.5)
 	if err := setPids(m.dirPath, r); err != nil {
-		return err
+		return m.controllerError("pids", err)
 	}
 	// memory (since kernel 4.5)
 	if err := setMemory(m.dirPath, r); err != nil {
-		return err
+		return m.controllerError("memory", err)
 	}
 	// io (since kernel 4.5)
 	if err := setIo(m.dirPath, r); err != nil {
-		return err
+		return m.controllerError("io", err)
 	}
 	// cpu (since kernel 4.15)
 	if err := setCpu(m.dirPath, r); err != nil {
-		return err
+		return m.controllerError("cpu", err)
 	}
 	// devices (since kernel 4.15, pseudo-controller)
 	//
@@ -197,25 +293,34 @@ func (m *Manager) Set(r *configs.Resources) error {
 	}
 	// cpuset (since kernel 5.0)
 	if err := setCpuset(m.dirPath, r); err != nil {
-		return err
+		return m.controllerError("cpuset", err)
 	}
 	// hugetlb (since kernel 5.6)
 	if err := setHugeTlb(m.dirPath, r); err != nil {
-		return err
+		return m.controllerError("hugetlb", err)
 	}
 	// rdma (since kernel 4.11)
 	if err := fscommon.RdmaSet(m.dirPath, r); err != nil {
+		return m.controllerError("rdma", err)
+	}
+	// network rate limits (since kernel 4.10, cgroup-bpf)
+	if err := setNetRate(m.dirPath, r); err != nil {
 		return err
 	}
 	// freezer (since kernel 5.2, pseudo-controller)
 	if err := setFreezer(m.dirPath, r.Freezer); err != nil {
 		return err
 	}
-	if err := m.setUnified(r.Unified); err != nil {
+	return m.setUnified(r.Unified)
+}
+
+// controllerError returns err, wrapping cgroups.ErrControllerUnavailable if
+// it is caused by the controller not being enabled for the cgroup.
+func (m *Manager) controllerError(controller string, err error) error {
+	if _, ok := m.controllers[controller]; ok || !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
-	m.config.Resources = r
-	return nil
+	return fmt.Errorf("%w: %w", &cgroups.ControllerUnavailableError{Controller: controller}, err)
 }
 
 func setDevices(dirPath string, r *configs.Resources) error {
@@ -228,6 +333,16 @@ func setDevices(dirPath string, r *configs.Resources) error {
 	return cgroups.DevicesSetV2(dirPath, r)
 }
 
+func setNetRate(dirPath string, r *configs.Resources) error {
+	if r.NetRate == nil {
+		return nil
+	}
+	if cgroups.NetRateSetV2 == nil {
+		return cgroups.ErrNetRateUnsupported
+	}
+	return cgroups.NetRateSetV2(dirPath, r)
+}
+
 func (m *Manager) setUnified(res map[string]string) error {
 	for k, v := range res {
 		if strings.Contains(k, "/") {
@@ -244,7 +359,7 @@ func (m *Manager) setUnified(res map[string]string) error {
 				}
 				c := sk[0]
 				if _, ok := m.controllers[c]; !ok && c != "cgroup" {
-					return fmt.Errorf("unified resource %q can't be set: controller %q not available", k, c)
+					return fmt.Errorf("unified resource %q can't be set: %w", k, &cgroups.ControllerUnavailableError{Controller: c})
 				}
 			}
 			return fmt.Errorf("unable to set unified resource %q: %w", k, err)
@@ -276,6 +391,29 @@ func OOMKillCount(path string) (uint64, error) {
 	return fscommon.GetValueByKey(path, "memory.events", "oom_kill")
 }
 
+func (m *Manager) Kill() error {
+	return cgroups.Kill(m.dirPath)
+}
+
+func (m *Manager) GetEffectiveCpus() (string, error) {
+	return m.getEffective("cpuset.cpus.effective")
+}
+
+func (m *Manager) GetEffectiveMems() (string, error) {
+	return m.getEffective("cpuset.mems.effective")
+}
+
+func (m *Manager) getEffective(file string) (string, error) {
+	if err := m.getControllers(); err != nil {
+		return "", err
+	}
+	val, err := fscommon.GetCgroupParamString(m.dirPath, file)
+	if err != nil {
+		return "", m.controllerError("cpuset", err)
+	}
+	return val, nil
+}
+
 func (m *Manager) OOMKillCount() (uint64, error) {
 	c, err := OOMKillCount(m.dirPath)
 	if err != nil && m.config.Rootless && os.IsNotExist(err) {
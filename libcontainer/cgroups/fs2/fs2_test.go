@@ -0,0 +1,36 @@
+package fs2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestControllerPath(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.controllers"), []byte("cpuset cpu io memory pids\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(&configs.Cgroup{}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"":        dir,
+		"freezer": dir,
+		"devices": dir,
+		"cpu":     dir,
+		"memory":  dir,
+		"hugetlb": "",
+		"rdma":    "",
+	} {
+		if got := m.ControllerPath(name); got != want {
+			t.Errorf("ControllerPath(%q): expected %q, got %q", name, want, got)
+		}
+	}
+}
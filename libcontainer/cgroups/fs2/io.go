@@ -42,11 +42,13 @@ func setIo(dirPath string, r *configs.Resources) error {
 		return nil
 	}
 
-	// If BFQ IO scheduler is available, use it.
+	// If BFQ IO scheduler is available, use it. The file is only opened
+	// to check that, and is written to using cgroups.WriteFile, so that
+	// the writes are seen by cgroups.DiffSet.
 	var bfq *os.File
 	if r.BlkioWeight != 0 || len(r.BlkioWeightDevice) > 0 {
 		var err error
-		bfq, err = cgroups.OpenFile(dirPath, "io.bfq.weight", os.O_RDWR)
+		bfq, err = cgroups.OpenFile(dirPath, "io.bfq.weight", os.O_RDONLY)
 		if err == nil {
 			defer bfq.Close()
 		} else if !os.IsNotExist(err) {
@@ -56,7 +58,7 @@ func setIo(dirPath string, r *configs.Resources) error {
 
 	if r.BlkioWeight != 0 {
 		if bfq != nil { // Use BFQ.
-			if _, err := bfq.WriteString(strconv.FormatUint(uint64(r.BlkioWeight), 10)); err != nil {
+			if err := cgroups.WriteFile(dirPath, "io.bfq.weight", strconv.FormatUint(uint64(r.BlkioWeight), 10)); err != nil {
 				return err
 			}
 		} else {
@@ -69,7 +71,7 @@ func setIo(dirPath string, r *configs.Resources) error {
 	}
 	if bfqDeviceWeightSupported(bfq) {
 		for _, wd := range r.BlkioWeightDevice {
-			if _, err := bfq.WriteString(wd.WeightString() + "\n"); err != nil {
+			if err := cgroups.WriteFile(dirPath, "io.bfq.weight", wd.WeightString()); err != nil {
 				return fmt.Errorf("setting device weight %q: %w", wd.WeightString(), err)
 			}
 		}
@@ -142,6 +144,10 @@ func statIo(dirPath string, stats *cgroups.Stats) error {
 			return &parseError{Path: dirPath, File: file, Err: err}
 		}
 
+		// Like in cgroupv1, the per-device totals are the sums of
+		// the read, write and discard values.
+		var totalBytes, totalIos uint64
+		var hasBytes, hasIos bool
 		for _, item := range v {
 			d := strings.Split(item, "=")
 			if len(d) != 2 {
@@ -151,25 +157,31 @@ func statIo(dirPath string, stats *cgroups.Stats) error {
 
 			// Map to the cgroupv1 naming and layout (in separate tables).
 			var targetTable *[]cgroups.BlkioStatEntry
+			var total *uint64
 			switch op {
 			// Equivalent to cgroupv1's blkio.io_service_bytes.
 			case "rbytes":
 				op = "Read"
-				targetTable = &parsedStats.IoServiceBytesRecursive
+				targetTable, total, hasBytes = &parsedStats.IoServiceBytesRecursive, &totalBytes, true
 			case "wbytes":
 				op = "Write"
-				targetTable = &parsedStats.IoServiceBytesRecursive
+				targetTable, total, hasBytes = &parsedStats.IoServiceBytesRecursive, &totalBytes, true
+			case "dbytes":
+				op = "Discard"
+				targetTable, total, hasBytes = &parsedStats.IoServiceBytesRecursive, &totalBytes, true
 			// Equivalent to cgroupv1's blkio.io_serviced.
 			case "rios":
 				op = "Read"
-				targetTable = &parsedStats.IoServicedRecursive
+				targetTable, total, hasIos = &parsedStats.IoServicedRecursive, &totalIos, true
 			case "wios":
 				op = "Write"
-				targetTable = &parsedStats.IoServicedRecursive
+				targetTable, total, hasIos = &parsedStats.IoServicedRecursive, &totalIos, true
+			case "dios":
+				op = "Discard"
+				targetTable, total, hasIos = &parsedStats.IoServicedRecursive, &totalIos, true
 			default:
-				// Skip over entries we cannot map to cgroupv1 stats for now.
-				// In the future we should expand the stats struct to include
-				// them.
+				// Skip over entries we cannot map to cgroupv1 stats
+				// (e.g. the io.latency or io.cost ones).
 				logrus.Debugf("cgroupv2 io stats: skipping over unmappable %s entry", item)
 				continue
 			}
@@ -178,6 +190,7 @@ func statIo(dirPath string, stats *cgroups.Stats) error {
 			if err != nil {
 				return &parseError{Path: dirPath, File: file, Err: err}
 			}
+			*total += value
 
 			entry := cgroups.BlkioStatEntry{
 				Op:    op,
@@ -187,6 +200,14 @@ func statIo(dirPath string, stats *cgroups.Stats) error {
 			}
 			*targetTable = append(*targetTable, entry)
 		}
+		if hasBytes {
+			parsedStats.IoServiceBytesRecursive = append(parsedStats.IoServiceBytesRecursive,
+				cgroups.BlkioStatEntry{Op: "Total", Major: major, Minor: minor, Value: totalBytes})
+		}
+		if hasIos {
+			parsedStats.IoServicedRecursive = append(parsedStats.IoServicedRecursive,
+				cgroups.BlkioStatEntry{Op: "Total", Major: major, Minor: minor, Value: totalIos})
+		}
 	}
 	stats.BlkioStats = parsedStats
 	return nil
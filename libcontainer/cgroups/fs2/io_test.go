@@ -22,6 +22,12 @@ var exampleIoStatsParsed = cgroups.BlkioStats{
 		{Major: 254, Minor: 0, Value: 0, Op: "Write"},
 		{Major: 259, Minor: 0, Value: 6911345664, Op: "Read"},
 		{Major: 259, Minor: 0, Value: 14245536256, Op: "Write"},
+		{Major: 254, Minor: 1, Value: 0, Op: "Discard"},
+		{Major: 254, Minor: 0, Value: 0, Op: "Discard"},
+		{Major: 259, Minor: 0, Value: 530485248, Op: "Discard"},
+		{Major: 254, Minor: 1, Value: 21146968064, Op: "Total"},
+		{Major: 254, Minor: 0, Value: 2702336, Op: "Total"},
+		{Major: 259, Minor: 0, Value: 21687367168, Op: "Total"},
 	},
 	IoServicedRecursive: []cgroups.BlkioStatEntry{
 		{Major: 254, Minor: 1, Value: 263278, Op: "Read"},
@@ -30,6 +36,12 @@ var exampleIoStatsParsed = cgroups.BlkioStats{
 		{Major: 254, Minor: 0, Value: 0, Op: "Write"},
 		{Major: 259, Minor: 0, Value: 264538, Op: "Read"},
 		{Major: 259, Minor: 0, Value: 244914, Op: "Write"},
+		{Major: 254, Minor: 1, Value: 0, Op: "Discard"},
+		{Major: 254, Minor: 0, Value: 0, Op: "Discard"},
+		{Major: 259, Minor: 0, Value: 2, Op: "Discard"},
+		{Major: 254, Minor: 1, Value: 511881, Op: "Total"},
+		{Major: 254, Minor: 0, Value: 97, Op: "Total"},
+		{Major: 259, Minor: 0, Value: 509454, Op: "Total"},
 	},
 }
 
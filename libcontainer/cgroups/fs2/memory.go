@@ -3,8 +3,11 @@ package fs2
 import (
 	"bufio"
 	"errors"
+	"fmt"
+	"io"
 	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -177,6 +180,54 @@ func getMemoryDataV2(path, name string) (cgroups.MemoryData, error) {
 	return memoryData, nil
 }
 
+// statMemoryNUMA parses memory.numa_stat, which looks like this:
+//
+//	anon N0=<node 0 bytes> N1=<node 1 bytes> ...
+//	file N0=<node 0 bytes> N1=<node 1 bytes> ...
+//	...
+//
+// Unlike the cgroup v1 one, the values are in bytes, and there are no totals.
+func statMemoryNUMA(dirPath string, stats *cgroups.Stats) error {
+	const file = "memory.numa_stat"
+	f, err := cgroups.OpenFile(dirPath, file, os.O_RDONLY)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	numa := make(map[string]map[uint8]uint64)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return &parseError{Path: dirPath, File: file, Err: fmt.Errorf("malformed line: %q", line)}
+		}
+		nodes := make(map[uint8]uint64, len(fields)-1)
+		for _, field := range fields[1:] {
+			node, val, ok := strings.Cut(field, "=")
+			if !ok || len(node) < 2 || node[0] != 'N' {
+				return &parseError{Path: dirPath, File: file, Err: fmt.Errorf("malformed line: %q", line)}
+			}
+			n, err := strconv.ParseUint(node[1:], 10, 8)
+			if err != nil {
+				return &parseError{Path: dirPath, File: file, Err: err}
+			}
+			v, err := strconv.ParseUint(val, 10, 64)
+			if err != nil {
+				return &parseError{Path: dirPath, File: file, Err: err}
+			}
+			nodes[uint8(n)] = v
+		}
+		numa[fields[0]] = nodes
+	}
+	if err := sc.Err(); err != nil {
+		return &parseError{Path: dirPath, File: file, Err: err}
+	}
+	stats.MemoryStats.NUMAStat = numa
+	return nil
+}
+
 func rootStatsFromMeminfo(stats *cgroups.Stats) error {
 	const file = "/proc/meminfo"
 	f, err := os.Open(file)
@@ -240,3 +291,37 @@ func rootStatsFromMeminfo(stats *cgroups.Stats) error {
 
 	return nil
 }
+
+// ResetMemoryPeak resets the peak memory usage (memory.peak) to the current
+// usage. As the kernel only applies the reset to the reads made through the
+// same file descriptor (Linux 6.12+), the file is kept open, and the peak
+// usage reported by GetStats is read from it.
+func (m *Manager) ResetMemoryPeak() error {
+	if m.peak == nil {
+		f, err := cgroups.OpenFile(m.dirPath, "memory.peak", os.O_RDWR)
+		if err != nil {
+			return err
+		}
+		m.peak = f
+	}
+	if _, err := m.peak.WriteString("reset\n"); err != nil {
+		return fmt.Errorf("unable to reset memory.peak (requires Linux 6.12): %w", err)
+	}
+	return nil
+}
+
+// statMemoryPeak sets the peak memory usage in stats from the memory.peak
+// file f, opened by ResetMemoryPeak.
+func statMemoryPeak(f *os.File, stats *cgroups.Stats) error {
+	buf := make([]byte, 32)
+	n, err := f.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	peak, err := strconv.ParseUint(strings.TrimSpace(string(buf[:n])), 10, 64)
+	if err != nil {
+		return &parseError{Path: filepath.Dir(f.Name()), File: "memory.peak", Err: err}
+	}
+	stats.MemoryStats.Usage.MaxUsage = peak
+	return nil
+}
@@ -3,6 +3,7 @@ package fs2
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -153,3 +154,51 @@ func TestRootStatsFromMeminfo(t *testing.T) {
 		t.Errorf("swap limit %d should be at least mem limit %d", stats.MemoryStats.SwapUsage.Limit, stats.MemoryStats.Usage.Limit)
 	}
 }
+
+func TestStatMemoryPeak(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "memory.peak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("4096\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := cgroups.NewStats()
+	if err := statMemoryPeak(f, stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.MemoryStats.Usage.MaxUsage != 4096 {
+		t.Errorf("expected peak memory usage 4096, got %d", stats.MemoryStats.Usage.MaxUsage)
+	}
+}
+
+func TestStatMemoryNUMA(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+	fakeCgroupDir := t.TempDir()
+	data := "anon N0=4096 N1=8192\nfile N0=0 N1=12288\n"
+	if err := os.WriteFile(filepath.Join(fakeCgroupDir, "memory.numa_stat"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gotStats := cgroups.NewStats()
+	if err := statMemoryNUMA(fakeCgroupDir, gotStats); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]map[uint8]uint64{
+		"anon": {0: 4096, 1: 8192},
+		"file": {0: 0, 1: 12288},
+	}
+	if !reflect.DeepEqual(gotStats.MemoryStats.NUMAStat, expected) {
+		t.Errorf("expected %v, got %v", expected, gotStats.MemoryStats.NUMAStat)
+	}
+
+	if err := os.WriteFile(filepath.Join(fakeCgroupDir, "memory.numa_stat"), []byte("anon 4096\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := statMemoryNUMA(fakeCgroupDir, cgroups.NewStats()); err == nil {
+		t.Error("expected an error for a malformed memory.numa_stat")
+	}
+}
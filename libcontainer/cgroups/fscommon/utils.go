@@ -33,6 +33,9 @@ func (e *ParseError) Error() string {
 
 func (e *ParseError) Unwrap() error { return e.Err }
 
+// Is makes a ParseError match cgroups.ErrParseFile.
+func (e *ParseError) Is(target error) bool { return target == cgroups.ErrParseFile }
+
 // ParseUint converts a string to an uint64 integer.
 // Negative values are returned at zero as, due to kernel bugs,
 // some of the memory cgroup stats can be negative.
@@ -1,6 +1,7 @@
 package fscommon
 
 import (
+	"errors"
 	"math"
 	"os"
 	"path/filepath"
@@ -79,8 +80,8 @@ func TestGetCgroupParamsInt(t *testing.T) {
 		t.Fatal(err)
 	}
 	_, err = GetCgroupParamUint(tempDir, cgroupFile)
-	if err == nil {
-		t.Fatal("Expecting error, got none")
+	if !errors.Is(err, cgroups.ErrParseFile) {
+		t.Fatalf("Expecting ErrParseFile, got %v", err)
 	}
 
 	// Unknown file.
@@ -0,0 +1,121 @@
+package netrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// progName is the name of the rate limit programs, used to find the ones
+// attached by runc to a cgroup.
+const progName = "runc_net_rate"
+
+// findRateFilters returns the rate limit programs attached to the cgroup dirFD
+// for the given attach type.
+func findRateFilters(dirFD int, attach ebpf.AttachType) ([]*ebpf.Program, error) {
+	res, err := link.QueryPrograms(link.QueryOptions{Target: dirFD, Attach: attach})
+	if err != nil {
+		return nil, err
+	}
+	var progs []*ebpf.Program
+	for _, p := range res.Programs {
+		prog, err := ebpf.NewProgramFromID(p.ID)
+		if err != nil {
+			// Skip over programs which can't be accessed by runc (see
+			// findAttachedCgroupDeviceFilters in cgroups/devices);
+			// these are not ours anyway.
+			if errors.Is(err, os.ErrPermission) || errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("cannot fetch program from id: %w", err)
+		}
+		info, err := prog.Info()
+		if err != nil || info.Name != progName {
+			prog.Close()
+			continue
+		}
+		progs = append(progs, prog)
+	}
+	return progs, nil
+}
+
+// loadRateFilter loads the rate limit program for l.
+func loadRateFilter(l *configs.NetRateLimit) (*ebpf.Program, error) {
+	// Increase `ulimit -l` limit to avoid BPF_PROG_LOAD error (#2167).
+	// This limit is not inherited into the container.
+	_ = unix.Setrlimit(unix.RLIMIT_MEMLOCK, &unix.Rlimit{
+		Cur: unix.RLIM_INFINITY,
+		Max: unix.RLIM_INFINITY,
+	})
+
+	state, err := ebpf.NewMap(&ebpf.MapSpec{
+		Type:       ebpf.Array,
+		KeySize:    4,
+		ValueSize:  8,
+		MaxEntries: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The program keeps a reference to the map.
+	defer state.Close()
+
+	return ebpf.NewProgram(&ebpf.ProgramSpec{
+		Name:         progName,
+		Type:         ebpf.CGroupSKB,
+		Instructions: rateFilter(l, state.FD()),
+		License:      license,
+	})
+}
+
+// setLimit replaces the rate limit program attached to the cgroup dirFD for
+// the given attach type with one for l, or removes it if l is nil.
+func setLimit(dirFD int, attach ebpf.AttachType, l *configs.NetRateLimit) error {
+	oldProgs, err := findRateFilters(dirFD, attach)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, prog := range oldProgs {
+			prog.Close()
+		}
+	}()
+
+	if l != nil {
+		prog, err := loadRateFilter(l)
+		if err != nil {
+			return err
+		}
+		defer prog.Close()
+		err = link.RawAttachProgram(link.RawAttachProgramOptions{
+			Target:  dirFD,
+			Program: prog,
+			Attach:  attach,
+			Flags:   unix.BPF_F_ALLOW_MULTI,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to call BPF_PROG_ATTACH (%s, BPF_F_ALLOW_MULTI): %w", attach, err)
+		}
+	}
+	// Detach the old programs only now, so that the traffic is never
+	// left unlimited.
+	for _, prog := range oldProgs {
+		err := link.RawDetachProgram(link.RawDetachProgramOptions{
+			Target:  dirFD,
+			Program: prog,
+			Attach:  attach,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to call BPF_PROG_DETACH (%s) on old rate limit program: %w", attach, err)
+		}
+		logrus.Debugf("removed old %s rate limit program from cgroup", attach)
+	}
+	return nil
+}
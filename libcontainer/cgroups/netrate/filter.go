@@ -0,0 +1,90 @@
+package netrate
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/cilium/ebpf/asm"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+const (
+	// license string format is same as kernel MODULE_LICENSE macro
+	license = "Apache"
+
+	nsPerSec = 1_000_000_000
+)
+
+// burstNs returns the time needed to send (or receive) the burst of l at its
+// rate, in nanoseconds.
+func burstNs(l *configs.NetRateLimit) int64 {
+	burst := l.Burst
+	if burst == 0 {
+		burst = l.Rate
+	}
+	hi, lo := bits.Mul64(burst, nsPerSec)
+	if hi >= l.Rate {
+		return math.MaxInt64
+	}
+	ns, _ := bits.Div64(hi, lo, l.Rate)
+	if ns > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(ns)
+}
+
+// rateFilter returns a cgroup_skb program which drops the packets exceeding
+// the rate limit l. The program state is a single 64-bit value, the first
+// element of the array map mapFD.
+//
+// This is the generic cell rate algorithm: the state is the time at which the
+// sent bytes would have been sent at the limit rate (the "theoretical arrival
+// time"), and a packet is dropped if sending it would move that time further
+// than the burst time into the future. The state is updated atomically, but
+// not in a single step, so concurrent packets may exceed the limit slightly.
+func rateFilter(l *configs.NetRateLimit, mapFD int) asm.Instructions {
+	return asm.Instructions{
+		// R9 = skb->len
+		asm.LoadMem(asm.R9, asm.R1, 0, asm.Word),
+
+		// R7 = the state, or allow if the lookup fails (which can't happen).
+		asm.StoreImm(asm.RFP, -4, 0, asm.Word),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.LoadMapPtr(asm.R1, mapFD),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "allow"),
+		asm.Mov.Reg(asm.R7, asm.R0),
+
+		// R6 = now
+		asm.FnKtimeGetNs.Call(),
+		asm.Mov.Reg(asm.R6, asm.R0),
+
+		// R1 = max(state, now)
+		asm.LoadMem(asm.R1, asm.R7, 0, asm.DWord),
+		asm.JGE.Reg(asm.R1, asm.R6, "cost"),
+		asm.StoreMem(asm.R7, 0, asm.R6, asm.DWord),
+		asm.Mov.Reg(asm.R1, asm.R6),
+
+		// R2 = the time it takes to send the packet at the limit rate.
+		asm.Mov.Reg(asm.R2, asm.R9).WithSymbol("cost"),
+		asm.Mul.Imm(asm.R2, nsPerSec),
+		asm.LoadImm(asm.R3, int64(l.Rate), asm.DWord),
+		asm.Div.Reg(asm.R2, asm.R3),
+
+		// Drop if state + cost - now > burst time.
+		asm.Add.Reg(asm.R1, asm.R2),
+		asm.Sub.Reg(asm.R1, asm.R6),
+		asm.LoadImm(asm.R3, burstNs(l), asm.DWord),
+		asm.JGT.Reg(asm.R1, asm.R3, "drop"),
+
+		// state += cost
+		asm.StoreXAdd(asm.R7, asm.R2, asm.DWord),
+		asm.Mov.Imm(asm.R0, 1).WithSymbol("allow"),
+		asm.Return(),
+
+		asm.Mov.Imm(asm.R0, 0).WithSymbol("drop"),
+		asm.Return(),
+	}
+}
@@ -0,0 +1,52 @@
+package netrate
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestBurstNs(t *testing.T) {
+	for _, tc := range []struct {
+		limit configs.NetRateLimit
+		ns    int64
+	}{
+		{limit: configs.NetRateLimit{Rate: 1000}, ns: 1_000_000_000},
+		{limit: configs.NetRateLimit{Rate: 1000, Burst: 500}, ns: 500_000_000},
+		{limit: configs.NetRateLimit{Rate: 3, Burst: 1}, ns: 333_333_333},
+		{limit: configs.NetRateLimit{Rate: 1, Burst: math.MaxUint64}, ns: math.MaxInt64},
+	} {
+		if ns := burstNs(&tc.limit); ns != tc.ns {
+			t.Errorf("%+v: expected %d, got %d", tc.limit, tc.ns, ns)
+		}
+	}
+}
+
+func TestRateFilter(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	// 1000 bytes per second, with a burst of 3000: the first three
+	// packets of 1000 bytes are allowed, the next ones are dropped
+	// (unless the test runs for more than a second).
+	prog, err := loadRateFilter(&configs.NetRateLimit{Rate: 1000, Burst: 3000})
+	if err != nil {
+		t.Skipf("cannot load program: %v", err)
+	}
+	defer prog.Close()
+
+	pkt := make([]byte, 1000)
+	for i, exp := range []uint32{1, 1, 1, 0, 0} {
+		ret, err := prog.Run(&ebpf.RunOptions{Data: pkt})
+		if err != nil {
+			t.Skipf("cannot run program: %v", err)
+		}
+		if ret != exp {
+			t.Fatalf("packet %d: expected %d, got %d", i, exp, ret)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package netrate contains functionality to limit the network bandwidth of
+// cgroups, which is exposed indirectly via libcontainer/cgroups managers.
+//
+// The limits are enforced by cgroup-bpf programs attached to the ingress and
+// egress hooks of a cgroup v2 (so they are unavailable on cgroup v1), which
+// drop the packets exceeding the rate of the container. For TCP, this results
+// in the senders slowing down; UDP packets are simply lost.
+//
+// To enable cgroup managers to set network rate limits, this package must be
+// imported.
+package netrate
+
+import (
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func init() {
+	cgroups.NetRateSetV2 = setV2
+}
+
+func setV2(dirPath string, r *configs.Resources) error {
+	if r.NetRate == nil {
+		return nil
+	}
+	dirFD, err := unix.Open(dirPath, unix.O_DIRECTORY|unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("cannot get dir FD for %s", dirPath)
+	}
+	defer unix.Close(dirFD)
+
+	if err := setLimit(dirFD, ebpf.AttachCGroupInetEgress, r.NetRate.Egress); err != nil {
+		return fmt.Errorf("unable to set egress rate limit: %w", err)
+	}
+	if err := setLimit(dirFD, ebpf.AttachCGroupInetIngress, r.NetRate.Ingress); err != nil {
+		return fmt.Errorf("unable to set ingress rate limit: %w", err)
+	}
+	return nil
+}
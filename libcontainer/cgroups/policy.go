@@ -0,0 +1,104 @@
+package cgroups
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// ResourcePolicy customizes how a cgroup manager applies the resources of a
+// container, e.g. to try out another distribution of the real-time CPU
+// budget, without changing the controllers code. The policies are compiled
+// in (see [RegisterResourcePolicy]), and selected by name using
+// configs.Cgroup.ResourcePolicy.
+type ResourcePolicy interface {
+	// PreApply is called by Manager.Apply before the container cgroups
+	// are set up, with the paths of the manager (see Manager.GetPaths),
+	// which must not be modified. It can change r, a copy of the container
+	// resources, which is then used by Apply. Note that only the settings
+	// applied by Apply (with cgroup v1, the real-time budget and the
+	// cpuset) are affected: the others are applied by Manager.Set, from
+	// the container configuration.
+	PreApply(paths map[string]string, r *configs.Resources) error
+	// PostApply is called by Manager.Apply once pid is in the container
+	// cgroups, with the resources returned by PreApply.
+	PostApply(paths map[string]string, r *configs.Resources, pid int) error
+}
+
+var (
+	policiesMu sync.Mutex
+	policies   = make(map[string]ResourcePolicy)
+)
+
+// RegisterResourcePolicy makes a resource policy available under the given
+// name. It is meant to be called from init functions, and panics if the name
+// is already used.
+func RegisterResourcePolicy(name string, p ResourcePolicy) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	if name == "" || p == nil {
+		panic("cgroups: invalid resource policy")
+	}
+	if _, ok := policies[name]; ok {
+		panic("cgroups: resource policy " + name + " registered twice")
+	}
+	policies[name] = p
+}
+
+// GetResourcePolicy returns the resource policy with the given name, or nil
+// if the name is empty.
+func GetResourcePolicy(name string) (ResourcePolicy, error) {
+	if name == "" {
+		return nil, nil
+	}
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	p, ok := policies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource policy %q", name)
+	}
+	return p, nil
+}
+
+// ResourcePolicies returns the names of the available resource policies.
+func ResourcePolicies() []string {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PreApplyPolicy calls the PreApply hook of the resource policy of c, if
+// any. It returns the policy, and the resources to be applied: r itself if
+// there is no policy, or the copy changed by the policy.
+func PreApplyPolicy(c *configs.Cgroup, paths map[string]string) (ResourcePolicy, *configs.Resources, error) {
+	policy, err := GetResourcePolicy(c.ResourcePolicy)
+	if err != nil || policy == nil {
+		return nil, c.Resources, err
+	}
+	var r configs.Resources
+	if c.Resources != nil {
+		r = *c.Resources
+	}
+	if err := policy.PreApply(paths, &r); err != nil {
+		return nil, nil, fmt.Errorf("resource policy %s: %w", c.ResourcePolicy, err)
+	}
+	return policy, &r, nil
+}
+
+// PostApplyPolicy calls the PostApply hook of policy, if not nil.
+func PostApplyPolicy(c *configs.Cgroup, policy ResourcePolicy, paths map[string]string, r *configs.Resources, pid int) error {
+	if policy == nil {
+		return nil
+	}
+	if err := policy.PostApply(paths, r, pid); err != nil {
+		return fmt.Errorf("resource policy %s: %w", c.ResourcePolicy, err)
+	}
+	return nil
+}
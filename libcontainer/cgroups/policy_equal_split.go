@@ -0,0 +1,73 @@
+package cgroups
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// EqualSplitPolicy is the name of the resource policy which, with cgroup v1,
+// gives the containers without a real-time budget of their own an equal
+// share of the real-time budget of their parent cpu cgroup: its runtime
+// divided by the number of its child cgroups, including the container one,
+// but no more than what the other children leave unallocated.
+const EqualSplitPolicy = "equal-split"
+
+func init() {
+	RegisterResourcePolicy(EqualSplitPolicy, equalSplitPolicy{})
+}
+
+type equalSplitPolicy struct{}
+
+func (equalSplitPolicy) PreApply(paths map[string]string, r *configs.Resources) error {
+	dir := paths["cpu"]
+	if dir == "" || r.CpuRtRuntime != 0 {
+		return nil
+	}
+	parent := filepath.Dir(dir)
+	runtime, period, err := readBandwidth(parent, "cpu.rt_runtime_us", "cpu.rt_period_us")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// No parent yet, or no real-time group scheduling.
+			return nil
+		}
+		return err
+	}
+	if runtime <= 0 {
+		// Either no budget to split, or an unlimited one (the root
+		// cgroup with kernel.sched_rt_runtime_us=-1).
+		return nil
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return err
+	}
+	children := int64(1)
+	free := runtime
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == filepath.Base(dir) {
+			continue
+		}
+		childRuntime, childPeriod, err := readBandwidth(filepath.Join(parent, e.Name()), "cpu.rt_runtime_us", "cpu.rt_period_us")
+		if err != nil || childPeriod == 0 {
+			continue
+		}
+		children++
+		if childRuntime > 0 {
+			free -= rtRuntimeFor(childRuntime, childPeriod, period)
+		}
+	}
+	share := min(runtime/children, free)
+	if share <= 0 {
+		return nil
+	}
+	r.CpuRtRuntime = share
+	r.CpuRtPeriod = period
+	return nil
+}
+
+func (equalSplitPolicy) PostApply(map[string]string, *configs.Resources, int) error {
+	return nil
+}
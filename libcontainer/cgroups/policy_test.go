@@ -0,0 +1,81 @@
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestEqualSplitPolicy(t *testing.T) {
+	// We're using a fake cgroupfs.
+	TestMode = true
+	parent := t.TempDir()
+	setRt := func(dir, runtime, period string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for file, data := range map[string]string{"cpu.rt_runtime_us": runtime, "cpu.rt_period_us": period} {
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(data+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	setRt(parent, "600000", "1000000")
+	// A sibling with 400ms/s (in another period), and one without a budget.
+	setRt(filepath.Join(parent, "a"), "200000", "500000")
+	setRt(filepath.Join(parent, "b"), "0", "1000000")
+
+	policy, err := GetResourcePolicy(EqualSplitPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]string{"cpu": filepath.Join(parent, "ct")}
+	for _, tc := range []struct {
+		name     string
+		r        configs.Resources
+		expected configs.Resources
+	}{
+		{
+			// min(600000/3, 600000-400000)
+			name:     "split",
+			expected: configs.Resources{CpuRtRuntime: 200000, CpuRtPeriod: 1000000},
+		},
+		{
+			name:     "own budget",
+			r:        configs.Resources{CpuRtRuntime: 1000},
+			expected: configs.Resources{CpuRtRuntime: 1000},
+		},
+	} {
+		r := tc.r
+		if err := policy.PreApply(paths, &r); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.expected, r)
+		}
+	}
+
+	// Not enough left.
+	setRt(filepath.Join(parent, "a"), "300000", "500000")
+	var r configs.Resources
+	if err := policy.PreApply(paths, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.CpuRtRuntime != 0 {
+		t.Errorf("expected no budget, got %d", r.CpuRtRuntime)
+	}
+}
+
+func TestGetResourcePolicy(t *testing.T) {
+	if p, err := GetResourcePolicy(""); p != nil || err != nil {
+		t.Errorf("expected no policy and no error, got %v, %v", p, err)
+	}
+	if _, err := GetResourcePolicy("no-such-policy"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
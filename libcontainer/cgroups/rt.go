@@ -0,0 +1,177 @@
+package cgroups
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// The paths of the global real-time bandwidth sysctls (variables for
+// testing purposes).
+var (
+	sysctlRtRuntime = "/proc/sys/kernel/sched_rt_runtime_us"
+	sysctlRtPeriod  = "/proc/sys/kernel/sched_rt_period_us"
+)
+
+// GetGlobalRtBandwidth returns the global real-time bandwidth, i.e. the
+// values of the kernel.sched_rt_runtime_us and kernel.sched_rt_period_us
+// sysctls. A runtime of -1 means there is no limit.
+func GetGlobalRtBandwidth() (runtime int64, period uint64, _ error) {
+	data, err := os.ReadFile(sysctlRtRuntime)
+	if err != nil {
+		return 0, 0, err
+	}
+	if runtime, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("unable to parse %s: %w", sysctlRtRuntime, err)
+	}
+	if data, err = os.ReadFile(sysctlRtPeriod); err != nil {
+		return 0, 0, err
+	}
+	if period, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("unable to parse %s: %w", sysctlRtPeriod, err)
+	}
+	if period == 0 {
+		return 0, 0, fmt.Errorf("invalid %s value 0", sysctlRtPeriod)
+	}
+	return runtime, period, nil
+}
+
+// ResolveRtPercent sets the real-time runtime of r from r.CpuRtPercent, if
+// set, and the effective period: r.CpuRtPeriod if set, otherwise the period
+// of the cgroup v1 cpu directory dir if it exists, otherwise the global one
+// (which new cgroups get).
+func ResolveRtPercent(r *configs.Resources, dir string) error {
+	if r.CpuRtPercent == 0 {
+		return nil
+	}
+	if r.CpuRtPercent < 0 || r.CpuRtPercent > 100 {
+		return fmt.Errorf("invalid cpu rt percent %v: must be between 0 and 100", r.CpuRtPercent)
+	}
+	period := r.CpuRtPeriod
+	if period == 0 && dir != "" {
+		data, err := ReadFile(dir, "cpu.rt_period_us")
+		if err == nil {
+			period, err = strconv.ParseUint(strings.TrimSpace(data), 10, 64)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if period == 0 {
+		var err error
+		if _, period, err = GetGlobalRtBandwidth(); err != nil {
+			return err
+		}
+	}
+	r.CpuRtRuntime = max(int64(float64(period)*r.CpuRtPercent/100+0.5), 1)
+	return nil
+}
+
+// rtRuntimeFor returns the runtime needed in the given limit period for the
+// requested runtime and period to fit in (rounded up).
+func rtRuntimeFor(runtime int64, period, limitPeriod uint64) int64 {
+	return int64((uint64(runtime)*limitPeriod + period - 1) / period)
+}
+
+// rtCgroupRoot returns the cgroup v1 cpu controller root directory, or an
+// empty string if there is none (a variable for testing purposes).
+var rtCgroupRoot = func() string {
+	if IsCgroup2UnifiedMode() {
+		return ""
+	}
+	dir, err := FindCgroupMountpoint("", "cpu")
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+func readBandwidth(dir, runtimeFile, periodFile string) (runtime int64, period uint64, _ error) {
+	data, err := ReadFile(dir, runtimeFile)
+	if err != nil {
+		return 0, 0, err
+	}
+	if runtime, err = strconv.ParseInt(strings.TrimSpace(data), 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("unable to parse %s: %w", runtimeFile, err)
+	}
+	if data, err = ReadFile(dir, periodFile); err != nil {
+		return 0, 0, err
+	}
+	if period, err = strconv.ParseUint(strings.TrimSpace(data), 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("unable to parse %s: %w", periodFile, err)
+	}
+	return runtime, period, nil
+}
+
+// CheckRtBandwidth checks that the real-time runtime requested in r fits in
+// the global real-time bandwidth, and, with cgroup v1, in the bandwidth of
+// the root cpu cgroup. If it does not, and adjust is set, the global runtime
+// (kernel.sched_rt_runtime_us) and the runtime of the root cpu cgroup are
+// raised to make it fit; otherwise, an error is returned.
+//
+// Note that the real-time bandwidth used by other cgroups is not taken into
+// account, so a successful check does not guarantee the runtime can be set.
+func CheckRtBandwidth(r *configs.Resources, adjust bool) error {
+	if r == nil || r.CpuRtRuntime <= 0 {
+		return nil
+	}
+	globalRuntime, globalPeriod, err := GetGlobalRtBandwidth()
+	if err != nil {
+		return fmt.Errorf("unable to get global real-time bandwidth: %w", err)
+	}
+	period := r.CpuRtPeriod
+	if period == 0 {
+		period = globalPeriod
+	}
+	if uint64(r.CpuRtRuntime) > period {
+		return fmt.Errorf("real-time runtime %d is larger than the period %d", r.CpuRtRuntime, period)
+	}
+	if globalRuntime >= 0 {
+		need := rtRuntimeFor(r.CpuRtRuntime, period, globalPeriod)
+		if need > globalRuntime {
+			if !adjust {
+				return fmt.Errorf("requested real-time runtime %d (period %d) exceeds the global real-time bandwidth (%s=%d, %s=%d): %w",
+					r.CpuRtRuntime, period, sysctlRtRuntime, globalRuntime, sysctlRtPeriod, globalPeriod, ErrBudgetExceeded)
+			}
+			if err := os.WriteFile(sysctlRtRuntime, []byte(strconv.FormatInt(need, 10)), 0o644); err != nil {
+				return fmt.Errorf("unable to adjust global real-time bandwidth: %w", err)
+			}
+			logrus.Warnf("raised %s from %d to %d", sysctlRtRuntime, globalRuntime, need)
+		}
+	}
+
+	root := rtCgroupRoot()
+	if root == "" {
+		return nil
+	}
+	rootRuntime, rootPeriod, err := readBandwidth(root, "cpu.rt_runtime_us", "cpu.rt_period_us")
+	if err != nil {
+		// No RT group scheduling (CONFIG_RT_GROUP_SCHED is not set).
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("unable to get real-time bandwidth of %s: %w", root, err)
+	}
+	if rootRuntime < 0 || rootPeriod == 0 {
+		return nil
+	}
+	need := rtRuntimeFor(r.CpuRtRuntime, period, rootPeriod)
+	if need <= rootRuntime {
+		return nil
+	}
+	if !adjust {
+		return fmt.Errorf("requested real-time runtime %d (period %d) exceeds the real-time bandwidth of %s (cpu.rt_runtime_us=%d, cpu.rt_period_us=%d): %w",
+			r.CpuRtRuntime, period, root, rootRuntime, rootPeriod, ErrBudgetExceeded)
+	}
+	if err := WriteFile(root, "cpu.rt_runtime_us", strconv.FormatInt(need, 10)); err != nil {
+		return fmt.Errorf("unable to adjust real-time bandwidth of %s: %w", root, err)
+	}
+	logrus.Warnf("raised %s/cpu.rt_runtime_us from %d to %d", root, rootRuntime, need)
+	return nil
+}
@@ -0,0 +1,117 @@
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestCheckRtBandwidth(t *testing.T) {
+	dir := t.TempDir()
+	oldRuntime, oldPeriod, oldRoot := sysctlRtRuntime, sysctlRtPeriod, rtCgroupRoot
+	sysctlRtRuntime = filepath.Join(dir, "sched_rt_runtime_us")
+	sysctlRtPeriod = filepath.Join(dir, "sched_rt_period_us")
+	rtCgroupRoot = func() string { return "" }
+	defer func() {
+		sysctlRtRuntime, sysctlRtPeriod, rtCgroupRoot = oldRuntime, oldPeriod, oldRoot
+	}()
+	set := func(runtime string) {
+		t.Helper()
+		if err := os.WriteFile(sysctlRtRuntime, []byte(runtime+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(sysctlRtPeriod, []byte("1000000\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func() string {
+		t.Helper()
+		data, err := os.ReadFile(sysctlRtRuntime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(data))
+	}
+
+	for _, tc := range []struct {
+		name    string
+		global  string
+		runtime int64
+		period  uint64
+		adjust  bool
+		isErr   bool
+		result  string
+	}{
+		{name: "no rt", global: "0", result: "0"},
+		{name: "fits", global: "950000", runtime: 500000, result: "950000"},
+		{name: "fits, other period", global: "950000", runtime: 9500, period: 10000, result: "950000"},
+		{name: "unlimited", global: "-1", runtime: 1000000, result: "-1"},
+		{name: "too large", global: "950000", runtime: 960000, isErr: true, result: "950000"},
+		{name: "too large, other period", global: "500000", runtime: 6000, period: 10000, isErr: true, result: "500000"},
+		{name: "adjust", global: "500000", runtime: 6000, period: 10000, adjust: true, result: "600000"},
+		{name: "larger than period", global: "950000", runtime: 20000, period: 10000, adjust: true, isErr: true, result: "950000"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			set(tc.global)
+			err := CheckRtBandwidth(&configs.Resources{CpuRtRuntime: tc.runtime, CpuRtPeriod: tc.period}, tc.adjust)
+			if tc.isErr && err == nil {
+				t.Error("expected error, got nil")
+			} else if !tc.isErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if got := get(); got != tc.result {
+				t.Errorf("expected global runtime %s, got %s", tc.result, got)
+			}
+		})
+	}
+}
+
+func TestResolveRtPercent(t *testing.T) {
+	TestMode = true
+	defer func() { TestMode = false }()
+
+	dir := t.TempDir()
+	oldPeriod := sysctlRtPeriod
+	sysctlRtPeriod = filepath.Join(dir, "sched_rt_period_us")
+	defer func() { sysctlRtPeriod = oldPeriod }()
+	if err := os.WriteFile(sysctlRtPeriod, []byte("1000000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cg := filepath.Join(dir, "ct")
+	if err := os.Mkdir(cg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cg, "cpu.rt_period_us"), []byte("100000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		r        configs.Resources
+		dir      string
+		expected int64
+	}{
+		{name: "no percent", r: configs.Resources{CpuRtRuntime: 1234}, expected: 1234},
+		{name: "period", r: configs.Resources{CpuRtPercent: 30, CpuRtPeriod: 500000}, dir: cg, expected: 150000},
+		{name: "cgroup period", r: configs.Resources{CpuRtPercent: 30}, dir: cg, expected: 30000},
+		{name: "global period", r: configs.Resources{CpuRtPercent: 2.5}, expected: 25000},
+		{name: "no cgroup", r: configs.Resources{CpuRtPercent: 30}, dir: filepath.Join(dir, "none"), expected: 300000},
+		{name: "tiny", r: configs.Resources{CpuRtPercent: 0.00001}, expected: 1},
+	} {
+		r := tc.r
+		if err := ResolveRtPercent(&r, tc.dir); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if r.CpuRtRuntime != tc.expected {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.expected, r.CpuRtRuntime)
+		}
+	}
+
+	if err := ResolveRtPercent(&configs.Resources{CpuRtPercent: 101}, ""); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -9,6 +9,14 @@ type ThrottlingData struct {
 	ThrottledTime uint64 `json:"throttled_time,omitempty"`
 }
 
+type BurstData struct {
+	// Number of periods in which the CPU bandwidth burst was used.
+	BurstsPeriods uint64 `json:"bursts_periods,omitempty"`
+	// Aggregate time the container ran above its quota (using the burst)
+	// for in nanoseconds.
+	BurstTime uint64 `json:"burst_time,omitempty"`
+}
+
 // CpuUsage denotes the usage of a CPU.
 // All CPU stats are aggregate since container inception.
 type CpuUsage struct {
@@ -44,10 +52,38 @@ type PSIStats struct {
 	Full PSIData `json:"full,omitempty"`
 }
 
+// RtBandwidth is the real-time bandwidth of a cgroup (cgroup v1 only).
+type RtBandwidth struct {
+	// Runtime is the real-time runtime in microseconds, -1 if unlimited.
+	Runtime int64 `json:"runtime"`
+	// Period is the real-time period in microseconds.
+	Period uint64 `json:"period"`
+}
+
+// RtHeadroom is the real-time bandwidth of a cgroup which is not allocated
+// to its children (cgroup v1 only).
+type RtHeadroom struct {
+	Path string `json:"path"`
+	RtBandwidth
+	// Allocated is the runtime of the children, scaled to Period.
+	Allocated int64 `json:"allocated"`
+	// Free is Runtime minus Allocated (0 if overcommitted), or -1 if the
+	// runtime is unlimited.
+	Free int64 `json:"free"`
+}
+
 type CpuStats struct {
 	CpuUsage       CpuUsage       `json:"cpu_usage,omitempty"`
 	ThrottlingData ThrottlingData `json:"throttling_data,omitempty"`
+	BurstData      BurstData      `json:"burst_data,omitempty"`
 	PSI            *PSIStats      `json:"psi,omitempty"`
+	RtBandwidth    *RtBandwidth   `json:"rt_bandwidth,omitempty"`
+	// RtHeadroom is the real-time bandwidth left unallocated in the cgroup
+	// and in each of its ancestors, innermost first.
+	RtHeadroom []RtHeadroom `json:"rt_headroom,omitempty"`
+	// Idle is the value of cpu.idle (1 if the cgroup is SCHED_IDLE, 0
+	// otherwise), or nil if not supported by the kernel.
+	Idle *int64 `json:"idle,omitempty"`
 }
 
 type CPUSetStats struct {
@@ -75,6 +111,11 @@ type CPUSetStats struct {
 	SchedLoadBalance uint64 `json:"sched_load_balance"`
 	// sched_relax_domain_level
 	SchedRelaxDomainLevel int64 `json:"sched_relax_domain_level"`
+	// List of the CPUs and memory nodes actually granted to the cpuset,
+	// which can be a subset of CPUs and Mems, if the ancestors don't
+	// allow all of them
+	EffectiveCPUs []uint16 `json:"effective_cpus,omitempty"`
+	EffectiveMems []uint16 `json:"effective_mems,omitempty"`
 }
 
 type MemoryData struct {
@@ -100,6 +141,9 @@ type MemoryStats struct {
 	// usage of memory pages by NUMA node
 	// see chapter 5.6 of memory controller documentation
 	PageUsageByNUMA PageUsageByNUMA `json:"page_usage_by_numa,omitempty"`
+	// usage of memory by NUMA node, in bytes, by memory.numa_stat item
+	// (cgroup v2 only, see the memory.numa_stat documentation)
+	NUMAStat map[string]map[uint8]uint64 `json:"numa_stat,omitempty"`
 	// if true, memory usage is accounted for throughout a hierarchy of cgroups.
 	UseHierarchy bool `json:"use_hierarchy"`
 
@@ -205,15 +205,20 @@ func (m *LegacyManager) Apply(pid int) error {
 
 	properties = append(properties, c.SystemdProps...)
 
+	policy, r, err := cgroups.PreApplyPolicy(c, m.paths)
+	if err != nil {
+		return err
+	}
+
 	if err := startUnit(m.dbus, unitName, properties, pid == -1); err != nil {
 		return err
 	}
 
-	if err := m.joinCgroups(pid); err != nil {
+	if err := m.joinCgroups(pid, r); err != nil {
 		return err
 	}
 
-	return nil
+	return cgroups.PostApplyPolicy(c, policy, m.paths, r, pid)
 }
 
 func (m *LegacyManager) Destroy() error {
@@ -238,7 +243,13 @@ func (m *LegacyManager) Path(subsys string) string {
 	return m.paths[subsys]
 }
 
-func (m *LegacyManager) joinCgroups(pid int) error {
+// ControllerPath implements [cgroups.Manager.ControllerPath]. The paths are
+// already by controller, including the hybrid one ("").
+func (m *LegacyManager) ControllerPath(name string) string {
+	return m.Path(name)
+}
+
+func (m *LegacyManager) joinCgroups(pid int, r *configs.Resources) error {
 	for _, sys := range legacySubsystems {
 		name := sys.Name()
 		switch name {
@@ -247,7 +258,7 @@ func (m *LegacyManager) joinCgroups(pid int) error {
 		case "cpuset":
 			if path, ok := m.paths[name]; ok {
 				s := &fs.CpusetGroup{}
-				if err := s.ApplyDir(path, m.cgroups.Resources, pid); err != nil {
+				if err := s.ApplyDir(path, r, pid); err != nil {
 					return err
 				}
 			}
@@ -256,11 +267,23 @@ func (m *LegacyManager) joinCgroups(pid int) error {
 				if err := os.MkdirAll(path, 0o755); err != nil {
 					return err
 				}
-				if err := cgroups.WriteCgroupProc(path, pid); err != nil {
+				if name == "cpu" && r != m.cgroups.Resources {
+					// Set the real-time budget changed by the
+					// resource policy, which Set doesn't know.
+					if err := (&fs.CpuGroup{}).SetRtSched(path, r); err != nil {
+						return err
+					}
+				}
+				if err := cgroups.WriteCgroupProcRetry(path, pid, r.GetAttachRetry()); err != nil {
 					return err
 				}
 			}
 		}
+		if path, ok := m.paths[name]; ok && name != "name=systemd" && m.cgroups.OwnerUID != nil {
+			if err := cgroups.Delegate(path, *m.cgroups.OwnerUID); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -371,6 +394,19 @@ func (m *LegacyManager) Set(r *configs.Resources) error {
 		return setErr
 	}
 
+	return m.setSubsystems(r)
+}
+
+func (m *LegacyManager) Diff(r *configs.Resources) ([]cgroups.FileChange, error) {
+	if r != nil && r.Unified != nil {
+		return nil, cgroups.ErrV1NoUnified
+	}
+	return cgroups.DiffSet(r, m.setSubsystems)
+}
+
+// setSubsystems sets the resources using the cgroup v1 files, which is needed
+// for those not supported by systemd.
+func (m *LegacyManager) setSubsystems(r *configs.Resources) error {
 	for _, sys := range legacySubsystems {
 		// Get the subsystem path, but don't error out for not found cgroups.
 		path, ok := m.paths[sys.Name()]
@@ -408,6 +444,22 @@ func (m *LegacyManager) Exists() bool {
 	return cgroups.PathExists(m.Path("devices"))
 }
 
+func (m *LegacyManager) Kill() error {
+	return cgroups.Kill(m.Path(""))
+}
+
+func (m *LegacyManager) GetEffectiveCpus() (string, error) {
+	return fs.GetEffectiveCpus(m.Path("cpuset"))
+}
+
+func (m *LegacyManager) GetEffectiveMems() (string, error) {
+	return fs.GetEffectiveMems(m.Path("cpuset"))
+}
+
+func (m *LegacyManager) ResetMemoryPeak() error {
+	return fs.ResetMemoryPeak(m.Path("memory"))
+}
+
 func (m *LegacyManager) OOMKillCount() (uint64, error) {
 	return fs.OOMKillCount(m.Path("memory"))
 }
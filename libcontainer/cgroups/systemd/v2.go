@@ -1,11 +1,8 @@
 package systemd
 
 import (
-	"bufio"
-	"errors"
 	"fmt"
 	"math"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -328,6 +325,12 @@ func (m *UnifiedManager) Apply(pid int) error {
 
 	properties = append(properties, c.SystemdProps...)
 
+	paths := map[string]string{"": m.path}
+	policy, r, err := cgroups.PreApplyPolicy(c, paths)
+	if err != nil {
+		return err
+	}
+
 	if err := startUnit(m.dbus, unitName, properties, pid == -1); err != nil {
 		return fmt.Errorf("unable to start unit %q (properties %+v): %w", unitName, properties, err)
 	}
@@ -337,51 +340,12 @@ func (m *UnifiedManager) Apply(pid int) error {
 	}
 
 	if c.OwnerUID != nil {
-		// The directory itself must be chowned.
-		err := os.Chown(m.path, *c.OwnerUID, -1)
-		if err != nil {
-			return err
-		}
-
-		filesToChown, err := cgroupFilesToChown()
-		if err != nil {
+		if err := cgroups.Delegate(m.path, *c.OwnerUID); err != nil {
 			return err
 		}
-
-		for _, v := range filesToChown {
-			err := os.Chown(m.path+"/"+v, *c.OwnerUID, -1)
-			// Some files might not be present.
-			if err != nil && !errors.Is(err, os.ErrNotExist) {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
-// The kernel exposes a list of files that should be chowned to the delegate
-// uid in /sys/kernel/cgroup/delegate.  If the file is not present
-// (Linux < 4.15), use the initial values mentioned in cgroups(7).
-func cgroupFilesToChown() ([]string, error) {
-	const cgroupDelegateFile = "/sys/kernel/cgroup/delegate"
-
-	f, err := os.Open(cgroupDelegateFile)
-	if err != nil {
-		return []string{"cgroup.procs", "cgroup.subtree_control", "cgroup.threads"}, nil
-	}
-	defer f.Close()
-
-	filesToChown := []string{}
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		filesToChown = append(filesToChown, scanner.Text())
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading %s: %w", cgroupDelegateFile, err)
 	}
 
-	return filesToChown, nil
+	return cgroups.PostApplyPolicy(c, policy, paths, r, pid)
 }
 
 func (m *UnifiedManager) Destroy() error {
@@ -407,6 +371,10 @@ func (m *UnifiedManager) Path(_ string) string {
 	return m.path
 }
 
+func (m *UnifiedManager) ControllerPath(name string) string {
+	return m.fsMgr.ControllerPath(name)
+}
+
 // getSliceFull value is used in initPath.
 // The value is incompatible with systemdDbus.PropSlice.
 func (m *UnifiedManager) getSliceFull() (string, error) {
@@ -493,6 +461,10 @@ func (m *UnifiedManager) Set(r *configs.Resources) error {
 	return m.fsMgr.Set(r)
 }
 
+func (m *UnifiedManager) Diff(r *configs.Resources) ([]cgroups.FileChange, error) {
+	return m.fsMgr.Diff(r)
+}
+
 func (m *UnifiedManager) GetPaths() map[string]string {
 	paths := make(map[string]string, 1)
 	paths[""] = m.path
@@ -511,6 +483,22 @@ func (m *UnifiedManager) Exists() bool {
 	return cgroups.PathExists(m.path)
 }
 
+func (m *UnifiedManager) Kill() error {
+	return m.fsMgr.Kill()
+}
+
+func (m *UnifiedManager) GetEffectiveCpus() (string, error) {
+	return m.fsMgr.GetEffectiveCpus()
+}
+
+func (m *UnifiedManager) GetEffectiveMems() (string, error) {
+	return m.fsMgr.GetEffectiveMems()
+}
+
+func (m *UnifiedManager) ResetMemoryPeak() error {
+	return m.fsMgr.ResetMemoryPeak()
+}
+
 func (m *UnifiedManager) OOMKillCount() (uint64, error) {
 	return m.fsMgr.OOMKillCount()
 }
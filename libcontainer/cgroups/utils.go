@@ -7,11 +7,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/userns"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
@@ -274,6 +276,107 @@ func RemovePath(path string) error {
 	return err
 }
 
+// RemovePathKill is like RemovePath, except that the processes left in the
+// cgroup path and its sub-cgroups are killed. The cgroups are handled bottom
+// up: kill is called for a cgroup which can't be removed because it still
+// has processes, once all its sub-cgroups are removed. A failure to
+// remove a sub-cgroup does not stop the others from being handled, and the
+// error then tells how many of the cgroups were removed, and which ones are
+// left, with their processes.
+func RemovePathKill(path string, kill func(path string) error) error {
+	r := &treeRemover{root: path, kill: kill}
+	if err := r.remove(path); err != nil {
+		return err
+	}
+	if len(r.left) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unable to remove %d of %d cgroups under %s: %s",
+		len(r.left), r.removed+len(r.left), path, strings.Join(r.left, "; "))
+}
+
+type treeRemover struct {
+	root    string
+	kill    func(path string) error
+	removed int
+	left    []string
+}
+
+func (r *treeRemover) remove(path string) error {
+	// Try the fast path first.
+	if err := rmdir(path, false); err == nil {
+		r.removed++
+		return nil
+	}
+	infos, err := os.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	left := len(r.left)
+	for _, info := range infos {
+		if info.IsDir() {
+			if err := r.remove(filepath.Join(path, info.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	err = rmdir(path, false)
+	// There is no point in killing the processes if a sub-cgroup is left.
+	if errors.Is(err, unix.EBUSY) && len(r.left) == left {
+		if kerr := r.kill(path); kerr != nil {
+			logrus.Debugf("unable to kill the processes in cgroup %s: %v", path, kerr)
+		}
+		err = rmdir(path, true)
+	}
+	if err == nil {
+		r.removed++
+		return nil
+	}
+	name, _ := filepath.Rel(r.root, path)
+	msg := name + ": " + err.Error()
+	if pids, _ := GetPids(path); len(pids) > 0 {
+		msg += fmt.Sprintf(" (processes %v)", pids)
+	}
+	r.left = append(r.left, msg)
+	return nil
+}
+
+// KillProcs sends SIGKILL to the processes in the cgroup path (but not to
+// those in its sub-cgroups), except the current one.
+func KillProcs(path string) error {
+	pids, err := GetPids(path)
+	if err != nil {
+		return err
+	}
+	self := os.Getpid()
+	for _, pid := range pids {
+		if pid == self {
+			continue
+		}
+		if err := unix.Kill(pid, unix.SIGKILL); err != nil && err != unix.ESRCH { //nolint:errorlint // unix errors are bare
+			return fmt.Errorf("kill %d: %w", pid, err)
+		}
+	}
+	return nil
+}
+
+// Kill kills all the processes in the cgroup v2 path and its sub-cgroups,
+// using cgroup.kill. It returns ErrKillUnsupported if path is empty (no
+// unified hierarchy) or cgroup.kill is not available.
+func Kill(path string) error {
+	if path == "" {
+		return ErrKillUnsupported
+	}
+	err := WriteFile(path, "cgroup.kill", "1")
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrKillUnsupported
+	}
+	return err
+}
+
 // RemovePaths iterates over the provided paths removing them.
 func RemovePaths(paths map[string]string) (err error) {
 	for s, p := range paths {
@@ -364,8 +467,21 @@ func GetPids(dir string) ([]int, error) {
 	return readProcsFile(dir)
 }
 
+// Defaults for configs.AttachRetry.
+const (
+	DefaultAttachAttempts = 5
+	DefaultAttachBackoff  = 30 * time.Millisecond
+)
+
 // WriteCgroupProc writes the specified pid into the cgroup's cgroup.procs file
 func WriteCgroupProc(dir string, pid int) error {
+	return WriteCgroupProcRetry(dir, pid, nil)
+}
+
+// WriteCgroupProcRetry is like WriteCgroupProc, but retries according to
+// retry, or the defaults if it is nil. Every retry is logged (at debug level)
+// and reported to retry.OnRetry.
+func WriteCgroupProcRetry(dir string, pid int, retry *configs.AttachRetry) error {
 	// Normally dir should not be empty, one case is that cgroup subsystem
 	// is not mounted, we will get empty dir, and we want it fail here.
 	if dir == "" {
@@ -377,28 +493,51 @@ func WriteCgroupProc(dir string, pid int) error {
 		return nil
 	}
 
+	attempts, backoff := DefaultAttachAttempts, DefaultAttachBackoff
+	var onRetry func(configs.AttachRetryEvent)
+	if retry != nil {
+		if retry.Attempts > 0 {
+			attempts = retry.Attempts
+		}
+		if retry.Backoff > 0 {
+			backoff = retry.Backoff
+		}
+		onRetry = retry.OnRetry
+	}
+
 	file, err := OpenFile(dir, CgroupProcesses, os.O_WRONLY)
 	if err != nil {
 		return fmt.Errorf("failed to write %v: %w", pid, err)
 	}
 	defer file.Close()
 
-	for i := 0; i < 5; i++ {
+	for i := 1; i <= attempts; i++ {
 		_, err = file.WriteString(strconv.Itoa(pid))
 		if err == nil {
 			return nil
 		}
 
 		// EINVAL might mean that the task being added to cgroup.procs is in state
-		// TASK_NEW. We should attempt to do so again.
-		if errors.Is(err, unix.EINVAL) {
-			time.Sleep(30 * time.Millisecond)
-			continue
+		// TASK_NEW, or (for a real-time task) that the cgroup is short of
+		// real-time runtime. We should attempt to do so again.
+		if !errors.Is(err, unix.EINVAL) {
+			return fmt.Errorf("failed to write %v: %w", pid, err)
 		}
-
-		return fmt.Errorf("failed to write %v: %w", pid, err)
+		if i == attempts {
+			break
+		}
+		logrus.WithFields(logrus.Fields{
+			"path":    dir,
+			"pid":     pid,
+			"attempt": i,
+			"backoff": backoff,
+		}).WithError(err).Debug("retrying cgroup attach")
+		if onRetry != nil {
+			onRetry(configs.AttachRetryEvent{Path: dir, Pid: pid, Attempt: i, Err: err, Backoff: backoff})
+		}
+		time.Sleep(backoff)
 	}
-	return err
+	return fmt.Errorf("failed to write %v after %d attempts: %w", pid, attempts, err)
 }
 
 // Since the OCI spec is designed for cgroup v1, in some cases
@@ -451,3 +590,57 @@ func ConvertBlkIOToIOWeightValue(blkIoWeight uint16) uint64 {
 	}
 	return 1 + (uint64(blkIoWeight)-10)*9999/990
 }
+
+// ParseCPUList parses a list of CPUs in the format used by cpuset.cpus and
+// the /sys/devices/system/cpu files (e.g. "0-3,7"), and returns the sorted
+// CPU numbers, without duplicates.
+func ParseCPUList(list string) ([]int, error) {
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return nil, nil
+	}
+	seen := map[int]struct{}{}
+	for _, r := range strings.Split(list, ",") {
+		first, last, isRange := strings.Cut(r, "-")
+		lo, err := strconv.Atoi(first)
+		if err != nil || lo < 0 {
+			return nil, fmt.Errorf("invalid cpu list %q", list)
+		}
+		hi := lo
+		if isRange {
+			if hi, err = strconv.Atoi(last); err != nil || hi < lo {
+				return nil, fmt.Errorf("invalid cpu list %q", list)
+			}
+		}
+		for cpu := lo; cpu <= hi; cpu++ {
+			seen[cpu] = struct{}{}
+		}
+	}
+	cpus := make([]int, 0, len(seen))
+	for cpu := range seen {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// FormatCPUList is the opposite of ParseCPUList. The cpus must be sorted.
+func FormatCPUList(cpus []int) string {
+	var b strings.Builder
+	for i := 0; i < len(cpus); i++ {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(cpus[i]))
+		if j > i {
+			b.WriteByte('-')
+			b.WriteString(strconv.Itoa(cpus[j]))
+		}
+		i = j
+	}
+	return b.String()
+}
@@ -3,11 +3,18 @@ package cgroups
 import (
 	"bytes"
 	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/moby/sys/mountinfo"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
 )
 
 const fedoraMountinfo = `15 35 0:3 / /proc rw,nosuid,nodev,noexec,relatime shared:5 - proc proc rw
@@ -646,3 +653,114 @@ func TestConvertBlkIOToIOWeightValue(t *testing.T) {
 		}
 	}
 }
+
+func TestKill(t *testing.T) {
+	TestMode = true
+	defer func() { TestMode = false }()
+
+	if err := Kill(""); !errors.Is(err, ErrKillUnsupported) {
+		t.Errorf("expected ErrKillUnsupported for an empty path, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.kill"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Kill(dir); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := os.ReadFile(filepath.Join(dir, "cgroup.kill")); err != nil || string(val) != "1" {
+		t.Errorf("expected cgroup.kill to contain 1, got %q (err: %v)", val, err)
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		out   []int
+		str   string
+		isErr bool
+	}{
+		{in: "", out: nil},
+		{in: "0", out: []int{0}, str: "0"},
+		{in: "0-3,7\n", out: []int{0, 1, 2, 3, 7}, str: "0-3,7"},
+		{in: "7,1-2,2,0", out: []int{0, 1, 2, 7}, str: "0-2,7"},
+		{in: "3-1", isErr: true},
+		{in: "1,,2", isErr: true},
+		{in: "a-b", isErr: true},
+		{in: "-1", isErr: true},
+	} {
+		cpus, err := ParseCPUList(tc.in)
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", tc.in, cpus)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(cpus, tc.out) {
+			t.Errorf("%q: expected %v, got %v", tc.in, tc.out, cpus)
+		}
+		if str := FormatCPUList(cpus); str != tc.str {
+			t.Errorf("%q: expected %q, got %q", tc.in, tc.str, str)
+		}
+	}
+}
+
+func TestWriteCgroupProcRetry(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	if IsCgroup2UnifiedMode() {
+		t.Skip("requires cgroup v1 real-time group scheduling")
+	}
+	parent, err := GetOwnCgroupPath("cpu")
+	if err != nil {
+		t.Skip(err)
+	}
+	// A new cgroup has no real-time runtime, so a real-time task can't be
+	// added to it, which the kernel reports with EINVAL.
+	dir := filepath.Join(parent, "test-attach-retry")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Skip(err)
+	}
+	defer os.Remove(dir)
+	if _, err := os.Stat(filepath.Join(dir, "cpu.rt_runtime_us")); err != nil {
+		t.Skip("no real-time group scheduling")
+	}
+
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	attr := &unix.SchedAttr{Size: unix.SizeofSchedAttr, Policy: unix.SCHED_FIFO, Priority: 1}
+	if err := unix.SchedSetAttr(cmd.Process.Pid, attr, 0); err != nil {
+		t.Skip(err)
+	}
+
+	var events []configs.AttachRetryEvent
+	retry := &configs.AttachRetry{
+		Attempts: 3,
+		Backoff:  time.Millisecond,
+		OnRetry:  func(e configs.AttachRetryEvent) { events = append(events, e) },
+	}
+	err = WriteCgroupProcRetry(dir, cmd.Process.Pid, retry)
+	if !errors.Is(err, unix.EINVAL) {
+		t.Fatalf("expected EINVAL, got %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 retries, got %d", len(events))
+	}
+	for i, e := range events {
+		if e.Attempt != i+1 || e.Pid != cmd.Process.Pid || e.Path != dir || e.Backoff != time.Millisecond {
+			t.Errorf("unexpected retry event %+v", e)
+		}
+	}
+}
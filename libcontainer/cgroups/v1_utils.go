@@ -39,6 +39,11 @@ func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("mountpoint for %s not found", e.Subsystem)
 }
 
+// Is makes a NotFoundError match ErrControllerUnavailable.
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrControllerUnavailable
+}
+
 func NewNotFoundError(sub string) error {
 	return &NotFoundError{
 		Subsystem: sub,
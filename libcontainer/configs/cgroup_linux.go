@@ -1,6 +1,12 @@
 package configs
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
 	systemdDbus "github.com/coreos/go-systemd/v22/dbus"
 	"github.com/opencontainers/runc/libcontainer/devices"
 )
@@ -45,11 +51,62 @@ type Cgroup struct {
 	// The host UID that should own the cgroup, or nil to accept
 	// the default ownership.  This should only be set when the
 	// cgroupfs is to be mounted read/write.
-	// Not all cgroup manager implementations support changing
-	// the ownership.
+	// With cgroup v1, the cgroup of each controller is chowned.
 	OwnerUID *int `json:"owner_uid,omitempty"`
+
+	// SplitSubCgroups, if set, makes runc create the RuntimeSubCgroup and
+	// WorkloadSubCgroup sub-cgroups of the container cgroup. The container
+	// init is run in the workload one, and the processes executed in the
+	// container are run in the runtime one by default. On cgroup v1, the
+	// real-time CPU budget of the container is given to the workload
+	// sub-cgroup only.
+	SplitSubCgroups bool `json:"split_sub_cgroups,omitempty"`
+
+	// RtSubCgroup, if set, is the name of a cgroup v1 cpu sub-cgroup of
+	// the container cgroup, which is given the real-time CPU budget of
+	// the container. Only the processes whose command is in
+	// RtSubCgroupCommands, or which are explicitly executed in it (see
+	// Process.RtSubCgroup), are run in it. Can't be used with
+	// SplitSubCgroups.
+	RtSubCgroup string `json:"rt_sub_cgroup,omitempty"`
+
+	// RtSubCgroupCommands are the commands (base names of the first
+	// argument) of the processes to run in the RtSubCgroup.
+	RtSubCgroupCommands []string `json:"rt_sub_cgroup_commands,omitempty"`
+
+	// ExecAccounting, if set, makes runc run the processes executed in the
+	// container in a sub-cgroup without limits, so that their resource
+	// usage can be reported separately. It is the ExecSubCgroup, or the
+	// RuntimeSubCgroup if SplitSubCgroups is set. On cgroup v1, it is only
+	// used for the cpu, cpuacct, memory, pids and blkio controllers; on
+	// cgroup v2, unless SplitSubCgroups is set, no controllers are enabled
+	// for it, so only its CPU usage is accounted.
+	ExecAccounting bool `json:"exec_accounting,omitempty"`
+
+	// Adopt tells runc to use the pre-existing cgroup at Path, created by
+	// an external manager. runc neither creates nor removes the cgroup,
+	// and does not change its ownership or enabled controllers; it only
+	// checks that the cgroup exists with the controllers needed for the
+	// configured resources. Not supported by the systemd cgroup driver.
+	Adopt bool `json:"adopt,omitempty"`
+
+	// ResourcePolicy is the name of the resource policy used when applying
+	// the resources (see cgroups.ResourcePolicy), or empty for none.
+	ResourcePolicy string `json:"resource_policy,omitempty"`
 }
 
+const (
+	// RuntimeSubCgroup is the sub-cgroup for runtime (helper) processes,
+	// used when Cgroup.SplitSubCgroups is set.
+	RuntimeSubCgroup = "runtime"
+	// WorkloadSubCgroup is the sub-cgroup for the container workload,
+	// used when Cgroup.SplitSubCgroups is set.
+	WorkloadSubCgroup = "workload"
+	// ExecSubCgroup is the sub-cgroup for the processes executed in the
+	// container, used when Cgroup.ExecAccounting is set.
+	ExecSubCgroup = "exec"
+)
+
 type Resources struct {
 	// Devices is the set of access rules for devices in the container.
 	Devices []*devices.Rule `json:"devices"`
@@ -81,6 +138,13 @@ type Resources struct {
 	// CPU period to be used for realtime scheduling (in usecs).
 	CpuRtPeriod uint64 `json:"cpu_rt_period"`
 
+	// CpuRtPercent, if not 0, is the realtime runtime as a percentage of
+	// the realtime period (e.g. 30 for 30% of each CPU). CpuRtRuntime is
+	// derived from it and the effective period (see
+	// cgroups.ResolveRtPercent), and so is rescaled when the period is
+	// changed.
+	CpuRtPercent float64 `json:"cpu_rt_percent,omitempty"`
+
 	// CPU to use
 	CpusetCpus string `json:"cpuset_cpus"`
 
@@ -90,6 +154,11 @@ type Resources struct {
 	// cgroup SCHED_IDLE
 	CPUIdle *int64 `json:"cpu_idle,omitempty"`
 
+	// Latency nice value of the cgroup, from -20 (most latency sensitive)
+	// to 19 (least latency sensitive). Only used if the kernel provides the
+	// cpu.latency.nice cgroup interface.
+	CpuLatencyNice *int64 `json:"cpu_latency_nice,omitempty"` //nolint:revive
+
 	// Process limit; set <= `0' to disable limit.
 	PidsLimit int64 `json:"pids_limit"`
 
@@ -135,6 +204,9 @@ type Resources struct {
 	// Rdma resource restriction configuration
 	Rdma map[string]LinuxRdma `json:"rdma"`
 
+	// NetRate limits the network bandwidth of the cgroup (cgroup v2 only).
+	NetRate *NetRate `json:"net_rate,omitempty"`
+
 	// Used on cgroups v2:
 
 	// CpuWeight sets a proportional bandwidth limit.
@@ -166,4 +238,80 @@ type Resources struct {
 	// if the new memory limits (Memory and MemorySwap) being set are lower
 	// than the current memory usage, and reject if so.
 	MemoryCheckBeforeUpdate bool `json:"memory_check_before_update"`
+
+	// AttachRetry configures the retries of adding a process to the cgroup.
+	// If nil, the defaults are used.
+	AttachRetry *AttachRetry `json:"attach_retry,omitempty"`
 }
+
+// AttachRetry configures how writing a process to cgroup.procs is retried
+// when the kernel transiently rejects it with EINVAL, e.g. because the task
+// is still being forked, or because a real-time cgroup with a tight budget is
+// short of runtime under load.
+type AttachRetry struct {
+	// Attempts is the maximum number of writes. If 0, 5 is used.
+	Attempts int `json:"attempts,omitempty"`
+	// Backoff is the time to wait before each retry. If 0, 30ms is used.
+	Backoff time.Duration `json:"backoff,omitempty"`
+	// OnRetry, if set, is called before each retry. It is not saved with
+	// the container state, so it is only used by the current process.
+	OnRetry func(AttachRetryEvent) `json:"-"`
+}
+
+// GetAttachRetry returns r.AttachRetry, or nil if r is nil.
+func (r *Resources) GetAttachRetry() *AttachRetry {
+	if r == nil {
+		return nil
+	}
+	return r.AttachRetry
+}
+
+// AttachRetryEvent describes a failed attempt to add a process to a cgroup,
+// which is about to be retried.
+type AttachRetryEvent struct {
+	// Path is the cgroup directory.
+	Path string
+	Pid  int
+	// Attempt is the number of the failed attempt, starting from 1.
+	Attempt int
+	Err     error
+	// Backoff is the time to wait before the next attempt.
+	Backoff time.Duration
+}
+
+// Merge sets the fields of r listed in mask to the values of the same fields
+// in from, leaving all other fields intact. The fields are specified by their
+// JSON names (as in state.json), e.g. "memory" or "cpu_rt_quota".
+//
+// This allows to update some resources of a running container without
+// knowing (and resending) the current values of all the others.
+func (r *Resources) Merge(from *Resources, mask []string) error {
+	if from == nil {
+		from = &Resources{}
+	}
+	dst := reflect.ValueOf(r).Elem()
+	src := reflect.ValueOf(from).Elem()
+	for _, name := range mask {
+		i, ok := resourcesFields()[name]
+		if !ok {
+			return fmt.Errorf("unknown resources field %q", name)
+		}
+		dst.Field(i).Set(src.Field(i))
+	}
+	return nil
+}
+
+// resourcesFields returns the indexes of the Resources fields, by their
+// JSON names. Fields which are not saved to JSON are not included.
+var resourcesFields = sync.OnceValue(func() map[string]int {
+	t := reflect.TypeOf(Resources{})
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = i
+	}
+	return fields
+})
@@ -0,0 +1,37 @@
+package configs
+
+import "testing"
+
+func TestResourcesMerge(t *testing.T) {
+	idle := int64(1)
+	r := &Resources{
+		Memory:       1 << 20,
+		CpuRtRuntime: 1000,
+		CpuRtPeriod:  10000,
+		CpusetCpus:   "0-1",
+		CPUIdle:      &idle,
+	}
+	from := &Resources{
+		Memory:       2 << 20,
+		CpuRtRuntime: 2000,
+		CpusetCpus:   "3",
+	}
+	if err := r.Merge(from, []string{"cpu_rt_quota", "cpu_idle"}); err != nil {
+		t.Fatal(err)
+	}
+	if r.CpuRtRuntime != 2000 {
+		t.Errorf("expected cpu_rt_quota to be updated, got %d", r.CpuRtRuntime)
+	}
+	if r.CPUIdle != nil {
+		t.Errorf("expected cpu_idle to be reset, got %d", *r.CPUIdle)
+	}
+	if r.Memory != 1<<20 || r.CpuRtPeriod != 10000 || r.CpusetCpus != "0-1" {
+		t.Errorf("unexpected changes to fields not in the mask: %+v", r)
+	}
+
+	for _, name := range []string{"bogus", "SkipDevices", "-", ""} {
+		if err := r.Merge(from, []string{name}); err == nil {
+			t.Errorf("%q: expected error, got nil", name)
+		}
+	}
+}
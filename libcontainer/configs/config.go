@@ -200,6 +200,28 @@ type Config struct {
 	// callers keyring in this case.
 	NoNewKeyring bool `json:"no_new_keyring"`
 
+	// AdjustRtBandwidth allows raising the global real-time bandwidth
+	// (kernel.sched_rt_runtime_us) when the container is created, if the
+	// requested real-time runtime does not fit in it.
+	AdjustRtBandwidth bool `json:"adjust_rt_bandwidth,omitempty"`
+
+	// ThawFrozenAncestors allows thawing the ancestors of the container
+	// cgroup when the container is created, if they are frozen, rather
+	// than failing.
+	ThawFrozenAncestors bool `json:"thaw_frozen_ancestors,omitempty"`
+
+	// StartFrozen makes the container init wait, in a frozen cgroup, to
+	// be started, once the reservations of the container (its cpuset and
+	// real-time budget) are verified to be in effect. They are verified
+	// again before the cgroup is thawed on start.
+	StartFrozen bool `json:"start_frozen,omitempty"`
+
+	// ExclusiveCPUs, if non-zero, is the number of CPUs to be assigned
+	// exclusively to the container. They are selected by runc among the CPUs
+	// not yet assigned to the other containers using the same state root,
+	// and set as the container's cpuset when it is created.
+	ExclusiveCPUs int `json:"exclusive_cpus,omitempty"`
+
 	// IntelRdt specifies settings for Intel RDT group that the container is placed into
 	// to limit the resources (e.g., L3 cache, memory bandwidth) the container has available
 	IntelRdt *IntelRdt `json:"intel_rdt,omitempty"`
@@ -225,8 +247,90 @@ type Config struct {
 
 	// IOPriority is the container's I/O priority.
 	IOPriority *IOPriority `json:"io_priority,omitempty"`
+
+	// DestroyTimeout is how long to wait, when destroying the container,
+	// for all the container processes to exit after being killed. If zero,
+	// DefaultDestroyTimeout is used.
+	DestroyTimeout time.Duration `json:"destroy_timeout,omitempty"`
+
+	// TimerSlack is the timer slack of the container init process, in
+	// nanoseconds (see PR_SET_TIMERSLACK in prctl(2)). If zero, it is
+	// inherited from runc (normally 50µs), which is too coarse for
+	// sub-100µs periodic loops.
+	TimerSlack uint64 `json:"timer_slack,omitempty"`
+
+	// StopSignal is the signal sent to the container init process to stop
+	// it (see libcontainer.Container.Stop). If zero, SIGTERM is used.
+	StopSignal int `json:"stop_signal,omitempty"`
+
+	// CoreSched, if set, makes the container processes use a core
+	// scheduling cookie, so that they never share an SMT core with the
+	// processes which do not have the same cookie.
+	CoreSched *CoreSched `json:"core_sched,omitempty"`
+
+	// ExeProtection is how the runc binary is protected from being
+	// overwritten by the container processes (see CVE-2019-5736). It is one
+	// of ExeProtectionMemfd (the default if empty), ExeProtectionBindRO, or
+	// ExeProtectionOff. It is not used when runc-dmz is used.
+	ExeProtection string `json:"exe_protection,omitempty"`
+
+	// ExecPolicy restricts the security settings which can be overridden
+	// for the processes executed in the container (see runc exec). If nil,
+	// any override is allowed.
+	ExecPolicy *ExecPolicy `json:"exec_policy,omitempty"`
+
+	// AuditLog is where the security-relevant setup steps of the container
+	// processes are recorded, as JSON lines: either the absolute path of a
+	// file, which is appended to, or "fd:N" for the file descriptor N of
+	// the runtime. If empty, they are not recorded.
+	AuditLog string `json:"audit_log,omitempty"`
+}
+
+// ExecPolicy lists the overrides of the container security settings which
+// are allowed for the processes executed in a running container, e.g. to
+// attach a debugging tool with a reduced confinement. Overrides which make
+// the confinement stricter (such as setting no_new_privs) are always
+// allowed.
+type ExecPolicy struct {
+	// AppArmorProfiles are the AppArmor profiles, other than that of the
+	// container, which the executed processes can use.
+	AppArmorProfiles []string `json:"apparmor_profiles,omitempty"`
+
+	// ProcessLabels are the SELinux process labels, other than that of
+	// the container, which the executed processes can use.
+	ProcessLabels []string `json:"process_labels,omitempty"`
+
+	// AllowNewPrivileges allows the executed processes not to set
+	// no_new_privs when it is set for the container.
+	AllowNewPrivileges bool `json:"allow_new_privileges,omitempty"`
 }
 
+// The values of Config.ExeProtection.
+const (
+	// ExeProtectionMemfd makes runc init run from a sealed copy of the
+	// runc binary, made in a memfd (or, if memfds are not available, in a
+	// temporary file).
+	ExeProtectionMemfd = "memfd"
+	// ExeProtectionBindRO makes runc init run from a read-only detached
+	// bind mount of the runc binary, which, unlike a copy, uses no memory
+	// or space. If it can not be used, ExeProtectionMemfd is used instead.
+	ExeProtectionBindRO = "bind-ro"
+	// ExeProtectionOff disables the protection. This is unsafe unless the
+	// container processes can not access the runc binary in any other way.
+	ExeProtectionOff = "off"
+)
+
+// CoreSched is the core scheduling (PR_SCHED_CORE) configuration.
+type CoreSched struct {
+	// Group is the name of the cookie group. All running containers in the
+	// same group share one cookie. If empty, the container gets its own
+	// cookie.
+	Group string `json:"group,omitempty"`
+}
+
+// DefaultDestroyTimeout is the default value of Config.DestroyTimeout.
+const DefaultDestroyTimeout = 10 * time.Second
+
 // Scheduler is based on the Linux sched_setattr(2) syscall.
 type Scheduler = specs.Scheduler
 
@@ -330,6 +434,12 @@ const (
 	// Poststop commands are executed after the container init process exits.
 	// Poststop commands are called in the Runtime Namespace.
 	Poststop HookName = "poststop"
+
+	// Prestop commands are executed before the stop signal is sent to the
+	// container init process (see libcontainer.Container.Stop). They are
+	// not part of the OCI runtime spec.
+	// Prestop commands are called in the Runtime Namespace.
+	Prestop HookName = "prestop"
 )
 
 // KnownHookNames returns the known hook names.
@@ -405,14 +515,19 @@ func (hooks *Hooks) MarshalJSON() ([]byte, error) {
 		return serializableHooks
 	}
 
-	return json.Marshal(map[string]interface{}{
+	m := map[string]interface{}{
 		"prestart":        serialize((*hooks)[Prestart]),
 		"createRuntime":   serialize((*hooks)[CreateRuntime]),
 		"createContainer": serialize((*hooks)[CreateContainer]),
 		"startContainer":  serialize((*hooks)[StartContainer]),
 		"poststart":       serialize((*hooks)[Poststart]),
 		"poststop":        serialize((*hooks)[Poststop]),
-	})
+	}
+	// Not an OCI hook, so only included when used.
+	if prestop := serialize((*hooks)[Prestop]); prestop != nil {
+		m[string(Prestop)] = prestop
+	}
+	return json.Marshal(m)
 }
 
 // Run executes all hooks for the given hook name.
@@ -18,10 +18,28 @@ const (
 	PerLinux32 = 0x0008
 )
 
+// Personality flags, which are ORed with the domain.
+const (
+	PerUname26          = 0x0020000
+	PerAddrNoRandomize  = 0x0040000
+	PerFdpicFuncptrs    = 0x0080000
+	PerMmapPageZero     = 0x0100000
+	PerAddrCompatLayout = 0x0200000
+	PerReadImpliesExec  = 0x0400000
+	PerAddrLimit32bit   = 0x0800000
+	PerShortInode       = 0x1000000
+	PerWholeSeconds     = 0x2000000
+	PerStickyTimeouts   = 0x4000000
+	PerAddrLimit3gb     = 0x8000000
+)
+
 type LinuxPersonality struct {
 	// Domain for the personality
 	// can only contain values "LINUX" and "LINUX32"
 	Domain int `json:"domain"`
+	// Flags is a bitmask of the personality flags (Per* constants
+	// other than the domains) to set in addition to the domain.
+	Flags int `json:"flags,omitempty"`
 }
 
 // HostUID gets the translated uid for the process on host which could be
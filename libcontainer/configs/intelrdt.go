@@ -13,4 +13,18 @@ type IntelRdt struct {
 	// The unit of memory bandwidth is specified in "percentages" by
 	// default, and in "MBps" if MBA Software Controller is enabled.
 	MemBwSchema string `json:"memBwSchema,omitempty"`
+
+	// The cache pseudo-locked region to set up for the container, if any.
+	PseudoLock *IntelRdtPseudoLock `json:"pseudo_lock,omitempty"`
+}
+
+// IntelRdtPseudoLock describes a cache pseudo-locked region, i.e. a portion
+// of a cache which is filled with the contents of a memory buffer, and then
+// locked so that it is never evicted. The buffer is available to the
+// container through the /dev/pseudo_lock/<name> character device, which can
+// be mmap'ed for cache-hot memory accesses.
+type IntelRdtPseudoLock struct {
+	// The schema of the region, which must refer to a single cache instance.
+	// Format: "L2:<cache_id>=<cbm>" or "L3:<cache_id>=<cbm>"
+	Schema string `json:"schema"`
 }
@@ -4,4 +4,9 @@ const (
 	// EXT_COPYUP is a directive to copy up the contents of a directory when
 	// a tmpfs is mounted over it.
 	EXT_COPYUP = 1 << iota //nolint:golint,revive // ignore "don't use ALL_CAPS" warning
+
+	// EXT_OVERLAY_AUTO is a directive to create the upper and work
+	// directories of an overlay mount under the container state directory,
+	// so they are removed together with the container.
+	EXT_OVERLAY_AUTO //nolint:golint,revive // ignore "don't use ALL_CAPS" warning
 )
@@ -3,6 +3,8 @@ package configs
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -89,6 +91,28 @@ type Namespace struct {
 	Path string        `json:"path"`
 }
 
+// nsPidfdPrefix is the prefix of a namespace path referring to the namespace
+// of a process by its pidfd (see [NsPidfd]).
+const nsPidfdPrefix = "pidfd:"
+
+// NsPidfd returns the file descriptor of a namespace path of the
+// "pidfd:<fd>" form, and whether path is of this form. Such a path refers to
+// the namespace of the process the pidfd is for. Unlike /proc/<pid>/ns/<ns>,
+// it can't end up referring to another process if the pid is recycled. The
+// pidfd is passed to runc using --preserve-fds, so fd is the same in runc
+// and in the container init.
+func NsPidfd(path string) (fd int, ok bool, err error) {
+	s, ok := strings.CutPrefix(path, nsPidfdPrefix)
+	if !ok {
+		return -1, false, nil
+	}
+	fd, err = strconv.Atoi(s)
+	if err != nil || fd < 0 {
+		return -1, true, fmt.Errorf("invalid namespace pidfd %q", path)
+	}
+	return fd, true, nil
+}
+
 func (n *Namespace) GetPath(pid int) string {
 	return fmt.Sprintf("/proc/%d/ns/%s", pid, NsName(n.Type))
 }
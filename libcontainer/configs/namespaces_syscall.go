@@ -2,7 +2,16 @@
 
 package configs
 
-import "golang.org/x/sys/unix"
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
 
 func (n *Namespace) Syscall() int {
 	return namespaceInfo[n.Type]
@@ -43,3 +52,59 @@ func (n Namespaces) IsPrivate(t NamespaceType) bool {
 	// Not found, so implicitly sharing a parent namespace.
 	return false
 }
+
+// OpenNsPath opens the namespace of type t at path, which is either the path
+// of a namespace file, or a pidfd reference (see [NsPidfd]).
+func OpenNsPath(t NamespaceType, path string) (*os.File, error) {
+	fd, ok, err := NsPidfd(path)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return os.Open(path)
+	}
+	pid, err := pidfdPid(fd)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open("/proc/" + strconv.Itoa(pid) + "/ns/" + NsName(t))
+	if err != nil {
+		return nil, err
+	}
+	// The process could have exited, and its pid been reused, before its
+	// namespace was opened. If it is still alive, the namespace is its own.
+	if err := unix.PidfdSendSignal(fd, 0, nil, 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, &os.SyscallError{Syscall: "pidfd_send_signal", Err: err})
+	}
+	return f, nil
+}
+
+// pidfdPid returns the pid of the process the pidfd fd is for.
+func pidfdPid(fd int) (int, error) {
+	f, err := os.Open("/proc/self/fdinfo/" + strconv.Itoa(fd))
+	if err != nil {
+		return -1, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		val, ok := strings.CutPrefix(s.Text(), "Pid:")
+		if !ok {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil {
+			return -1, fmt.Errorf("invalid pid in fdinfo of pidfd %d: %w", fd, err)
+		}
+		if pid <= 0 {
+			// The process has exited (-1), or is not in our pid namespace (0).
+			return -1, fmt.Errorf("pidfd %d: %w", fd, unix.ESRCH)
+		}
+		return pid, nil
+	}
+	if err := s.Err(); err != nil {
+		return -1, err
+	}
+	return -1, errors.New("fd " + strconv.Itoa(fd) + " is not a pidfd")
+}
@@ -0,0 +1,21 @@
+package configs
+
+// NetRate is a network bandwidth limit for the processes of a cgroup, enforced
+// by cgroup-bpf programs (cgroup v2 only). The packets exceeding the limit are
+// dropped, rather than queued, so this is meant to keep a container from
+// hogging the network, not for traffic shaping.
+type NetRate struct {
+	// Egress limits the outgoing traffic.
+	Egress *NetRateLimit `json:"egress,omitempty"`
+	// Ingress limits the incoming traffic.
+	Ingress *NetRateLimit `json:"ingress,omitempty"`
+}
+
+// NetRateLimit is a token bucket.
+type NetRateLimit struct {
+	// Rate is the sustained rate, in bytes per second.
+	Rate uint64 `json:"rate"`
+	// Burst is the number of bytes which can be sent (or received) at once
+	// after an idle period. Defaults to Rate (i.e. one second worth).
+	Burst uint64 `json:"burst,omitempty"`
+}
@@ -43,6 +43,16 @@ type Network struct {
 	// container.
 	HostInterfaceName string `json:"host_interface_name"`
 
+	// HostInterfaceIndex is the host index of an existing interface moved
+	// into the container (type netdev). It is recorded when the container
+	// is created, and used to find the interface once it is returned to
+	// the host.
+	HostInterfaceIndex int `json:"host_interface_index,omitempty"`
+
+	// VirtualFunction holds the SR-IOV virtual function settings to apply,
+	// if the interface moved into the container (type netdev) is a VF.
+	VirtualFunction *VirtualFunction `json:"virtual_function,omitempty"`
+
 	// HairpinMode specifies if hairpin NAT should be enabled on the virtual interface
 	// bridge port in the case of type veth
 	// Note: This is unsupported on some systems.
@@ -50,6 +60,27 @@ type Network struct {
 	HairpinMode bool `json:"hairpin_mode"`
 }
 
+// VirtualFunction defines the settings of an SR-IOV virtual function. They are
+// set through the physical function, before the VF is moved into the container.
+type VirtualFunction struct {
+	// Vlan is the VLAN ID to tag the VF traffic with, 0 disables tagging.
+	Vlan int `json:"vlan,omitempty"`
+
+	// Qos is the 802.1p priority for the VLAN tagged traffic.
+	Qos int `json:"qos,omitempty"`
+
+	// MinTxRate and MaxTxRate are the transmit rate limits, in Mbps.
+	// 0 means no limit.
+	MinTxRate int `json:"min_tx_rate,omitempty"`
+	MaxTxRate int `json:"max_tx_rate,omitempty"`
+
+	// SpoofCheck enables or disables MAC spoof checking, if set.
+	SpoofCheck *bool `json:"spoof_check,omitempty"`
+
+	// Trust enables or disables the VF trusted mode, if set.
+	Trust *bool `json:"trust,omitempty"`
+}
+
 // Route defines a routing table entry.
 //
 // Routes can be specified to create entries in the routing table as the container
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -22,6 +23,7 @@ type check func(config *configs.Config) error
 func Validate(config *configs.Config) error {
 	checks := []check{
 		cgroupsCheck,
+		cpuRtCheck,
 		rootfs,
 		network,
 		uts,
@@ -33,6 +35,9 @@ func Validate(config *configs.Config) error {
 		mountsStrict,
 		scheduler,
 		ioPriority,
+		exeProtection,
+		exclusiveCPUs,
+		auditLog,
 	}
 	for _, c := range checks {
 		if err := c(config); err != nil {
@@ -42,6 +47,8 @@ func Validate(config *configs.Config) error {
 	// Relaxed validation rules for backward compatibility
 	warns := []check{
 		mountsWarn,
+		tmpfsSizeWarn,
+		cpuRtQuotaWarn,
 	}
 	for _, c := range warns {
 		if err := c(config); err != nil {
@@ -103,6 +110,11 @@ func security(config *configs.Config) error {
 }
 
 func namespaces(config *configs.Config) error {
+	for _, ns := range config.Namespaces {
+		if _, _, err := configs.NsPidfd(ns.Path); err != nil {
+			return err
+		}
+	}
 	if config.Namespaces.Contains(configs.NEWUSER) {
 		if _, err := os.Stat("/proc/self/ns/user"); os.IsNotExist(err) {
 			return errors.New("user namespaces aren't enabled in the kernel")
@@ -237,12 +249,39 @@ func sysctl(config *configs.Config) error {
 				return fmt.Errorf("sysctl %q is not allowed as it conflicts with the OCI %q field", s, "hostname")
 			}
 		}
+		if hint, ok := globalSchedSysctl(s); ok {
+			return fmt.Errorf("sysctl %q is not in a separate kernel namespace: scheduler settings are system-wide; %s", s, hint)
+		}
 		return fmt.Errorf("sysctl %q is not in a separate kernel namespace", s)
 	}
 
 	return nil
 }
 
+// schedSysctlHints maps the scheduler-related sysctls, which are all global
+// (there is no scheduler namespace), to the configuration which can be used
+// to get a similar effect for a container.
+var schedSysctlHints = map[string]string{
+	"kernel.sched_rt_runtime_us":          "use linux.resources.cpu.realtimeRuntime to set the container's real-time bandwidth",
+	"kernel.sched_rt_period_us":           "use linux.resources.cpu.realtimePeriod to set the container's real-time bandwidth",
+	"kernel.sched_rr_timeslice_ms":        "use process.scheduler to set the container's scheduling policy",
+	"kernel.sched_autogroup_enabled":      "use process.scheduler.nice to set the container's nice value",
+	"kernel.sched_cfs_bandwidth_slice_us": "use linux.resources.cpu.quota and linux.resources.cpu.period to limit the container's CPU time",
+	"kernel.numa_balancing":               "use linux.resources.cpu.mems to bind the container's memory to NUMA nodes",
+}
+
+// globalSchedSysctl tells whether s is a scheduler-related sysctl, and
+// returns a hint on how to achieve the same for a container.
+func globalSchedSysctl(s string) (string, bool) {
+	if hint, ok := schedSysctlHints[s]; ok {
+		return hint, true
+	}
+	if strings.HasPrefix(s, "kernel.sched_") || strings.HasPrefix(s, "kernel.numa_balancing") {
+		return "it can only be set on the host", true
+	}
+	return "", false
+}
+
 func intelrdtCheck(config *configs.Config) error {
 	if config.IntelRdt != nil {
 		if config.IntelRdt.ClosID == "." || config.IntelRdt.ClosID == ".." || strings.Contains(config.IntelRdt.ClosID, "/") {
@@ -255,6 +294,11 @@ func intelrdtCheck(config *configs.Config) error {
 		if !intelrdt.IsMBAEnabled() && config.IntelRdt.MemBwSchema != "" {
 			return errors.New("intelRdt.memBwSchema is specified in config, but Intel RDT/MBA is not enabled")
 		}
+		if pl := config.IntelRdt.PseudoLock; pl != nil {
+			if err := intelrdt.ValidatePseudoLockSchema(pl.Schema); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -270,11 +314,53 @@ func cgroupsCheck(config *configs.Config) error {
 		return fmt.Errorf("cgroup: either Path or Name and Parent should be used, got %+v", c)
 	}
 
+	if c.Adopt {
+		if c.Systemd {
+			return errors.New("cgroup: adopting an existing cgroup is not supported by the systemd cgroup driver")
+		}
+		if c.Path == "" {
+			return errors.New("cgroup: adopting an existing cgroup requires Path to be set")
+		}
+	}
+
+	if c.RtSubCgroup != "" {
+		if cgroups.IsCgroup2UnifiedMode() {
+			return errors.New("cgroup: a real-time sub-cgroup requires cgroup v1")
+		}
+		if c.SplitSubCgroups {
+			return errors.New("cgroup: a real-time sub-cgroup can't be used with split sub-cgroups")
+		}
+		if n := c.RtSubCgroup; n == "." || n == ".." || strings.Contains(n, "/") {
+			return fmt.Errorf("cgroup: invalid real-time sub-cgroup name %q", n)
+		}
+	} else if len(c.RtSubCgroupCommands) > 0 {
+		return errors.New("cgroup: real-time sub-cgroup commands require a real-time sub-cgroup")
+	}
+
 	r := c.Resources
 	if r == nil {
 		return nil
 	}
 
+	if i := r.CPUIdle; i != nil && *i != 0 && *i != 1 {
+		return fmt.Errorf("cgroup: invalid cpu idle value %d: must be 0 or 1", *i)
+	}
+
+	if n := r.CpuLatencyNice; n != nil && (*n < -20 || *n > 19) {
+		return fmt.Errorf("cgroup: invalid cpu latency nice value %d: must be between -20 and 19", *n)
+	}
+
+	if n := r.NetRate; n != nil {
+		if !cgroups.IsCgroup2UnifiedMode() {
+			return errors.New("cgroup: network rate limits require cgroup v2")
+		}
+		for _, l := range []*configs.NetRateLimit{n.Egress, n.Ingress} {
+			if l != nil && l.Rate == 0 {
+				return errors.New("cgroup: network rate must be greater than 0")
+			}
+		}
+	}
+
 	if !cgroups.IsCgroup2UnifiedMode() && r.Unified != nil {
 		return cgroups.ErrV1NoUnified
 	}
@@ -289,6 +375,22 @@ func cgroupsCheck(config *configs.Config) error {
 	return nil
 }
 
+// exclusiveCPUs validates the number of CPUs to be assigned exclusively to
+// the container, which are selected by runc.
+func exclusiveCPUs(config *configs.Config) error {
+	n := config.ExclusiveCPUs
+	if n == 0 {
+		return nil
+	}
+	if n < 0 {
+		return fmt.Errorf("invalid number of exclusive cpus %d", n)
+	}
+	if config.Cgroups != nil && config.Cgroups.Resources != nil && config.Cgroups.Resources.CpusetCpus != "" {
+		return errors.New("exclusive cpus can't be used together with cpuset cpus")
+	}
+	return nil
+}
+
 func checkBindOptions(m *configs.Mount) error {
 	if !m.IsBind() {
 		return nil
@@ -339,6 +441,223 @@ func checkIDMapMounts(config *configs.Config, m *configs.Mount) error {
 	return nil
 }
 
+func checkOverlayAutoMounts(m *configs.Mount) error {
+	if m.Extensions&configs.EXT_OVERLAY_AUTO == 0 {
+		return nil
+	}
+	if m.Device != "overlay" {
+		return errors.New("overlay-auto is only supported for overlay mounts")
+	}
+	var hasLower bool
+	for _, o := range strings.Split(m.Data, ",") {
+		key, _, _ := strings.Cut(o, "=")
+		switch key {
+		case "lowerdir":
+			hasLower = true
+		case "upperdir", "workdir":
+			return fmt.Errorf("overlay-auto mounts cannot have %s set", key)
+		}
+	}
+	if !hasLower {
+		return errors.New("overlay-auto mounts must have lowerdir set")
+	}
+	return nil
+}
+
+// tmpfsHugeValues are the valid values of the tmpfs huge= option
+// (see "Transparent Hugepage Support" in the kernel documentation).
+var tmpfsHugeValues = map[string]struct{}{
+	"never":       {},
+	"always":      {},
+	"within_size": {},
+	"advise":      {},
+}
+
+// parseTmpfsSize parses the size= and nr_blocks= options of a tmpfs mount.
+// It returns the size either in bytes, or in percents of the RAM (if pct is
+// true), or -1 if neither option is set.
+func parseTmpfsSize(data string) (size int64, pct bool, _ error) {
+	size = -1
+	for _, o := range strings.Split(data, ",") {
+		key, val, _ := strings.Cut(o, "=")
+		switch key {
+		case "size":
+			if n, ok := strings.CutSuffix(val, "%"); ok {
+				p, err := strconv.ParseInt(n, 10, 64)
+				if err != nil || p < 0 {
+					return 0, false, fmt.Errorf("invalid tmpfs size %q", val)
+				}
+				size, pct = p, true
+				continue
+			}
+			n, err := parseMemSize(val)
+			if err != nil {
+				return 0, false, fmt.Errorf("invalid tmpfs size %q: %w", val, err)
+			}
+			size, pct = n, false
+		case "nr_blocks":
+			n, err := parseMemSize(val)
+			if err != nil {
+				return 0, false, fmt.Errorf("invalid tmpfs nr_blocks %q: %w", val, err)
+			}
+			size, pct = n*int64(os.Getpagesize()), false
+		}
+	}
+	return size, pct, nil
+}
+
+// tmpfsSize returns the size of a tmpfs mount, in bytes, as set by the size=
+// or nr_blocks= options in data, or the kernel default (half of the RAM).
+// The value of 0 means unlimited.
+func tmpfsSize(data string) (int64, error) {
+	size, pct, err := parseTmpfsSize(data)
+	if err != nil {
+		return 0, err
+	}
+	if size >= 0 && !pct {
+		return size, nil
+	}
+	if size < 0 {
+		size = 50 // Kernel default.
+	}
+	var si unix.Sysinfo_t
+	if err := unix.Sysinfo(&si); err != nil {
+		return 0, &os.SyscallError{Syscall: "sysinfo", Err: err}
+	}
+	return int64(si.Totalram) * int64(si.Unit) * size / 100, nil
+}
+
+// parseMemSize parses a size with an optional k, m, g, t, p, or e suffix,
+// as done by the kernel's memparse().
+func parseMemSize(val string) (int64, error) {
+	shift := 0
+	if l := len(val); l > 0 {
+		switch val[l-1] {
+		case 'k', 'K':
+			shift = 10
+		case 'm', 'M':
+			shift = 20
+		case 'g', 'G':
+			shift = 30
+		case 't', 'T':
+			shift = 40
+		case 'p', 'P':
+			shift = 50
+		case 'e', 'E':
+			shift = 60
+		}
+		if shift != 0 {
+			val = val[:l-1]
+		}
+	}
+	n, err := strconv.ParseInt(val, 0, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > (1<<63-1)>>shift {
+		return 0, errors.New("out of range")
+	}
+	return n << shift, nil
+}
+
+func checkTmpfsOptions(m *configs.Mount) error {
+	if m.Device != "tmpfs" {
+		return nil
+	}
+	for _, o := range strings.Split(m.Data, ",") {
+		if val, ok := strings.CutPrefix(o, "huge="); ok {
+			if _, ok := tmpfsHugeValues[val]; !ok {
+				return fmt.Errorf("invalid tmpfs huge= value %q", val)
+			}
+		}
+	}
+	_, _, err := parseTmpfsSize(m.Data)
+	return err
+}
+
+// cpuRtCheck validates the real-time bandwidth settings, which the kernel
+// otherwise rejects with a bare EINVAL when writing them to the cgroup.
+func cpuRtCheck(config *configs.Config) error {
+	if config.Cgroups == nil || config.Cgroups.Resources == nil {
+		return nil
+	}
+	r := config.Cgroups.Resources
+	runtime, period := r.CpuRtRuntime, r.CpuRtPeriod
+	if runtime < -1 {
+		return fmt.Errorf("cgroup: invalid cpu rt runtime %d: must be -1 (unlimited) or positive", runtime)
+	}
+	if r.CpuRtPercent < 0 || r.CpuRtPercent > 100 {
+		return fmt.Errorf("cgroup: invalid cpu rt percent %v: must be between 0 and 100", r.CpuRtPercent)
+	}
+	if period == 0 {
+		// The runtime is checked against the current period of the
+		// cgroup when it is set.
+		return nil
+	}
+	if runtime == 0 {
+		return fmt.Errorf("cgroup: cpu rt period %d is set without a cpu rt runtime", period)
+	}
+	if runtime > 0 && uint64(runtime) > period {
+		return fmt.Errorf("cgroup: cpu rt runtime %d can't be larger than the cpu rt period %d", runtime, period)
+	}
+	return nil
+}
+
+// cpuRtQuotaWarn warns when the real-time budget of a CPU is larger than
+// the CFS quota of the whole container. The real-time tasks are not
+// throttled by the CFS quota, so the quota does not limit the CPU time of
+// the container as one might expect.
+func cpuRtQuotaWarn(config *configs.Config) error {
+	if config.Cgroups == nil || config.Cgroups.Resources == nil {
+		return nil
+	}
+	r := config.Cgroups.Resources
+	if r.CpuQuota <= 0 || r.CpuRtRuntime == 0 || r.CpuRtPeriod == 0 {
+		return nil
+	}
+	period := r.CpuPeriod
+	if period == 0 {
+		period = 100000 // The kernel default.
+	}
+	rtShare := 1.0
+	if r.CpuRtRuntime > 0 {
+		rtShare = float64(r.CpuRtRuntime) / float64(r.CpuRtPeriod)
+	}
+	if cfsShare := float64(r.CpuQuota) / float64(period); rtShare > cfsShare {
+		return fmt.Errorf("cpu rt runtime %d/%d exceeds the cpu quota %d/%d; real-time tasks are not limited by the quota",
+			r.CpuRtRuntime, r.CpuRtPeriod, r.CpuQuota, period)
+	}
+	return nil
+}
+
+// tmpfsSizeWarn warns about the tmpfs mounts which can grow larger than the
+// container memory limit, as the tmpfs pages are charged to the container
+// memory cgroup, and filling such a tmpfs leads to an OOM kill rather than
+// ENOSPC.
+func tmpfsSizeWarn(config *configs.Config) error {
+	if config.Cgroups == nil || config.Cgroups.Resources == nil {
+		return nil
+	}
+	limit := config.Cgroups.Resources.Memory
+	if limit <= 0 {
+		return nil
+	}
+	var errs []error
+	for _, m := range config.Mounts {
+		if m.Device != "tmpfs" {
+			continue
+		}
+		size, err := tmpfsSize(m.Data)
+		if err != nil {
+			return err
+		}
+		if size == 0 || size > limit {
+			errs = append(errs, fmt.Errorf("tmpfs mount %s size (%d) exceeds the container memory limit (%d)", m.Destination, size, limit))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func mountsWarn(config *configs.Config) error {
 	for _, m := range config.Mounts {
 		if !filepath.IsAbs(m.Destination) {
@@ -356,6 +675,12 @@ func mountsStrict(config *configs.Config) error {
 		if err := checkIDMapMounts(config, m); err != nil {
 			return fmt.Errorf("invalid mount %+v: %w", m, err)
 		}
+		if err := checkOverlayAutoMounts(m); err != nil {
+			return fmt.Errorf("invalid mount %+v: %w", m, err)
+		}
+		if err := checkTmpfsOptions(m); err != nil {
+			return fmt.Errorf("invalid mount %+v: %w", m, err)
+		}
 	}
 	return nil
 }
@@ -368,7 +693,16 @@ func isHostNetNS(path string) (bool, error) {
 	if err := unix.Stat(currentProcessNetns, &st1); err != nil {
 		return false, &os.PathError{Op: "stat", Path: currentProcessNetns, Err: err}
 	}
-	if err := unix.Stat(path, &st2); err != nil {
+	if _, ok, _ := configs.NsPidfd(path); ok {
+		f, err := configs.OpenNsPath(configs.NEWNET, path)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		if err := unix.Fstat(int(f.Fd()), &st2); err != nil {
+			return false, &os.PathError{Op: "fstat", Path: path, Err: err}
+		}
+	} else if err := unix.Stat(path, &st2); err != nil {
 		return false, &os.PathError{Op: "stat", Path: path, Err: err}
 	}
 
@@ -402,9 +736,37 @@ func ioPriority(config *configs.Config) error {
 	if config.IOPriority == nil {
 		return nil
 	}
+	if _, ok := configs.IOPrioClassMapping[config.IOPriority.Class]; !ok {
+		return fmt.Errorf("invalid ioPriority.Class: %q", config.IOPriority.Class)
+	}
 	priority := config.IOPriority.Priority
 	if priority < 0 || priority > 7 {
 		return fmt.Errorf("invalid ioPriority.Priority: %d", priority)
 	}
 	return nil
 }
+
+func exeProtection(config *configs.Config) error {
+	switch config.ExeProtection {
+	case "", configs.ExeProtectionMemfd, configs.ExeProtectionBindRO, configs.ExeProtectionOff:
+		return nil
+	}
+	return fmt.Errorf("invalid exe protection %q (must be %q, %q, or %q)", config.ExeProtection,
+		configs.ExeProtectionMemfd, configs.ExeProtectionBindRO, configs.ExeProtectionOff)
+}
+
+func auditLog(config *configs.Config) error {
+	if config.AuditLog == "" {
+		return nil
+	}
+	if fd, ok := strings.CutPrefix(config.AuditLog, "fd:"); ok {
+		if n, err := strconv.Atoi(fd); err != nil || n < 0 {
+			return fmt.Errorf("invalid audit log file descriptor %q", fd)
+		}
+		return nil
+	}
+	if !filepath.IsAbs(config.AuditLog) {
+		return fmt.Errorf("audit log %q must be an absolute path or fd:N", config.AuditLog)
+	}
+	return nil
+}
@@ -3,8 +3,11 @@ package validate
 import (
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
@@ -402,6 +405,50 @@ func TestValidateSysctlWithBindHostNetNS(t *testing.T) {
 	}
 }
 
+func TestValidateSysctlWithPidfdHostNetNS(t *testing.T) {
+	fd, err := unix.PidfdOpen(os.Getpid(), 0)
+	if err != nil {
+		t.Skipf("pidfd_open: %v", err)
+	}
+	defer unix.Close(fd)
+
+	config := &configs.Config{
+		Rootfs: "/var",
+		Sysctl: map[string]string{"net.ctl": "ctl"},
+		Namespaces: configs.Namespaces(
+			[]configs.Namespace{
+				{
+					Type: configs.NEWNET,
+					Path: "pidfd:" + strconv.Itoa(fd),
+				},
+			},
+		),
+	}
+
+	err = Validate(config)
+	if err == nil || !strings.Contains(err.Error(), "host network namespace") {
+		t.Errorf("expected host network namespace error, got %v", err)
+	}
+}
+
+func TestValidateNamespacePidfd(t *testing.T) {
+	config := &configs.Config{
+		Rootfs: "/var",
+		Namespaces: configs.Namespaces(
+			[]configs.Namespace{
+				{
+					Type: configs.NEWNET,
+					Path: "pidfd:net",
+				},
+			},
+		),
+	}
+
+	if err := Validate(config); err == nil {
+		t.Error("Expected error to occur but it was nil")
+	}
+}
+
 func TestValidateSysctlWithoutNETNamespace(t *testing.T) {
 	config := &configs.Config{
 		Rootfs:     "/var",
@@ -846,15 +893,20 @@ func TestValidateScheduler(t *testing.T) {
 func TestValidateIOPriority(t *testing.T) {
 	testCases := []struct {
 		isErr    bool
+		class    specs.IOPriorityClass
 		priority int
 	}{
-		{isErr: false, priority: 0},
-		{isErr: false, priority: 7},
-		{isErr: true, priority: -1},
+		{isErr: false, class: specs.IOPRIO_CLASS_BE, priority: 0},
+		{isErr: false, class: specs.IOPRIO_CLASS_BE, priority: 7},
+		{isErr: false, class: specs.IOPRIO_CLASS_IDLE, priority: 0},
+		{isErr: true, class: specs.IOPRIO_CLASS_BE, priority: -1},
+		{isErr: true, class: "IOPRIO_CLASS_FOO", priority: 0},
+		{isErr: true, priority: 0},
 	}
 
 	for _, tc := range testCases {
 		ioPriroty := configs.IOPriority{
+			Class:    tc.class,
 			Priority: tc.priority,
 		}
 		config := &configs.Config{
@@ -864,10 +916,431 @@ func TestValidateIOPriority(t *testing.T) {
 
 		err := Validate(config)
 		if tc.isErr && err == nil {
-			t.Errorf("iopriority: %d, expected error, got nil", tc.priority)
+			t.Errorf("iopriority: %s %d, expected error, got nil", tc.class, tc.priority)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("iopriority: %s %d, expected nil, got error %v", tc.class, tc.priority, err)
+		}
+	}
+}
+
+func TestValidateAdoptCgroup(t *testing.T) {
+	testCases := []struct {
+		isErr  bool
+		cgroup configs.Cgroup
+	}{
+		{isErr: false, cgroup: configs.Cgroup{Adopt: true, Path: "/kubepods/pod1/ctr"}},
+		{isErr: true, cgroup: configs.Cgroup{Adopt: true}},
+		{isErr: true, cgroup: configs.Cgroup{Adopt: true, Systemd: true, Parent: "system.slice", Name: "ctr"}},
+	}
+
+	for _, tc := range testCases {
+		cg := tc.cgroup
+		config := &configs.Config{
+			Rootfs:  "/var",
+			Cgroups: &cg,
+		}
+
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("cgroup: %+v, expected error, got nil", tc.cgroup)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("cgroup: %+v, expected nil, got error %v", tc.cgroup, err)
+		}
+	}
+}
+
+func TestValidateRtSubCgroup(t *testing.T) {
+	if cgroups.IsCgroup2UnifiedMode() {
+		t.Skip("cgroup v1 is not enabled")
+	}
+	testCases := []struct {
+		isErr  bool
+		cgroup configs.Cgroup
+	}{
+		{isErr: false, cgroup: configs.Cgroup{RtSubCgroup: "rt"}},
+		{isErr: false, cgroup: configs.Cgroup{RtSubCgroup: "rt", RtSubCgroupCommands: []string{"rtapp"}}},
+		{isErr: true, cgroup: configs.Cgroup{RtSubCgroup: "rt", SplitSubCgroups: true}},
+		{isErr: true, cgroup: configs.Cgroup{RtSubCgroup: ".."}},
+		{isErr: true, cgroup: configs.Cgroup{RtSubCgroup: "a/b"}},
+		{isErr: true, cgroup: configs.Cgroup{RtSubCgroupCommands: []string{"rtapp"}}},
+	}
+
+	for _, tc := range testCases {
+		cg := tc.cgroup
+		config := &configs.Config{
+			Rootfs:  "/var",
+			Cgroups: &cg,
+		}
+
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("cgroup: %+v, expected error, got nil", tc.cgroup)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("cgroup: %+v, expected nil, got error %v", tc.cgroup, err)
+		}
+	}
+}
+
+func TestValidateCPUIdle(t *testing.T) {
+	for _, tc := range []struct {
+		idle  int64
+		isErr bool
+	}{
+		{idle: 0},
+		{idle: 1},
+		{idle: -1, isErr: true},
+		{idle: 2, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs: "/var",
+			Cgroups: &configs.Cgroup{
+				Resources: &configs.Resources{CPUIdle: &tc.idle},
+			},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("idle %d: expected error, got nil", tc.idle)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("idle %d: expected nil, got error %v", tc.idle, err)
+		}
+	}
+}
+
+func TestValidateNetRate(t *testing.T) {
+	for _, tc := range []struct {
+		rate  *configs.NetRate
+		isErr bool
+	}{
+		{rate: &configs.NetRate{Egress: &configs.NetRateLimit{Rate: 1000}}},
+		{rate: &configs.NetRate{Ingress: &configs.NetRateLimit{Rate: 1000, Burst: 100}}},
+		{rate: &configs.NetRate{Egress: &configs.NetRateLimit{}}, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs: "/var",
+			Cgroups: &configs.Cgroup{
+				Resources: &configs.Resources{NetRate: tc.rate},
+			},
+		}
+		// Network rate limits are not supported on cgroup v1.
+		isErr := tc.isErr || !cgroups.IsCgroup2UnifiedMode()
+		err := Validate(config)
+		if isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.rate)
+		}
+		if !isErr && err != nil {
+			t.Errorf("%+v: expected nil, got error %v", tc.rate, err)
+		}
+	}
+}
+
+func TestValidateCpuLatencyNice(t *testing.T) {
+	for _, tc := range []struct {
+		nice  int64
+		isErr bool
+	}{
+		{nice: -20},
+		{nice: 0},
+		{nice: 19},
+		{nice: -21, isErr: true},
+		{nice: 20, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs: "/var",
+			Cgroups: &configs.Cgroup{
+				Resources: &configs.Resources{CpuLatencyNice: &tc.nice},
+			},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("nice %d: expected error, got nil", tc.nice)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("nice %d: expected nil, got error %v", tc.nice, err)
+		}
+	}
+}
+
+func TestValidateOverlayAutoMounts(t *testing.T) {
+	testCases := []struct {
+		name  string
+		isErr bool
+		mount *configs.Mount
+	}{
+		{
+			name:  "not overlay-auto",
+			mount: &configs.Mount{Device: "overlay", Data: "lowerdir=/a,upperdir=/b,workdir=/c"},
+		},
+		{
+			name:  "overlay-auto",
+			mount: &configs.Mount{Device: "overlay", Data: "lowerdir=/a:/b", Extensions: configs.EXT_OVERLAY_AUTO},
+		},
+		{
+			name:  "not overlay",
+			isErr: true,
+			mount: &configs.Mount{Device: "tmpfs", Extensions: configs.EXT_OVERLAY_AUTO},
+		},
+		{
+			name:  "no lowerdir",
+			isErr: true,
+			mount: &configs.Mount{Device: "overlay", Extensions: configs.EXT_OVERLAY_AUTO},
+		},
+		{
+			name:  "upperdir set",
+			isErr: true,
+			mount: &configs.Mount{Device: "overlay", Data: "lowerdir=/a,upperdir=/b", Extensions: configs.EXT_OVERLAY_AUTO},
+		},
+		{
+			name:  "workdir set",
+			isErr: true,
+			mount: &configs.Mount{Device: "overlay", Data: "workdir=/b,lowerdir=/a", Extensions: configs.EXT_OVERLAY_AUTO},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mount.Destination = "/mnt"
+			config := &configs.Config{
+				Rootfs: "/var",
+				Mounts: []*configs.Mount{tc.mount},
+			}
+			err := mountsStrict(config)
+			if tc.isErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tc.isErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestValidateTmpfsMounts(t *testing.T) {
+	testCases := []struct {
+		isErr bool
+		data  string
+	}{
+		{isErr: false, data: ""},
+		{isErr: false, data: "size=64m,huge=within_size"},
+		{isErr: false, data: "huge=always,size=50%"},
+		{isErr: false, data: "nr_blocks=100"},
+		{isErr: true, data: "huge=yes"},
+		{isErr: true, data: "size=lots"},
+		{isErr: true, data: "size=-1"},
+		{isErr: true, data: "size=x%"},
+		{isErr: true, data: "size=9999999999e"},
+	}
+
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs: "/var",
+			Mounts: []*configs.Mount{
+				{Device: "tmpfs", Destination: "/tmp", Data: tc.data},
+			},
+		}
+		err := mountsStrict(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%q: expected error, got nil", tc.data)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%q: %v", tc.data, err)
+		}
+	}
+}
+
+func TestTmpfsSize(t *testing.T) {
+	var si unix.Sysinfo_t
+	if err := unix.Sysinfo(&si); err != nil {
+		t.Fatal(err)
+	}
+	ram := int64(si.Totalram) * int64(si.Unit)
+
+	for _, tc := range []struct {
+		data string
+		size int64
+	}{
+		{data: "mode=755,size=65536k", size: 64 << 20},
+		{data: "size=1G,size=2g", size: 2 << 30},
+		{data: "size=0", size: 0},
+		{data: "size=4096", size: 4096},
+		{data: "nr_blocks=2", size: 2 * int64(os.Getpagesize())},
+		{data: "size=25%", size: ram * 25 / 100},
+		{data: "", size: ram / 2},
+	} {
+		size, err := tmpfsSize(tc.data)
+		if err != nil {
+			t.Errorf("%q: %v", tc.data, err)
+			continue
+		}
+		if size != tc.size {
+			t.Errorf("%q: expected %d, got %d", tc.data, tc.size, size)
+		}
+	}
+}
+
+func TestTmpfsSizeWarn(t *testing.T) {
+	config := &configs.Config{
+		Cgroups: &configs.Cgroup{
+			Resources: &configs.Resources{Memory: 128 << 20},
+		},
+		Mounts: []*configs.Mount{
+			{Device: "tmpfs", Destination: "/dev", Data: "size=65536k"},
+			{Device: "bind", Destination: "/data"},
+		},
+	}
+	if err := tmpfsSizeWarn(config); err != nil {
+		t.Errorf("unexpected warning: %v", err)
+	}
+
+	config.Mounts = append(config.Mounts, &configs.Mount{Device: "tmpfs", Destination: "/tmp", Data: "size=1g"})
+	if err := tmpfsSizeWarn(config); err == nil {
+		t.Error("expected a warning, got nil")
+	}
+
+	// No memory limit.
+	config.Cgroups.Resources.Memory = 0
+	if err := tmpfsSizeWarn(config); err != nil {
+		t.Errorf("unexpected warning: %v", err)
+	}
+}
+
+func TestValidateSchedSysctl(t *testing.T) {
+	for s, hint := range map[string]string{
+		"kernel.sched_rt_runtime_us":                    "linux.resources.cpu.realtimeRuntime",
+		"kernel/sched_rt_period_us":                     "linux.resources.cpu.realtimePeriod",
+		"kernel.sched_autogroup_enabled":                "process.scheduler.nice",
+		"kernel.sched_schedstats":                       "only be set on the host",
+		"kernel.numa_balancing_promote_rate_limit_MBps": "only be set on the host",
+	} {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Sysctl:     map[string]string{s: "1"},
+			Namespaces: configs.Namespaces{{Type: configs.NEWIPC}, {Type: configs.NEWUTS}},
+		}
+		err := Validate(config)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", s)
+			continue
+		}
+		if !strings.Contains(err.Error(), "scheduler settings are system-wide") || !strings.Contains(err.Error(), hint) {
+			t.Errorf("%s: unexpected error: %v", s, err)
+		}
+	}
+}
+
+func TestValidateExeProtection(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		isErr bool
+	}{
+		{value: ""},
+		{value: configs.ExeProtectionMemfd},
+		{value: configs.ExeProtectionBindRO},
+		{value: configs.ExeProtectionOff},
+		{value: "bind", isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs:        "/var",
+			ExeProtection: tc.value,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%q: expected error, got nil", tc.value)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.value, err)
+		}
+	}
+}
+
+func TestValidateAuditLog(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		isErr bool
+	}{
+		{value: ""},
+		{value: "/var/log/runc-audit.log"},
+		{value: "fd:3"},
+		{value: "audit.log", isErr: true},
+		{value: "fd:three", isErr: true},
+		{value: "fd:-1", isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs:   "/var",
+			AuditLog: tc.value,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%q: expected error, got nil", tc.value)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.value, err)
+		}
+	}
+}
+
+func TestValidateCpuRt(t *testing.T) {
+	for _, tc := range []struct {
+		runtime int64
+		period  uint64
+		isErr   bool
+	}{
+		{},
+		{runtime: 950000},
+		{runtime: -1},
+		{runtime: 500000, period: 1000000},
+		{runtime: 1000000, period: 1000000},
+		{runtime: -1, period: 1000000},
+		{runtime: -2, isErr: true},
+		{period: 1000000, isErr: true},
+		{runtime: 1000001, period: 1000000, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs: "/var",
+			Cgroups: &configs.Cgroup{
+				Resources: &configs.Resources{CpuRtRuntime: tc.runtime, CpuRtPeriod: tc.period},
+			},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("rt %d/%d: expected error, got nil", tc.runtime, tc.period)
 		}
 		if !tc.isErr && err != nil {
-			t.Errorf("iopriority: %d, expected nil, got error %v", tc.priority, err)
+			t.Errorf("rt %d/%d: expected nil, got error %v", tc.runtime, tc.period, err)
 		}
 	}
 }
+
+func TestCpuRtQuotaWarn(t *testing.T) {
+	config := &configs.Config{
+		Cgroups: &configs.Cgroup{
+			Resources: &configs.Resources{
+				CpuQuota:     50000,
+				CpuRtRuntime: 100000,
+				CpuRtPeriod:  1000000,
+			},
+		},
+	}
+	if err := cpuRtQuotaWarn(config); err != nil {
+		t.Errorf("unexpected warning: %v", err)
+	}
+
+	config.Cgroups.Resources.CpuRtRuntime = 600000
+	if err := cpuRtQuotaWarn(config); err == nil {
+		t.Error("expected a warning, got nil")
+	}
+
+	// Unlimited real-time runtime.
+	config.Cgroups.Resources.CpuRtRuntime = -1
+	if err := cpuRtQuotaWarn(config); err == nil {
+		t.Error("expected a warning, got nil")
+	}
+
+	// No cpu quota.
+	config.Cgroups.Resources.CpuQuota = -1
+	if err := cpuRtQuotaWarn(config); err != nil {
+		t.Errorf("unexpected warning: %v", err)
+	}
+}
@@ -56,4 +56,8 @@ type BaseState struct {
 
 	// Config is the container's configuration.
 	Config configs.Config `json:"config"`
+
+	// Annotations are the user defined annotations of the container (from
+	// the OCI spec), for the benefit of the state file consumers.
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
@@ -37,6 +37,7 @@ const stdioFdCount = 3
 type Container struct {
 	id                   string
 	stateDir             string
+	store                stateStore
 	config               *configs.Config
 	cgroupManager        cgroups.Manager
 	intelRdtManager      *intelrdt.Manager
@@ -47,6 +48,9 @@ type Container struct {
 	state                containerState
 	created              time.Time
 	fifo                 *os.File
+	stateLocked          bool
+	cpuPaused            []CgroupFileValue
+	bootstrapTimings     []BootstrapPhase
 }
 
 // State represents a running container's state
@@ -76,6 +80,14 @@ type State struct {
 
 	// Intel RDT "resource control" filesystem path
 	IntelRdtPath string `json:"intel_rdt_path"`
+
+	// CPUPaused holds the CPU settings of a container paused with
+	// [Container.PauseCPU], to be restored on resume.
+	CPUPaused []CgroupFileValue `json:"cpu_paused,omitempty"`
+
+	// BootstrapTimings are the timings of the phases of the container
+	// bootstrap (see [Container.BootstrapTimings]).
+	BootstrapTimings []BootstrapPhase `json:"bootstrap_timings,omitempty"`
 }
 
 // ID returns the container's unique ID
@@ -144,6 +156,11 @@ func (c *Container) Stats() (*Stats, error) {
 	if stats.CgroupStats, err = c.cgroupManager.GetStats(); err != nil {
 		return stats, fmt.Errorf("unable to get container cgroup stats: %w", err)
 	}
+	if sub := execSubCgroup(c.config.Cgroups); sub != "" {
+		if stats.ExecCgroupStats, err = execSubCgroupStats(c.cgroupManager, sub); err != nil {
+			return stats, fmt.Errorf("unable to get exec cgroup stats: %w", err)
+		}
+	}
 	if c.intelRdtManager != nil {
 		if stats.IntelRdtStats, err = c.intelRdtManager.GetStats(); err != nil {
 			return stats, fmt.Errorf("unable to get container Intel RDT stats: %w", err)
@@ -162,11 +179,57 @@ func (c *Container) Stats() (*Stats, error) {
 	return stats, nil
 }
 
+// ResetMemoryPeak resets the peak memory usage of the container, as reported
+// by Stats, to its current usage. With cgroup v2, the reset is only seen by
+// the subsequent Stats of c (see cgroups.Manager.ResetMemoryPeak).
+func (c *Container) ResetMemoryPeak() error {
+	return c.cgroupManager.ResetMemoryPeak()
+}
+
 // Set resources of container as configured. Can be used to change resources
 // when the container is running.
 func (c *Container) Set(config configs.Config) error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	unlock, err := c.lockState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return c.set(config)
+}
+
+// Update is like Set, but only the resources listed in mask (by their JSON
+// names, see [configs.Resources.Merge]) are changed to the values from r, and
+// the rest of the container's configuration is kept as is.
+func (c *Container) Update(r *configs.Resources, mask []string) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	unlock, err := c.lockState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// Start from the latest saved state, which another runc instance
+	// may have changed since the container was loaded.
+	state, err := c.store.load(c.id)
+	if err != nil {
+		return err
+	}
+	config := state.Config
+	cg := *config.Cgroups
+	res := *cg.Resources
+	if err := res.Merge(r, mask); err != nil {
+		return err
+	}
+	// Do not reapply the device rules unless asked to (see runc update).
+	res.SkipDevices = !slices.Contains(mask, "devices")
+	cg.Resources = &res
+	config.Cgroups = &cg
+	return c.set(config)
+}
+
+func (c *Container) set(config configs.Config) error {
 	status, err := c.currentStatus()
 	if err != nil {
 		return err
@@ -174,6 +237,27 @@ func (c *Container) Set(config configs.Config) error {
 	if status == Stopped {
 		return ErrNotRunning
 	}
+	if c.cpuPaused != nil {
+		// The CPU settings would be overwritten on resume.
+		return ErrCPUPaused
+	}
+	audit, err := openAuditLog(c.config, c.id, "update")
+	if err != nil {
+		return err
+	}
+	defer audit.close()
+	if audit != nil {
+		defer cgroups.RecordWrites(audit.recordCgroupWrite)()
+	}
+	rtSub := rtSubCgroup(c.config.Cgroups)
+	if err := lowerSubCgroupRtRuntime(c.cgroupManager, rtSub, config.Cgroups.Resources); err != nil {
+		return err
+	}
+	if c.config.Cgroups.SplitSubCgroups {
+		if err := setSubCgroupCpusets(c.cgroupManager, config.Cgroups.Resources); err != nil {
+			return err
+		}
+	}
 	if err := c.cgroupManager.Set(config.Cgroups.Resources); err != nil {
 		// Set configs back
 		if err2 := c.cgroupManager.Set(c.config.Cgroups.Resources); err2 != nil {
@@ -181,6 +265,10 @@ func (c *Container) Set(config configs.Config) error {
 		}
 		return err
 	}
+	checkEffectiveCpuset(c.cgroupManager, config.Cgroups.Resources)
+	if err := setSubCgroupRtSched(c.cgroupManager, rtSub, config.Cgroups.Resources); err != nil {
+		return err
+	}
 	if c.intelRdtManager != nil {
 		if err := c.intelRdtManager.Set(&config); err != nil {
 			// Set configs back
@@ -195,8 +283,25 @@ func (c *Container) Set(config configs.Config) error {
 	}
 	// After config setting succeed, update config and states
 	c.config = &config
-	_, err = c.updateState(nil)
-	return err
+	if _, err := c.updateState(nil); err != nil {
+		return err
+	}
+	return audit.Err()
+}
+
+// Diff returns the changes of the cgroup files which Set(config) would make,
+// without making them. Only the cgroup resources of config are considered.
+func (c *Container) Diff(config configs.Config) ([]cgroups.FileChange, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	status, err := c.currentStatus()
+	if err != nil {
+		return nil, err
+	}
+	if status == Stopped {
+		return nil, ErrNotRunning
+	}
+	return c.cgroupManager.Diff(config.Cgroups.Resources)
 }
 
 // Start starts a process inside the container. Returns error if process fails
@@ -222,6 +327,39 @@ func (c *Container) Run(process *Process) error {
 	return nil
 }
 
+// Restart runs a new init process (process) in a stopped container which has
+// not been destroyed, e.g. kept with runc run --keep. The container state,
+// including its cgroups and CPU placement, is reused: the container is not
+// admitted again, only the new init process is added to its cgroups.
+func (c *Container) Restart(process *Process) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	status, err := c.currentStatus()
+	if err != nil {
+		return err
+	}
+	if status != Stopped {
+		return ErrNotStopped
+	}
+	if !process.Init {
+		return errors.New("restart requires an init process")
+	}
+	if c.config.Cgroups.SplitSubCgroups && cgroups.IsCgroup2UnifiedMode() {
+		// The container cgroup has the controllers enabled for its
+		// sub-cgroups, so the new init can't join it.
+		return errors.New("restarting a container with split sub-cgroups requires cgroup v1")
+	}
+	// Forget the previous init, and its exec fifo if it was never
+	// started.
+	c.initProcess = nil
+	c.initProcessStartTime = 0
+	c.deleteExecFifo()
+	if err := c.start(process); err != nil {
+		return err
+	}
+	return c.exec()
+}
+
 // Exec signals the container to exec the users process at the end of the init.
 func (c *Container) Exec() error {
 	c.m.Lock()
@@ -230,6 +368,30 @@ func (c *Container) Exec() error {
 }
 
 func (c *Container) exec() error {
+	start := time.Now()
+	if err := c.awaitExec(); err != nil {
+		return err
+	}
+	c.timePhase(PhaseExec, start)
+	if _, err := c.updateState(nil); err != nil {
+		logrus.Warnf("unable to save the bootstrap timings: %v", err)
+	}
+	return nil
+}
+
+// awaitExec releases the container init, waiting on the exec fifo, to
+// execute the container process.
+func (c *Container) awaitExec() error {
+	if c.frozenUntilStart() {
+		// The reservations may have been changed since the container
+		// was created (e.g. by a CPU hotplug).
+		if err := verifyResources(c.cgroupManager, c.config.Cgroups.Resources); err != nil {
+			return err
+		}
+		if err := c.cgroupManager.Freeze(configs.Thawed); err != nil {
+			return fmt.Errorf("unable to thaw the container cgroup: %w", err)
+		}
+	}
 	path := filepath.Join(c.stateDir, execFifoFilename)
 	pid := c.initProcess.pid()
 	blockingFifoOpenCh := awaitFifoOpen(path)
@@ -317,6 +479,24 @@ func (c *Container) start(process *Process) (retErr error) {
 				c.deleteExecFifo()
 			}
 		}()
+	} else if err := checkExecPolicy(c.config, process); err != nil {
+		return err
+	}
+	if err := process.loadEnv(); err != nil {
+		return err
+	}
+
+	op := "exec"
+	if process.Init {
+		op = "create"
+	}
+	audit, err := openAuditLog(c.config, c.id, op)
+	if err != nil {
+		return err
+	}
+	defer audit.close()
+	if audit != nil && process.Init {
+		defer cgroups.RecordWrites(audit.recordCgroupWrite)()
 	}
 
 	parent, err := c.newParentProcess(process)
@@ -329,6 +509,10 @@ func (c *Container) start(process *Process) (retErr error) {
 	logsDone := parent.forwardChildLogs()
 	if logsDone != nil {
 		defer func() {
+			if logsDone == nil {
+				// Already waited for.
+				return
+			}
 			// Wait for log forwarder to finish. This depends on
 			// runc init closing the _LIBCONTAINER_LOGPIPE log fd.
 			err := <-logsDone
@@ -350,6 +534,34 @@ func (c *Container) start(process *Process) (retErr error) {
 	if err := parent.start(); err != nil {
 		return fmt.Errorf("unable to start container process: %w", err)
 	}
+	audit.recordProcess(c.config, process, parent.pid())
+	if err := audit.Err(); err != nil {
+		if err := ignoreTerminateErrors(parent.terminate()); err != nil {
+			logrus.Warn(fmt.Errorf("error writing the audit log: %w", err))
+		}
+		return err
+	}
+
+	if process.Init && c.config.StartFrozen {
+		// Runc init closes the log fd right before it waits on the
+		// exec fifo for the container to be started, which is where
+		// it is to be kept.
+		if logsDone != nil {
+			err := <-logsDone
+			logsDone = nil
+			if err != nil {
+				return fmt.Errorf("unable to forward init logs: %w", err)
+			}
+		}
+		if err := freezeVerified(c.cgroupManager, c.config.Cgroups.Resources); err != nil {
+			// Thaw init, which can't be killed while frozen on cgroup v1.
+			_ = c.cgroupManager.Freeze(configs.Thawed)
+			if err := ignoreTerminateErrors(parent.terminate()); err != nil {
+				logrus.Warn(fmt.Errorf("error starting frozen: %w", err))
+			}
+			return fmt.Errorf("unable to start frozen: %w", err)
+		}
+	}
 
 	if process.Init {
 		c.fifo.Close()
@@ -370,15 +582,31 @@ func (c *Container) start(process *Process) (retErr error) {
 	return nil
 }
 
-// Signal sends a specified signal to container's init.
+// Signal sends a specified signal to container's init, or, if all is true,
+// to all the container's processes.
+//
+// When all is true, the container's cgroup is frozen while the signal is being
+// sent, so that every process receives the signal exactly once, including
+// the ones forked during signal delivery.
 //
 // When s is SIGKILL and the container does not have its own PID namespace, all
 // the container's processes are killed. In this scenario, the libcontainer
 // user may be required to implement a proper child reaper.
-func (c *Container) Signal(s os.Signal) error {
+func (c *Container) Signal(s os.Signal, all bool) error {
 	c.m.Lock()
 	defer c.m.Unlock()
 
+	if all {
+		sig, ok := s.(unix.Signal)
+		if !ok {
+			return errors.New("os: unsupported signal type")
+		}
+		if err := signalAllProcesses(c.cgroupManager, sig); err != nil {
+			return fmt.Errorf("unable to signal all processes: %w", err)
+		}
+		return nil
+	}
+
 	// When a container has its own PID namespace, inside it the init PID
 	// is 1, and thus it is handled specially by the kernel. In particular,
 	// killing init with SIGKILL from an ancestor namespace will also kill
@@ -402,6 +630,41 @@ func (c *Container) Signal(s os.Signal) error {
 	return c.signal(s)
 }
 
+// StopSignal returns the signal used to stop the container (see
+// configs.Config.StopSignal).
+func (c *Container) StopSignal() unix.Signal {
+	if c.config.StopSignal != 0 {
+		return unix.Signal(c.config.StopSignal)
+	}
+	return unix.SIGTERM
+}
+
+// Stop runs the prestop hooks of the container, then sends it its stop signal
+// (see [Container.StopSignal]), as [Container.Signal] does. A failure of the
+// hooks is only logged, so that they can not prevent the container from
+// being stopped.
+func (c *Container) Stop(all bool) error {
+	if err := c.runPrestopHooks(); err != nil {
+		logrus.Warnf("prestop hook: %v", err)
+	}
+	return c.Signal(c.StopSignal(), all)
+}
+
+// runPrestopHooks runs the prestop hooks, unless the container init is gone.
+func (c *Container) runPrestopHooks() error {
+	c.m.Lock()
+	if len(c.config.Hooks[configs.Prestop]) == 0 || !c.hasInit() {
+		c.m.Unlock()
+		return nil
+	}
+	s, err := c.currentOCIState()
+	c.m.Unlock()
+	if err != nil {
+		return err
+	}
+	return c.config.Hooks.Run(configs.Prestop, s)
+}
+
 func (c *Container) signal(s os.Signal) error {
 	// To avoid a PID reuse attack, don't kill non-running container.
 	if !c.hasInit() {
@@ -551,16 +814,32 @@ func (c *Container) newParentProcess(p *Process) (parentProcess, error) {
 			err = dmz.ErrNoDmzBinary
 		}
 		if errors.Is(err, dmz.ErrNoDmzBinary) {
-			safeExe, err = dmz.CloneSelfExe(c.stateDir)
-			if err != nil {
-				return nil, fmt.Errorf("unable to create safe /proc/self/exe clone for runc init: %w", err)
+			switch c.config.ExeProtection {
+			case configs.ExeProtectionOff:
+				logrus.Warn("runc binary protection is disabled, the container may be able to overwrite it")
+				exePath = "/proc/self/exe"
+			case configs.ExeProtectionBindRO:
+				safeExe, err = dmz.ReadOnlySelfExe()
+				if err != nil {
+					logrus.Debugf("unable to use read-only bind mount of runc binary, falling back to /proc/self/exe clone: %v", err)
+				} else {
+					logrus.Debug("runc-dmz: using read-only bind mount of /proc/self/exe") // used for tests
+				}
+			}
+			if safeExe == nil && c.config.ExeProtection != configs.ExeProtectionOff {
+				safeExe, err = dmz.CloneSelfExe(c.stateDir)
+				if err != nil {
+					return nil, fmt.Errorf("unable to create safe /proc/self/exe clone for runc init: %w", err)
+				}
+				logrus.Debug("runc-dmz: using /proc/self/exe clone") // used for tests
+			}
+			if safeExe != nil {
+				exePath = "/proc/self/fd/" + strconv.Itoa(int(safeExe.Fd()))
+				p.clonedExes = append(p.clonedExes, safeExe)
 			}
-			exePath = "/proc/self/fd/" + strconv.Itoa(int(safeExe.Fd()))
-			p.clonedExes = append(p.clonedExes, safeExe)
-			logrus.Debug("runc-dmz: using /proc/self/exe clone") // used for tests
 		}
 		// Just to make sure we don't run without protection.
-		if dmzExe == nil && safeExe == nil {
+		if dmzExe == nil && safeExe == nil && c.config.ExeProtection != configs.ExeProtectionOff {
 			// This should never happen.
 			return nil, fmt.Errorf("[internal error] attempted to spawn a container with no /proc/self/exe protection")
 		}
@@ -651,9 +930,15 @@ func (c *Container) newInitProcess(p *Process, cmd *exec.Cmd, comm *processComm)
 	cmd.Env = append(cmd.Env, "_LIBCONTAINER_INITTYPE="+string(initStandard))
 	nsMaps := make(map[configs.NamespaceType]string)
 	for _, ns := range c.config.Namespaces {
-		if ns.Path != "" {
-			nsMaps[ns.Type] = ns.Path
+		if ns.Path == "" {
+			continue
+		}
+		// A pidfd is used by the container init as is, so it must be one
+		// of the extra files (starting at fd 3).
+		if fd, ok, _ := configs.NsPidfd(ns.Path); ok && (fd < 3 || fd >= 3+len(p.ExtraFiles)) {
+			return nil, fmt.Errorf("namespace %s: fd %d is not passed to the container", ns.Path, fd)
 		}
+		nsMaps[ns.Type] = ns.Path
 	}
 	data, err := c.bootstrapData(c.config.Namespaces.CloneFlags(), nsMaps)
 	if err != nil {
@@ -695,8 +980,25 @@ func (c *Container) newSetnsProcess(p *Process, cmd *exec.Cmd, comm *processComm
 		bootstrapData:   data,
 		initProcessPid:  state.InitProcessPid,
 	}
-	if len(p.SubCgroupPaths) > 0 {
-		if add, ok := p.SubCgroupPaths[""]; ok {
+	subCgroupPaths := p.SubCgroupPaths
+	if sub := c.config.Cgroups.RtSubCgroup; p.RtSubCgroup {
+		if sub == "" {
+			return nil, errors.New("container has no real-time sub-cgroup")
+		}
+		if len(subCgroupPaths) > 0 {
+			return nil, errors.New("a process can't be run in both the real-time sub-cgroup and sub-cgroup paths")
+		}
+		subCgroupPaths = rtSubCgroupPaths(proc.cgroupPaths, sub)
+	} else if len(subCgroupPaths) == 0 && sub != "" && isRtCommand(c.config.Cgroups, p.Args) {
+		subCgroupPaths = rtSubCgroupPaths(proc.cgroupPaths, sub)
+	}
+	if len(subCgroupPaths) == 0 && c.config.Cgroups.SplitSubCgroups {
+		subCgroupPaths = runtimeSubCgroupPaths(proc.cgroupPaths)
+	} else if sub := execSubCgroup(c.config.Cgroups); len(subCgroupPaths) == 0 && sub != "" {
+		subCgroupPaths = execSubCgroupPaths(proc.cgroupPaths, sub)
+	}
+	if len(subCgroupPaths) > 0 {
+		if add, ok := subCgroupPaths[""]; ok {
 			// cgroup v1: using the same path for all controllers.
 			// cgroup v2: the only possible way.
 			for k := range proc.cgroupPaths {
@@ -711,7 +1013,7 @@ func (c *Container) newSetnsProcess(p *Process, cmd *exec.Cmd, comm *processComm
 			proc.initProcessPid = 0
 		} else {
 			// Per-controller paths.
-			for ctrl, add := range p.SubCgroupPaths {
+			for ctrl, add := range subCgroupPaths {
 				if val, ok := proc.cgroupPaths[ctrl]; ok {
 					subPath := path.Join(val, add)
 					if !strings.HasPrefix(subPath, val) {
@@ -731,7 +1033,7 @@ func (c *Container) newInitConfig(process *Process) *initConfig {
 	cfg := &initConfig{
 		Config:           c.config,
 		Args:             process.Args,
-		Env:              process.Env,
+		Env:              process.environ(),
 		User:             process.User,
 		AdditionalGroups: process.AdditionalGroups,
 		Cwd:              process.Cwd,
@@ -747,6 +1049,8 @@ func (c *Container) newInitConfig(process *Process) *initConfig {
 		CreateConsole:    process.ConsoleSocket != nil,
 		ConsoleWidth:     process.ConsoleWidth,
 		ConsoleHeight:    process.ConsoleHeight,
+		TimerSlack:       c.config.TimerSlack,
+		IOPriority:       c.config.IOPriority,
 	}
 	if process.NoNewPrivileges != nil {
 		cfg.NoNewPrivileges = *process.NoNewPrivileges
@@ -760,8 +1064,14 @@ func (c *Container) newInitConfig(process *Process) *initConfig {
 	if len(process.Rlimits) > 0 {
 		cfg.Rlimits = process.Rlimits
 	}
+	if process.TimerSlack != 0 {
+		cfg.TimerSlack = process.TimerSlack
+	}
+	if process.IOPriority != nil {
+		cfg.IOPriority = process.IOPriority
+	}
 	if cgroups.IsCgroup2UnifiedMode() {
-		cfg.Cgroup2Path = c.cgroupManager.Path("")
+		cfg.Cgroup2Path = c.cgroupManager.ControllerPath("")
 	}
 
 	return cfg
@@ -788,6 +1098,12 @@ func (c *Container) Destroy() error {
 func (c *Container) Pause() error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	// Serialize with PoliceForks, which briefly freezes the container.
+	unlock, err := c.lockState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	status, err := c.currentStatus()
 	if err != nil {
 		return err
@@ -806,15 +1122,33 @@ func (c *Container) Pause() error {
 
 // Resume resumes the execution of any user processes in the
 // container before setting the container state to RUNNING.
-// This is only performed if the current state is PAUSED.
-// If the Container state is RUNNING, does nothing.
+// This is only performed if the current state is PAUSED, or if the
+// container was paused with [Container.PauseCPU], in which case its
+// CPU settings are restored.
 func (c *Container) Resume() error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	unlock, err := c.lockState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	status, err := c.currentStatus()
 	if err != nil {
 		return err
 	}
+	if c.cpuPaused != nil {
+		if err := restoreCgroupFiles(c.cpuPaused); err != nil {
+			return err
+		}
+		c.cpuPaused = nil
+		if _, err := c.updateState(nil); err != nil {
+			return err
+		}
+		if status != Paused {
+			return nil
+		}
+	}
 	if status != Paused {
 		return ErrNotPaused
 	}
@@ -833,7 +1167,7 @@ func (c *Container) NotifyOOM() (<-chan struct{}, error) {
 	if c.config.RootlessCgroups {
 		logrus.Warn("getting OOM notifications may fail if you don't have the full access to cgroups")
 	}
-	path := c.cgroupManager.Path("memory")
+	path := c.cgroupManager.ControllerPath("memory")
 	if cgroups.IsCgroup2UnifiedMode() {
 		return notifyOnOOMV2(path)
 	}
@@ -847,7 +1181,7 @@ func (c *Container) NotifyMemoryPressure(level PressureLevel) (<-chan struct{},
 	if c.config.RootlessCgroups {
 		logrus.Warn("getting memory pressure notifications may fail if you don't have the full access to cgroups")
 	}
-	return notifyMemoryPressure(c.cgroupManager.Path("memory"), level)
+	return notifyMemoryPressure(c.cgroupManager.ControllerPath("memory"), level)
 }
 
 func (c *Container) updateState(process parentProcess) (*State, error) {
@@ -861,30 +1195,13 @@ func (c *Container) updateState(process parentProcess) (*State, error) {
 	return state, nil
 }
 
-func (c *Container) saveState(s *State) (retErr error) {
-	tmpFile, err := os.CreateTemp(c.stateDir, "state-")
+func (c *Container) saveState(s *State) error {
+	unlock, err := c.lockState()
 	if err != nil {
 		return err
 	}
-
-	defer func() {
-		if retErr != nil {
-			tmpFile.Close()
-			os.Remove(tmpFile.Name())
-		}
-	}()
-
-	err = utils.WriteJSON(tmpFile, s)
-	if err != nil {
-		return err
-	}
-	err = tmpFile.Close()
-	if err != nil {
-		return err
-	}
-
-	stateFilePath := filepath.Join(c.stateDir, stateFilename)
-	return os.Rename(tmpFile.Name(), stateFilePath)
+	defer unlock()
+	return c.store.save(c.id, s)
 }
 
 func (c *Container) currentStatus() (Status, error) {
@@ -903,7 +1220,7 @@ func (c *Container) refreshState() error {
 	if err != nil {
 		return err
 	}
-	if paused {
+	if paused && !c.frozenUntilStart() {
 		return c.state.transition(&pausedState{c: c})
 	}
 	if !c.hasInit() {
@@ -957,6 +1274,7 @@ func (c *Container) currentState() *State {
 	if c.intelRdtManager != nil {
 		intelRdtPath = c.intelRdtManager.GetPath()
 	}
+	_, annotations := utils.Annotations(c.config.Labels)
 	state := &State{
 		BaseState: BaseState{
 			ID:                   c.ID(),
@@ -964,12 +1282,15 @@ func (c *Container) currentState() *State {
 			InitProcessPid:       pid,
 			InitProcessStartTime: startTime,
 			Created:              c.created,
+			Annotations:          annotations,
 		},
 		Rootless:            c.config.RootlessEUID && c.config.RootlessCgroups,
 		CgroupPaths:         c.cgroupManager.GetPaths(),
 		IntelRdtPath:        intelRdtPath,
 		NamespacePaths:      make(map[configs.NamespaceType]string),
 		ExternalDescriptors: externalDescriptors,
+		CPUPaused:           c.cpuPaused,
+		BootstrapTimings:    c.bootstrapTimings,
 	}
 	if pid > 0 {
 		for _, ns := range c.config.Namespaces {
@@ -1026,7 +1347,13 @@ func (c *Container) orderNamespacePaths(namespaces map[configs.NamespaceType]str
 				return nil, fmt.Errorf("namespace %s is not supported", ns)
 			}
 			// only set to join this namespace if it exists
-			if _, err := os.Lstat(p); err != nil {
+			if _, ok, _ := configs.NsPidfd(p); ok {
+				f, err := configs.OpenNsPath(ns, p)
+				if err != nil {
+					return nil, fmt.Errorf("namespace pidfd: %w", err)
+				}
+				f.Close()
+			} else if _, err := os.Lstat(p); err != nil {
 				return nil, fmt.Errorf("namespace path: %w", err)
 			}
 			// do not allow namespace path with comma as we use it to separate
@@ -3,6 +3,7 @@ package libcontainer
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
@@ -36,6 +37,10 @@ func (m *mockCgroupManager) Set(_ *configs.Resources) error {
 	return nil
 }
 
+func (m *mockCgroupManager) Diff(_ *configs.Resources) ([]cgroups.FileChange, error) {
+	return nil, nil
+}
+
 func (m *mockCgroupManager) Destroy() error {
 	return nil
 }
@@ -49,6 +54,22 @@ func (m *mockCgroupManager) OOMKillCount() (uint64, error) {
 	return 0, nil
 }
 
+func (m *mockCgroupManager) Kill() error {
+	return cgroups.ErrKillUnsupported
+}
+
+func (m *mockCgroupManager) GetEffectiveCpus() (string, error) {
+	return "", &cgroups.ControllerUnavailableError{Controller: "cpuset"}
+}
+
+func (m *mockCgroupManager) GetEffectiveMems() (string, error) {
+	return "", &cgroups.ControllerUnavailableError{Controller: "cpuset"}
+}
+
+func (m *mockCgroupManager) ResetMemoryPeak() error {
+	return nil
+}
+
 func (m *mockCgroupManager) GetPaths() map[string]string {
 	return m.paths
 }
@@ -57,6 +78,10 @@ func (m *mockCgroupManager) Path(subsys string) string {
 	return m.paths[subsys]
 }
 
+func (m *mockCgroupManager) ControllerPath(name string) string {
+	return m.paths[name]
+}
+
 func (m *mockCgroupManager) Freeze(state configs.FreezerState) error {
 	return nil
 }
@@ -232,8 +257,14 @@ func TestGetContainerStateAfterUpdate(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	root := t.TempDir()
+	stateDir := filepath.Join(root, "myid")
+	if err := os.Mkdir(stateDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
 	container := &Container{
-		stateDir: t.TempDir(),
+		stateDir: stateDir,
+		store:    &fileStateStore{root: root},
 		id:       "myid",
 		config: &configs.Config{
 			Namespaces: []configs.Namespace{
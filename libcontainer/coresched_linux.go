@@ -0,0 +1,142 @@
+package libcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+// coreSchedFile is the file, relative to the runc root directory, which
+// holds the members of the core scheduling cookie groups.
+const coreSchedFile = "core-sched.json"
+
+// coreSchedMember is a process holding the cookie of a core scheduling
+// group, i.e. the init process of a container in the group.
+type coreSchedMember struct {
+	Pid       int    `json:"pid"`
+	StartTime uint64 `json:"start_time"`
+}
+
+// alive tells whether the member process is still running.
+func (m coreSchedMember) alive() bool {
+	stat, err := system.Stat(m.Pid)
+	return err == nil && stat.StartTime == m.StartTime && stat.State != system.Zombie
+}
+
+// coreSchedCreate creates a new core scheduling cookie for all threads of
+// the process with the given pid.
+func coreSchedCreate(pid int) error {
+	err := unix.Prctl(unix.PR_SCHED_CORE, unix.PR_SCHED_CORE_CREATE, uintptr(pid), unix.PR_SCHED_CORE_SCOPE_THREAD_GROUP, 0)
+	if err != nil {
+		return &os.SyscallError{Syscall: "prctl(PR_SCHED_CORE_CREATE)", Err: err}
+	}
+	return nil
+}
+
+// coreSchedShare makes all threads of the process with pid "to" use the
+// core scheduling cookie of the process with pid "from".
+func coreSchedShare(from, to int) error {
+	errCh := make(chan error, 1)
+	go func() {
+		// The cookie of the current thread is changed. Keep the thread
+		// locked, so it is terminated rather than reused once we are done.
+		runtime.LockOSThread()
+		err := unix.Prctl(unix.PR_SCHED_CORE, unix.PR_SCHED_CORE_SHARE_FROM, uintptr(from), unix.PR_SCHED_CORE_SCOPE_THREAD, 0)
+		if err != nil {
+			errCh <- &os.SyscallError{Syscall: "prctl(PR_SCHED_CORE_SHARE_FROM)", Err: err}
+			return
+		}
+		err = unix.Prctl(unix.PR_SCHED_CORE, unix.PR_SCHED_CORE_SHARE_TO, uintptr(to), unix.PR_SCHED_CORE_SCOPE_THREAD_GROUP, 0)
+		if err != nil {
+			errCh <- &os.SyscallError{Syscall: "prctl(PR_SCHED_CORE_SHARE_TO)", Err: err}
+			return
+		}
+		errCh <- nil
+	}()
+	return <-errCh
+}
+
+// setupCoreSched assigns a core scheduling cookie to the container init
+// process with the given pid. If the cookie group is set, and some other
+// container in the group is running, its cookie is used. Otherwise, a new
+// cookie is created.
+//
+// The members of the groups are recorded in a file under the root directory.
+func setupCoreSched(root, id string, cs *configs.CoreSched, pid int) error {
+	if cs.Group == "" {
+		return coreSchedCreate(pid)
+	}
+	f, err := os.OpenFile(filepath.Join(root, coreSchedFile), os.O_RDWR|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		return &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+	groups := map[string]map[string]coreSchedMember{}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &groups); err != nil {
+			return fmt.Errorf("invalid %s: %w", f.Name(), err)
+		}
+	}
+	// Forget about the containers which are gone.
+	for name, members := range groups {
+		for cid, m := range members {
+			if cid == id || !m.alive() {
+				delete(members, cid)
+			}
+		}
+		if len(members) == 0 {
+			delete(groups, name)
+		}
+	}
+
+	members := groups[cs.Group]
+	if members == nil {
+		members = map[string]coreSchedMember{}
+		groups[cs.Group] = members
+	}
+	shared := false
+	for cid, m := range members {
+		if err := coreSchedShare(m.Pid, pid); err != nil {
+			// The process may have exited in the meantime.
+			logrus.Debugf("core sched group %q: unable to share cookie of container %s: %v", cs.Group, cid, err)
+			continue
+		}
+		shared = true
+		break
+	}
+	if !shared {
+		if err := coreSchedCreate(pid); err != nil {
+			return err
+		}
+	}
+	stat, err := system.Stat(pid)
+	if err != nil {
+		return err
+	}
+	members[id] = coreSchedMember{Pid: pid, StartTime: stat.StartTime}
+
+	if data, err = json.Marshal(groups); err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(data, 0)
+	return err
+}
@@ -0,0 +1,76 @@
+package libcontainer
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func coreSchedCookie(t *testing.T, pid int) uint64 {
+	t.Helper()
+	var cookie uint64
+	err := unix.Prctl(unix.PR_SCHED_CORE, unix.PR_SCHED_CORE_GET, uintptr(pid), unix.PR_SCHED_CORE_SCOPE_THREAD, uintptr(unsafe.Pointer(&cookie)))
+	if err != nil {
+		t.Fatalf("PR_SCHED_CORE_GET: %v", err)
+	}
+	return cookie
+}
+
+func startSleep(t *testing.T) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command("sleep", "1m")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return cmd
+}
+
+func TestSetupCoreSched(t *testing.T) {
+	var cookie uint64
+	err := unix.Prctl(unix.PR_SCHED_CORE, unix.PR_SCHED_CORE_GET, 0, unix.PR_SCHED_CORE_SCOPE_THREAD, uintptr(unsafe.Pointer(&cookie)))
+	if errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENODEV) {
+		t.Skipf("core scheduling not supported: %v", err)
+	}
+	root := t.TempDir()
+	group := &configs.CoreSched{Group: "test"}
+
+	a, b, c := startSleep(t), startSleep(t), startSleep(t)
+	if err := setupCoreSched(root, "a", group, a.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	if err := setupCoreSched(root, "b", group, b.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	if err := setupCoreSched(root, "c", &configs.CoreSched{}, c.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	ca, cb, cc := coreSchedCookie(t, a.Process.Pid), coreSchedCookie(t, b.Process.Pid), coreSchedCookie(t, c.Process.Pid)
+	if ca == 0 || ca != cb {
+		t.Errorf("expected containers in a group to share a cookie, got %#x and %#x", ca, cb)
+	}
+	if cc == 0 || cc == ca {
+		t.Errorf("expected a container without a group to have its own cookie, got %#x", cc)
+	}
+
+	// Once all the group members are gone, a new cookie is created.
+	for _, cmd := range []*exec.Cmd{a, b} {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+	d := startSleep(t)
+	if err := setupCoreSched(root, "d", group, d.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	if cd := coreSchedCookie(t, d.Process.Pid); cd == 0 || cd == ca {
+		t.Errorf("expected a new cookie, got %#x", cd)
+	}
+}
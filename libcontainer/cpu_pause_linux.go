@@ -0,0 +1,126 @@
+package libcontainer
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+// The CFS bandwidth of a CPU-paused container: the minimum quota the kernel
+// accepts over the maximum period, that is 0.1% of a CPU.
+const (
+	cpuPauseQuota  = 1000
+	cpuPausePeriod = 1000000
+)
+
+// CgroupFileValue is the value of a cgroup file, saved to be restored later.
+type CgroupFileValue struct {
+	Dir   string `json:"dir"`
+	File  string `json:"file"`
+	Value string `json:"value"`
+}
+
+// PauseCPU is a soft pause: rather than freezing the container processes, it
+// lowers the CPU bandwidth of the container to the minimum, so that they are
+// barely scheduled but can still handle signals and be probed. If rt is set,
+// the real-time runtime of the container is also set to 0 (cgroup v1 only),
+// which is useful to free its real-time budget while others are rebalanced.
+//
+// The CPU settings are restored by [Container.Resume].
+func (c *Container) PauseCPU(rt bool) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	status, err := c.currentStatus()
+	if err != nil {
+		return err
+	}
+	switch status {
+	case Running, Created:
+	case Paused:
+		return ErrPaused
+	default:
+		return ErrNotRunning
+	}
+	if c.cpuPaused != nil {
+		return ErrCPUPaused
+	}
+	saved, err := pauseCPU(c.cgroupManager, rtSubCgroup(c.config.Cgroups), rt)
+	if err != nil {
+		if err2 := restoreCgroupFiles(saved); err2 != nil {
+			logrus.Warnf("unable to restore the container CPU settings: %v", err2)
+		}
+		return err
+	}
+	c.cpuPaused = saved
+	_, err = c.updateState(nil)
+	return err
+}
+
+// pauseCPU sets the CPU bandwidth of the cgroup of m to the minimum, and
+// returns the previous values of the files it changed, in the order they
+// were written.
+func pauseCPU(m cgroups.Manager, rtSub string, rt bool) ([]CgroupFileValue, error) {
+	var saved []CgroupFileValue
+	write := func(dir, file, val string) error {
+		old, err := cgroups.ReadFile(dir, file)
+		if err != nil {
+			return err
+		}
+		if err := cgroups.WriteFile(dir, file, val); err != nil {
+			return err
+		}
+		saved = append(saved, CgroupFileValue{Dir: dir, File: file, Value: strings.TrimSpace(old)})
+		return nil
+	}
+
+	dir := m.ControllerPath("cpu")
+	if dir == "" {
+		return nil, &cgroups.ControllerUnavailableError{Controller: "cpu"}
+	}
+	if cgroups.IsCgroup2UnifiedMode() {
+		if rt {
+			return nil, errors.New("pausing the real-time runtime requires cgroup v1")
+		}
+		return saved, write(dir, "cpu.max", strconv.Itoa(cpuPauseQuota)+" "+strconv.Itoa(cpuPausePeriod))
+	}
+
+	// Lower the quota first, so that the quota to period ratio only ever
+	// decreases (it can't be higher than that of the parent cgroup).
+	if err := write(dir, "cpu.cfs_quota_us", strconv.Itoa(cpuPauseQuota)); err != nil {
+		return saved, err
+	}
+	if err := write(dir, "cpu.cfs_period_us", strconv.Itoa(cpuPausePeriod)); err != nil {
+		return saved, err
+	}
+	if rt {
+		// The runtime of a cgroup v1 can't be lower than that of its
+		// children, so the real-time sub-cgroup goes first.
+		if rtSub != "" {
+			if err := write(filepath.Join(dir, rtSub), "cpu.rt_runtime_us", "0"); err != nil {
+				return saved, err
+			}
+		}
+		if err := write(dir, "cpu.rt_runtime_us", "0"); err != nil {
+			return saved, err
+		}
+	}
+	return saved, nil
+}
+
+// restoreCgroupFiles writes back the values saved by pauseCPU, in the
+// reverse order.
+func restoreCgroupFiles(saved []CgroupFileValue) error {
+	var errs []error
+	for i := len(saved) - 1; i >= 0; i-- {
+		f := saved[i]
+		if err := cgroups.WriteFile(f.Dir, f.File, f.Value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
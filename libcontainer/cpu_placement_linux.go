@@ -0,0 +1,191 @@
+package libcontainer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/topology"
+)
+
+// cpuPlacementFile is the file, relative to the runc root directory, which
+// holds the CPUs exclusively assigned to containers (see
+// configs.Config.ExclusiveCPUs).
+const cpuPlacementFile = "cpu-placement.json"
+
+// cpuAllocation is a CPU exclusively assigned to a container.
+type cpuAllocation struct {
+	Container string `json:"container"`
+	// RtRuntime is the real-time runtime of the container on the CPU.
+	RtRuntime int64 `json:"rtRuntime,omitempty"`
+}
+
+// cpuPlacement is the content of the cpuPlacementFile, by CPU.
+type cpuPlacement map[int]cpuAllocation
+
+// placeExclusiveCPUs selects the CPUs to be assigned exclusively to the
+// container id, records them in the placement file of root, and sets them
+// as the container cpuset, with the memory nodes of these CPUs (unless set).
+func placeExclusiveCPUs(root, id string, config *configs.Config) error {
+	n := config.ExclusiveCPUs
+	if n == 0 {
+		return nil
+	}
+	topo, err := topology.Discover()
+	if err != nil {
+		return fmt.Errorf("unable to get the cpu topology: %w", err)
+	}
+	r := config.Cgroups.Resources
+	return updateCPUPlacement(root, func(p cpuPlacement) error {
+		cpus, err := pickExclusiveCPUs(topo, p, n)
+		if err != nil {
+			return err
+		}
+		var nodes []int
+		for _, cpu := range cpus {
+			p[cpu] = cpuAllocation{Container: id, RtRuntime: r.CpuRtRuntime}
+			if node := topo.Node(cpu); node >= 0 && !slices.Contains(nodes, node) {
+				nodes = append(nodes, node)
+			}
+		}
+		r.CpusetCpus = cgroups.FormatCPUList(cpus)
+		if r.CpusetMems == "" && len(nodes) > 0 {
+			sort.Ints(nodes)
+			r.CpusetMems = cgroups.FormatCPUList(nodes)
+		}
+		return nil
+	})
+}
+
+// releaseExclusiveCPUs removes the CPUs assigned to the container id from
+// the placement file of root.
+func releaseExclusiveCPUs(root, id string) error {
+	return updateCPUPlacement(root, func(p cpuPlacement) error {
+		for cpu, a := range p {
+			if a.Container == id {
+				delete(p, cpu)
+			}
+		}
+		return nil
+	})
+}
+
+// updateCPUPlacement calls fn with the CPU placement of root, and saves it
+// if fn succeeds. The CPUs of the containers which no longer exist (e.g. if
+// runc was killed before releasing them) are released first.
+func updateCPUPlacement(root string, fn func(cpuPlacement) error) error {
+	f, err := os.OpenFile(filepath.Join(root, cpuPlacementFile), os.O_RDWR|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		return &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	p := make(cpuPlacement)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &p); err != nil {
+			return fmt.Errorf("invalid %s: %w", f.Name(), err)
+		}
+	}
+	for cpu, a := range p {
+		stateDir, err := securejoin.SecureJoin(root, a.Container)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(stateDir); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			delete(p, cpu)
+		}
+	}
+	if err := fn(p); err != nil {
+		return err
+	}
+
+	if data, err = json.Marshal(p); err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(data, 0)
+	return err
+}
+
+// pickExclusiveCPUs returns n online CPUs which are not in p. Free cores are
+// used first, whole, so that the CPUs do not share cores with the ones of
+// other workloads, and isolated CPUs are preferred. The CPUs are grouped by
+// NUMA node as much as possible.
+func pickExclusiveCPUs(topo *topology.Topology, p cpuPlacement, n int) ([]int, error) {
+	type candidate struct {
+		cpus     []int
+		whole    bool
+		isolated bool
+		node     int
+	}
+	var (
+		cands []candidate
+		free  int
+	)
+	for _, core := range topo.Cores {
+		var c candidate
+		c.isolated = true
+		for _, cpu := range core.CPUs {
+			if _, ok := p[cpu]; ok {
+				continue
+			}
+			c.cpus = append(c.cpus, cpu)
+			if !slices.Contains(topo.Isolated, cpu) {
+				c.isolated = false
+			}
+		}
+		if len(c.cpus) == 0 {
+			continue
+		}
+		c.whole = len(c.cpus) == len(core.CPUs)
+		c.node = topo.Node(c.cpus[0])
+		cands = append(cands, c)
+		free += len(c.cpus)
+	}
+	if free < n {
+		return nil, fmt.Errorf("%d exclusive cpus requested, only %d available", n, free)
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.whole != b.whole {
+			return a.whole
+		}
+		if a.isolated != b.isolated {
+			return a.isolated
+		}
+		return a.node < b.node
+	})
+	var cpus []int
+	for _, c := range cands {
+		for _, cpu := range c.cpus {
+			if len(cpus) == n {
+				break
+			}
+			cpus = append(cpus, cpu)
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
@@ -0,0 +1,113 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/topology"
+)
+
+// testTopology has 4 cores of 2 threads (0,4 1,5 2,6 and 3,7), the first
+// two in NUMA node 0 and the others in node 1, with CPUs 3 and 7 isolated.
+var testTopology = &topology.Topology{
+	Online:   topology.CPUList{0, 1, 2, 3, 4, 5, 6, 7},
+	Isolated: topology.CPUList{3, 7},
+	Cores: []topology.Core{
+		{ID: 0, CPUs: topology.CPUList{0, 4}},
+		{ID: 1, CPUs: topology.CPUList{1, 5}},
+		{ID: 2, CPUs: topology.CPUList{2, 6}},
+		{ID: 3, CPUs: topology.CPUList{3, 7}},
+	},
+	Nodes: []topology.Node{
+		{ID: 0, CPUs: topology.CPUList{0, 1, 4, 5}},
+		{ID: 1, CPUs: topology.CPUList{2, 3, 6, 7}},
+	},
+}
+
+func TestPickExclusiveCPUs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		used     []int
+		n        int
+		expected []int
+	}{
+		{name: "isolated core first", n: 2, expected: []int{3, 7}},
+		{name: "whole cores", n: 3, expected: []int{0, 3, 7}},
+		{name: "whole cores before partial ones", used: []int{0, 3}, n: 2, expected: []int{1, 5}},
+		{name: "partial cores last", used: []int{0, 1, 2, 3}, n: 3, expected: []int{4, 5, 7}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := make(cpuPlacement)
+			for _, cpu := range tc.used {
+				p[cpu] = cpuAllocation{Container: "other"}
+			}
+			cpus, err := pickExclusiveCPUs(testTopology, p, tc.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cpus, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, cpus)
+			}
+		})
+	}
+
+	p := cpuPlacement{0: {}, 1: {}, 2: {}, 3: {}, 4: {}, 5: {}}
+	if _, err := pickExclusiveCPUs(testTopology, p, 3); err == nil {
+		t.Error("expected an error when not enough cpus are free")
+	}
+}
+
+func TestCPUPlacement(t *testing.T) {
+	root := t.TempDir()
+	for _, id := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(root, id), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alloc := func(id string, cpus ...int) {
+		t.Helper()
+		if err := updateCPUPlacement(root, func(p cpuPlacement) error {
+			for _, cpu := range cpus {
+				p[cpu] = cpuAllocation{Container: id}
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(expected cpuPlacement) {
+		t.Helper()
+		if err := updateCPUPlacement(root, func(p cpuPlacement) error {
+			if !reflect.DeepEqual(p, expected) {
+				t.Errorf("expected %v, got %v", expected, p)
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alloc("a", 1, 2)
+	alloc("b", 3)
+	// The cpus of a container without a state directory are released.
+	alloc("gone", 4)
+	check(cpuPlacement{1: {Container: "a"}, 2: {Container: "a"}, 3: {Container: "b"}})
+
+	if err := releaseExclusiveCPUs(root, "a"); err != nil {
+		t.Fatal(err)
+	}
+	check(cpuPlacement{3: {Container: "b"}})
+}
+
+func TestPlaceExclusiveCPUsNone(t *testing.T) {
+	config := &configs.Config{Cgroups: &configs.Cgroup{Resources: &configs.Resources{}}}
+	if err := placeExclusiveCPUs(t.TempDir(), "a", config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Cgroups.Resources.CpusetCpus != "" {
+		t.Errorf("expected no cpuset, got %q", config.Cgroups.Resources.CpusetCpus)
+	}
+}
@@ -0,0 +1,127 @@
+package libcontainer
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/cgroups/fs"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// EffectiveCpuset returns the CPUs and the memory nodes the container
+// processes can actually use, which are the ones set in the container
+// config, restricted by the ancestor cgroups (see
+// [cgroups.Manager.GetEffectiveCpus]).
+func (c *Container) EffectiveCpuset() (cpus, mems string, _ error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	cpus, err := c.cgroupManager.GetEffectiveCpus()
+	if err != nil {
+		return "", "", err
+	}
+	mems, err = c.cgroupManager.GetEffectiveMems()
+	if err != nil {
+		return "", "", err
+	}
+	return cpus, mems, nil
+}
+
+// RescanCPUs re-asserts the cpuset and the real-time budget of the container
+// after a CPU hotplug, as the kernel drops the CPUs going offline from the
+// cgroup v1 cpusets, and does not add them back once they are online again
+// (see [fs.RestoreCpuset]). The requested CPUs which are offline are left
+// out. With cgroup v2, the kernel restores the effective cpusets by itself,
+// so there is nothing to do.
+func (c *Container) RescanCPUs() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	status, err := c.currentStatus()
+	if err != nil {
+		return err
+	}
+	if status == Stopped {
+		return ErrNotRunning
+	}
+	r := c.config.Cgroups.Resources
+	if r == nil || cgroups.IsCgroup2UnifiedMode() {
+		return nil
+	}
+	if dir := c.cgroupManager.ControllerPath("cpuset"); dir != "" && (r.CpusetCpus != "" || r.CpusetMems != "") {
+		cpus, mems, err := fs.RestoreCpuset(dir, r.CpusetCpus, r.CpusetMems)
+		if err != nil {
+			return fmt.Errorf("unable to restore the cpuset: %w", err)
+		}
+		if c.config.Cgroups.SplitSubCgroups {
+			if err := setSubCgroupCpusets(c.cgroupManager, &configs.Resources{CpusetCpus: cpus, CpusetMems: mems}); err != nil {
+				return fmt.Errorf("unable to restore the sub-cgroup cpusets: %w", err)
+			}
+		}
+	}
+	if dir := c.cgroupManager.ControllerPath("cpu"); dir != "" && (r.CpuRtRuntime != 0 || r.CpuRtPeriod != 0) {
+		if err := (&fs.CpuGroup{}).SetRtSched(dir, r); err != nil {
+			return fmt.Errorf("unable to restore the real-time budget: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkEffectiveCpuset warns about the CPUs and memory nodes requested in r
+// which are not effective, because the ancestor cgroups do not allow them.
+// With cgroup v2, the kernel accepts such a cpuset without an error, so
+// this is the only indication the container is not running where expected
+// (e.g. on the CPUs reserved for its real-time tasks).
+func checkEffectiveCpuset(m cgroups.Manager, r *configs.Resources) {
+	if r == nil {
+		return
+	}
+	check := func(what, requested string, getEffective func() (string, error)) {
+		if requested == "" {
+			return
+		}
+		effective, err := getEffective()
+		if err != nil {
+			logrus.Debugf("unable to check the effective %s: %v", what, err)
+			return
+		}
+		missing, err := missingFromList(requested, effective)
+		if err != nil {
+			logrus.Debugf("unable to check the effective %s: %v", what, err)
+			return
+		}
+		if missing != "" {
+			logrus.Warnf("requested %s %s are not allowed by the parent cgroups; the effective %s are %q",
+				what, missing, what, effective)
+		}
+	}
+	check("cpus", r.CpusetCpus, m.GetEffectiveCpus)
+	check("memory nodes", r.CpusetMems, m.GetEffectiveMems)
+}
+
+// missingFromList returns, as a list, the CPUs (or memory nodes) of the list
+// requested which are not in the list effective.
+func missingFromList(requested, effective string) (string, error) {
+	want, err := cgroups.ParseCPUList(requested)
+	if err != nil {
+		return "", err
+	}
+	have, err := cgroups.ParseCPUList(effective)
+	if err != nil {
+		return "", err
+	}
+	haveSet := make(map[int]struct{}, len(have))
+	for _, n := range have {
+		haveSet[n] = struct{}{}
+	}
+	var missing []int
+	for _, n := range want {
+		if _, ok := haveSet[n]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	if len(missing) == 0 {
+		return "", nil
+	}
+	return cgroups.FormatCPUList(missing), nil
+}
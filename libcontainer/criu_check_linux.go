@@ -0,0 +1,105 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+
+	criurpc "github.com/checkpoint-restore/go-criu/v6/rpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// CriuCheck is the result of Container.CheckCriu.
+type CriuCheck struct {
+	// Version is the CRIU version (e.g. 31701 for 3.17.1), or 0 if it
+	// could not be determined.
+	Version int
+	// Unsupported are the reasons why a checkpoint with the given options
+	// would fail.
+	Unsupported []string
+	// Warnings are the features which won't work, or will work in a
+	// degraded way, without preventing a checkpoint.
+	Warnings []string
+}
+
+// OK tells whether a checkpoint is expected to succeed.
+func (c *CriuCheck) OK() bool {
+	return len(c.Unsupported) == 0
+}
+
+// FormatCriuVersion formats a CRIU version as returned by CheckCriu, e.g.
+// "3.17.1" for 31701.
+func FormatCriuVersion(v int) string {
+	return fmt.Sprintf("%d.%d.%d", v/10000, v/100%100, v%100)
+}
+
+// CheckCriu queries CRIU for the features needed to checkpoint the container
+// with the given options, and reports what won't work, without checkpointing
+// the container. An error is only returned if CRIU could not be queried.
+func (c *Container) CheckCriu(criuOpts *CriuOpts) (*CriuCheck, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	check := &CriuCheck{}
+	if err := c.checkCriuVersion(30000); err != nil && c.criuVersion == 0 {
+		// CRIU is not installed, or is too old to report its version.
+		check.Unsupported = append(check.Unsupported, err.Error())
+		return check, nil
+	}
+	check.Version = c.criuVersion
+	check.Unsupported, check.Warnings = criuVersionCheck(c.criuVersion, c.config, criuOpts)
+	if c.criuVersion < 30000 {
+		return check, nil
+	}
+
+	for _, f := range []struct {
+		name string
+		feat *criurpc.CriuFeatures
+		want bool
+	}{
+		{"pre-dump (memory tracking)", &criurpc.CriuFeatures{MemTrack: proto.Bool(true)}, criuOpts.PreDump},
+		{"lazy pages", &criurpc.CriuFeatures{LazyPages: proto.Bool(true)}, criuOpts.LazyPages},
+	} {
+		if !f.want {
+			continue
+		}
+		err := c.checkCriuFeatures(criuOpts, f.feat)
+		if errors.Is(err, ErrCriuMissingFeatures) {
+			check.Unsupported = append(check.Unsupported, f.name+" is not supported by CRIU or the kernel")
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return check, nil
+}
+
+// criuVersionCheck returns the reasons why checkpointing a container with
+// the given config and options would fail (unsupported), or be degraded
+// (warnings), with the given CRIU version.
+func criuVersionCheck(version int, config *configs.Config, criuOpts *CriuOpts) (unsupported, warnings []string) {
+	if version < 30000 {
+		unsupported = append(unsupported, fmt.Sprintf("CRIU version %s must be 3.0.0 or higher", FormatCriuVersion(version)))
+		return unsupported, nil
+	}
+	if cgroups.IsCgroup2UnifiedMode() && version < 31400 {
+		warnings = append(warnings, "the cgroup v2 freezer requires CRIU 3.14, the processes will be frozen using ptrace")
+	}
+	if version < 31700 {
+		warnings = append(warnings, "processes using rseq (e.g. with glibc 2.35 or later) can only be checkpointed with CRIU 3.17 or later")
+	}
+	for _, ns := range []struct {
+		t          configs.NamespaceType
+		minVersion int
+	}{
+		{configs.NEWNET, 31100},
+		{configs.NEWPID, 31500},
+	} {
+		if config.Namespaces.PathOf(ns.t) != "" && version < ns.minVersion {
+			warnings = append(warnings, fmt.Sprintf("the external %s namespace requires CRIU %s, it will not be handled as external",
+				configs.NsName(ns.t), FormatCriuVersion(ns.minVersion)))
+		}
+	}
+	return unsupported, warnings
+}
@@ -0,0 +1,49 @@
+package libcontainer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestFormatCriuVersion(t *testing.T) {
+	if v := FormatCriuVersion(31701); v != "3.17.1" {
+		t.Errorf("expected 3.17.1, got %s", v)
+	}
+}
+
+func TestCriuVersionCheck(t *testing.T) {
+	config := &configs.Config{
+		Namespaces: configs.Namespaces{
+			{Type: configs.NEWNET, Path: "/run/netns/test"},
+			{Type: configs.NEWPID},
+		},
+	}
+	opts := &CriuOpts{}
+
+	unsupported, _ := criuVersionCheck(20000, config, opts)
+	if len(unsupported) != 1 {
+		t.Errorf("expected CRIU 2.0 to be unsupported, got %v", unsupported)
+	}
+
+	unsupported, warnings := criuVersionCheck(31000, config, opts)
+	if len(unsupported) != 0 {
+		t.Errorf("unexpected unsupported features with CRIU 3.10: %v", unsupported)
+	}
+	var rseq, netns bool
+	for _, w := range warnings {
+		rseq = rseq || strings.Contains(w, "rseq")
+		netns = netns || strings.Contains(w, "external net namespace")
+		if strings.Contains(w, "external pid namespace") {
+			t.Errorf("unexpected warning about the (not external) pid namespace: %s", w)
+		}
+	}
+	if !rseq || !netns {
+		t.Errorf("expected rseq and netns warnings with CRIU 3.10, got %v", warnings)
+	}
+
+	if unsupported, warnings := criuVersionCheck(31700, config, opts); len(unsupported) != 0 || len(warnings) != 0 {
+		t.Errorf("expected no problems with CRIU 3.17, got %v and %v", unsupported, warnings)
+	}
+}
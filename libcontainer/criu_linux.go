@@ -11,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"time"
 
@@ -196,6 +197,10 @@ func (c *Container) handleCheckpointingExternalNamespaces(rpcOpts *criurpc.CriuO
 	// CRIU expects the information about an external namespace
 	// like this: --external <TYPE>[<inode>]:<key>
 	// This <key> is always 'extRoot<TYPE>NS'.
+	if _, ok, _ := configs.NsPidfd(nsPath); ok {
+		// The pidfd was only valid when the container was created.
+		nsPath = (&configs.Namespace{Type: t}).GetPath(c.initProcess.pid())
+	}
 	var ns unix.Stat_t
 	if err := unix.Stat(nsPath, &ns); err != nil {
 		return err
@@ -232,6 +237,11 @@ func (c *Container) handleRestoringNamespaces(rpcOpts *criurpc.CriuOpts, extraFi
 			}
 			// CRIU has code to handle NEWTIME, but it does not seem to be defined in runc
 
+			if _, ok, _ := configs.NsPidfd(nsPath); ok {
+				// CRIU opens the namespace path itself.
+				return fmt.Errorf("joining the %v namespace by pidfd is not supported on restore", ns.Type)
+			}
+
 			// CRIU will issue a warning for NEWUSER:
 			// criu/namespaces.c: 'join-ns with user-namespace is not fully tested and dangerous'
 			rpcOpts.JoinNs = append(rpcOpts.JoinNs, &criurpc.JoinNamespace{
@@ -257,7 +267,7 @@ func (c *Container) handleRestoringExternalNamespaces(rpcOpts *criurpc.CriuOpts,
 	// like this: --inherit-fd fd[<fd>]:<key>
 	// The <key> needs to be the same as during checkpointing.
 	// We are always using 'extRoot<TYPE>NS' as the key in this.
-	nsFd, err := os.Open(nsPath)
+	nsFd, err := configs.OpenNsPath(t, nsPath)
 	if err != nil {
 		logrus.Errorf("If a specific network namespace is defined it must exist: %s", err)
 		return fmt.Errorf("Requested network namespace %v does not exist", nsPath)
@@ -290,6 +300,12 @@ func (c *Container) Checkpoint(criuOpts *CriuOpts) error {
 	if err := c.checkCriuVersion(30000); err != nil {
 		return err
 	}
+	// Let the user know about what won't work with this CRIU version (see
+	// CheckCriu), but try anyway.
+	_, warnings := criuVersionCheck(c.criuVersion, c.config, criuOpts)
+	for _, w := range warnings {
+		logrus.Warn(w)
+	}
 
 	if criuOpts.ImagesDirectory == "" {
 		return errors.New("invalid directory to save checkpoint")
@@ -319,6 +335,8 @@ func (c *Container) Checkpoint(criuOpts *CriuOpts) error {
 		ShellJob:        proto.Bool(criuOpts.ShellJob),
 		LeaveRunning:    proto.Bool(criuOpts.LeaveRunning),
 		TcpEstablished:  proto.Bool(criuOpts.TcpEstablished),
+		TcpClose:        proto.Bool(criuOpts.TcpClose),
+		TcpSkipInFlight: proto.Bool(criuOpts.TcpSkipInFlight),
 		ExtUnixSk:       proto.Bool(criuOpts.ExternalUnixConnections),
 		FileLocks:       proto.Bool(criuOpts.FileLocks),
 		EmptyNs:         proto.Uint32(criuOpts.EmptyNs),
@@ -362,7 +380,7 @@ func (c *Container) Checkpoint(criuOpts *CriuOpts) error {
 	// is not set, CRIU uses ptrace() to pause the processes.
 	// Note cgroup v2 freezer is only supported since CRIU release 3.14.
 	if !cgroups.IsCgroup2UnifiedMode() || c.checkCriuVersion(31400) == nil {
-		if fcg := c.cgroupManager.Path("freezer"); fcg != "" {
+		if fcg := c.cgroupManager.ControllerPath("freezer"); fcg != "" {
 			rpcOpts.FreezeCgroup = proto.String(fcg)
 		}
 	}
@@ -674,6 +692,7 @@ func (c *Container) Restore(process *Process, criuOpts *CriuOpts) error {
 			ShellJob:        proto.Bool(criuOpts.ShellJob),
 			ExtUnixSk:       proto.Bool(criuOpts.ExternalUnixConnections),
 			TcpEstablished:  proto.Bool(criuOpts.TcpEstablished),
+			TcpClose:        proto.Bool(criuOpts.TcpClose),
 			FileLocks:       proto.Bool(criuOpts.FileLocks),
 			EmptyNs:         proto.Uint32(criuOpts.EmptyNs),
 			OrphanPtsMaster: proto.Bool(true),
@@ -1087,6 +1106,33 @@ func unlockNetwork(config *configs.Config) error {
 	return nil
 }
 
+// setHostnameOf sets the hostname in the UTS namespace of the process with
+// the given pid.
+func setHostnameOf(pid int, hostname string) error {
+	ns, err := os.Open(fmt.Sprintf("/proc/%d/ns/uts", pid))
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		// The UTS namespace of the current thread is changed. Keep the
+		// thread locked, so it is terminated rather than reused once we
+		// are done.
+		runtime.LockOSThread()
+		if err := unix.Setns(int(ns.Fd()), unix.CLONE_NEWUTS); err != nil {
+			errCh <- &os.SyscallError{Syscall: "setns", Err: err}
+			return
+		}
+		if err := unix.Sethostname([]byte(hostname)); err != nil {
+			errCh <- &os.SyscallError{Syscall: "sethostname", Err: err}
+			return
+		}
+		errCh <- nil
+	}()
+	return <-errCh
+}
+
 func (c *Container) criuNotifications(resp *criurpc.CriuResp, process *Process, cmd *exec.Cmd, opts *CriuOpts, fds []string, oob []byte) error {
 	notify := resp.GetNotify()
 	if notify == nil {
@@ -1110,6 +1156,12 @@ func (c *Container) criuNotifications(resp *criurpc.CriuResp, process *Process,
 			return err
 		}
 	case "setup-namespaces":
+		// Set the hostname before the hooks run, so they see the new one.
+		if opts.Hostname != "" {
+			if err := setHostnameOf(int(notify.GetPid()), opts.Hostname); err != nil {
+				return err
+			}
+		}
 		if c.config.Hooks != nil {
 			s, err := c.currentOCIState()
 			if err != nil {
@@ -0,0 +1,40 @@
+package libcontainer
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestSetHostnameOf(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	cmd := exec.Command("sh", "-c", "read x; hostname")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWUTS}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	err = setHostnameOf(cmd.Process.Pid, "clone")
+	stdin.Close()
+	if werr := cmd.Wait(); werr != nil {
+		t.Fatal(werr)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "clone" {
+		t.Errorf("expected hostname clone, got %q", got)
+	}
+	if h, _ := os.Hostname(); h == "clone" {
+		t.Error("the hostname of the current namespace was changed")
+	}
+}
@@ -18,6 +18,8 @@ type CriuOpts struct {
 	ParentImage             string             // directory for storing parent image files in pre-dump and dump
 	LeaveRunning            bool               // leave container in running state after checkpoint
 	TcpEstablished          bool               // checkpoint/restore established TCP connections
+	TcpClose                bool               // don't dump the state of established TCP connections, restore them closed
+	TcpSkipInFlight         bool               // skip in-flight (not yet accepted) TCP connections
 	ExternalUnixConnections bool               // allow external unix connections
 	ShellJob                bool               // allow to dump and restore shell jobs
 	FileLocks               bool               // handle file locks, for safety
@@ -31,4 +33,5 @@ type CriuOpts struct {
 	StatusFd                int                // fd for feedback when lazy server is ready
 	LsmProfile              string             // LSM profile used to restore the container
 	LsmMountContext         string             // LSM mount context value to use during restore
+	Hostname                string             // hostname to set in the restored UTS namespace
 }
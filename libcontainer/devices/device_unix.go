@@ -4,8 +4,11 @@ package devices
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"golang.org/x/sys/unix"
 )
@@ -117,3 +120,50 @@ func GetDevices(path string) ([]*Device, error) {
 	}
 	return out, nil
 }
+
+// DeviceFromSysfs resolves a sysfs device directory (such as
+// /sys/class/drm/card1 or /sys/block/sda) to the device's current major and
+// minor numbers, and returns a device allowed with the given permissions.
+//
+// Device numbers of dynamically registered drivers (such as GPUs) may change
+// across reboots, so reading them from sysfs when the container is created
+// avoids relying on stale numbers recorded in a configuration.
+//
+// The returned device has an empty Path and zero FileMode, Uid and Gid;
+// callers are expected to fill those in.
+func DeviceFromSysfs(sysPath, permissions string) (*Device, error) {
+	data, err := os.ReadFile(filepath.Join(sysPath, "dev"))
+	if err != nil {
+		return nil, err
+	}
+	majMin := strings.TrimSpace(string(data))
+	maj, min, ok := strings.Cut(majMin, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid device number %q in %s/dev", majMin, sysPath)
+	}
+	major, err := strconv.ParseUint(maj, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid major number in %s/dev: %w", sysPath, err)
+	}
+	minor, err := strconv.ParseUint(min, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minor number in %s/dev: %w", sysPath, err)
+	}
+
+	// Devices in the "block" subsystem are block devices, everything
+	// else exposing a dev file is a character device.
+	devType := CharDevice
+	if subsys, err := os.Readlink(filepath.Join(sysPath, "subsystem")); err == nil && filepath.Base(subsys) == "block" {
+		devType = BlockDevice
+	}
+
+	return &Device{
+		Rule: Rule{
+			Type:        devType,
+			Major:       int64(major),
+			Minor:       int64(minor),
+			Permissions: Permissions(permissions),
+			Allow:       true,
+		},
+	}, nil
+}
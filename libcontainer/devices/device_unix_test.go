@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"golang.org/x/sys/unix"
@@ -94,3 +95,53 @@ func TestHostDevicesAllValid(t *testing.T) {
 		}
 	}
 }
+
+func TestDeviceFromSysfs(t *testing.T) {
+	for _, tc := range []struct {
+		name, dev, subsystem string
+		expType              Type
+		expMajor, expMinor   int64
+		expErr               bool
+	}{
+		{name: "drm", dev: "226:1\n", subsystem: "../../../../class/drm", expType: CharDevice, expMajor: 226, expMinor: 1},
+		{name: "block", dev: "259:0\n", subsystem: "../../../../block", expType: BlockDevice, expMajor: 259, expMinor: 0},
+		{name: "no subsystem", dev: "1:3", expType: CharDevice, expMajor: 1, expMinor: 3},
+		{name: "no colon", dev: "2261\n", expErr: true},
+		{name: "bad major", dev: "x:1\n", expErr: true},
+		{name: "bad minor", dev: "226:-1\n", expErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "dev"), []byte(tc.dev), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if tc.subsystem != "" {
+				if err := os.Symlink(tc.subsystem, filepath.Join(dir, "subsystem")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dev, err := DeviceFromSysfs(dir, "rw")
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", dev)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dev.Type != tc.expType || dev.Major != tc.expMajor || dev.Minor != tc.expMinor {
+				t.Errorf("expected %c %d:%d, got %c %d:%d", tc.expType, tc.expMajor, tc.expMinor, dev.Type, dev.Major, dev.Minor)
+			}
+			if !dev.Allow || dev.Permissions != "rw" {
+				t.Errorf("unexpected rule %+v", dev.Rule)
+			}
+		})
+	}
+}
+
+func TestDeviceFromSysfsMissing(t *testing.T) {
+	if _, err := DeviceFromSysfs(t.TempDir(), "rwm"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
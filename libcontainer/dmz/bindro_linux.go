@@ -0,0 +1,57 @@
+package dmz
+
+import (
+	"errors"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrOverlayfsExe is returned by ReadOnlySelfExe if the runc binary is on
+// overlayfs.
+var ErrOverlayfsExe = errors.New("runc binary is on overlayfs")
+
+// ReadOnlySelfExe returns the current process's binary (/proc/self/exe),
+// opened through a read-only detached bind mount of it. Unlike the clone
+// made by CloneSelfExe, this needs no memory or disk space, but it requires
+// Linux 5.12 and CAP_SYS_ADMIN.
+//
+// The binary must not be on overlayfs: a read-only bind mount only prevents
+// writes through that mount, and the file of an overlayfs mount can still be
+// changed through its upper directory, which is usually also used by the
+// other users (e.g. containers) of the same image layers.
+func ReadOnlySelfExe() (*os.File, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs("/proc/self/exe", &st); err != nil {
+		return nil, &os.PathError{Op: "statfs", Path: "/proc/self/exe", Err: err}
+	}
+	if st.Type == unix.OVERLAYFS_SUPER_MAGIC {
+		return nil, ErrOverlayfsExe
+	}
+	treeFd, err := unix.OpenTree(unix.AT_FDCWD, "/proc/self/exe", unix.OPEN_TREE_CLONE|unix.OPEN_TREE_CLOEXEC)
+	if err != nil {
+		return nil, &os.PathError{Op: "open_tree", Path: "/proc/self/exe", Err: err}
+	}
+	defer unix.Close(treeFd)
+	attr := &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY | unix.MOUNT_ATTR_NOSUID | unix.MOUNT_ATTR_NODEV}
+	if err := unix.MountSetattr(treeFd, "", unix.AT_EMPTY_PATH, attr); err != nil {
+		return nil, &os.PathError{Op: "mount_setattr", Path: "/proc/self/exe", Err: err}
+	}
+	// Reopen the binary through the detached mount, so it can be executed.
+	path := "/proc/self/fd/" + strconv.Itoa(treeFd)
+	file, err := os.OpenFile(path, os.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the mount is read-only.
+	if err := unix.Fstatfs(int(file.Fd()), &st); err != nil {
+		file.Close()
+		return nil, &os.PathError{Op: "fstatfs", Path: path, Err: err}
+	}
+	if st.Flags&unix.ST_RDONLY == 0 {
+		file.Close()
+		return nil, errors.New("bind mount of runc binary is not read-only")
+	}
+	return file, nil
+}
@@ -10,4 +10,8 @@ var (
 	ErrRunning    = errors.New("container still running")
 	ErrNotRunning = errors.New("container not running")
 	ErrNotPaused  = errors.New("container not paused")
+	ErrCPUPaused  = errors.New("container CPU-paused")
+	ErrNotStopped = errors.New("container not stopped")
+	ErrExecPolicy = errors.New("not allowed by the container exec policy")
+	ErrInMemory   = errors.New("container state is only held in memory by another process")
 )
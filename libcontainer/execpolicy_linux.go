@@ -0,0 +1,39 @@
+package libcontainer
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// checkExecPolicy checks the security settings of a process to be executed
+// in the container against the container exec policy, i.e. that the settings
+// p overrides, if any, are allowed by config.ExecPolicy.
+func checkExecPolicy(config *configs.Config, p *Process) error {
+	policy := config.ExecPolicy
+	if policy == nil {
+		return nil
+	}
+	if ap := p.AppArmorProfile; ap != "" && ap != config.AppArmorProfile {
+		if !slices.Contains(policy.AppArmorProfiles, ap) {
+			return fmt.Errorf("apparmor profile %q: %w", ap, ErrExecPolicy)
+		}
+		logrus.Infof("exec: overriding the apparmor profile with %q", ap)
+	}
+	if l := p.Label; l != "" && l != config.ProcessLabel {
+		if !slices.Contains(policy.ProcessLabels, l) {
+			return fmt.Errorf("process label %q: %w", l, ErrExecPolicy)
+		}
+		logrus.Infof("exec: overriding the process label with %q", l)
+	}
+	if p.NoNewPrivileges != nil && !*p.NoNewPrivileges && config.NoNewPrivileges {
+		if !policy.AllowNewPrivileges {
+			return fmt.Errorf("unsetting no_new_privs: %w", ErrExecPolicy)
+		}
+		logrus.Info("exec: not setting no_new_privs")
+	}
+	return nil
+}
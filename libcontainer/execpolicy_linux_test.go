@@ -0,0 +1,61 @@
+package libcontainer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestCheckExecPolicy(t *testing.T) {
+	config := &configs.Config{
+		AppArmorProfile: "container",
+		ProcessLabel:    "system_u:system_r:container_t:s0:c1,c2",
+		NoNewPrivileges: true,
+	}
+	f, tr := false, true
+	processes := []struct {
+		desc    string
+		process Process
+		allowed bool // by the policy below
+	}{
+		{"no override", Process{}, true},
+		{"same settings", Process{AppArmorProfile: "container", Label: config.ProcessLabel, NoNewPrivileges: &tr}, true},
+		{"allowed profile", Process{AppArmorProfile: "unconfined"}, true},
+		{"other profile", Process{AppArmorProfile: "other"}, false},
+		{"allowed label", Process{Label: "system_u:system_r:spc_t:s0"}, true},
+		{"other label", Process{Label: "system_u:system_r:other_t:s0"}, false},
+		{"new privileges", Process{NoNewPrivileges: &f}, false},
+	}
+
+	// Without a policy, everything is allowed.
+	for _, tc := range processes {
+		if err := checkExecPolicy(config, &tc.process); err != nil {
+			t.Errorf("%s: unexpected error without a policy: %v", tc.desc, err)
+		}
+	}
+
+	config.ExecPolicy = &configs.ExecPolicy{
+		AppArmorProfiles: []string{"unconfined"},
+		ProcessLabels:    []string{"system_u:system_r:spc_t:s0"},
+	}
+	for _, tc := range processes {
+		err := checkExecPolicy(config, &tc.process)
+		if tc.allowed && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.desc, err)
+		} else if !tc.allowed && !errors.Is(err, ErrExecPolicy) {
+			t.Errorf("%s: expected ErrExecPolicy, got %v", tc.desc, err)
+		}
+	}
+
+	config.ExecPolicy.AllowNewPrivileges = true
+	if err := checkExecPolicy(config, &Process{NoNewPrivileges: &f}); err != nil {
+		t.Errorf("new privileges: unexpected error: %v", err)
+	}
+	// Setting no_new_privs is always allowed.
+	config.NoNewPrivileges = false
+	config.ExecPolicy.AllowNewPrivileges = false
+	if err := checkExecPolicy(config, &Process{NoNewPrivileges: &tr}); err != nil {
+		t.Errorf("no new privileges: unexpected error: %v", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"golang.org/x/sys/unix"
 
+	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/cgroups/manager"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/configs/validate"
@@ -85,14 +86,35 @@ func Create(root, id string, config *configs.Config) (*Container, error) {
 	if st == configs.Frozen {
 		return nil, errors.New("container's cgroup unexpectedly frozen")
 	}
+	// Nor any of its ancestors, or the container processes would be frozen
+	// as soon as they join it, making runc hang.
+	if err := cgroups.CheckFrozenAncestors(cm.ControllerPath("freezer"), config.ThawFrozenAncestors); err != nil {
+		return nil, fmt.Errorf("unable to create container cgroup: %w", err)
+	}
+
+	// Fail early if the real-time runtime can not possibly be set.
+	if err := cgroups.CheckRtBandwidth(config.Cgroups.Resources, config.AdjustRtBandwidth); err != nil {
+		return nil, err
+	}
 
 	// Parent directory is already created above, so Mkdir is enough.
 	if err := os.Mkdir(stateDir, 0o711); err != nil {
 		return nil, err
 	}
+	if err := setupOverlayAutoMounts(stateDir, config); err != nil {
+		_ = os.RemoveAll(stateDir)
+		return nil, err
+	}
+	// The state directory must exist first, as the CPUs of the containers
+	// without one are considered free.
+	if err := placeExclusiveCPUs(root, id, config); err != nil {
+		_ = os.RemoveAll(stateDir)
+		return nil, err
+	}
 	c := &Container{
 		id:              id,
 		stateDir:        stateDir,
+		store:           newStateStore(root),
 		config:          config,
 		cgroupManager:   cm,
 		intelRdtManager: intelrdt.NewManager(config, id, ""),
@@ -116,8 +138,20 @@ func Load(root, id string) (*Container, error) {
 	if err != nil {
 		return nil, err
 	}
-	state, err := loadState(stateDir)
+	if _, err := os.Stat(stateDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotExist
+		}
+		return nil, err
+	}
+	store := newStateStore(root)
+	state, err := store.load(id)
 	if err != nil {
+		if _, ok := store.(*memoryStateStore); !ok && errors.Is(err, ErrNotExist) {
+			if err := checkMemoryState(stateDir); err != nil {
+				return nil, err
+			}
+		}
 		return nil, err
 	}
 	r := &nonChildProcess{
@@ -137,7 +171,10 @@ func Load(root, id string) (*Container, error) {
 		cgroupManager:        cm,
 		intelRdtManager:      intelrdt.NewManager(&state.Config, id, state.IntelRdtPath),
 		stateDir:             stateDir,
+		store:                store,
 		created:              state.Created,
+		cpuPaused:            state.CPUPaused,
+		bootstrapTimings:     state.BootstrapTimings,
 	}
 	c.state = &loadedState{c: c}
 	if err := c.refreshState(); err != nil {
@@ -0,0 +1,144 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups/fscommon"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// Defaults for [ForkPolicy].
+const (
+	DefaultForkCheckInterval = 100 * time.Millisecond
+	DefaultForkFreezeTime    = 100 * time.Millisecond
+)
+
+// ForkPolicy is the policy enforced by [Container.PoliceForks].
+type ForkPolicy struct {
+	// MaxRate is the maximum growth rate of the number of tasks in the
+	// container (pids.current), in tasks per second.
+	MaxRate float64
+	// Interval is the time between two readings of the number of tasks,
+	// over which the growth rate is calculated. If 0,
+	// DefaultForkCheckInterval is used.
+	Interval time.Duration
+	// FreezeTime is how long the container is frozen for once MaxRate is
+	// exceeded. If 0, DefaultForkFreezeTime is used.
+	FreezeTime time.Duration
+}
+
+// ForkThrottle describes a freeze of the container by [Container.PoliceForks].
+type ForkThrottle struct {
+	// Rate is the growth rate of the number of tasks which triggered the
+	// freeze, in tasks per second.
+	Rate float64
+	// Tasks is the number of tasks in the container.
+	Tasks uint64
+	// Frozen is how long the container was frozen for.
+	Frozen time.Duration
+}
+
+// PoliceForks watches the growth rate of the number of tasks in the
+// container and, whenever it exceeds policy.MaxRate, briefly freezes the
+// container and calls throttled, if not nil. This stops fork storms much
+// sooner than the pids limit would, before they starve the other workloads
+// (e.g. the real-time ones) on the same CPUs.
+//
+// The container must have a pids cgroup. Containers paused by the user are
+// left alone. PoliceForks returns once stop is closed or the container is
+// stopped.
+func (c *Container) PoliceForks(policy ForkPolicy, stop <-chan struct{}, throttled func(ForkThrottle)) error {
+	if policy.MaxRate <= 0 {
+		return fmt.Errorf("invalid maximum fork rate %v", policy.MaxRate)
+	}
+	if policy.Interval < 0 || policy.FreezeTime < 0 {
+		return errors.New("fork check interval and freeze time must not be negative")
+	}
+	if policy.Interval == 0 {
+		policy.Interval = DefaultForkCheckInterval
+	}
+	if policy.FreezeTime == 0 {
+		policy.FreezeTime = DefaultForkFreezeTime
+	}
+	path := c.cgroupManager.ControllerPath("pids")
+	if path == "" {
+		return errors.New("the container has no pids cgroup")
+	}
+	prev, err := fscommon.GetCgroupParamUint(path, "pids.current")
+	if err != nil {
+		return err
+	}
+	prevTime := time.Now()
+	ticker := time.NewTicker(policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+		cur, err := fscommon.GetCgroupParamUint(path, "pids.current")
+		if err != nil {
+			if status, err2 := c.Status(); err2 == nil && status == Stopped {
+				return nil
+			}
+			return err
+		}
+		now := time.Now()
+		var rate float64
+		if cur > prev {
+			rate = float64(cur-prev) / now.Sub(prevTime).Seconds()
+		}
+		if rate > policy.MaxRate {
+			frozen, err := c.freezeFor(policy.FreezeTime)
+			if err != nil {
+				if errors.Is(err, ErrNotRunning) {
+					return nil
+				}
+				return err
+			}
+			if frozen && throttled != nil {
+				throttled(ForkThrottle{Rate: rate, Tasks: cur, Frozen: policy.FreezeTime})
+			}
+			// Don't count the freeze time in the next rate.
+			now = time.Now()
+		}
+		prev, prevTime = cur, now
+	}
+}
+
+// freezeFor freezes the container for the given time, unless it is paused
+// or frozen already, in which case false is returned. The state lock is held
+// meanwhile, so that the container can not be paused or resumed concurrently.
+func (c *Container) freezeFor(d time.Duration) (bool, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	unlock, err := c.lockState()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	status, err := c.currentStatus()
+	if err != nil {
+		return false, err
+	}
+	switch status {
+	case Running, Created:
+	case Paused:
+		return false, nil
+	default:
+		return false, ErrNotRunning
+	}
+	if state, err := c.cgroupManager.GetFreezerState(); err != nil {
+		return false, err
+	} else if state != configs.Thawed {
+		return false, nil
+	}
+	if err := c.cgroupManager.Freeze(configs.Frozen); err != nil {
+		return false, err
+	}
+	time.Sleep(d)
+	return true, c.cgroupManager.Freeze(configs.Thawed)
+}
@@ -72,6 +72,8 @@ type initConfig struct {
 	RootlessCgroups  bool                  `json:"rootless_cgroups,omitempty"`
 	SpecState        *specs.State          `json:"spec_state,omitempty"`
 	Cgroup2Path      string                `json:"cgroup2_path,omitempty"`
+	TimerSlack       uint64                `json:"timer_slack,omitempty"`
+	IOPriority       *configs.IOPriority   `json:"io_priority,omitempty"`
 }
 
 // Init is part of "runc init" implementation.
@@ -624,17 +626,26 @@ func setupNetwork(config *initConfig) error {
 
 func setupRoute(config *configs.Config) error {
 	for _, config := range config.Routes {
-		_, dst, err := net.ParseCIDR(config.Destination)
-		if err != nil {
-			return err
+		// Omitted entries use their IP family default.
+		var (
+			dst     *net.IPNet
+			src, gw net.IP
+			err     error
+		)
+		if config.Destination != "" {
+			if _, dst, err = net.ParseCIDR(config.Destination); err != nil {
+				return err
+			}
 		}
-		src := net.ParseIP(config.Source)
-		if src == nil {
-			return fmt.Errorf("Invalid source for route: %s", config.Source)
+		if config.Source != "" {
+			if src = net.ParseIP(config.Source); src == nil {
+				return fmt.Errorf("Invalid source for route: %s", config.Source)
+			}
 		}
-		gw := net.ParseIP(config.Gateway)
-		if gw == nil {
-			return fmt.Errorf("Invalid gateway for route: %s", config.Gateway)
+		if config.Gateway != "" {
+			if gw = net.ParseIP(config.Gateway); gw == nil {
+				return fmt.Errorf("Invalid gateway for route: %s", config.Gateway)
+			}
 		}
 		l, err := netlink.LinkByName(config.InterfaceName)
 		if err != nil {
@@ -690,11 +701,26 @@ func setupScheduler(config *configs.Config) error {
 }
 
 func setupPersonality(config *configs.Config) error {
-	return system.SetLinuxPersonality(config.Personality.Domain)
+	return system.SetLinuxPersonality(config.Personality.Domain | config.Personality.Flags)
+}
+
+// setTimerSlack sets the timer slack of the current thread, which is kept
+// across execve.
+func setTimerSlack(ns uint64) error {
+	if err := unix.Prctl(unix.PR_SET_TIMERSLACK, uintptr(ns), 0, 0, 0); err != nil {
+		return fmt.Errorf("error setting timer slack: %w", err)
+	}
+	return nil
 }
 
 // signalAllProcesses freezes then iterates over all the processes inside the
-// manager's cgroups sending the signal s to them.
+// manager's cgroups sending the signal s to them, and thaws the cgroup
+// afterwards (unless it was frozen before, and s is not SIGKILL).
+//
+// Freezing guarantees that the set of processes does not change while the
+// signal is being sent, so every process gets the signal exactly once. For
+// this reason, a failure to freeze is an error, except for SIGKILL, which is
+// sent in a best-effort manner.
 //
 // signalAllProcesses returns ErrNotRunning when the cgroup does not exist.
 func signalAllProcesses(m cgroups.Manager, s unix.Signal) error {
@@ -703,23 +729,37 @@ func signalAllProcesses(m cgroups.Manager, s unix.Signal) error {
 	}
 	// Use cgroup.kill, if available.
 	if s == unix.SIGKILL {
-		if p := m.Path(""); p != "" { // Either cgroup v2 or hybrid.
-			err := cgroups.WriteFile(p, "cgroup.kill", "1")
-			if err == nil || !errors.Is(err, os.ErrNotExist) {
-				return err
-			}
-			// Fallback to old implementation.
+		err := m.Kill()
+		if !errors.Is(err, cgroups.ErrKillUnsupported) {
+			return err
 		}
+		// Fallback to old implementation.
 	}
 
+	prevState, err := m.GetFreezerState()
+	if err != nil {
+		logrus.Debugf("unable to get freezer state: %v", err)
+	}
 	if err := m.Freeze(configs.Frozen); err != nil {
+		if s != unix.SIGKILL {
+			return fmt.Errorf("unable to freeze: %w", err)
+		}
 		logrus.Warn(err)
 	}
-	pids, err := m.GetAllPids()
-	if err != nil {
+	thaw := func() {
+		// A paused container stays paused, except for SIGKILL: on
+		// cgroup v1, killing a frozen process does nothing until
+		// it's thawed.
+		if prevState == configs.Frozen && s != unix.SIGKILL {
+			return
+		}
 		if err := m.Freeze(configs.Thawed); err != nil {
 			logrus.Warn(err)
 		}
+	}
+	pids, err := m.GetAllPids()
+	if err != nil {
+		thaw()
 		return err
 	}
 	for _, pid := range pids {
@@ -728,9 +768,7 @@ func signalAllProcesses(m cgroups.Manager, s unix.Signal) error {
 			logrus.Warnf("kill %d: %v", pid, err)
 		}
 	}
-	if err := m.Freeze(configs.Thawed); err != nil {
-		logrus.Warn(err)
-	}
+	thaw()
 
 	return nil
 }
@@ -1404,7 +1404,7 @@ func testPidnsInitKill(t *testing.T, config *configs.Config) {
 	ok(t, err)
 
 	// Kill the container.
-	err = container.Signal(syscall.SIGKILL)
+	err = container.Signal(syscall.SIGKILL, false)
 	ok(t, err)
 	_, err = process1.Wait()
 	if err == nil {
@@ -474,12 +474,24 @@ func (m *Manager) Apply(pid int) (err error) {
 		return newLastCmdError(err)
 	}
 
+	if m.config.IntelRdt.PseudoLock != nil {
+		if err := m.setupPseudoLock(); err != nil {
+			return err
+		}
+	}
+
 	m.path = path
 	return nil
 }
 
 // Destroys the Intel RDT container-specific 'container_id' group
 func (m *Manager) Destroy() error {
+	// The pseudo-locked region is always owned by the container.
+	if m.config.IntelRdt != nil && m.config.IntelRdt.PseudoLock != nil {
+		if err := m.destroyPseudoLock(); err != nil {
+			return err
+		}
+	}
 	// Don't remove resctrl group if closid has been explicitly specified. The
 	// group is likely externally managed, i.e. by some other entity than us.
 	// There are probably other containers/tasks sharing the same group.
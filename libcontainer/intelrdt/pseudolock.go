@@ -0,0 +1,126 @@
+package intelrdt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/devices"
+)
+
+/*
+ * About cache pseudo-locking:
+ * A resource group can be used to "pseudo-lock" a region of the L2 or L3
+ * cache: the kernel allocates a memory buffer, loads it into the cache
+ * portion described by the group's schemata, and makes sure no other group
+ * can evict it. The buffer can then be mmap'ed by the application through
+ * the /dev/pseudo_lock/<group> character device, resulting in a low and
+ * predictable memory access latency, as needed by hard real-time workloads.
+ *
+ * The region is set up by writing "pseudo-locksetup" to the "mode" file of a
+ * newly created group, and then a single cache instance schema (e.g.
+ * "L2:1=0x3") to its "schemata" file, after which the group's mode becomes
+ * "pseudo-locked". The capacity bitmask must not overlap with the allocations
+ * of any other group (including the default one), and no tasks can be added
+ * to the group. The region is freed when the group is removed.
+ *
+ * For more information, see the "Cache Pseudo-Locking" section of
+ * https://www.kernel.org/doc/Documentation/x86/resctrl.rst
+ */
+
+const (
+	pseudoLockDevDir = "/dev/pseudo_lock"
+	pseudoLockSetup  = "pseudo-locksetup"
+	pseudoLocked     = "pseudo-locked"
+)
+
+// ValidatePseudoLockSchema checks that schema describes a pseudo-locked
+// region of a single instance of a cache which can be allocated.
+func ValidatePseudoLockSchema(schema string) error {
+	res, domain, ok := strings.Cut(schema, ":")
+	if !ok || (res != "L2" && res != "L3") {
+		return fmt.Errorf("invalid pseudo-lock schema %q: must be L2:<cache_id>=<cbm> or L3:<cache_id>=<cbm>", schema)
+	}
+	if id, cbm, ok := strings.Cut(domain, "="); !ok || id == "" || cbm == "" || strings.ContainsAny(cbm, "=;") {
+		return fmt.Errorf("invalid pseudo-lock schema %q: must refer to a single cache instance", schema)
+	}
+	root, err := Root()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(root, "info", res)); err != nil {
+		return fmt.Errorf("pseudo-lock schema %q is specified in config, but Intel RDT %s cache allocation is not enabled", schema, res)
+	}
+	return nil
+}
+
+// pseudoLockGroup returns the name of the resource group of the container's
+// pseudo-locked region, which is also the name of its device. The colon
+// makes sure it does not clash with the groups named after container IDs.
+func (m *Manager) pseudoLockGroup() string {
+	return "pseudo-lock:" + m.id
+}
+
+// setupPseudoLock creates the pseudo-locked region of the container, unless
+// it already exists.
+func (m *Manager) setupPseudoLock() (retErr error) {
+	rootPath, err := Root()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(rootPath, m.pseudoLockGroup())
+	if mode, err := getIntelRdtParamString(path, "mode"); err == nil && mode == pseudoLocked {
+		return nil
+	}
+	if err := os.Mkdir(path, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+		return newLastCmdError(err)
+	}
+	defer func() {
+		if retErr != nil {
+			_ = os.Remove(path)
+		}
+	}()
+	if err := writeFile(path, "mode", pseudoLockSetup); err != nil {
+		return err
+	}
+	if err := writeFile(path, "schemata", m.config.IntelRdt.PseudoLock.Schema); err != nil {
+		return err
+	}
+	mode, err := getIntelRdtParamString(path, "mode")
+	if err != nil {
+		return err
+	}
+	if mode != pseudoLocked {
+		return newLastCmdError(fmt.Errorf("intelrdt: unable to pseudo-lock %s: group mode is %q", path, mode))
+	}
+	return nil
+}
+
+// destroyPseudoLock frees the pseudo-locked region of the container.
+func (m *Manager) destroyPseudoLock() error {
+	rootPath, err := Root()
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(rootPath, m.pseudoLockGroup()))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// PseudoLockDevice returns the character device of the container's
+// pseudo-locked region, to be made available in the container, or nil if no
+// region is configured. It must be called after Apply.
+func (m *Manager) PseudoLockDevice() (*devices.Device, error) {
+	if m.config.IntelRdt == nil || m.config.IntelRdt.PseudoLock == nil {
+		return nil, nil
+	}
+	dev, err := devices.DeviceFromPath(filepath.Join(pseudoLockDevDir, m.pseudoLockGroup()), "rw")
+	if err != nil {
+		return nil, fmt.Errorf("intelrdt: unable to get pseudo-lock device: %w", err)
+	}
+	return dev, nil
+}
@@ -0,0 +1,36 @@
+package intelrdt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatePseudoLockSchema(t *testing.T) {
+	NewIntelRdtTestUtil(t)
+	if err := os.MkdirAll(filepath.Join(intelRdtRoot, "info", "L2"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		schema string
+		valid  bool
+	}{
+		{schema: "L2:1=0x3", valid: true},
+		{schema: "L2:0=f0", valid: true},
+		{schema: "L3:0=f0"}, // L3 allocation is not enabled.
+		{schema: "MB:0=20"},
+		{schema: "L2:0=f;1=f"},
+		{schema: "L2:0"},
+		{schema: "L2:=f"},
+		{schema: "0=f"},
+		{schema: ""},
+	} {
+		err := ValidatePseudoLockSchema(tc.schema)
+		if tc.valid && err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.schema, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("%q: expected error, got nil", tc.schema)
+		}
+	}
+}
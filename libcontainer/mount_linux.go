@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
@@ -123,6 +124,14 @@ func mountViaFds(source string, srcFile *mountSource, target, dstFd, fstype stri
 		}
 	}
 
+	if srcFile == nil && dst != "" && fsmountSupported(fstype, flags, data) {
+		err := fsmountViaFds(source, target, dst, dstFd, fstype, flags, data)
+		if !errors.Is(err, errFsmountUnsupported) {
+			return err
+		}
+		// Fall back to mount(2).
+	}
+
 	var op string
 	var err error
 	if isMoveMount {
@@ -149,6 +158,171 @@ func mountViaFds(source string, srcFile *mountSource, target, dstFd, fstype stri
 	return nil
 }
 
+// errFsmountUnsupported is returned by fsmountViaFds when the new mount API
+// can not be used, and the caller should fall back to mount(2).
+var errFsmountUnsupported = errors.New("new mount api is not supported")
+
+// fsmountAttrs maps mount(2) flags which fsmountViaFds is able to handle to
+// their fsmount(2) MOUNT_ATTR_* equivalents. Note that MS_RELATIME is the
+// default for fsmount(2), and MS_SILENT has no equivalent (it's a no-op).
+var fsmountAttrs = []struct {
+	flag uintptr
+	attr int
+}{
+	{unix.MS_RDONLY, unix.MOUNT_ATTR_RDONLY},
+	{unix.MS_NOSUID, unix.MOUNT_ATTR_NOSUID},
+	{unix.MS_NODEV, unix.MOUNT_ATTR_NODEV},
+	{unix.MS_NOEXEC, unix.MOUNT_ATTR_NOEXEC},
+	{unix.MS_NOATIME, unix.MOUNT_ATTR_NOATIME},
+	{unix.MS_NODIRATIME, unix.MOUNT_ATTR_NODIRATIME},
+	{unix.MS_STRICTATIME, unix.MOUNT_ATTR_STRICTATIME},
+	{unix.MS_RELATIME, unix.MOUNT_ATTR_RELATIME},
+	{unix.MS_SILENT, 0},
+}
+
+// fsmountSupported tells whether a mount with the given parameters can be
+// done by fsmountViaFds. Only the creation of a new filesystem instance is
+// supported (i.e. not bind mounts, remounts, moves, or propagation changes),
+// with the per-mount flags which have a MOUNT_ATTR_* equivalent.
+func fsmountSupported(fstype string, flags uintptr, data string) bool {
+	if fstype == "" || fstype == "bind" {
+		return false
+	}
+	for _, f := range fsmountAttrs {
+		flags &^= f.flag
+	}
+	// Escaped option separators are filesystem-specific, and can not be
+	// reliably converted to separate fsconfig(2) calls.
+	return flags == 0 && !strings.Contains(data, `\`)
+}
+
+// mountOption is a single mount(2) data option, either "key" or "key=value".
+type mountOption struct {
+	key, value string
+	hasValue   bool
+}
+
+// parseMountData splits mount(2) data into a list of options. Commas inside
+// double quotes (as used by SELinux context options) do not separate options,
+// and the quotes surrounding a value are removed.
+func parseMountData(data string) []mountOption {
+	var (
+		opts   []mountOption
+		quoted bool
+		start  int
+	)
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) {
+			if data[i] == '"' {
+				quoted = !quoted
+			}
+			if data[i] != ',' || quoted {
+				continue
+			}
+		}
+		if opt := data[start:i]; opt != "" {
+			key, val, ok := strings.Cut(opt, "=")
+			if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+				val = val[1 : len(val)-1]
+			}
+			opts = append(opts, mountOption{key: key, value: val, hasValue: ok})
+		}
+		start = i + 1
+	}
+	return opts
+}
+
+// fsLogMessages returns the messages logged by the kernel to the filesystem
+// context fd, which usually provide much more details about the failure than
+// the error number alone.
+func fsLogMessages(fsfd int) []string {
+	var msgs []string
+	buf := make([]byte, 4096)
+	for {
+		n, err := unix.Read(fsfd, buf)
+		if err != nil || n <= 0 {
+			return msgs
+		}
+		msgs = append(msgs, strings.TrimSpace(string(buf[:n])))
+	}
+}
+
+// fsmountViaFds creates a new filesystem instance using the new mount API
+// (fsopen(2), fsconfig(2), and fsmount(2)), and attaches the resulting
+// detached mount to dst using move_mount(2). Unlike mount(2), this reports
+// the filesystem's own error messages in case the configuration is rejected,
+// and the mount is fully set up before it becomes visible at dst.
+//
+// If the new mount API is not available, errFsmountUnsupported is returned.
+func fsmountViaFds(source, target, dst, dstFd, fstype string, flags uintptr, data string) error {
+	fsfd, err := unix.Fsopen(fstype, unix.FSOPEN_CLOEXEC)
+	if err != nil {
+		// ENOSYS is returned by older kernels, EPERM may be returned by
+		// seccomp filters which block the new mount API, and errors such
+		// as an unknown filesystem type are reported by mount(2) anyway.
+		logrus.Debugf("fsopen %s: %v, falling back to mount(2)", fstype, err)
+		return errFsmountUnsupported
+	}
+	defer unix.Close(fsfd)
+
+	newErr := func(op string, err error) error {
+		if msgs := fsLogMessages(fsfd); len(msgs) > 0 {
+			err = fmt.Errorf("%w (%s)", err, strings.Join(msgs, "; "))
+		}
+		return &mountError{
+			op:     op,
+			source: source,
+			target: target,
+			dstFd:  dstFd,
+			flags:  flags,
+			data:   data,
+			err:    err,
+		}
+	}
+
+	if source != "" {
+		if err := unix.FsconfigSetString(fsfd, "source", source); err != nil {
+			return newErr("fsconfig", fmt.Errorf("source=%s: %w", source, err))
+		}
+	}
+	if flags&unix.MS_RDONLY != 0 {
+		// Make the superblock read-only too, as mount(2) does.
+		if err := unix.FsconfigSetFlag(fsfd, "ro"); err != nil {
+			return newErr("fsconfig", fmt.Errorf("ro: %w", err))
+		}
+	}
+	for _, opt := range parseMountData(data) {
+		if opt.hasValue {
+			err = unix.FsconfigSetString(fsfd, opt.key, opt.value)
+		} else {
+			err = unix.FsconfigSetFlag(fsfd, opt.key)
+		}
+		if err != nil {
+			return newErr("fsconfig", fmt.Errorf("%s: %w", opt.key, err))
+		}
+	}
+	if err := unix.FsconfigCreate(fsfd); err != nil {
+		return newErr("fsconfig", fmt.Errorf("create: %w", err))
+	}
+
+	var attrs int
+	for _, f := range fsmountAttrs {
+		if flags&f.flag != 0 {
+			attrs |= f.attr
+		}
+	}
+	mntfd, err := unix.Fsmount(fsfd, unix.FSMOUNT_CLOEXEC, attrs)
+	if err != nil {
+		return newErr("fsmount", err)
+	}
+	defer unix.Close(mntfd)
+
+	if err := unix.MoveMount(mntfd, "", unix.AT_FDCWD, dst, unix.MOVE_MOUNT_F_EMPTY_PATH|unix.MOVE_MOUNT_T_SYMLINKS); err != nil {
+		return newErr("move_mount", err)
+	}
+	return nil
+}
+
 // unmount is a simple unix.Unmount wrapper.
 func unmount(target string, flags int) error {
 	err := unix.Unmount(target, flags)
@@ -239,7 +413,7 @@ func mountFd(nsHandles *userns.Handles, m *configs.Mount) (*mountSource, error)
 				return nil, fmt.Errorf("failed to create userns for %s id-mapping: %w", m.Source, err)
 			}
 		} else {
-			usernsFile, err = os.Open(m.IDMapping.UserNSPath)
+			usernsFile, err = configs.OpenNsPath(configs.NEWUSER, m.IDMapping.UserNSPath)
 			if err != nil {
 				return nil, fmt.Errorf("failed to open existing userns for %s id-mapping: %w", m.Source, err)
 			}
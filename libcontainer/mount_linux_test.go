@@ -0,0 +1,106 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseMountData(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		opts []mountOption
+	}{
+		{data: ""},
+		{
+			data: "mode=755,size=65536k",
+			opts: []mountOption{
+				{key: "mode", value: "755", hasValue: true},
+				{key: "size", value: "65536k", hasValue: true},
+			},
+		},
+		{
+			data: "newinstance,,ptmxmode=0666,opt=",
+			opts: []mountOption{
+				{key: "newinstance"},
+				{key: "ptmxmode", value: "0666", hasValue: true},
+				{key: "opt", hasValue: true},
+			},
+		},
+		{
+			data: `nr_inodes=10,context="system_u:object_r:container_file_t:s0:c1,c2"`,
+			opts: []mountOption{
+				{key: "nr_inodes", value: "10", hasValue: true},
+				{key: "context", value: "system_u:object_r:container_file_t:s0:c1,c2", hasValue: true},
+			},
+		},
+	} {
+		opts := parseMountData(tc.data)
+		if !reflect.DeepEqual(opts, tc.opts) {
+			t.Errorf("%q: expected %+v, got %+v", tc.data, tc.opts, opts)
+		}
+	}
+}
+
+func TestFsmountSupported(t *testing.T) {
+	for _, tc := range []struct {
+		fstype string
+		flags  uintptr
+		data   string
+		ok     bool
+	}{
+		{"tmpfs", unix.MS_NOSUID | unix.MS_NODEV | unix.MS_RDONLY, "mode=755", true},
+		{"proc", unix.MS_NOSUID | unix.MS_NOEXEC | unix.MS_NODEV, "", true},
+		{"", 0, "", false},
+		{"bind", unix.MS_BIND, "", false},
+		{"tmpfs", unix.MS_REMOUNT, "", false},
+		{"tmpfs", unix.MS_MOVE, "", false},
+		{"tmpfs", unix.MS_SYNCHRONOUS, "", false},
+		{"overlay", 0, `lowerdir=/a\,b`, false},
+	} {
+		if ok := fsmountSupported(tc.fstype, tc.flags, tc.data); ok != tc.ok {
+			t.Errorf("%s flags=0x%x data=%q: expected %v, got %v", tc.fstype, tc.flags, tc.data, tc.ok, ok)
+		}
+	}
+}
+
+func TestFsmountViaFds(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	dir := t.TempDir()
+
+	err := fsmountViaFds("tmpfs", dir, dir, "", "tmpfs", unix.MS_NOSUID|unix.MS_RDONLY, "size=1m,mode=700")
+	if errors.Is(err, errFsmountUnsupported) {
+		t.Skip("new mount api is not supported")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Unmount(dir, unix.MNT_DETACH) //nolint:errcheck
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Type != unix.TMPFS_MAGIC {
+		t.Fatalf("expected tmpfs, got 0x%x", st.Type)
+	}
+	if st.Flags&unix.ST_RDONLY == 0 || st.Flags&unix.ST_NOSUID == 0 {
+		t.Fatalf("expected ro,nosuid mount, got flags 0x%x", st.Flags)
+	}
+
+	// A bad option should result in an error with the kernel's message.
+	err = fsmountViaFds("tmpfs", dir, dir, "", "tmpfs", 0, "size=bogus")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "fsconfig") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Log(err)
+}
@@ -0,0 +1,305 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// mountPropagation is the mount propagation type, one of MS_SHARED,
+// MS_SLAVE, MS_PRIVATE, or MS_UNBINDABLE.
+type mountPropagation int
+
+func (p mountPropagation) String() string {
+	switch p {
+	case unix.MS_SHARED:
+		return "shared"
+	case unix.MS_SLAVE:
+		return "slave"
+	case unix.MS_PRIVATE:
+		return "private"
+	case unix.MS_UNBINDABLE:
+		return "unbindable"
+	}
+	return fmt.Sprintf("unknown (0x%x)", int(p))
+}
+
+// mountState is the actual state of a mount, as reported by the kernel.
+type mountState struct {
+	readonly bool
+	// propagation holds MS_SHARED and/or MS_SLAVE (a mount can be both,
+	// i.e. a slave of a peer group while being shared itself), or
+	// MS_PRIVATE, or MS_UNBINDABLE.
+	propagation int
+}
+
+// statmount(2) request and reply, from <linux/mount.h>. Only the fields
+// returned for STATMOUNT_MNT_BASIC are used.
+type mntIDReq struct {
+	size  uint32
+	spare uint32
+	mntID uint64
+	param uint64
+}
+
+type statmountBasic struct {
+	size           uint32
+	mntOpts        uint32
+	mask           uint64
+	sbDevMajor     uint32
+	sbDevMinor     uint32
+	sbMagic        uint64
+	sbFlags        uint32
+	fsType         uint32
+	mntID          uint64
+	mntParentID    uint64
+	mntIDOld       uint32
+	mntParentIDOld uint32
+	mntAttr        uint64
+	mntPropagation uint64
+	mntPeerGroup   uint64
+	mntMaster      uint64
+	propagateFrom  uint64
+	mntRoot        uint32
+	mntPoint       uint32
+	spare          [50]uint64
+}
+
+const statmountMntBasic = 0x2 // STATMOUNT_MNT_BASIC
+
+// errStatmountUnsupported is returned by statmountState when either
+// statmount(2) or STATX_MNT_ID_UNIQUE is not supported (Linux < 6.8).
+var errStatmountUnsupported = errors.New("statmount is not supported")
+
+// statmountState returns the state of the mount on which path resides,
+// using statx(2) and statmount(2).
+func statmountState(path string) (*mountState, error) {
+	var stx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, path, 0, unix.STATX_MNT_ID_UNIQUE, &stx); err != nil {
+		return nil, &os.PathError{Op: "statx", Path: path, Err: err}
+	}
+	if stx.Mask&unix.STATX_MNT_ID_UNIQUE == 0 {
+		return nil, errStatmountUnsupported
+	}
+	req := mntIDReq{
+		size:  uint32(unsafe.Sizeof(mntIDReq{})),
+		mntID: stx.Mnt_id,
+		param: statmountMntBasic,
+	}
+	var sm statmountBasic
+	_, _, errno := unix.Syscall6(unix.SYS_STATMOUNT,
+		uintptr(unsafe.Pointer(&req)), uintptr(unsafe.Pointer(&sm)), unsafe.Sizeof(sm), 0, 0, 0)
+	if errno != 0 {
+		if errno == unix.ENOSYS {
+			return nil, errStatmountUnsupported
+		}
+		return nil, &os.SyscallError{Syscall: "statmount", Err: errno}
+	}
+	return &mountState{
+		readonly:    sm.mntAttr&unix.MOUNT_ATTR_RDONLY != 0,
+		propagation: int(sm.mntPropagation),
+	}, nil
+}
+
+// mountinfoState returns the state of the topmost mount on path, using
+// /proc/self/mountinfo. It is used when statmount(2) is not available.
+func mountinfoState(path string) (*mountState, error) {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := mountinfo.GetMounts(mountinfo.SingleEntryFilter(path))
+	if err != nil {
+		return nil, err
+	}
+	if len(mounts) == 0 {
+		return nil, fmt.Errorf("%s is not a mount point", path)
+	}
+	// The last entry is the topmost mount.
+	mi := mounts[len(mounts)-1]
+	st := &mountState{propagation: unix.MS_PRIVATE}
+	for _, o := range strings.Split(mi.Options, ",") {
+		if o == "ro" {
+			st.readonly = true
+		}
+	}
+	var prop int
+	for _, o := range strings.Fields(mi.Optional) {
+		switch {
+		case strings.HasPrefix(o, "shared:"):
+			prop |= unix.MS_SHARED
+		case strings.HasPrefix(o, "master:"):
+			prop |= unix.MS_SLAVE
+		case o == "unbindable":
+			prop |= unix.MS_UNBINDABLE
+		}
+	}
+	if prop != 0 {
+		st.propagation = prop
+	}
+	return st, nil
+}
+
+func getMountState(path string) (*mountState, error) {
+	st, err := statmountState(path)
+	if errors.Is(err, errStatmountUnsupported) {
+		return mountinfoState(path)
+	}
+	return st, err
+}
+
+// expectedMountState returns the mount state which is expected from the
+// configuration of m. The returned readonly and propagation pointers are nil
+// if the configuration does not require any particular value.
+func expectedMountState(m *configs.Mount) (readonly *bool, propagation *int) {
+	// A mount is only expected to be read-write if "rw" (or "rrw") is set
+	// explicitly, as otherwise the kernel may legitimately make it
+	// read-only (for example, a bind mount keeps the flags of the source).
+	ro, rw := m.Flags&unix.MS_RDONLY != 0, m.ClearedFlags&unix.MS_RDONLY != 0
+	if m.RecAttr != nil {
+		if m.RecAttr.Attr_set&unix.MOUNT_ATTR_RDONLY != 0 {
+			ro, rw = true, false
+		} else if m.RecAttr.Attr_clr&unix.MOUNT_ATTR_RDONLY != 0 {
+			ro, rw = false, true
+		}
+	}
+	if ro || rw {
+		readonly = &ro
+	}
+	if n := len(m.PropagationFlags); n > 0 {
+		p := m.PropagationFlags[n-1] &^ unix.MS_REC
+		propagation = &p
+	}
+	return readonly, propagation
+}
+
+// propagationMatches tells whether the actual propagation type matches the
+// requested one. Note that making a non-shared mount a slave results in a
+// private mount (see mount_namespaces(7)).
+func propagationMatches(requested, actual int) bool {
+	switch requested {
+	case unix.MS_SHARED:
+		return actual&unix.MS_SHARED != 0
+	case unix.MS_SLAVE:
+		return actual&unix.MS_SHARED == 0
+	case unix.MS_PRIVATE:
+		return actual == unix.MS_PRIVATE
+	case unix.MS_UNBINDABLE:
+		return actual == unix.MS_UNBINDABLE
+	}
+	return true
+}
+
+// mountStateDiff returns a description of the differences between the
+// expected and the actual mount state, or an empty string if they match.
+func mountStateDiff(readonly *bool, propagation *int, st *mountState) string {
+	var diff []string
+	if readonly != nil && *readonly != st.readonly {
+		exp, got := "rw", "ro"
+		if *readonly {
+			exp, got = got, exp
+		}
+		diff = append(diff, "expected "+exp+", got "+got)
+	}
+	if propagation != nil && !propagationMatches(*propagation, st.propagation) {
+		var got []string
+		for _, p := range []int{unix.MS_SHARED, unix.MS_SLAVE, unix.MS_PRIVATE, unix.MS_UNBINDABLE} {
+			if st.propagation&p != 0 {
+				got = append(got, mountPropagation(p).String())
+			}
+		}
+		diff = append(diff, "expected "+mountPropagation(*propagation).String()+" propagation, got "+strings.Join(got, "+"))
+	}
+	return strings.Join(diff, "; ")
+}
+
+// remediateMount tries to bring the mount m to the expected state.
+func remediateMount(m *configs.Mount, readonly *bool, propagation *int) error {
+	return utils.WithProcfd("/", m.Destination, func(dstFd string) error {
+		if readonly != nil {
+			var attr unix.MountAttr
+			if *readonly {
+				attr.Attr_set = unix.MOUNT_ATTR_RDONLY
+			} else {
+				attr.Attr_clr = unix.MOUNT_ATTR_RDONLY
+			}
+			if err := unix.MountSetattr(-1, dstFd, 0, &attr); err != nil {
+				return &os.PathError{Op: "mount_setattr", Path: m.Destination, Err: err}
+			}
+		}
+		if propagation != nil {
+			// Use the flag as configured, as it may have MS_REC set.
+			pflag := m.PropagationFlags[len(m.PropagationFlags)-1]
+			if err := mountViaFds("", nil, m.Destination, dstFd, "", uintptr(pflag), ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// verifyMounts checks that the read-only status and the propagation type of
+// every mount in the container match the configuration. This catches the
+// cases in which the kernel silently ignores the requested flags (for
+// example, when a recursive bind mount contains locked submounts). On a
+// mismatch, the mount is fixed up once, and an error describing the
+// difference is returned if it still does not match.
+//
+// It must be called after pivot_root(2).
+func verifyMounts(config *configs.Config) error {
+	for i, m := range config.Mounts {
+		// cgroup v1 mounts expand into a tmpfs with submounts.
+		if m.Device == "cgroup" {
+			continue
+		}
+		if mountIsCovered(config.Mounts[i+1:], m.Destination) {
+			continue
+		}
+		readonly, propagation := expectedMountState(m)
+		if readonly == nil && propagation == nil {
+			continue
+		}
+		st, err := getMountState(m.Destination)
+		if err != nil {
+			return fmt.Errorf("unable to verify mount %s: %w", m.Destination, err)
+		}
+		diff := mountStateDiff(readonly, propagation, st)
+		if diff == "" {
+			continue
+		}
+		logrus.Debugf("mount %s: %s, fixing up", m.Destination, diff)
+		if err := remediateMount(m, readonly, propagation); err != nil {
+			return fmt.Errorf("mount %s: %s (fix up failed: %w)", m.Destination, diff, err)
+		}
+		if st, err = getMountState(m.Destination); err != nil {
+			return fmt.Errorf("unable to verify mount %s: %w", m.Destination, err)
+		}
+		if diff := mountStateDiff(readonly, propagation, st); diff != "" {
+			return fmt.Errorf("mount %s: %s", m.Destination, diff)
+		}
+	}
+	return nil
+}
+
+// mountIsCovered tells whether dest is (or is inside) a destination of one
+// of the mounts.
+func mountIsCovered(mounts []*configs.Mount, dest string) bool {
+	dest = utils.CleanPath(dest)
+	for _, m := range mounts {
+		d := utils.CleanPath(m.Destination)
+		if d == dest || d == "/" || strings.HasPrefix(dest, d+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package libcontainer
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestExpectedMountState(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		mount       configs.Mount
+		readonly    *bool
+		propagation int
+	}{
+		{
+			name:  "bind, no flags",
+			mount: configs.Mount{Device: "bind", Flags: unix.MS_BIND | unix.MS_REC},
+		},
+		{
+			name:     "ro",
+			mount:    configs.Mount{Device: "tmpfs", Flags: unix.MS_RDONLY},
+			readonly: &[]bool{true}[0],
+		},
+		{
+			name:     "rw",
+			mount:    configs.Mount{Device: "bind", Flags: unix.MS_BIND, ClearedFlags: unix.MS_RDONLY},
+			readonly: &[]bool{false}[0],
+		},
+		{
+			name:     "rro",
+			mount:    configs.Mount{Device: "bind", Flags: unix.MS_BIND, RecAttr: &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}},
+			readonly: &[]bool{true}[0],
+		},
+		{
+			name:        "rslave,rshared",
+			mount:       configs.Mount{Device: "bind", Flags: unix.MS_BIND, PropagationFlags: []int{unix.MS_REC | unix.MS_SLAVE, unix.MS_REC | unix.MS_SHARED}},
+			propagation: unix.MS_SHARED,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			readonly, propagation := expectedMountState(&tc.mount)
+			if (readonly == nil) != (tc.readonly == nil) || (readonly != nil && *readonly != *tc.readonly) {
+				t.Errorf("unexpected readonly: %v", readonly)
+			}
+			if tc.propagation == 0 {
+				if propagation != nil {
+					t.Errorf("expected no propagation, got %v", *propagation)
+				}
+			} else if propagation == nil || *propagation != tc.propagation {
+				t.Errorf("expected propagation %v, got %v", tc.propagation, propagation)
+			}
+		})
+	}
+}
+
+func TestMountStateDiff(t *testing.T) {
+	ro, shared, slave := true, unix.MS_SHARED, unix.MS_SLAVE
+
+	st := &mountState{readonly: true, propagation: unix.MS_SHARED | unix.MS_SLAVE}
+	if diff := mountStateDiff(&ro, &shared, st); diff != "" {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+	// A mount which is both a slave and shared is not a pure slave.
+	if diff := mountStateDiff(nil, &slave, st); diff != "expected slave propagation, got shared+slave" {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+	// Making a private mount a slave leaves it private.
+	st = &mountState{propagation: unix.MS_PRIVATE}
+	if diff := mountStateDiff(nil, &slave, st); diff != "" {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+	if diff := mountStateDiff(&ro, &shared, st); diff != "expected ro, got rw; expected shared propagation, got private" {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+}
+
+func TestGetMountState(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	dir := t.TempDir()
+	if err := unix.Mount("tmpfs", dir, "tmpfs", unix.MS_RDONLY, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Unmount(dir, unix.MNT_DETACH) //nolint:errcheck
+	if err := unix.Mount("", dir, "", unix.MS_PRIVATE, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, fn := range map[string]func(string) (*mountState, error){
+		"statmount": statmountState,
+		"mountinfo": mountinfoState,
+	} {
+		st, err := fn(dir)
+		if err == errStatmountUnsupported {
+			t.Logf("%s: %v", name, err)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !st.readonly || st.propagation != unix.MS_PRIVATE {
+			t.Errorf("%s: expected ro private mount, got %+v", name, st)
+		}
+	}
+}
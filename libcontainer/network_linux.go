@@ -2,18 +2,24 @@ package libcontainer
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/types"
+	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 )
 
 var strategies = map[string]networkStrategy{
 	"loopback": &loopback{},
+	"netdev":   &netdev{},
 }
 
 // networkStrategy represents a specific network configuration for
@@ -23,6 +29,7 @@ type networkStrategy interface {
 	initialize(*network) error
 	detach(*configs.Network) error
 	attach(*configs.Network) error
+	release(*configs.Network) error
 }
 
 // getStrategy returns the specific network strategy for the
@@ -35,6 +42,21 @@ func getStrategy(tpe string) (networkStrategy, error) {
 	return s, nil
 }
 
+// releaseNetworkInterfaces releases the network resources held by the
+// container once its network namespace is gone.
+func releaseNetworkInterfaces(config *configs.Config) error {
+	for _, config := range config.Networks {
+		strategy, err := getStrategy(config.Type)
+		if err != nil {
+			return err
+		}
+		if err := strategy.release(config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Returns the network statistics for the network interfaces represented by the NetworkRuntimeInfo.
 func getNetworkInterfaceStats(interfaceName string) (*types.NetworkInterface, error) {
 	out := &types.NetworkInterface{Name: interfaceName}
@@ -98,3 +120,231 @@ func (l *loopback) attach(n *configs.Network) (err error) {
 func (l *loopback) detach(n *configs.Network) (err error) {
 	return nil
 }
+
+func (l *loopback) release(n *configs.Network) (err error) {
+	return nil
+}
+
+// netdev is a network strategy that moves an existing host interface
+// (such as a macvlan device or an SR-IOV VF) into the container.
+type netdev struct{}
+
+func (d *netdev) create(n *network, nspid int) error {
+	link, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return fmt.Errorf("unable to find host interface %q: %w", n.HostInterfaceName, err)
+	}
+	if n.VirtualFunction != nil {
+		if err := setupVirtualFunction(n.HostInterfaceName, n.VirtualFunction); err != nil {
+			return err
+		}
+	}
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	// This sends RTM_NEWLINK with IFLA_NET_NS_FD.
+	if err := netlink.LinkSetNsFd(link, int(ns.Fd())); err != nil {
+		return fmt.Errorf("unable to move interface %q into container: %w", n.HostInterfaceName, err)
+	}
+	n.HostInterfaceIndex = link.Attrs().Index
+	return nil
+}
+
+func (d *netdev) initialize(n *network) error {
+	link, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	if n.Name != n.HostInterfaceName {
+		if err := netlink.LinkSetName(link, n.Name); err != nil {
+			return fmt.Errorf("unable to rename interface %q to %q: %w", n.HostInterfaceName, n.Name, err)
+		}
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetHardwareAddr(link, mac); err != nil {
+			return err
+		}
+	}
+	if n.Mtu != 0 {
+		if err := netlink.LinkSetMTU(link, n.Mtu); err != nil {
+			return err
+		}
+	}
+	if n.TxQueueLen != 0 {
+		if err := netlink.LinkSetTxQLen(link, n.TxQueueLen); err != nil {
+			return err
+		}
+	}
+	if err := netlink.LinkSetUp(link); err != nil {
+		return err
+	}
+	return setupStaticAddresses(link, &n.Network)
+}
+
+// setupStaticAddresses assigns the static IPv4 and IPv6 addresses of n to
+// the link, and adds default routes through the configured gateways.
+func setupStaticAddresses(link netlink.Link, n *configs.Network) error {
+	for _, a := range []struct{ addr, gw string }{
+		{n.Address, n.Gateway},
+		{n.IPv6Address, n.IPv6Gateway},
+	} {
+		if a.addr != "" {
+			addr, err := netlink.ParseAddr(a.addr)
+			if err != nil {
+				return err
+			}
+			if err := netlink.AddrAdd(link, addr); err != nil {
+				return fmt.Errorf("unable to add address %s to %q: %w", a.addr, n.Name, err)
+			}
+		}
+		if a.gw != "" {
+			gw := net.ParseIP(a.gw)
+			if gw == nil {
+				return fmt.Errorf("invalid gateway %q for %q", a.gw, n.Name)
+			}
+			route := &netlink.Route{
+				Scope:     netlink.SCOPE_UNIVERSE,
+				LinkIndex: link.Attrs().Index,
+				Gw:        gw,
+			}
+			if err := netlink.RouteAdd(route); err != nil {
+				return fmt.Errorf("unable to add default route via %s: %w", a.gw, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Can be changed by unit tests.
+var sysClassNet = "/sys/class/net"
+
+// virtualFunctionOf returns the name of the physical function interface and
+// the VF index of the SR-IOV virtual function interface ifname.
+func virtualFunctionOf(ifname string) (string, int, error) {
+	dev := filepath.Join(sysClassNet, ifname, "device")
+	vfPCI, err := filepath.EvalSymlinks(dev)
+	if err != nil {
+		return "", 0, err
+	}
+	pfNets, err := os.ReadDir(filepath.Join(dev, "physfn", "net"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", 0, fmt.Errorf("interface %q is not an SR-IOV virtual function", ifname)
+		}
+		return "", 0, err
+	}
+	if len(pfNets) == 0 {
+		return "", 0, fmt.Errorf("no physical function interface found for %q", ifname)
+	}
+	pf := pfNets[0].Name()
+
+	virtfns, err := filepath.Glob(filepath.Join(dev, "physfn", "virtfn*"))
+	if err != nil {
+		return "", 0, err
+	}
+	for _, v := range virtfns {
+		p, err := filepath.EvalSymlinks(v)
+		if err != nil || p != vfPCI {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(v), "virtfn"))
+		if err != nil {
+			continue
+		}
+		return pf, idx, nil
+	}
+	return "", 0, fmt.Errorf("unable to find VF index of %q on %q", ifname, pf)
+}
+
+// setupVirtualFunction applies the SR-IOV VF settings through the physical
+// function of the interface ifname.
+func setupVirtualFunction(ifname string, vf *configs.VirtualFunction) error {
+	pfName, idx, err := virtualFunctionOf(ifname)
+	if err != nil {
+		return err
+	}
+	pf, err := netlink.LinkByName(pfName)
+	if err != nil {
+		return err
+	}
+	if vf.Vlan != 0 || vf.Qos != 0 {
+		if err := netlink.LinkSetVfVlanQos(pf, idx, vf.Vlan, vf.Qos); err != nil {
+			return fmt.Errorf("unable to set vlan of %q (%s vf %d): %w", ifname, pfName, idx, err)
+		}
+	}
+	if vf.MinTxRate != 0 || vf.MaxTxRate != 0 {
+		if err := netlink.LinkSetVfRate(pf, idx, vf.MinTxRate, vf.MaxTxRate); err != nil {
+			return fmt.Errorf("unable to set tx rate of %q (%s vf %d): %w", ifname, pfName, idx, err)
+		}
+	}
+	if vf.SpoofCheck != nil {
+		if err := netlink.LinkSetVfSpoofchk(pf, idx, *vf.SpoofCheck); err != nil {
+			return fmt.Errorf("unable to set spoof checking of %q (%s vf %d): %w", ifname, pfName, idx, err)
+		}
+	}
+	if vf.Trust != nil {
+		if err := netlink.LinkSetVfTrust(pf, idx, *vf.Trust); err != nil {
+			return fmt.Errorf("unable to set trust of %q (%s vf %d): %w", ifname, pfName, idx, err)
+		}
+	}
+	return nil
+}
+
+func (d *netdev) attach(n *configs.Network) (err error) {
+	return nil
+}
+
+func (d *netdev) detach(n *configs.Network) (err error) {
+	return nil
+}
+
+// release restores the original name of an interface returned to the host.
+//
+// Once the container's network namespace is destroyed, the kernel moves
+// physical interfaces back to the initial network namespace, keeping their
+// index and the in-container name (or devN if that name is taken). Virtual
+// interfaces, such as macvlan, are deleted instead.
+func (d *netdev) release(n *configs.Network) error {
+	if n.HostInterfaceIndex == 0 {
+		return nil
+	}
+	// The namespace is cleaned up asynchronously, so give the kernel some
+	// time to return the interface.
+	var (
+		link netlink.Link
+		err  error
+	)
+	for i := 0; i < 100; i++ {
+		link, err = netlink.LinkByIndex(n.HostInterfaceIndex)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		logrus.Debugf("interface %q was not returned to the host: %v", n.HostInterfaceName, err)
+		return nil
+	}
+	name := link.Attrs().Name
+	if name == n.HostInterfaceName {
+		return nil
+	}
+	// Make sure the index was not reused by an unrelated interface.
+	if name != n.Name && !strings.HasPrefix(name, "dev") {
+		logrus.Warnf("not restoring interface %q: index %d now belongs to %q", n.HostInterfaceName, n.HostInterfaceIndex, name)
+		return nil
+	}
+	if err := netlink.LinkSetDown(link); err != nil {
+		return err
+	}
+	if err := netlink.LinkSetName(link, n.HostInterfaceName); err != nil {
+		return fmt.Errorf("unable to restore interface name %q: %w", n.HostInterfaceName, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVirtualFunctionOf(t *testing.T) {
+	root := t.TempDir()
+	pci := filepath.Join(root, "devices")
+	pf := filepath.Join(pci, "0000:01:00.0")
+	for _, d := range []string{
+		filepath.Join(pf, "net", "enp1s0f0"),
+		filepath.Join(pci, "0000:01:00.1"),
+		filepath.Join(pci, "0000:01:00.2"),
+	} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(pf, "virtfn0"):                       filepath.Join(pci, "0000:01:00.1"),
+		filepath.Join(pf, "virtfn1"):                       filepath.Join(pci, "0000:01:00.2"),
+		filepath.Join(pci, "0000:01:00.1", "physfn"):       pf,
+		filepath.Join(pci, "0000:01:00.2", "physfn"):       pf,
+		filepath.Join(root, "net", "enp1s0f0v0", "device"): filepath.Join(pci, "0000:01:00.1"),
+		filepath.Join(root, "net", "enp1s0f0v1", "device"): filepath.Join(pci, "0000:01:00.2"),
+		filepath.Join(root, "net", "enp1s0f0", "device"):   pf,
+	}
+	for l, target := range links {
+		if err := os.MkdirAll(filepath.Dir(l), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(old string) { sysClassNet = old }(sysClassNet)
+	sysClassNet = filepath.Join(root, "net")
+
+	for _, tc := range []struct {
+		ifname string
+		idx    int
+	}{
+		{"enp1s0f0v0", 0},
+		{"enp1s0f0v1", 1},
+	} {
+		pfName, idx, err := virtualFunctionOf(tc.ifname)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.ifname, err)
+		}
+		if pfName != "enp1s0f0" || idx != tc.idx {
+			t.Errorf("%s: expected enp1s0f0 vf %d, got %s vf %d", tc.ifname, tc.idx, pfName, idx)
+		}
+	}
+
+	for _, ifname := range []string{"enp1s0f0", "missing"} {
+		if _, _, err := virtualFunctionOf(ifname); err == nil {
+			t.Errorf("%s: expected error", ifname)
+		}
+	}
+}
@@ -480,9 +480,19 @@ void join_namespaces(char *nslist)
 			bail("failed to parse %s", namespace);
 		*path++ = '\0';
 
-		fd = open(path, O_RDONLY);
-		if (fd < 0)
-			bail("failed to open %s", path);
+		if (!strncmp(path, "pidfd:", strlen("pidfd:"))) {
+			/*
+			 * A pidfd passed to us by runc. It may be used for several
+			 * namespaces, and is closed after setns, so use a copy.
+			 */
+			fd = dup(atoi(path + strlen("pidfd:")));
+			if (fd < 0)
+				bail("failed to dup %s", path);
+		} else {
+			fd = open(path, O_RDONLY);
+			if (fd < 0)
+				bail("failed to open %s", path);
+		}
 
 		ns->fd = fd;
 		strncpy(ns->type, namespace, PATH_MAX - 1);
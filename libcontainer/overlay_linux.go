@@ -0,0 +1,49 @@
+package libcontainer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// overlayAutoDir is the directory, relative to the container state
+// directory, under which the upper and work directories of overlay-auto
+// mounts are created.
+const overlayAutoDir = "overlay"
+
+// setupOverlayAutoMounts creates the upper and work directories for every
+// overlay mount with the EXT_OVERLAY_AUTO extension under the container
+// state directory, and adds them to the mount options. The directories are
+// owned by the container's root user, and are removed together with the
+// state directory when the container is destroyed.
+func setupOverlayAutoMounts(stateDir string, config *configs.Config) error {
+	for i, m := range config.Mounts {
+		if m.Extensions&configs.EXT_OVERLAY_AUTO == 0 {
+			continue
+		}
+		rootuid, err := config.HostRootUID()
+		if err != nil {
+			return err
+		}
+		rootgid, err := config.HostRootGID()
+		if err != nil {
+			return err
+		}
+		dir := filepath.Join(stateDir, overlayAutoDir, strconv.Itoa(i))
+		upper := filepath.Join(dir, "upper")
+		work := filepath.Join(dir, "work")
+		for _, d := range []string{upper, work} {
+			if err := os.MkdirAll(d, 0o755); err != nil {
+				return fmt.Errorf("overlay-auto: %w", err)
+			}
+			if err := os.Chown(d, rootuid, rootgid); err != nil {
+				return fmt.Errorf("overlay-auto: %w", err)
+			}
+		}
+		m.Data += ",upperdir=" + upper + ",workdir=" + work
+	}
+	return nil
+}
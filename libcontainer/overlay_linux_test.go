@@ -0,0 +1,42 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestSetupOverlayAutoMounts(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	stateDir := t.TempDir()
+	config := &configs.Config{
+		Mounts: []*configs.Mount{
+			{Device: "tmpfs", Destination: "/tmp", Data: "mode=1777"},
+			{Device: "overlay", Destination: "/data", Data: "lowerdir=/a:/b", Extensions: configs.EXT_OVERLAY_AUTO},
+		},
+	}
+	if err := setupOverlayAutoMounts(stateDir, config); err != nil {
+		t.Fatal(err)
+	}
+
+	if d := config.Mounts[0].Data; d != "mode=1777" {
+		t.Errorf("unexpected tmpfs mount data %q", d)
+	}
+	upper := filepath.Join(stateDir, overlayAutoDir, "1", "upper")
+	work := filepath.Join(stateDir, overlayAutoDir, "1", "work")
+	exp := "lowerdir=/a:/b,upperdir=" + upper + ",workdir=" + work
+	if d := config.Mounts[1].Data; d != exp {
+		t.Errorf("expected overlay mount data %q, got %q", exp, d)
+	}
+	for _, d := range []string{upper, work} {
+		if fi, err := os.Stat(d); err != nil {
+			t.Error(err)
+		} else if !fi.IsDir() {
+			t.Errorf("%s is not a directory", d)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/system"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// openPidfd returns a pidfd referring to the process with the given pid.
+// If startTime is non-zero, it is checked against the start time of the
+// process after the pidfd is obtained, so the returned pidfd is guaranteed
+// to refer to the expected process rather than to a recycled pid.
+//
+// If the kernel does not support pidfd_open(2) (Linux < 5.3), nil is
+// returned with no error, and callers are expected to fall back to pid-based
+// operations.
+func openPidfd(pid int, startTime uint64) (*os.File, error) {
+	fd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		if errors.Is(err, unix.ENOSYS) {
+			return nil, nil
+		}
+		return nil, &os.SyscallError{Syscall: "pidfd_open", Err: err}
+	}
+	pidfd := os.NewFile(uintptr(fd), "pidfd:"+strconv.Itoa(pid))
+	if startTime != 0 {
+		stat, err := system.Stat(pid)
+		if err == nil && stat.StartTime != startTime {
+			err = fmt.Errorf("start time mismatch (%d != %d)", stat.StartTime, startTime)
+		}
+		if err != nil {
+			pidfd.Close()
+			return nil, fmt.Errorf("pid %d: %w: %w", pid, unix.ESRCH, err)
+		}
+	}
+	return pidfd, nil
+}
+
+// pidfdSignal sends a signal to a process, using pidfd if available, or the
+// pid otherwise.
+func pidfdSignal(pidfd *os.File, pid int, sig os.Signal) error {
+	s, ok := sig.(unix.Signal)
+	if !ok {
+		return errors.New("os: unsupported signal type")
+	}
+	if pidfd != nil {
+		err := unix.PidfdSendSignal(int(pidfd.Fd()), s, nil, 0)
+		if !errors.Is(err, unix.ENOSYS) {
+			return err
+		}
+	}
+	return unix.Kill(pid, s)
+}
+
+// childPidfd returns a pidfd for a child process which has not yet been
+// waited for, and thus whose pid can not be reused. Errors are logged and
+// a nil pidfd is returned, in which case signals are sent using the pid.
+func childPidfd(pid int) *os.File {
+	pidfd, err := openPidfd(pid, 0)
+	if err != nil {
+		logrus.WithError(err).Debugf("unable to open pidfd for pid %d", pid)
+	}
+	return pidfd
+}
+
+// closePidfd closes the pidfd pointed to by pidfd (if any), and sets it to
+// nil.
+func closePidfd(pidfd **os.File) {
+	if *pidfd != nil {
+		_ = (*pidfd).Close()
+		*pidfd = nil
+	}
+}
@@ -0,0 +1,51 @@
+package libcontainer
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/system"
+	"golang.org/x/sys/unix"
+)
+
+func TestPidfdSignal(t *testing.T) {
+	cmd := exec.Command("sleep", "1h")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("unable to start sleep: %v", err)
+	}
+	defer func() { _ = cmd.Process.Kill() }()
+	pid := cmd.Process.Pid
+
+	stat, err := system.Stat(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wrong start time means the pid has been reused.
+	if _, err := openPidfd(pid, stat.StartTime+1); !errors.Is(err, unix.ESRCH) {
+		t.Fatalf("expected ESRCH, got %v", err)
+	}
+
+	pidfd, err := openPidfd(pid, stat.StartTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pidfd == nil {
+		t.Skip("pidfd_open(2) is not supported")
+	}
+	defer closePidfd(&pidfd)
+
+	if err := pidfdSignal(pidfd, pid, unix.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	state, _ := cmd.Process.Wait()
+	if ws, ok := state.Sys().(syscall.WaitStatus); !ok || ws.Signal() != unix.SIGKILL {
+		t.Fatalf("expected process to be killed by SIGKILL, got %v", state)
+	}
+	// The process is now reaped; the pidfd must not signal anything else.
+	if err := pidfdSignal(pidfd, pid, unix.Signal(0)); !errors.Is(err, unix.ESRCH) {
+		t.Fatalf("expected ESRCH, got %v", err)
+	}
+}
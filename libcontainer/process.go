@@ -26,6 +26,21 @@ type Process struct {
 	// Env specifies the environment variables for the process.
 	Env []string
 
+	// EnvFiles are the paths of files with more environment variables for
+	// the process, one KEY=VALUE per line (empty lines and lines starting
+	// with # are ignored). They are read when the process is started,
+	// and added after Env, so that secrets are not kept in the container
+	// configuration.
+	EnvFiles []string
+
+	// EnvFds are file descriptors to read more environment variables
+	// from, in the same format as EnvFiles, after them. They are read
+	// to the end when the process is started, and closed.
+	EnvFds []int
+
+	// env is the environment read from EnvFiles and EnvFds.
+	env []string
+
 	// User will set the uid and gid of the executing process running inside the container
 	// local to the container's user and group configuration.
 	User string
@@ -99,9 +114,18 @@ type Process struct {
 	// For cgroup v2, the only key allowed is "".
 	SubCgroupPaths map[string]string
 
+	// RtSubCgroup, if set, runs the process in the real-time sub-cgroup of
+	// the container (see configs.Cgroup.RtSubCgroup). It can't be used
+	// with SubCgroupPaths.
+	RtSubCgroup bool
+
 	Scheduler *configs.Scheduler
 
 	IOPriority *configs.IOPriority
+
+	// TimerSlack, if not zero, overrides the timer slack of the container
+	// (see configs.Config.TimerSlack) for this process.
+	TimerSlack uint64
 }
 
 // Wait waits for the process to exit.
@@ -0,0 +1,76 @@
+package libcontainer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadEnv reads the environment variables of p from p.EnvFiles and
+// p.EnvFds, closing the latter.
+func (p *Process) loadEnv() error {
+	p.env = nil
+	for _, path := range p.EnvFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("env file: %w", err)
+		}
+		env, err := parseEnv(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("env file %s: %w", path, err)
+		}
+		p.env = append(p.env, env...)
+	}
+	for _, fd := range p.EnvFds {
+		f := os.NewFile(uintptr(fd), "env-fd:"+strconv.Itoa(fd))
+		if f == nil {
+			return fmt.Errorf("env fd %d: invalid file descriptor", fd)
+		}
+		env, err := parseEnv(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("env fd %d: %w", fd, err)
+		}
+		p.env = append(p.env, env...)
+	}
+	// They can only be read once.
+	p.EnvFds = nil
+	return nil
+}
+
+// environ returns the environment of p: p.Env, followed by the variables
+// read by loadEnv.
+func (p *Process) environ() []string {
+	if len(p.env) == 0 {
+		return p.Env
+	}
+	return append(p.Env[:len(p.Env):len(p.Env)], p.env...)
+}
+
+// parseEnv parses an environment file, with one KEY=VALUE per line. Empty
+// lines and lines starting with # are ignored. The values are taken
+// verbatim, without any quoting.
+func parseEnv(r io.Reader) ([]string, error) {
+	var env []string
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimLeft(s.Text(), " \t")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		key, _, ok := strings.Cut(line, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			// Not showing the line, which may hold a secret.
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		env = append(env, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
@@ -0,0 +1,63 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	env, err := parseEnv(strings.NewReader("# comment\n\nA=1\n  B= two words \nC=x=y\nD=\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"A=1", "B= two words ", "C=x=y", "D="}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected %q, got %q", expected, env)
+	}
+
+	for _, bad := range []string{"A=1\nSECRET\n", "=1\n", "A B=1\n"} {
+		_, err := parseEnv(strings.NewReader(bad))
+		if err == nil {
+			t.Errorf("%q: expected an error, got nil", bad)
+		} else if strings.Contains(err.Error(), "SECRET") {
+			t.Errorf("%q: the error shows the line: %v", bad, err)
+		}
+	}
+}
+
+func TestProcessLoadEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env")
+	if err := os.WriteFile(path, []byte("A=file\nB=file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString("B=fd\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	env := []string{"PATH=/bin", "A=config"}
+	p := &Process{Env: env, EnvFiles: []string{path}, EnvFds: []int{int(r.Fd())}}
+	if err := p.loadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"PATH=/bin", "A=config", "A=file", "B=file", "B=fd"}
+	if got := p.environ(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if len(p.Env) != len(env) {
+		t.Errorf("Env was modified: %q", p.Env)
+	}
+	if p.EnvFds != nil {
+		t.Errorf("expected EnvFds to be cleared, got %v", p.EnvFds)
+	}
+	if _, err := r.Stat(); err == nil {
+		t.Error("expected the env fd to be closed")
+	}
+}
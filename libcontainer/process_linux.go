@@ -107,6 +107,7 @@ type setnsProcess struct {
 	process         *Process
 	bootstrapData   io.Reader
 	initProcessPid  int
+	pidfd           *os.File
 }
 
 func (p *setnsProcess) startTime() (uint64, error) {
@@ -115,22 +116,15 @@ func (p *setnsProcess) startTime() (uint64, error) {
 }
 
 func (p *setnsProcess) signal(sig os.Signal) error {
-	s, ok := sig.(unix.Signal)
-	if !ok {
-		return errors.New("os: unsupported signal type")
+	if p.cmd.ProcessState != nil {
+		return os.ErrProcessDone
 	}
-	return unix.Kill(p.pid(), s)
+	return pidfdSignal(p.pidfd, p.pid(), sig)
 }
 
 func (p *setnsProcess) start() (retErr error) {
 	defer p.comm.closeParent()
 
-	if p.process.IOPriority != nil {
-		if err := setIOPriority(p.process.IOPriority); err != nil {
-			return err
-		}
-	}
-
 	// get the "before" value of oom kill count
 	oom, _ := p.manager.OOMKillCount()
 	err := p.cmd.Start()
@@ -171,7 +165,7 @@ func (p *setnsProcess) start() (retErr error) {
 		return fmt.Errorf("error executing setns process: %w", err)
 	}
 	for _, path := range p.cgroupPaths {
-		if err := cgroups.WriteCgroupProc(path, p.pid()); err != nil && !p.rootlessCgroups {
+		if err := cgroups.WriteCgroupProcRetry(path, p.pid(), p.config.Config.Cgroups.Resources.GetAttachRetry()); err != nil && !p.rootlessCgroups {
 			// On cgroup v2 + nesting + domain controllers, WriteCgroupProc may fail with EBUSY.
 			// https://github.com/opencontainers/runc/issues/2356#issuecomment-621277643
 			// Try to join the cgroup of InitProcessPid.
@@ -184,7 +178,7 @@ func (p *setnsProcess) start() (retErr error) {
 						logrus.Debugf("adding pid %d to cgroups %v failed (%v), attempting to join %q (obtained from %s)",
 							p.pid(), p.cgroupPaths, err, initCg, initCgDirpath)
 						// NOTE: initCgDirPath is not guaranteed to exist because we didn't pause the container.
-						err = cgroups.WriteCgroupProc(initCgDirpath, p.pid())
+						err = cgroups.WriteCgroupProcRetry(initCgDirpath, p.pid(), p.config.Config.Cgroups.Resources.GetAttachRetry())
 					}
 				}
 			}
@@ -193,6 +187,11 @@ func (p *setnsProcess) start() (retErr error) {
 			}
 		}
 	}
+	if p.config.Config.CoreSched != nil && p.initProcessPid != 0 {
+		if err := coreSchedShare(p.initProcessPid, p.pid()); err != nil {
+			return fmt.Errorf("error sharing core scheduling cookie with pid %d: %w", p.pid(), err)
+		}
+	}
 	if p.intelRdtPath != "" {
 		// if Intel RDT "resource control" filesystem path exists
 		_, err := os.Stat(p.intelRdtPath)
@@ -323,6 +322,7 @@ func (p *setnsProcess) execSetns() error {
 		return err
 	}
 	p.cmd.Process = process
+	p.pidfd = childPidfd(pid.Pid)
 	p.process.ops = p
 	return nil
 }
@@ -342,6 +342,7 @@ func (p *setnsProcess) terminate() error {
 
 func (p *setnsProcess) wait() (*os.ProcessState, error) {
 	err := p.cmd.Wait()
+	closePidfd(&p.pidfd)
 
 	// Return actual ProcessState even on Wait error
 	return p.cmd.ProcessState, err
@@ -373,6 +374,7 @@ type initProcess struct {
 	fds             []string
 	process         *Process
 	bootstrapData   io.Reader
+	pidfd           *os.File
 }
 
 func (p *initProcess) pid() int {
@@ -417,6 +419,7 @@ func (p *initProcess) waitForChildExit(childPid int) error {
 		return err
 	}
 	p.cmd.Process = process
+	p.pidfd = childPidfd(childPid)
 	p.process.ops = p
 	return nil
 }
@@ -527,7 +530,10 @@ func (p *initProcess) goCreateMountSources(ctx context.Context) (mountSourceRequ
 
 func (p *initProcess) start() (retErr error) {
 	defer p.comm.closeParent()
+	p.container.bootstrapTimings = nil
+	phaseStart := time.Now()
 	err := p.cmd.Start()
+	p.container.timePhase(PhaseSpawn, phaseStart)
 	p.process.ops = p
 	// close the child-side of the pipes (controlled by child)
 	p.comm.closeChild()
@@ -576,17 +582,45 @@ func (p *initProcess) start() (retErr error) {
 		}
 	}()
 
+	phaseStart = time.Now()
 	// Do this before syncing with child so that no children can escape the
 	// cgroup. We don't need to worry about not doing this and not being root
 	// because we'd be using the rootless cgroup manager in that case.
 	if err := p.manager.Apply(p.pid()); err != nil {
 		return fmt.Errorf("unable to apply cgroup configuration: %w", err)
 	}
+	if p.config.Config.Cgroups.SplitSubCgroups {
+		if err := setupSubCgroups(p.manager, p.config.Config.Cgroups.Resources, p.pid()); err != nil {
+			return fmt.Errorf("unable to set up sub-cgroups: %w", err)
+		}
+	} else if rtSubCgroup(p.config.Config.Cgroups) != "" {
+		if err := setupRtSubCgroup(p.manager, p.config.Config.Cgroups, p.pid(), p.config.Args); err != nil {
+			return fmt.Errorf("unable to set up real-time sub-cgroup: %w", err)
+		}
+	}
+	if execSubCgroup(p.config.Config.Cgroups) == configs.ExecSubCgroup {
+		if err := setupExecSubCgroup(p.manager); err != nil {
+			return fmt.Errorf("unable to set up exec sub-cgroup: %w", err)
+		}
+	}
 	if p.intelRdtManager != nil {
 		if err := p.intelRdtManager.Apply(p.pid()); err != nil {
 			return fmt.Errorf("unable to apply Intel RDT configuration: %w", err)
 		}
+		dev, err := p.intelRdtManager.PseudoLockDevice()
+		if err != nil {
+			return err
+		}
+		if dev != nil {
+			// Make the pseudo-locked region available in the container.
+			dev.Allow = true
+			p.config.Config.Devices = append(p.config.Config.Devices, dev)
+			p.config.Config.Cgroups.Resources.Devices = append(p.config.Config.Cgroups.Resources.Devices, &dev.Rule)
+		}
 	}
+	p.container.timePhase(PhaseCgroupApply, phaseStart)
+
+	phaseStart = time.Now()
 	if _, err := io.Copy(p.comm.initSockParent, p.bootstrapData); err != nil {
 		return fmt.Errorf("can't copy bootstrap data to pipe: %w", err)
 	}
@@ -599,6 +633,8 @@ func (p *initProcess) start() (retErr error) {
 	if err != nil {
 		return fmt.Errorf("can't get final child's PID from pipe: %w", err)
 	}
+	p.container.timePhase(PhaseNsexec, phaseStart)
+	phaseStart = time.Now()
 
 	// Save the standard descriptor names before the container process
 	// can potentially move them (e.g., via dup2()).  If we don't do this now,
@@ -613,6 +649,13 @@ func (p *initProcess) start() (retErr error) {
 	if err := p.waitForChildExit(childPid); err != nil {
 		return fmt.Errorf("error waiting for our first child to exit: %w", err)
 	}
+	p.container.timePhase(PhaseNsexecExit, phaseStart)
+
+	if cs := p.config.Config.CoreSched; cs != nil {
+		if err := setupCoreSched(filepath.Dir(p.container.stateDir), p.container.id, cs, childPid); err != nil {
+			return fmt.Errorf("unable to set up core scheduling: %w", err)
+		}
+	}
 
 	// Spin up a goroutine to handle remapping mount requests by runc init.
 	// There is no point doing this for rootless containers because they cannot
@@ -639,6 +682,7 @@ func (p *initProcess) start() (retErr error) {
 	if err := utils.WriteJSON(p.comm.initSockParent, p.config); err != nil {
 		return fmt.Errorf("error sending config to init process: %w", err)
 	}
+	phaseStart = time.Now()
 
 	var seenProcReady bool
 	ierr := parseSync(p.comm.syncSockParent, func(sync *syncT) error {
@@ -717,6 +761,7 @@ func (p *initProcess) start() (retErr error) {
 			}
 		case procReady:
 			seenProcReady = true
+			p.container.timePhase(PhaseInitFinish, phaseStart)
 			// Set rlimits, this has to be done here because we lose permissions
 			// to raise the limits once we enter a user-namespace
 			if err := setupRlimits(p.config.Rlimits, p.pid()); err != nil {
@@ -749,15 +794,23 @@ func (p *initProcess) start() (retErr error) {
 				return err
 			}
 		case procHooks:
+			p.container.timePhase(PhaseInitSetup, phaseStart)
+			phaseStart = time.Now()
 			// Setup cgroup before prestart hook, so that the prestart hook could apply cgroup permissions.
 			if err := p.manager.Set(p.config.Config.Cgroups.Resources); err != nil {
 				return fmt.Errorf("error setting cgroup config for procHooks process: %w", err)
 			}
+			checkEffectiveCpuset(p.manager, p.config.Config.Cgroups.Resources)
+			if err := setSubCgroupRtSched(p.manager, rtSubCgroup(p.config.Config.Cgroups), p.config.Config.Cgroups.Resources); err != nil {
+				return fmt.Errorf("error setting real-time sub-cgroup config for procHooks process: %w", err)
+			}
 			if p.intelRdtManager != nil {
 				if err := p.intelRdtManager.Set(p.config.Config); err != nil {
 					return fmt.Errorf("error setting Intel RDT config for procHooks process: %w", err)
 				}
 			}
+			p.container.timePhase(PhaseCgroupSet, phaseStart)
+			phaseStart = time.Now()
 			if len(p.config.Config.Hooks) != 0 {
 				s, err := p.container.currentOCIState()
 				if err != nil {
@@ -775,6 +828,9 @@ func (p *initProcess) start() (retErr error) {
 					return err
 				}
 			}
+			p.container.timePhase(PhaseHooks, phaseStart)
+			// The rest of the init is timed from here.
+			phaseStart = time.Now()
 			// Sync with child.
 			if err := writeSync(p.comm.syncSockParent, procHooksDone); err != nil {
 				return err
@@ -799,6 +855,7 @@ func (p *initProcess) start() (retErr error) {
 
 func (p *initProcess) wait() (*os.ProcessState, error) {
 	err := p.cmd.Wait()
+	closePidfd(&p.pidfd)
 	return p.cmd.ProcessState, err
 }
 
@@ -840,17 +897,19 @@ func (p *initProcess) createNetworkInterfaces() error {
 		if err := strategy.create(n, p.pid()); err != nil {
 			return err
 		}
+		// Keep any runtime information recorded by the strategy in the
+		// container config, so that it is available on destroy.
+		*config = n.Network
 		p.config.Networks = append(p.config.Networks, n)
 	}
 	return nil
 }
 
 func (p *initProcess) signal(sig os.Signal) error {
-	s, ok := sig.(unix.Signal)
-	if !ok {
-		return errors.New("os: unsupported signal type")
+	if p.cmd.ProcessState != nil {
+		return os.ErrProcessDone
 	}
-	return unix.Kill(p.pid(), s)
+	return pidfdSignal(p.pidfd, p.pid(), sig)
 }
 
 func (p *initProcess) setExternalDescriptors(newFds []string) {
@@ -989,8 +1048,10 @@ func initWaiter(r io.Reader) chan error {
 	return ch
 }
 
+// setIOPriority sets the I/O priority of the calling thread, which, in runc
+// init, is the one executing the container process (see [Init]).
 func setIOPriority(ioprio *configs.IOPriority) error {
-	const ioprioWhoPgrp = 1
+	const ioprioWhoProcess = 1
 
 	class, ok := configs.IOPrioClassMapping[ioprio.Class]
 	if !ok {
@@ -1000,7 +1061,7 @@ func setIOPriority(ioprio *configs.IOPriority) error {
 	// Combine class and priority into a single value
 	// https://github.com/torvalds/linux/blob/v5.18/include/uapi/linux/ioprio.h#L5-L17
 	iop := (class << 13) | ioprio.Priority
-	_, _, errno := unix.RawSyscall(unix.SYS_IOPRIO_SET, ioprioWhoPgrp, 0, uintptr(iop))
+	_, _, errno := unix.RawSyscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(iop))
 	if errno != 0 {
 		return fmt.Errorf("failed to set io priority: %w", errno)
 	}
@@ -0,0 +1,83 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"golang.org/x/sys/unix"
+)
+
+// Repair reconciles the saved state of the container with the actual state
+// of its processes and cgroups, which may be inconsistent after a node crash
+// or a killed runc instance. It returns the descriptions of the problems
+// found and fixed, which is empty if there were none.
+//
+// If the container init is gone, the container is marked stopped, and its
+// cgroup, if left empty, is removed (releasing its real-time runtime, if
+// any). If the init is alive but the container cgroup is missing, or the init
+// is not in it, the cgroup is recreated and the init is moved back to it.
+func (c *Container) Repair() ([]string, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	unlock, err := c.lockState()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	status, err := c.currentStatus()
+	if err != nil {
+		return nil, err
+	}
+	var fixed []string
+	if status == Stopped {
+		if c.initProcess == nil || c.initProcess.pid() <= 0 {
+			// Properly stopped, nothing to repair.
+			return nil, nil
+		}
+		fixed = append(fixed, fmt.Sprintf("init process %d is gone, marked the container stopped", c.initProcess.pid()))
+		c.initProcess = nil
+		if c.cgroupManager.Exists() {
+			pids, err := c.cgroupManager.GetAllPids()
+			if err != nil && !errors.Is(err, os.ErrNotExist) && !errors.Is(err, unix.ENODEV) {
+				return fixed, fmt.Errorf("unable to get cgroup PIDs: %w", err)
+			}
+			if len(pids) != 0 {
+				return fixed, fmt.Errorf("container's cgroup is not empty: %d process(es) left, use runc delete --force to kill them", len(pids))
+			}
+			if err := c.cgroupManager.Destroy(); err != nil {
+				return fixed, fmt.Errorf("unable to remove the leftover cgroup: %w", err)
+			}
+			msg := "removed the leftover cgroup"
+			if r := c.config.Cgroups.Resources; r != nil && r.CpuRtRuntime > 0 {
+				msg += fmt.Sprintf(" (releasing its real-time runtime of %dus)", r.CpuRtRuntime)
+			}
+			fixed = append(fixed, msg)
+		}
+	} else {
+		pid := c.initProcess.pid()
+		if !c.cgroupManager.Exists() {
+			if err := c.cgroupManager.Apply(pid); err != nil {
+				return fixed, fmt.Errorf("unable to recreate the container's cgroup: %w", err)
+			}
+			if err := c.cgroupManager.Set(c.config.Cgroups.Resources); err != nil {
+				return fixed, fmt.Errorf("unable to set the container's cgroup resources: %w", err)
+			}
+			fixed = append(fixed, "recreated the missing container cgroup")
+		} else if pids, err := c.cgroupManager.GetAllPids(); err == nil && !slices.Contains(pids, pid) {
+			if err := c.cgroupManager.Apply(pid); err != nil {
+				return fixed, fmt.Errorf("unable to move init process %d back to the container's cgroup: %w", pid, err)
+			}
+			fixed = append(fixed, fmt.Sprintf("moved init process %d back to the container's cgroup", pid))
+		}
+	}
+	if len(fixed) == 0 {
+		return nil, nil
+	}
+	if _, err := c.updateState(nil); err != nil {
+		return fixed, err
+	}
+	return fixed, c.refreshState()
+}
@@ -108,11 +108,17 @@ func (p *nonChildProcess) startTime() (uint64, error) {
 }
 
 func (p *nonChildProcess) signal(s os.Signal) error {
-	proc, err := os.FindProcess(p.processPid)
+	// Since the process is not our child, its pid could have been reused
+	// by the time the signal is sent. Use a pidfd, which is only obtained
+	// if the process start time matches, to eliminate the race.
+	pidfd, err := openPidfd(p.processPid, p.processStartTime)
 	if err != nil {
 		return err
 	}
-	return proc.Signal(s)
+	if pidfd != nil {
+		defer pidfd.Close()
+	}
+	return pidfdSignal(pidfd, p.processPid, s)
 }
 
 func (p *nonChildProcess) externalDescriptors() []string {
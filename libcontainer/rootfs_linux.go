@@ -246,6 +246,10 @@ func finalizeRootfs(config *configs.Config) (err error) {
 		}
 	}
 
+	if err := verifyMounts(config); err != nil {
+		return err
+	}
+
 	// set rootfs ( / ) as readonly
 	if config.Readonlyfs {
 		if err := setReadonly(); err != nil {
@@ -580,7 +584,15 @@ func mountToRootfs(c *mountConfig, m mountEntry) error {
 			return err
 		}
 		// Selinux kernels do not support labeling of /proc or /sys.
-		return mountPropagate(m, rootfs, "")
+		err := mountPropagate(m, rootfs, "")
+		if m.Device == "proc" && errors.Is(err, unix.EPERM) {
+			if masked := procOvermounts(); len(masked) > 0 {
+				// The kernel only allows a new procfs mount in a user
+				// namespace if an existing one is fully visible.
+				err = fmt.Errorf("%w (/proc is restricted, with %s over-mounted; is runc running inside a container with masked paths?)", err, strings.Join(masked, ", "))
+			}
+		}
+		return err
 	}
 
 	dest, err := createMountpoint(rootfs, m)
@@ -769,6 +781,25 @@ const procRootIno = 1
 // dest is required to be an abs path and have any symlinks resolved before calling this function.
 //
 // If m is nil, don't stat the filesystem.  This is used for restore of a checkpoint.
+// procOvermounts returns the paths inside /proc which have something mounted
+// on top of them, such as the masked paths of a container runc is running in.
+func procOvermounts() []string {
+	mounts, err := mountinfo.GetMounts(mountinfo.PrefixFilter("/proc"))
+	if err != nil {
+		logrus.Debugf("unable to get /proc submounts: %v", err)
+		return nil
+	}
+	var paths []string
+	for _, mi := range mounts {
+		// binfmt_misc is mounted on an empty directory, which is fine.
+		if mi.Mountpoint == "/proc" || mi.FSType == "binfmt_misc" {
+			continue
+		}
+		paths = append(paths, mi.Mountpoint)
+	}
+	return paths
+}
+
 func checkProcMount(rootfs, dest string, m mountEntry) error {
 	const procPath = "/proc"
 	path, err := filepath.Rel(filepath.Join(rootfs, procPath), dest)
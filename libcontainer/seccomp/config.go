@@ -2,6 +2,8 @@ package seccomp
 
 import (
 	"fmt"
+	"runtime"
+	"slices"
 	"sort"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -87,6 +89,56 @@ func KnownArchs() []string {
 	return res
 }
 
+// compatArchs are the architectures whose syscalls can be made by the
+// processes running on an architecture (e.g. using int 0x80 on amd64).
+var compatArchs = map[string][]string{
+	"amd64":       {"x86", "x32"},
+	"arm64":       {"arm"},
+	"mips64":      {"mips", "mips64n32"},
+	"mips64n32":   {"mips", "mips64"},
+	"mipsel64":    {"mipsel", "mipsel64n32"},
+	"mipsel64n32": {"mipsel", "mipsel64"},
+	"ppc64":       {"ppc"},
+	"s390x":       {"s390"},
+}
+
+// goArchs maps the GOARCH values to the names of the archs.
+var goArchs = map[string]string{
+	"386":      "x86",
+	"amd64":    "amd64",
+	"arm":      "arm",
+	"arm64":    "arm64",
+	"mips":     "mips",
+	"mipsle":   "mipsel",
+	"mips64":   "mips64",
+	"mips64le": "mipsel64",
+	"ppc64":    "ppc64",
+	"ppc64le":  "ppc64le",
+	"riscv64":  "riscv64",
+	"s390x":    "s390x",
+}
+
+// AddCompatArchs returns archs with the compat archs of each of them added,
+// e.g. x86 and x32 for amd64. If archs is empty, the native arch is used.
+func AddCompatArchs(archs []string) []string {
+	if len(archs) == 0 {
+		native, ok := goArchs[runtime.GOARCH]
+		if !ok {
+			return nil
+		}
+		archs = []string{native}
+	}
+	res := slices.Clone(archs)
+	for _, arch := range archs {
+		for _, compat := range compatArchs[arch] {
+			if !slices.Contains(res, compat) {
+				res = append(res, compat)
+			}
+		}
+	}
+	return res
+}
+
 // ConvertStringToOperator converts a string into a Seccomp comparison operator.
 // Comparison operators use the names they are assigned by Libseccomp's header.
 // Attempting to convert a string that is not a valid operator results in an
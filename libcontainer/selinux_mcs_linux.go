@@ -0,0 +1,131 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/selinux/go-selinux"
+)
+
+const (
+	// DefaultMCSCategories is the default number of categories, c0 to
+	// c1023, from which the automatically allocated MCS levels are made.
+	DefaultMCSCategories = 1024
+
+	// DefaultMCSProcessLabel and DefaultMCSMountLabel are the labels which
+	// the automatically allocated MCS levels are added to, if the container
+	// has no process or mount label.
+	DefaultMCSProcessLabel = "system_u:system_r:container_t"
+	DefaultMCSMountLabel   = "system_u:object_r:container_file_t"
+
+	// mcsSensitivity is the sensitivity of the allocated MCS levels.
+	mcsSensitivity = "s0"
+
+	// mcsAllocFile is the file, relative to the runc root directory, which
+	// holds the MCS levels allocated to containers.
+	mcsAllocFile = "mcs-alloc.json"
+)
+
+// AutoMCSOpts are the options for automatic MCS level allocation.
+type AutoMCSOpts struct {
+	// Categories is the number of categories, c0 to cN-1, from which the
+	// category pairs are allocated. If 0, DefaultMCSCategories is used.
+	Categories int
+}
+
+// AllocateMCS allocates an MCS level, made of a pair of categories which no
+// other container has, for the container with the given id, and returns the
+// given process and mount labels (or the default ones, if empty) with their
+// level set to it.
+//
+// The allocations are recorded in a file under the root directory, which is
+// locked until the returned unlock function is called. The caller must create
+// the container (see [Create]) before calling unlock, as the allocations of
+// containers which do not exist are considered stale and are reclaimed.
+func AllocateMCS(root, id, processLabel, mountLabel string, opts *AutoMCSOpts) (newProcessLabel, newMountLabel string, unlock func(), _ error) {
+	categories := opts.Categories
+	if categories == 0 {
+		categories = DefaultMCSCategories
+	}
+	if categories < 2 {
+		return "", "", nil, fmt.Errorf("invalid number of MCS categories %d", categories)
+	}
+	if processLabel == "" {
+		processLabel = DefaultMCSProcessLabel
+	}
+	if mountLabel == "" {
+		mountLabel = DefaultMCSMountLabel
+	}
+	processCtx, err := selinux.NewContext(processLabel)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("process label %q: %w", processLabel, err)
+	}
+	mountCtx, err := selinux.NewContext(mountLabel)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("mount label %q: %w", mountLabel, err)
+	}
+
+	f, err := openAllocFile(root, mcsAllocFile)
+	if err != nil {
+		return "", "", nil, err
+	}
+	level, err := allocateMCSLevel(f, root, id, categories)
+	if err != nil {
+		f.Close()
+		return "", "", nil, fmt.Errorf("unable to allocate an MCS level: %w", err)
+	}
+	processCtx["level"] = level
+	mountCtx["level"] = level
+	return processCtx.Get(), mountCtx.Get(), func() { f.Close() }, nil
+}
+
+// allocateMCSLevel allocates a level for the container id, and records it
+// in f, which must be locked by the caller.
+func allocateMCSLevel(f *os.File, root, id string, categories int) (string, error) {
+	if exists, err := containerExists(root, id); err != nil {
+		return "", err
+	} else if exists {
+		return "", ErrExist
+	}
+	allocs := map[string]string{}
+	if err := readAllocFile(f, &allocs); err != nil {
+		return "", err
+	}
+	used := make(map[string]bool, len(allocs))
+	for cid, level := range allocs {
+		exists, err := containerExists(root, cid)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			// Reclaim the allocations of the containers which are gone.
+			delete(allocs, cid)
+			continue
+		}
+		used[level] = true
+	}
+	level, err := findFreeMCSLevel(used, categories)
+	if err != nil {
+		return "", err
+	}
+	allocs[id] = level
+	if err := writeAllocFile(f, allocs); err != nil {
+		return "", err
+	}
+	return level, nil
+}
+
+// findFreeMCSLevel returns the first level made of two distinct categories
+// lower than categories which is not used.
+func findFreeMCSLevel(used map[string]bool, categories int) (string, error) {
+	for c1 := 0; c1 < categories; c1++ {
+		for c2 := c1 + 1; c2 < categories; c2++ {
+			level := fmt.Sprintf("%s:c%d,c%d", mcsSensitivity, c1, c2)
+			if !used[level] {
+				return level, nil
+			}
+		}
+	}
+	return "", errors.New("no free category pair available")
+}
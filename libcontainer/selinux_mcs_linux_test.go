@@ -0,0 +1,62 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllocateMCS(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	opts := &AutoMCSOpts{Categories: 3}
+
+	allocate := func(id string, processLabel string) (string, string, error) {
+		p, m, unlock, err := AllocateMCS(root, id, processLabel, "", opts)
+		if err != nil {
+			return "", "", err
+		}
+		defer unlock()
+		// Pretend the container is created.
+		if err := os.Mkdir(filepath.Join(root, id), 0o711); err != nil {
+			t.Fatal(err)
+		}
+		return p, m, nil
+	}
+
+	// With 3 categories, there are 3 pairs.
+	for _, tc := range []struct{ id, label, process, mount string }{
+		{"a", "", "system_u:system_r:container_t:s0:c0,c1", "system_u:object_r:container_file_t:s0:c0,c1"},
+		{"b", "user_u:user_r:custom_t", "user_u:user_r:custom_t:s0:c0,c2", "system_u:object_r:container_file_t:s0:c0,c2"},
+		{"c", "", "system_u:system_r:container_t:s0:c1,c2", "system_u:object_r:container_file_t:s0:c1,c2"},
+	} {
+		p, m, err := allocate(tc.id, tc.label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != tc.process || m != tc.mount {
+			t.Fatalf("%s: unexpected labels %q, %q", tc.id, p, m)
+		}
+	}
+	if _, _, err := allocate("d", ""); err == nil {
+		t.Fatal("expected an error, pool is exhausted")
+	}
+	if _, _, err := allocate("a", ""); !errors.Is(err, ErrExist) {
+		t.Fatalf("expected ErrExist, got %v", err)
+	}
+	if _, _, _, err := AllocateMCS(root, "e", "invalid", "", opts); err == nil {
+		t.Fatal("expected an error for an invalid label")
+	}
+
+	// Once a container is gone, its level is reclaimed.
+	if err := os.Remove(filepath.Join(root, "b")); err != nil {
+		t.Fatal(err)
+	}
+	p, _, err := allocate("d", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != "system_u:system_r:container_t:s0:c0,c2" {
+		t.Fatalf("unexpected label %q", p)
+	}
+}
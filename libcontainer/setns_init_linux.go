@@ -77,6 +77,11 @@ func (l *linuxSetnsInit) Init() error {
 			return err
 		}
 	}
+	if l.config.IOPriority != nil {
+		if err := setIOPriority(l.config.IOPriority); err != nil {
+			return err
+		}
+	}
 
 	// Tell our parent that we're ready to exec. This must be done before the
 	// Seccomp rules have been applied, because we need to be able to read and
@@ -112,6 +117,11 @@ func (l *linuxSetnsInit) Init() error {
 			return err
 		}
 	}
+	if l.config.TimerSlack != 0 {
+		if err := setTimerSlack(l.config.TimerSlack); err != nil {
+			return err
+		}
+	}
 	// Check for the arg early to make sure it exists.
 	name, err := exec.LookPath(l.config.Args[0])
 	if err != nil {
@@ -0,0 +1,194 @@
+package specconv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// networkInterfacesAnnotation holds a JSON array of existing host network
+// interfaces (such as macvlan devices or SR-IOV VFs) to be moved into the
+// container's network namespace, for example:
+//
+//	[{"hostInterfaceName": "enp1s0f0v1", "name": "eth0"}]
+const networkInterfacesAnnotation = "org.opencontainers.runc.network.interfaces"
+
+// netdevConfig is the per-interface configuration accepted in
+// networkInterfacesAnnotation.
+type netdevConfig struct {
+	// HostInterfaceName is the name of the interface on the host.
+	HostInterfaceName string `json:"hostInterfaceName"`
+	// Name is the interface name inside the container. Defaults to
+	// HostInterfaceName.
+	Name       string `json:"name,omitempty"`
+	MacAddress string `json:"macAddress,omitempty"`
+	Mtu        int    `json:"mtu,omitempty"`
+	TxQueueLen int    `json:"txQueueLen,omitempty"`
+	// Address and IPv6Address are static addresses in CIDR form, and
+	// Gateway and IPv6Gateway are used for the default routes.
+	Address     string `json:"address,omitempty"`
+	Gateway     string `json:"gateway,omitempty"`
+	IPv6Address string `json:"ipv6Address,omitempty"`
+	IPv6Gateway string `json:"ipv6Gateway,omitempty"`
+	// VirtualFunction holds the SR-IOV VF settings, for VF interfaces.
+	VirtualFunction *virtualFunctionConfig `json:"virtualFunction,omitempty"`
+}
+
+type virtualFunctionConfig struct {
+	Vlan       int   `json:"vlan,omitempty"`
+	Qos        int   `json:"qos,omitempty"`
+	MinTxRate  int   `json:"minTxRate,omitempty"`
+	MaxTxRate  int   `json:"maxTxRate,omitempty"`
+	SpoofCheck *bool `json:"spoofCheck,omitempty"`
+	Trust      *bool `json:"trust,omitempty"`
+}
+
+func (vf *virtualFunctionConfig) convert() (*configs.VirtualFunction, error) {
+	if vf.Vlan < 0 || vf.Vlan > 4095 {
+		return nil, fmt.Errorf("invalid vlan %d", vf.Vlan)
+	}
+	if vf.Qos < 0 || vf.Qos > 7 {
+		return nil, fmt.Errorf("invalid qos %d", vf.Qos)
+	}
+	if vf.Qos != 0 && vf.Vlan == 0 {
+		return nil, errors.New("qos requires a vlan")
+	}
+	if vf.MinTxRate < 0 || vf.MaxTxRate < 0 {
+		return nil, errors.New("tx rates must not be negative")
+	}
+	if vf.MaxTxRate != 0 && vf.MinTxRate > vf.MaxTxRate {
+		return nil, fmt.Errorf("minTxRate %d is greater than maxTxRate %d", vf.MinTxRate, vf.MaxTxRate)
+	}
+	return &configs.VirtualFunction{
+		Vlan:       vf.Vlan,
+		Qos:        vf.Qos,
+		MinTxRate:  vf.MinTxRate,
+		MaxTxRate:  vf.MaxTxRate,
+		SpoofCheck: vf.SpoofCheck,
+		Trust:      vf.Trust,
+	}, nil
+}
+
+// networkRoutesAnnotation holds a JSON array of static routes to add in
+// the container's network namespace, for example:
+//
+//	[{"destination": "10.1.0.0/16", "gateway": "10.0.0.1", "interfaceName": "eth0"}]
+const networkRoutesAnnotation = "org.opencontainers.runc.network.routes"
+
+// networkRateAnnotation holds a JSON object with the network rate limits of
+// the container (see configs.NetRate), in bytes per second, for example:
+//
+//	{"egress": {"rate": 12500000}, "ingress": {"rate": 12500000, "burst": 1048576}}
+const networkRateAnnotation = "org.opencontainers.runc.network.rate"
+
+// routeConfig is the per-route configuration accepted in
+// networkRoutesAnnotation.
+type routeConfig struct {
+	Destination   string `json:"destination,omitempty"`
+	Source        string `json:"source,omitempty"`
+	Gateway       string `json:"gateway,omitempty"`
+	InterfaceName string `json:"interfaceName"`
+}
+
+// createNetworkInterfaces adds the host interfaces requested via
+// networkInterfacesAnnotation to the container config.
+func createNetworkInterfaces(spec *specs.Spec, config *configs.Config) error {
+	v, ok := spec.Annotations[networkInterfacesAnnotation]
+	if !ok {
+		return nil
+	}
+	if !config.Namespaces.Contains(configs.NEWNET) || config.Namespaces.PathOf(configs.NEWNET) != "" {
+		return fmt.Errorf("annotation %s requires a new network namespace", networkInterfacesAnnotation)
+	}
+	var ifaces []netdevConfig
+	if err := json.Unmarshal([]byte(v), &ifaces); err != nil {
+		return fmt.Errorf("annotation %s value parse error: %w", networkInterfacesAnnotation, err)
+	}
+	names := make(map[string]struct{}, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.HostInterfaceName == "" {
+			return fmt.Errorf("annotation %s: hostInterfaceName is required", networkInterfacesAnnotation)
+		}
+		name := iface.Name
+		if name == "" {
+			name = iface.HostInterfaceName
+		}
+		if name == "lo" {
+			return errors.New("unable to move an interface into the container as lo")
+		}
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("annotation %s: duplicate interface name %q", networkInterfacesAnnotation, name)
+		}
+		names[name] = struct{}{}
+		var vf *configs.VirtualFunction
+		if iface.VirtualFunction != nil {
+			var err error
+			if vf, err = iface.VirtualFunction.convert(); err != nil {
+				return fmt.Errorf("annotation %s: interface %q: %w", networkInterfacesAnnotation, iface.HostInterfaceName, err)
+			}
+		}
+		config.Networks = append(config.Networks, &configs.Network{
+			Type:              "netdev",
+			Name:              name,
+			HostInterfaceName: iface.HostInterfaceName,
+			MacAddress:        iface.MacAddress,
+			Mtu:               iface.Mtu,
+			TxQueueLen:        iface.TxQueueLen,
+			Address:           iface.Address,
+			Gateway:           iface.Gateway,
+			IPv6Address:       iface.IPv6Address,
+			IPv6Gateway:       iface.IPv6Gateway,
+			VirtualFunction:   vf,
+		})
+	}
+	return nil
+}
+
+// createNetworkRoutes adds the static routes requested via
+// networkRoutesAnnotation to the container config.
+func createNetworkRoutes(spec *specs.Spec, config *configs.Config) error {
+	v, ok := spec.Annotations[networkRoutesAnnotation]
+	if !ok {
+		return nil
+	}
+	if !config.Namespaces.Contains(configs.NEWNET) || config.Namespaces.PathOf(configs.NEWNET) != "" {
+		return fmt.Errorf("annotation %s requires a new network namespace", networkRoutesAnnotation)
+	}
+	var routes []routeConfig
+	if err := json.Unmarshal([]byte(v), &routes); err != nil {
+		return fmt.Errorf("annotation %s value parse error: %w", networkRoutesAnnotation, err)
+	}
+	for _, r := range routes {
+		if r.InterfaceName == "" {
+			return fmt.Errorf("annotation %s: interfaceName is required", networkRoutesAnnotation)
+		}
+		if r.Destination == "" && r.Source == "" && r.Gateway == "" {
+			return fmt.Errorf("annotation %s: one of destination, source or gateway is required", networkRoutesAnnotation)
+		}
+		config.Routes = append(config.Routes, &configs.Route{
+			Destination:   r.Destination,
+			Source:        r.Source,
+			Gateway:       r.Gateway,
+			InterfaceName: r.InterfaceName,
+		})
+	}
+	return nil
+}
+
+// createNetworkRate sets the network rate limits requested via
+// networkRateAnnotation.
+func createNetworkRate(spec *specs.Spec, r *configs.Resources) error {
+	v, ok := spec.Annotations[networkRateAnnotation]
+	if !ok {
+		return nil
+	}
+	var rate configs.NetRate
+	if err := json.Unmarshal([]byte(v), &rate); err != nil {
+		return fmt.Errorf("annotation %s value parse error: %w", networkRateAnnotation, err)
+	}
+	r.NetRate = &rate
+	return nil
+}
@@ -0,0 +1,125 @@
+package specconv
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/devices"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	vfioDevicePrefix = "vfio:"
+	uioDevicePrefix  = "uio:"
+
+	// vfioContainerPath is the VFIO container device, which has a fixed
+	// misc major:minor of 10:196.
+	vfioContainerPath = "/dev/vfio/vfio"
+)
+
+// Can be changed by unit tests.
+var deviceFromPath = devices.DeviceFromPath
+
+// isPassthroughDevice tells whether the spec device uses one of the
+// "vfio:<group>" or "uio:<n>" shorthand types.
+func isPassthroughDevice(d *specs.LinuxDevice) bool {
+	return strings.HasPrefix(d.Type, vfioDevicePrefix) || strings.HasPrefix(d.Type, uioDevicePrefix)
+}
+
+// expandPassthroughDevice expands a "vfio:<group>" or "uio:<n>" shorthand
+// device entry into the set of host device nodes it requires. The major and
+// minor numbers are taken from the host nodes, as they are allocated
+// dynamically and may change across reboots.
+//
+// For VFIO, both the VFIO container node (/dev/vfio/vfio) and the group node
+// (/dev/vfio/<group>) are returned. For uio, /dev/uio<n> is returned.
+func expandPassthroughDevice(d *specs.LinuxDevice) ([]*devices.Device, error) {
+	var paths []string
+	switch {
+	case strings.HasPrefix(d.Type, vfioDevicePrefix):
+		group := strings.TrimPrefix(d.Type, vfioDevicePrefix)
+		if err := checkVfioGroup(group); err != nil {
+			return nil, fmt.Errorf("invalid device type %q: %w", d.Type, err)
+		}
+		paths = []string{vfioContainerPath, filepath.Join("/dev/vfio", group)}
+	case strings.HasPrefix(d.Type, uioDevicePrefix):
+		n := strings.TrimPrefix(d.Type, uioDevicePrefix)
+		if _, err := strconv.ParseUint(n, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid device type %q: uio device number must be a non-negative integer", d.Type)
+		}
+		paths = []string{"/dev/uio" + n}
+	default:
+		return nil, fmt.Errorf("invalid device type %q", d.Type)
+	}
+
+	devs := make([]*devices.Device, 0, len(paths))
+	for _, p := range paths {
+		dev, err := deviceFromPath(p, "rwm")
+		if err != nil {
+			return nil, fmt.Errorf("unable to look up %s for device type %q: %w", p, d.Type, err)
+		}
+		if dev.Type != devices.CharDevice {
+			return nil, fmt.Errorf("%s is not a character device", p)
+		}
+		dev.Allow = true
+		if d.FileMode != nil {
+			dev.FileMode = *d.FileMode
+		}
+		// The node is created inside the container, so the host owner
+		// is irrelevant; default to the container's root.
+		dev.Uid, dev.Gid = 0, 0
+		if d.UID != nil {
+			dev.Uid = *d.UID
+		}
+		if d.GID != nil {
+			dev.Gid = *d.GID
+		}
+		devs = append(devs, dev)
+	}
+	return devs, nil
+}
+
+// checkVfioGroup validates a VFIO group name, which is either a decimal
+// IOMMU group number, or "noiommu-<n>" for no-IOMMU mode.
+func checkVfioGroup(group string) error {
+	n := strings.TrimPrefix(group, "noiommu-")
+	if _, err := strconv.ParseUint(n, 10, 32); err != nil {
+		return fmt.Errorf("VFIO group must be a number or noiommu-<number>, got %q", group)
+	}
+	return nil
+}
+
+// hasVfioDevice tells whether the spec requests any VFIO passthrough device.
+func hasVfioDevice(spec *specs.Spec) bool {
+	if spec.Linux == nil {
+		return false
+	}
+	for _, d := range spec.Linux.Devices {
+		if strings.HasPrefix(d.Type, vfioDevicePrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// addVfioCapabilities adds CAP_IPC_LOCK to the container capabilities, as
+// VFIO users need to lock (pin) the memory used for DMA mappings.
+func addVfioCapabilities(caps *configs.Capabilities) {
+	const capIPCLock = "CAP_IPC_LOCK"
+	add := func(set []string) []string {
+		for _, c := range set {
+			if c == capIPCLock {
+				return set
+			}
+		}
+		return append(set, capIPCLock)
+	}
+	logrus.Debugf("adding %s to the container capabilities for VFIO device passthrough", capIPCLock)
+	caps.Bounding = add(caps.Bounding)
+	caps.Effective = add(caps.Effective)
+	caps.Permitted = add(caps.Permitted)
+}
@@ -3,11 +3,13 @@
 package specconv
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -133,7 +135,8 @@ func initMaps() {
 			clear bool
 			flag  int
 		}{
-			"tmpcopyup": {false, configs.EXT_COPYUP},
+			"tmpcopyup":    {false, configs.EXT_COPYUP},
+			"overlay-auto": {false, configs.EXT_OVERLAY_AUTO},
 		}
 
 		complexFlags = map[string]func(*configs.Mount){
@@ -322,9 +325,19 @@ type CreateOpts struct {
 	UseSystemdCgroup bool
 	NoPivotRoot      bool
 	NoNewKeyring     bool
-	Spec             *specs.Spec
-	RootlessEUID     bool
-	RootlessCgroups  bool
+	// AdjustRtBandwidth sets configs.Config.AdjustRtBandwidth.
+	AdjustRtBandwidth bool
+	// ThawFrozenAncestors sets configs.Config.ThawFrozenAncestors.
+	ThawFrozenAncestors bool
+	// StartFrozen sets configs.Config.StartFrozen.
+	StartFrozen bool
+	// CgroupRoot, if set, replaces the parent of the container cgroup
+	// (with systemd, the slice), e.g. to restore a container under a
+	// different parent than it was checkpointed in.
+	CgroupRoot      string
+	Spec            *specs.Spec
+	RootlessEUID    bool
+	RootlessCgroups bool
 }
 
 // getwd is a wrapper similar to os.Getwd, except it always gets
@@ -361,15 +374,18 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		labels = append(labels, k+"="+v)
 	}
 	config := &configs.Config{
-		Rootfs:          rootfsPath,
-		NoPivotRoot:     opts.NoPivotRoot,
-		Readonlyfs:      spec.Root.Readonly,
-		Hostname:        spec.Hostname,
-		Domainname:      spec.Domainname,
-		Labels:          append(labels, "bundle="+cwd),
-		NoNewKeyring:    opts.NoNewKeyring,
-		RootlessEUID:    opts.RootlessEUID,
-		RootlessCgroups: opts.RootlessCgroups,
+		Rootfs:              rootfsPath,
+		NoPivotRoot:         opts.NoPivotRoot,
+		Readonlyfs:          spec.Root.Readonly,
+		Hostname:            spec.Hostname,
+		Domainname:          spec.Domainname,
+		Labels:              append(labels, "bundle="+cwd),
+		NoNewKeyring:        opts.NoNewKeyring,
+		AdjustRtBandwidth:   opts.AdjustRtBandwidth,
+		ThawFrozenAncestors: opts.ThawFrozenAncestors,
+		StartFrozen:         opts.StartFrozen,
+		RootlessEUID:        opts.RootlessEUID,
+		RootlessCgroups:     opts.RootlessCgroups,
 	}
 
 	for _, m := range spec.Mounts {
@@ -422,6 +438,12 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 				},
 			}
 		}
+		if err := createNetworkInterfaces(spec, config); err != nil {
+			return nil, err
+		}
+		if err := createNetworkRoutes(spec, config); err != nil {
+			return nil, err
+		}
 		if config.Namespaces.Contains(configs.NEWUSER) {
 			if err := setupUserNamespace(spec, config); err != nil {
 				return nil, err
@@ -460,9 +482,6 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 			}
 		}
 		if spec.Linux.Personality != nil {
-			if len(spec.Linux.Personality.Flags) > 0 {
-				logrus.Warnf("ignoring unsupported personality flags: %+v because personality flag has not supported at this time", spec.Linux.Personality.Flags)
-			}
 			domain, err := getLinuxPersonalityFromStr(string(spec.Linux.Personality.Domain))
 			if err != nil {
 				return nil, err
@@ -470,6 +489,13 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 			config.Personality = &configs.LinuxPersonality{
 				Domain: domain,
 			}
+			for _, f := range spec.Linux.Personality.Flags {
+				flag, ok := personalityFlags[string(f)]
+				if !ok {
+					return nil, fmt.Errorf("invalid personality flag %s", f)
+				}
+				config.Personality.Flags |= flag
+			}
 		}
 
 	}
@@ -515,6 +541,22 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 			config.Cgroups.OwnerUID = &ownerUid
 		}
 	}
+	if v, ok := spec.Annotations[delegateCgroupAnnotation]; ok {
+		delegate, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", delegateCgroupAnnotation, v, err)
+		}
+		if delegate {
+			if !config.Namespaces.Contains(configs.NEWUSER) {
+				return nil, fmt.Errorf("annotation %s requires a user namespace", delegateCgroupAnnotation)
+			}
+			ownerUid, err := config.HostRootUID()
+			if err != nil {
+				return nil, fmt.Errorf("annotation %s: %w", delegateCgroupAnnotation, err)
+			}
+			config.Cgroups.OwnerUID = &ownerUid
+		}
+	}
 
 	if spec.Process != nil {
 		config.OomScoreAdj = spec.Process.OOMScoreAdj
@@ -530,6 +572,9 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 				Ambient:     spec.Process.Capabilities.Ambient,
 			}
 		}
+		if config.Capabilities != nil && hasVfioDevice(spec) {
+			addVfioCapabilities(config.Capabilities)
+		}
 		if spec.Process.Scheduler != nil {
 			s := *spec.Process.Scheduler
 			config.Scheduler = &s
@@ -541,10 +586,272 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		}
 	}
 	createHooks(spec, config)
+	if v, ok := spec.Annotations[disableIOUringAnnotation]; ok {
+		disable, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", disableIOUringAnnotation, v, err)
+		}
+		if disable {
+			disableIOUring(config)
+		}
+	}
+	if v, ok := spec.Annotations[seccompErrnoAnnotation]; ok {
+		errnos, err := parseSyscallErrnos(v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", seccompErrnoAnnotation, v, err)
+		}
+		setSyscallErrnos(config, errnos)
+	}
+	if v, ok := spec.Annotations[seccompCompatArchsAnnotation]; ok {
+		compat, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", seccompCompatArchsAnnotation, v, err)
+		}
+		if compat && config.Seccomp != nil {
+			config.Seccomp.Architectures = seccomp.AddCompatArchs(config.Seccomp.Architectures)
+		}
+	}
+	if v, ok := spec.Annotations[coreSchedAnnotation]; ok {
+		enable, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", coreSchedAnnotation, v, err)
+		}
+		if enable {
+			config.CoreSched = &configs.CoreSched{}
+		}
+	}
+	if v, ok := spec.Annotations[coreSchedGroupAnnotation]; ok && v != "" {
+		config.CoreSched = &configs.CoreSched{Group: v}
+	}
+	if v, ok := spec.Annotations[exeProtectionAnnotation]; ok {
+		config.ExeProtection = v
+	}
+	if v, ok := spec.Annotations[auditLogAnnotation]; ok {
+		config.AuditLog = v
+	}
+	if v, ok := spec.Annotations[execPolicyAnnotation]; ok {
+		var policy configs.ExecPolicy
+		if err := json.Unmarshal([]byte(v), &policy); err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", execPolicyAnnotation, v, err)
+		}
+		config.ExecPolicy = &policy
+	}
+	if v, ok := spec.Annotations[pseudoLockAnnotation]; ok {
+		if config.IntelRdt == nil {
+			return nil, fmt.Errorf("annotation %s requires linux.intelRdt to be set", pseudoLockAnnotation)
+		}
+		config.IntelRdt.PseudoLock = &configs.IntelRdtPseudoLock{Schema: v}
+	}
+	if v, ok := spec.Annotations[destroyTimeoutAnnotation]; ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("annotation %s=%s: invalid duration", destroyTimeoutAnnotation, v)
+		}
+		config.DestroyTimeout = timeout
+	}
+	if v, ok := spec.Annotations[exclusivePlacementAnnotation]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", exclusivePlacementAnnotation, v, err)
+		}
+		config.ExclusiveCPUs = n
+	}
+	if v, ok := spec.Annotations[timerSlackAnnotation]; ok {
+		slack, err := time.ParseDuration(v)
+		if err != nil || slack <= 0 {
+			return nil, fmt.Errorf("annotation %s=%s: invalid duration", timerSlackAnnotation, v)
+		}
+		config.TimerSlack = uint64(slack.Nanoseconds())
+	}
+	if v, ok := spec.Annotations[stopSignalAnnotation]; ok {
+		sig, err := parseSignal(v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s: %w", stopSignalAnnotation, v, err)
+		}
+		config.StopSignal = int(sig)
+	}
+	if v, ok := spec.Annotations[prestopHooksAnnotation]; ok {
+		var hooks []specs.Hook
+		if err := json.Unmarshal([]byte(v), &hooks); err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", prestopHooksAnnotation, v, err)
+		}
+		for _, h := range hooks {
+			config.Hooks[configs.Prestop] = append(config.Hooks[configs.Prestop], configs.NewCommandHook(createCommandHook(h)))
+		}
+	}
 	config.Version = specs.Version
 	return config, nil
 }
 
+// timerSlackAnnotation sets the timer slack of the container init process,
+// as a duration such as "1us" (see configs.Config.TimerSlack).
+const timerSlackAnnotation = "org.opencontainers.runc.timer-slack"
+
+// stopSignalAnnotation sets the signal, by name (e.g. "SIGQUIT" or "QUIT") or
+// number, used to stop the container (see configs.Config.StopSignal), like
+// the StopSignal of an image config.
+const stopSignalAnnotation = "org.opencontainers.runc.stop-signal"
+
+// prestopHooksAnnotation adds hooks to be run before the stop signal is
+// sent to the container (see configs.Prestop). Its value is a JSON array of
+// hooks, in the same format as the other hooks of the spec.
+const prestopHooksAnnotation = "org.opencontainers.runc.hooks.prestop"
+
+// parseSignal parses a signal name, with or without the SIG prefix, or
+// number.
+func parseSignal(s string) (unix.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n <= 0 || n > 64 {
+			return 0, fmt.Errorf("invalid signal number %d", n)
+		}
+		return unix.Signal(n), nil
+	}
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return 0, fmt.Errorf("unknown signal %q", s)
+	}
+	return sig, nil
+}
+
+// destroyTimeoutAnnotation sets how long to wait for the container processes
+// to exit when the container is destroyed (see configs.Config.DestroyTimeout).
+const destroyTimeoutAnnotation = "org.opencontainers.runc.destroy-timeout"
+
+// exclusivePlacementAnnotation makes runc select the given number of CPUs to
+// be assigned exclusively to the container (see configs.Config.ExclusiveCPUs).
+const exclusivePlacementAnnotation = "org.opencontainers.runc.cpu.exclusive-placement"
+
+// exeProtectionAnnotation sets how the runc binary is protected from the
+// container (see configs.Config.ExeProtection).
+const exeProtectionAnnotation = "org.opencontainers.runc.exe-protection"
+
+// auditLogAnnotation makes runc record the security-relevant setup steps of
+// the container processes, as JSON lines, to the given file or "fd:N" (see
+// configs.Config.AuditLog).
+const auditLogAnnotation = "org.opencontainers.runc.audit-log"
+
+// execPolicyAnnotation restricts the security settings which can be
+// overridden by runc exec. Its value is a JSON-encoded configs.ExecPolicy,
+// e.g. {"apparmor_profiles":["unconfined"],"allow_new_privileges":true}.
+const execPolicyAnnotation = "org.opencontainers.runc.exec-policy"
+
+// pseudoLockAnnotation sets up a cache pseudo-locked region for the
+// container, its value being the region schema (see
+// configs.IntelRdtPseudoLock).
+const pseudoLockAnnotation = "org.opencontainers.runc.intelrdt.pseudo-lock"
+
+// coreSchedAnnotation makes the container use its own core scheduling
+// cookie (see configs.Config.CoreSched).
+const coreSchedAnnotation = "org.opencontainers.runc.core-sched"
+
+// coreSchedGroupAnnotation makes the container share the core scheduling
+// cookie with the other containers having the same value, which is the name
+// of the cookie group (see configs.CoreSched.Group).
+const coreSchedGroupAnnotation = "org.opencontainers.runc.core-sched.group"
+
+// disableIOUringAnnotation makes the io_uring syscalls fail with EPERM in
+// the container, like they do when the kernel.io_uring_disabled sysctl is
+// set to 2, but without affecting the rest of the system.
+const disableIOUringAnnotation = "org.opencontainers.runc.io_uring.disable"
+
+// ioUringSyscalls are the syscalls which make up the io_uring interface.
+var ioUringSyscalls = []string{"io_uring_setup", "io_uring_enter", "io_uring_register"}
+
+// disableIOUring adds seccomp rules denying the io_uring syscalls to config,
+// replacing any rules for these syscalls in the existing seccomp profile.
+// If there is no seccomp profile, one which allows everything else is used.
+func disableIOUring(config *configs.Config) {
+	errnos := make(map[string]uint, len(ioUringSyscalls))
+	for _, name := range ioUringSyscalls {
+		errnos[name] = uint(unix.EPERM)
+	}
+	setSyscallErrnos(config, errnos)
+}
+
+// seccompCompatArchsAnnotation makes the seccomp profile also apply to the
+// compat architectures (e.g. x86 and x32 on amd64) of the architectures it
+// lists, or of the native one if it lists none, rather than the syscalls of
+// these architectures being rejected.
+const seccompCompatArchsAnnotation = "org.opencontainers.runc.seccomp.compat-archs"
+
+// seccompErrnoAnnotation makes the given syscalls fail with the given errno,
+// replacing the rules for these syscalls in the seccomp profile. Its value is
+// a JSON object mapping syscall names to errno numbers or names, e.g.
+// {"clone3":"ENOSYS","mkdirat":13}. If there is no seccomp profile, one
+// which allows everything else is used.
+const seccompErrnoAnnotation = "org.opencontainers.runc.seccomp.errno"
+
+// parseSyscallErrnos parses the value of seccompErrnoAnnotation.
+func parseSyscallErrnos(v string) (map[string]uint, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(v), &raw); err != nil {
+		return nil, err
+	}
+	errnos := make(map[string]uint, len(raw))
+	for name, r := range raw {
+		var errno uint
+		if err := json.Unmarshal(r, &errno); err == nil {
+			errnos[name] = errno
+			continue
+		}
+		var errnoName string
+		if err := json.Unmarshal(r, &errnoName); err != nil {
+			return nil, fmt.Errorf("invalid errno %s for %s", r, name)
+		}
+		errno = errnoFromName(errnoName)
+		if errno == 0 {
+			return nil, fmt.Errorf("unknown errno %s for %s", errnoName, name)
+		}
+		errnos[name] = errno
+	}
+	return errnos, nil
+}
+
+// errnoFromName returns the errno with the given name (e.g. "ENOSYS"), or 0
+// if there is none.
+func errnoFromName(name string) uint {
+	for e := unix.Errno(1); e < 4096; e++ {
+		if unix.ErrnoName(e) == name {
+			return uint(e)
+		}
+	}
+	return 0
+}
+
+// setSyscallErrnos adds seccomp rules to config making the given syscalls
+// fail with the given errno, replacing any rules for these syscalls in the
+// existing seccomp profile. If there is no seccomp profile, one which allows
+// everything else is used.
+func setSyscallErrnos(config *configs.Config, errnos map[string]uint) {
+	if config.Seccomp == nil {
+		config.Seccomp = &configs.Seccomp{DefaultAction: configs.Allow}
+	}
+	syscalls := config.Seccomp.Syscalls[:0]
+	for _, call := range config.Seccomp.Syscalls {
+		if _, ok := errnos[call.Name]; !ok {
+			syscalls = append(syscalls, call)
+		}
+	}
+	names := make([]string, 0, len(errnos))
+	for name := range errnos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errnoRet := errnos[name]
+		syscalls = append(syscalls, &configs.Syscall{
+			Name:     name,
+			Action:   configs.Errno,
+			ErrnoRet: &errnoRet,
+		})
+	}
+	config.Seccomp.Syscalls = syscalls
+}
+
 func toConfigIDMap(specMaps []specs.LinuxIDMapping) []configs.IDMap {
 	if specMaps == nil {
 		return nil
@@ -623,6 +930,22 @@ func checkPropertyName(s string) error {
 	return nil
 }
 
+// personalityFlags maps personality flag names, as used in
+// <linux/personality.h>, to their values.
+var personalityFlags = map[string]int{
+	"UNAME26":            configs.PerUname26,
+	"ADDR_NO_RANDOMIZE":  configs.PerAddrNoRandomize,
+	"FDPIC_FUNCPTRS":     configs.PerFdpicFuncptrs,
+	"MMAP_PAGE_ZERO":     configs.PerMmapPageZero,
+	"ADDR_COMPAT_LAYOUT": configs.PerAddrCompatLayout,
+	"READ_IMPLIES_EXEC":  configs.PerReadImpliesExec,
+	"ADDR_LIMIT_32BIT":   configs.PerAddrLimit32bit,
+	"SHORT_INODE":        configs.PerShortInode,
+	"WHOLE_SECONDS":      configs.PerWholeSeconds,
+	"STICKY_TIMEOUTS":    configs.PerStickyTimeouts,
+	"ADDR_LIMIT_3GB":     configs.PerAddrLimit3gb,
+}
+
 // getLinuxPersonalityFromStr converts the string domain received from spec to equivalent integer.
 func getLinuxPersonalityFromStr(domain string) (int, error) {
 	if domain == string(specs.PerLinux32) {
@@ -697,6 +1020,74 @@ func initSystemdProps(spec *specs.Spec) ([]systemdDbus.Property, error) {
 	return sp, nil
 }
 
+// splitSubCgroupsAnnotation enables the runtime/workload sub-cgroup layout
+// (see configs.Cgroup.SplitSubCgroups).
+const splitSubCgroupsAnnotation = "org.opencontainers.runc.cgroups.split"
+
+// rtSubCgroupAnnotation is the name of the sub-cgroup given the real-time
+// CPU budget of the container (see configs.Cgroup.RtSubCgroup).
+const rtSubCgroupAnnotation = "org.opencontainers.runc.cgroups.rt-sub-cgroup"
+
+// rtSubCgroupCommandsAnnotation is a comma-separated list of the commands
+// run in the real-time sub-cgroup (see configs.Cgroup.RtSubCgroupCommands).
+const rtSubCgroupCommandsAnnotation = "org.opencontainers.runc.cgroups.rt-sub-cgroup.commands"
+
+// execAccountingAnnotation makes runc account for the resource usage of the
+// processes executed in the container separately (see
+// configs.Cgroup.ExecAccounting).
+const execAccountingAnnotation = "org.opencontainers.runc.cgroups.exec-accounting"
+
+// delegateCgroupAnnotation makes the container's cgroup owned by the root
+// user of the container's user namespace (see configs.Cgroup.OwnerUID), so
+// that it can create sub-cgroups, e.g. to manage its real-time threads.
+const delegateCgroupAnnotation = "org.opencontainers.runc.cgroups.delegate"
+
+// adoptCgroupAnnotation makes runc use a cgroup created by an external
+// manager (see configs.Cgroup.Adopt).
+const adoptCgroupAnnotation = "org.opencontainers.runc.cgroups.adopt"
+
+// cpuLatencyNiceAnnotation sets the latency nice value of the container's
+// cgroup (see configs.Resources.CpuLatencyNice).
+const cpuLatencyNiceAnnotation = "org.opencontainers.runc.cpu.latency-nice"
+
+// cpuRtPercentAnnotation sets the real-time runtime of the container as a
+// percentage of the real-time period (see configs.Resources.CpuRtPercent),
+// instead of linux.resources.cpu.realtimeRuntime.
+const cpuRtPercentAnnotation = "org.opencontainers.runc.cpu.rt-percent"
+
+// resourcePolicyAnnotation selects the resource policy used when applying
+// the container resources (see configs.Cgroup.ResourcePolicy).
+const resourcePolicyAnnotation = "org.opencontainers.runc.cgroups.resource-policy"
+
+// attachRetryAnnotation configures the retries of adding a process to the
+// container cgroup (see configs.AttachRetry), as comma-separated
+// attempts=<n> and backoff=<duration> options, e.g. "attempts=10,backoff=5ms".
+const attachRetryAnnotation = "org.opencontainers.runc.cgroups.attach-retry"
+
+func parseAttachRetry(v string) (*configs.AttachRetry, error) {
+	retry := &configs.AttachRetry{}
+	for _, opt := range strings.Split(v, ",") {
+		key, val, _ := strings.Cut(opt, "=")
+		switch key {
+		case "attempts":
+			n, err := strconv.Atoi(val)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid number of attempts %q", val)
+			}
+			retry.Attempts = n
+		case "backoff":
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid backoff %q", val)
+			}
+			retry.Backoff = d
+		default:
+			return nil, fmt.Errorf("unknown option %q", opt)
+		}
+	}
+	return retry, nil
+}
+
 func CreateCgroupConfig(opts *CreateOpts, defaultDevs []*devices.Device) (*configs.Cgroup, error) {
 	var (
 		myCgroupPath string
@@ -720,6 +1111,47 @@ func CreateCgroupConfig(opts *CreateOpts, defaultDevs []*devices.Device) (*confi
 		c.SystemdProps = sp
 	}
 
+	for k, opt := range map[string]*bool{
+		splitSubCgroupsAnnotation: &c.SplitSubCgroups,
+		adoptCgroupAnnotation:     &c.Adopt,
+		execAccountingAnnotation:  &c.ExecAccounting,
+	} {
+		if v, ok := spec.Annotations[k]; ok {
+			val, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("annotation %s=%s value parse error: %w", k, v, err)
+			}
+			*opt = val
+		}
+	}
+	c.RtSubCgroup = spec.Annotations[rtSubCgroupAnnotation]
+	if v := spec.Annotations[rtSubCgroupCommandsAnnotation]; v != "" {
+		c.RtSubCgroupCommands = strings.Split(v, ",")
+	}
+	if v, ok := spec.Annotations[cpuLatencyNiceAnnotation]; ok {
+		nice, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", cpuLatencyNiceAnnotation, v, err)
+		}
+		c.Resources.CpuLatencyNice = &nice
+	}
+	if v, ok := spec.Annotations[attachRetryAnnotation]; ok {
+		retry, err := parseAttachRetry(v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s: %w", attachRetryAnnotation, v, err)
+		}
+		c.Resources.AttachRetry = retry
+	}
+	if v, ok := spec.Annotations[resourcePolicyAnnotation]; ok {
+		if _, err := cgroups.GetResourcePolicy(v); err != nil {
+			return nil, fmt.Errorf("annotation %s=%s: %w", resourcePolicyAnnotation, v, err)
+		}
+		c.ResourcePolicy = v
+	}
+	if err := createNetworkRate(spec, c.Resources); err != nil {
+		return nil, err
+	}
+
 	if spec.Linux != nil && spec.Linux.CgroupsPath != "" {
 		if useSystemdCgroup {
 			myCgroupPath = spec.Linux.CgroupsPath
@@ -744,11 +1176,22 @@ func CreateCgroupConfig(opts *CreateOpts, defaultDevs []*devices.Device) (*confi
 			c.ScopePrefix = parts[1]
 			c.Name = parts[2]
 		}
+		if opts.CgroupRoot != "" {
+			c.Parent = opts.CgroupRoot
+		}
 	} else {
 		if myCgroupPath == "" {
 			c.Name = name
 		}
 		c.Path = myCgroupPath
+		if root := opts.CgroupRoot; root != "" {
+			root = libcontainerUtils.CleanPath(root)
+			if c.Path != "" {
+				c.Path = filepath.Join(root, filepath.Base(c.Path))
+			} else {
+				c.Parent = root
+			}
+		}
 	}
 
 	// In rootless containers, any attempt to make cgroup changes is likely to fail.
@@ -913,6 +1356,20 @@ func CreateCgroupConfig(opts *CreateOpts, defaultDevs []*devices.Device) (*confi
 		}
 	}
 
+	if v, ok := spec.Annotations[cpuRtPercentAnnotation]; ok {
+		percent, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s=%s value parse error: %w", cpuRtPercentAnnotation, v, err)
+		}
+		if c.Resources.CpuRtRuntime != 0 {
+			return nil, fmt.Errorf("annotation %s can't be used with linux.resources.cpu.realtimeRuntime", cpuRtPercentAnnotation)
+		}
+		c.Resources.CpuRtPercent = percent
+		if err := cgroups.ResolveRtPercent(c.Resources, ""); err != nil {
+			return nil, fmt.Errorf("annotation %s=%s: %w", cpuRtPercentAnnotation, v, err)
+		}
+	}
+
 	// Append the default allowed devices to the end of the list.
 	for _, device := range defaultDevs {
 		c.Resources.Devices = append(c.Resources.Devices, &device.Rule)
@@ -969,6 +1426,24 @@ next:
 	// Merge in additional devices from the spec.
 	if spec.Linux != nil {
 		for _, d := range spec.Linux.Devices {
+			if isPassthroughDevice(&d) {
+				devs, err := expandPassthroughDevice(&d)
+				if err != nil {
+					return nil, err
+				}
+			nextPassthrough:
+				for _, dev := range devs {
+					// /dev/vfio/vfio is shared by all VFIO groups.
+					for _, cd := range config.Devices {
+						if cd.Path == dev.Path {
+							continue nextPassthrough
+						}
+					}
+					config.Devices = append(config.Devices, dev)
+					dedupedAllowDevs = append(dedupedAllowDevs, dev)
+				}
+				continue
+			}
 			var uid, gid uint32
 			var filemode os.FileMode = 0o666
 
@@ -1012,6 +1487,15 @@ func setupUserNamespace(spec *specs.Spec, config *configs.Config) error {
 		// Cache the current userns mappings in our configuration, so that we
 		// can calculate uid and gid mappings within runc. These mappings are
 		// never used for configuring the container if the path is set.
+		if _, ok, _ := configs.NsPidfd(path); ok {
+			f, err := configs.OpenNsPath(configs.NEWUSER, path)
+			if err != nil {
+				return fmt.Errorf("failed to open userns: %w", err)
+			}
+			defer f.Close()
+			// The fd is inherited by the process joining the namespace.
+			path = "/proc/self/fd/" + strconv.Itoa(int(f.Fd()))
+		}
 		uidMap, gidMap, err := userns.GetUserNamespaceMappings(path)
 		if err != nil {
 			return fmt.Errorf("failed to cache mappings for userns: %w", err)
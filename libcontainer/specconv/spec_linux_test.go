@@ -2,10 +2,13 @@ package specconv
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	dbus "github.com/godbus/dbus/v5"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/configs/validate"
 	"github.com/opencontainers/runc/libcontainer/devices"
@@ -535,6 +538,37 @@ func TestLinuxCgroupsPathNotSpecified(t *testing.T) {
 	}
 }
 
+func TestLinuxCgroupRoot(t *testing.T) {
+	for _, tc := range []struct {
+		cgroupsPath, expPath, expParent string
+		systemd                         bool
+	}{
+		{cgroupsPath: "/kubepods/pod1/id", expPath: "/kubepods/pod2/id"},
+		{expParent: "/kubepods/pod2"},
+		{cgroupsPath: "pod1.slice:cri:id", expParent: "pod2.slice", systemd: true},
+	} {
+		spec := &specs.Spec{Linux: &specs.Linux{CgroupsPath: tc.cgroupsPath}}
+		root := "/kubepods/pod2"
+		if tc.systemd {
+			root = "pod2.slice"
+		}
+		opts := &CreateOpts{
+			CgroupName:       "id",
+			UseSystemdCgroup: tc.systemd,
+			CgroupRoot:       root,
+			Spec:             spec,
+		}
+		cgroup, err := CreateCgroupConfig(opts, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cgroup.Path != tc.expPath || cgroup.Parent != tc.expParent {
+			t.Errorf("%q: expected path %q and parent %q, got %q and %q",
+				tc.cgroupsPath, tc.expPath, tc.expParent, cgroup.Path, cgroup.Parent)
+		}
+	}
+}
+
 func TestSpecconvExampleValidate(t *testing.T) {
 	spec := Example()
 	spec.Root.Path = "/"
@@ -956,3 +990,722 @@ func TestCreateDevices(t *testing.T) {
 		t.Errorf("device /dev/ram0 not found in config devices; got %v", conf.Devices)
 	}
 }
+
+func TestCreateDevicesPassthrough(t *testing.T) {
+	hostDevs := map[string]*devices.Device{
+		"/dev/vfio/vfio": {Rule: devices.Rule{Type: devices.CharDevice, Major: 10, Minor: 196}, Path: "/dev/vfio/vfio", FileMode: 0o666},
+		"/dev/vfio/12":   {Rule: devices.Rule{Type: devices.CharDevice, Major: 243, Minor: 0}, Path: "/dev/vfio/12", FileMode: 0o600},
+		"/dev/vfio/13":   {Rule: devices.Rule{Type: devices.CharDevice, Major: 243, Minor: 1}, Path: "/dev/vfio/13", FileMode: 0o600},
+		"/dev/uio0":      {Rule: devices.Rule{Type: devices.CharDevice, Major: 240, Minor: 0}, Path: "/dev/uio0", FileMode: 0o600},
+	}
+	deviceFromPath = func(path, perms string) (*devices.Device, error) {
+		d, ok := hostDevs[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		dev := *d
+		dev.Permissions = devices.Permissions(perms)
+		return &dev, nil
+	}
+	defer func() { deviceFromPath = devices.DeviceFromPath }()
+
+	spec := Example()
+	spec.Linux.Devices = []specs.LinuxDevice{
+		{Type: "vfio:12"},
+		{Type: "vfio:13"},
+		{Type: "uio:0"},
+	}
+	conf := &configs.Config{}
+	allowDevs, err := createDevices(spec, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path := range hostDevs {
+		n := 0
+		for _, d := range conf.Devices {
+			if d.Path == path {
+				n++
+			}
+		}
+		if n != 1 {
+			t.Errorf("expected device %s to be in config exactly once, found %d times", path, n)
+		}
+		found := false
+		for _, d := range allowDevs {
+			if d.Path == path && d.Allow && d.Permissions == "rwm" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected an allow rule for device %s", path)
+		}
+	}
+
+	for _, typ := range []string{"vfio:", "vfio:../1", "vfio:noiommu-x", "uio:-1", "vfio:14"} {
+		spec.Linux.Devices = []specs.LinuxDevice{{Type: typ}}
+		if _, err := createDevices(spec, &configs.Config{}); err == nil {
+			t.Errorf("expected error for device type %q, got nil", typ)
+		}
+	}
+}
+
+func TestVfioCapabilities(t *testing.T) {
+	caps := &configs.Capabilities{
+		Bounding:  []string{"CAP_IPC_LOCK"},
+		Effective: []string{"CAP_CHOWN"},
+	}
+	addVfioCapabilities(caps)
+	for name, set := range map[string][]string{
+		"bounding":  caps.Bounding,
+		"effective": caps.Effective,
+		"permitted": caps.Permitted,
+	} {
+		n := 0
+		for _, c := range set {
+			if c == "CAP_IPC_LOCK" {
+				n++
+			}
+		}
+		if n != 1 {
+			t.Errorf("expected CAP_IPC_LOCK once in %s set, got %v", name, set)
+		}
+	}
+}
+
+func TestCreateNetworkInterfaces(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{
+		networkInterfacesAnnotation: `[{"hostInterfaceName": "enp1s0f0v1", "name": "eth0", "mtu": 9000}, {"hostInterfaceName": "macvlan0"}]`,
+	}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Networks) != 3 {
+		t.Fatalf("expected 3 networks, got %d", len(config.Networks))
+	}
+	if config.Networks[0].Type != "loopback" {
+		t.Errorf("expected loopback first, got %q", config.Networks[0].Type)
+	}
+	exp := []configs.Network{
+		{Type: "netdev", Name: "eth0", HostInterfaceName: "enp1s0f0v1", Mtu: 9000},
+		{Type: "netdev", Name: "macvlan0", HostInterfaceName: "macvlan0"},
+	}
+	for i, n := range config.Networks[1:] {
+		if *n != exp[i] {
+			t.Errorf("expected %+v, got %+v", exp[i], *n)
+		}
+	}
+	if err := validate.Validate(config); err != nil {
+		t.Errorf("Expected specconv to produce valid container config: %v", err)
+	}
+
+	for _, v := range []string{
+		`{"hostInterfaceName": "eth0"}`,
+		`[{"name": "eth0"}]`,
+		`[{"hostInterfaceName": "eth0", "name": "lo"}]`,
+		`[{"hostInterfaceName": "eth0"}, {"hostInterfaceName": "eth1", "name": "eth0"}]`,
+	} {
+		spec.Annotations[networkInterfacesAnnotation] = v
+		if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+			t.Errorf("expected error for %s", v)
+		}
+	}
+
+	spec.Annotations[networkInterfacesAnnotation] = `[{"hostInterfaceName": "eth0"}]`
+	for i, ns := range spec.Linux.Namespaces {
+		if ns.Type == specs.NetworkNamespace {
+			spec.Linux.Namespaces[i].Path = "/proc/1/ns/net"
+		}
+	}
+	if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+		t.Error("expected error when joining an existing network namespace")
+	}
+}
+
+func TestCreateNetworkStaticConfig(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{
+		networkInterfacesAnnotation: `[{"hostInterfaceName": "eth1", "address": "10.0.0.2/24", "gateway": "10.0.0.1", "ipv6Address": "fd00::2/64"}]`,
+		networkRoutesAnnotation:     `[{"destination": "10.1.0.0/16", "gateway": "10.0.0.254", "interfaceName": "eth1"}]`,
+	}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := config.Networks[len(config.Networks)-1]
+	if n.Address != "10.0.0.2/24" || n.Gateway != "10.0.0.1" || n.IPv6Address != "fd00::2/64" || n.IPv6Gateway != "" {
+		t.Errorf("unexpected network %+v", *n)
+	}
+	expRoute := configs.Route{Destination: "10.1.0.0/16", Gateway: "10.0.0.254", InterfaceName: "eth1"}
+	if len(config.Routes) != 1 || *config.Routes[0] != expRoute {
+		t.Errorf("expected routes [%+v], got %+v", expRoute, config.Routes)
+	}
+
+	for _, v := range []string{
+		`[{"destination": "10.1.0.0/16"}]`,
+		`[{"interfaceName": "eth1"}]`,
+		`not json`,
+	} {
+		spec.Annotations[networkRoutesAnnotation] = v
+		if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+			t.Errorf("expected error for %s", v)
+		}
+	}
+}
+
+func TestCreateNetworkVirtualFunction(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{
+		networkInterfacesAnnotation: `[{"hostInterfaceName": "enp1s0f0v1", "virtualFunction": {"vlan": 100, "qos": 3, "maxTxRate": 1000, "spoofCheck": false}}]`,
+	}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vf := config.Networks[len(config.Networks)-1].VirtualFunction
+	if vf == nil {
+		t.Fatal("expected virtual function settings")
+	}
+	if vf.Vlan != 100 || vf.Qos != 3 || vf.MinTxRate != 0 || vf.MaxTxRate != 1000 || vf.Trust != nil {
+		t.Errorf("unexpected virtual function settings %+v", *vf)
+	}
+	if vf.SpoofCheck == nil || *vf.SpoofCheck {
+		t.Errorf("expected spoof checking to be disabled, got %v", vf.SpoofCheck)
+	}
+
+	for _, v := range []string{
+		`{"vlan": 4096}`,
+		`{"qos": 1}`,
+		`{"vlan": 1, "qos": 8}`,
+		`{"minTxRate": 100, "maxTxRate": 10}`,
+		`{"maxTxRate": -1}`,
+	} {
+		spec.Annotations[networkInterfacesAnnotation] = `[{"hostInterfaceName": "enp1s0f0v1", "virtualFunction": ` + v + `}]`
+		if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+			t.Errorf("expected error for %s", v)
+		}
+	}
+}
+
+func TestSplitSubCgroups(t *testing.T) {
+	for _, tc := range []struct {
+		value  string
+		exp    bool
+		expErr bool
+	}{
+		{value: "", exp: false},
+		{value: "true", exp: true},
+		{value: "false", exp: false},
+		{value: "yes", expErr: true},
+	} {
+		spec := &specs.Spec{}
+		if tc.value != "" {
+			spec.Annotations = map[string]string{splitSubCgroupsAnnotation: tc.value}
+		}
+		cg, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil)
+		if tc.expErr {
+			if err == nil {
+				t.Errorf("%q: expected error", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", tc.value, err)
+		}
+		if cg.SplitSubCgroups != tc.exp {
+			t.Errorf("%q: expected SplitSubCgroups %v, got %v", tc.value, tc.exp, cg.SplitSubCgroups)
+		}
+	}
+}
+
+func TestDestroyTimeout(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DestroyTimeout != 0 {
+		t.Errorf("expected no destroy timeout, got %s", config.DestroyTimeout)
+	}
+
+	spec.Annotations = map[string]string{destroyTimeoutAnnotation: "30s"}
+	config, err = CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DestroyTimeout != 30*time.Second {
+		t.Errorf("expected destroy timeout of 30s, got %s", config.DestroyTimeout)
+	}
+
+	for _, v := range []string{"30", "-1s", "0s"} {
+		spec.Annotations[destroyTimeoutAnnotation] = v
+		if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+			t.Errorf("expected error for %q", v)
+		}
+	}
+}
+
+func TestExecPolicyAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{
+		execPolicyAnnotation: `{"apparmor_profiles":["unconfined"],"allow_new_privileges":true}`,
+	}
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &configs.ExecPolicy{AppArmorProfiles: []string{"unconfined"}, AllowNewPrivileges: true}
+	if !reflect.DeepEqual(config.ExecPolicy, exp) {
+		t.Errorf("expected exec policy %+v, got %+v", exp, config.ExecPolicy)
+	}
+
+	spec.Annotations[execPolicyAnnotation] = "unconfined"
+	if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+		t.Error("expected error for an invalid exec policy")
+	}
+}
+
+func TestPersonalityFlags(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Linux.Personality = &specs.LinuxPersonality{
+		Domain: specs.PerLinux32,
+		Flags:  []specs.LinuxPersonalityFlag{"ADDR_NO_RANDOMIZE", "UNAME26"},
+	}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Personality.Domain != configs.PerLinux32 {
+		t.Errorf("expected domain %#x, got %#x", configs.PerLinux32, config.Personality.Domain)
+	}
+	if exp := configs.PerAddrNoRandomize | configs.PerUname26; config.Personality.Flags != exp {
+		t.Errorf("expected flags %#x, got %#x", exp, config.Personality.Flags)
+	}
+
+	spec.Linux.Personality.Flags = []specs.LinuxPersonalityFlag{"NO_SUCH_FLAG"}
+	if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+		t.Error("expected error for an invalid flag")
+	}
+}
+
+func TestDisableIOUring(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{disableIOUringAnnotation: "true"}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Seccomp == nil || config.Seccomp.DefaultAction != configs.Allow {
+		t.Fatalf("expected a seccomp profile allowing everything by default, got %+v", config.Seccomp)
+	}
+	checkRules := func() {
+		t.Helper()
+		var n int
+		for _, call := range config.Seccomp.Syscalls {
+			if !strings.HasPrefix(call.Name, "io_uring_") {
+				continue
+			}
+			n++
+			if call.Action != configs.Errno || call.ErrnoRet == nil || *call.ErrnoRet != uint(unix.EPERM) {
+				t.Errorf("unexpected rule for %s: %+v", call.Name, call)
+			}
+		}
+		if n != len(ioUringSyscalls) {
+			t.Errorf("expected %d io_uring rules, got %d", len(ioUringSyscalls), n)
+		}
+	}
+	checkRules()
+
+	// Rules from the existing profile are replaced.
+	spec.Linux.Seccomp = &specs.LinuxSeccomp{
+		DefaultAction: specs.ActErrno,
+		Syscalls: []specs.LinuxSyscall{
+			{Names: []string{"read", "io_uring_setup", "io_uring_enter"}, Action: specs.ActAllow},
+		},
+	}
+	config, err = CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Seccomp.DefaultAction != configs.Errno {
+		t.Errorf("expected the default action to be kept, got %v", config.Seccomp.DefaultAction)
+	}
+	checkRules()
+	if len(config.Seccomp.Syscalls) != len(ioUringSyscalls)+1 || config.Seccomp.Syscalls[0].Name != "read" {
+		t.Errorf("unexpected rules: %+v", config.Seccomp.Syscalls)
+	}
+
+	spec.Annotations[disableIOUringAnnotation] = "yes please"
+	if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+		t.Error("expected error for an invalid annotation value")
+	}
+}
+
+func TestSeccompErrnoAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	errnoRet := uint(unix.EPERM)
+	spec.Linux.Seccomp = &specs.LinuxSeccomp{
+		DefaultAction:   specs.ActErrno,
+		DefaultErrnoRet: &errnoRet,
+		Syscalls: []specs.LinuxSyscall{
+			{Names: []string{"read", "clone3"}, Action: specs.ActAllow},
+		},
+	}
+	spec.Annotations = map[string]string{seccompErrnoAnnotation: `{"clone3":"ENOSYS","mkdirat":13}`}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Seccomp.DefaultErrnoRet == nil || *config.Seccomp.DefaultErrnoRet != errnoRet {
+		t.Errorf("expected the default errno to be kept, got %v", config.Seccomp.DefaultErrnoRet)
+	}
+	want := map[string]uint{"read": 0, "clone3": uint(unix.ENOSYS), "mkdirat": 13}
+	if len(config.Seccomp.Syscalls) != len(want) {
+		t.Fatalf("unexpected rules: %+v", config.Seccomp.Syscalls)
+	}
+	for _, call := range config.Seccomp.Syscalls {
+		errno, ok := want[call.Name]
+		switch {
+		case !ok:
+			t.Errorf("unexpected rule for %s", call.Name)
+		case errno == 0:
+			if call.Action != configs.Allow {
+				t.Errorf("expected the rule for %s to be kept, got %+v", call.Name, call)
+			}
+		case call.Action != configs.Errno || call.ErrnoRet == nil || *call.ErrnoRet != errno:
+			t.Errorf("expected %s to fail with errno %d, got %+v", call.Name, errno, call)
+		}
+	}
+
+	for _, v := range []string{`{"clone3":"ENOSUCHERRNO"}`, `{"clone3":-1}`, `["clone3"]`} {
+		spec.Annotations[seccompErrnoAnnotation] = v
+		if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+			t.Errorf("expected error for %s", v)
+		}
+	}
+}
+
+func TestSeccompCompatArchsAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Linux.Seccomp = &specs.LinuxSeccomp{
+		DefaultAction: specs.ActErrno,
+		Architectures: []specs.Arch{specs.ArchX86_64, specs.ArchAARCH64, specs.ArchX86},
+	}
+	spec.Annotations = map[string]string{seccompCompatArchsAnnotation: "true"}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"amd64", "arm64", "x86", "x32", "arm"}
+	if !reflect.DeepEqual(config.Seccomp.Architectures, want) {
+		t.Errorf("expected architectures %v, got %v", want, config.Seccomp.Architectures)
+	}
+
+	spec.Annotations[seccompCompatArchsAnnotation] = "false"
+	config, err = CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Seccomp.Architectures) != 3 {
+		t.Errorf("expected the architectures to be kept, got %v", config.Seccomp.Architectures)
+	}
+}
+
+func TestCoreSched(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+
+	for _, tc := range []struct {
+		annotations map[string]string
+		exp         *configs.CoreSched
+		isErr       bool
+	}{
+		{},
+		{annotations: map[string]string{coreSchedAnnotation: "false"}},
+		{annotations: map[string]string{coreSchedAnnotation: "true"}, exp: &configs.CoreSched{}},
+		{annotations: map[string]string{coreSchedGroupAnnotation: "pod1"}, exp: &configs.CoreSched{Group: "pod1"}},
+		{annotations: map[string]string{coreSchedAnnotation: "maybe"}, isErr: true},
+	} {
+		spec.Annotations = tc.annotations
+		config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("%v: expected error, got nil", tc.annotations)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: %v", tc.annotations, err)
+		}
+		if !reflect.DeepEqual(config.CoreSched, tc.exp) {
+			t.Errorf("%v: expected %+v, got %+v", tc.annotations, tc.exp, config.CoreSched)
+		}
+	}
+}
+
+func TestDelegateCgroup(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+
+	// No user namespace.
+	spec.Annotations = map[string]string{delegateCgroupAnnotation: "true"}
+	if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	spec.Linux.Namespaces = append(spec.Linux.Namespaces, specs.LinuxNamespace{Type: specs.UserNamespace})
+	spec.Linux.UIDMappings = []specs.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	spec.Linux.GIDMappings = []specs.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	for _, tc := range []struct {
+		value string
+		exp   *int
+		isErr bool
+	}{
+		{value: "false"},
+		{value: "true", exp: &[]int{100000}[0]},
+		{value: "yes", isErr: true},
+	} {
+		spec.Annotations = map[string]string{delegateCgroupAnnotation: tc.value}
+		config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.value, err)
+		}
+		if !reflect.DeepEqual(config.Cgroups.OwnerUID, tc.exp) {
+			t.Errorf("%s: expected owner %v, got %v", tc.value, tc.exp, config.Cgroups.OwnerUID)
+		}
+	}
+}
+
+func TestPseudoLockAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{pseudoLockAnnotation: "L2:1=0x3"}
+
+	// No linux.intelRdt.
+	if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	spec.Linux.IntelRdt = &specs.LinuxIntelRdt{}
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl := config.IntelRdt.PseudoLock; pl == nil || pl.Schema != "L2:1=0x3" {
+		t.Errorf("expected pseudo-lock schema L2:1=0x3, got %+v", pl)
+	}
+}
+
+func TestNetworkRateAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{networkRateAnnotation: `{"egress": {"rate": 1000}, "ingress": {"rate": 2000, "burst": 4000}}`}
+
+	cg, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &configs.NetRate{
+		Egress:  &configs.NetRateLimit{Rate: 1000},
+		Ingress: &configs.NetRateLimit{Rate: 2000, Burst: 4000},
+	}
+	if !reflect.DeepEqual(cg.Resources.NetRate, expected) {
+		t.Errorf("expected %+v, got %+v", expected, cg.Resources.NetRate)
+	}
+
+	spec.Annotations[networkRateAnnotation] = `{"egress": 1000}`
+	if _, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestExclusivePlacement(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{exclusivePlacementAnnotation: "2"}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ExclusiveCPUs != 2 {
+		t.Errorf("expected 2 exclusive cpus, got %d", config.ExclusiveCPUs)
+	}
+
+	spec.Annotations[exclusivePlacementAnnotation] = "two"
+	if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+		t.Error("expected error for an invalid number of cpus")
+	}
+}
+
+func TestStopSignalAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		exp   int
+	}{
+		{value: "SIGQUIT", exp: int(unix.SIGQUIT)},
+		{value: "quit", exp: int(unix.SIGQUIT)},
+		{value: "37", exp: 37},
+		{value: "SIGNOPE"},
+		{value: "0"},
+	} {
+		spec := Example()
+		spec.Root.Path = "/"
+		spec.Annotations = map[string]string{stopSignalAnnotation: tc.value}
+		config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+		if tc.exp == 0 {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.value, err)
+			continue
+		}
+		if config.StopSignal != tc.exp {
+			t.Errorf("%s: expected %d, got %d", tc.value, tc.exp, config.StopSignal)
+		}
+	}
+}
+
+func TestPrestopHooksAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{
+		prestopHooksAnnotation: `[{"path":"/bin/drain","args":["drain","-q"],"timeout":5}]`,
+	}
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks := config.Hooks[configs.Prestop]
+	if len(hooks) != 1 {
+		t.Fatalf("expected 1 prestop hook, got %d", len(hooks))
+	}
+	timeout := 5 * time.Second
+	exp := configs.Command{Path: "/bin/drain", Args: []string{"drain", "-q"}, Timeout: &timeout}
+	if cmd := hooks[0].(configs.CommandHook).Command; !reflect.DeepEqual(cmd, exp) {
+		t.Errorf("expected %+v, got %+v", exp, cmd)
+	}
+
+	spec.Annotations[prestopHooksAnnotation] = "/bin/drain"
+	if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+		t.Error("expected error for invalid hooks")
+	}
+}
+
+func TestTimerSlackAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{timerSlackAnnotation: "1us"}
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TimerSlack != 1000 {
+		t.Errorf("expected timer slack 1000, got %d", config.TimerSlack)
+	}
+
+	for _, v := range []string{"0", "-1us", "1000"} {
+		spec.Annotations[timerSlackAnnotation] = v
+		if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+			t.Errorf("%s: expected error, got nil", v)
+		}
+	}
+}
+
+func TestAttachRetryAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{attachRetryAnnotation: "attempts=10,backoff=5ms"}
+
+	cg, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &configs.AttachRetry{Attempts: 10, Backoff: 5 * time.Millisecond}
+	if !reflect.DeepEqual(cg.Resources.AttachRetry, expected) {
+		t.Errorf("expected %+v, got %+v", expected, cg.Resources.AttachRetry)
+	}
+
+	for _, v := range []string{"attempts=0", "backoff=5", "tries=3", ""} {
+		spec.Annotations[attachRetryAnnotation] = v
+		if _, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil); err == nil {
+			t.Errorf("%q: expected error, got nil", v)
+		}
+	}
+}
+
+func TestResourcePolicyAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Annotations = map[string]string{resourcePolicyAnnotation: cgroups.EqualSplitPolicy}
+
+	cg, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cg.ResourcePolicy != cgroups.EqualSplitPolicy {
+		t.Errorf("expected resource policy %q, got %q", cgroups.EqualSplitPolicy, cg.ResourcePolicy)
+	}
+
+	spec.Annotations[resourcePolicyAnnotation] = "no-such-policy"
+	if _, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestCpuRtPercentAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	period := uint64(500000)
+	spec.Linux.Resources.CPU = &specs.LinuxCPU{RealtimePeriod: &period}
+	spec.Annotations = map[string]string{cpuRtPercentAnnotation: "30"}
+
+	cg, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cg.Resources.CpuRtPercent != 30 || cg.Resources.CpuRtRuntime != 150000 {
+		t.Errorf("expected 30%% (150000us), got %v%% (%dus)", cg.Resources.CpuRtPercent, cg.Resources.CpuRtRuntime)
+	}
+
+	for _, v := range []string{"0x", "-1", "101"} {
+		spec.Annotations[cpuRtPercentAnnotation] = v
+		if _, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil); err == nil {
+			t.Errorf("%q: expected error, got nil", v)
+		}
+	}
+
+	runtime := int64(1000)
+	spec.Linux.Resources.CPU.RealtimeRuntime = &runtime
+	spec.Annotations[cpuRtPercentAnnotation] = "30"
+	if _, err := CreateCgroupConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}, nil); err == nil {
+		t.Error("expected error with a realtime runtime, got nil")
+	}
+}
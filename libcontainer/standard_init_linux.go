@@ -161,8 +161,8 @@ func (l *linuxStandardInit) Init() error {
 			return err
 		}
 	}
-	if l.config.Config.IOPriority != nil {
-		if err := setIOPriority(l.config.Config.IOPriority); err != nil {
+	if l.config.IOPriority != nil {
+		if err := setIOPriority(l.config.IOPriority); err != nil {
 			return err
 		}
 	}
@@ -235,6 +235,12 @@ func (l *linuxStandardInit) Init() error {
 		}
 	}
 
+	if l.config.TimerSlack != 0 {
+		if err := setTimerSlack(l.config.TimerSlack); err != nil {
+			return err
+		}
+	}
+
 	// Close the pipe to signal that we have completed our init.
 	logrus.Debugf("init: closing the pipe to signal completion")
 	_ = l.pipe.Close()
@@ -0,0 +1,93 @@
+package libcontainer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// freezeVerified checks that the resources reserved for the container are
+// in effect (see verifyResources), and freezes its cgroup, so that the
+// container init does not run any further until it is thawed by
+// [Container.Exec].
+func freezeVerified(m cgroups.Manager, r *configs.Resources) error {
+	if err := verifyResources(m, r); err != nil {
+		return err
+	}
+	if err := m.Freeze(configs.Frozen); err != nil {
+		return fmt.Errorf("unable to freeze the container cgroup: %w", err)
+	}
+	return nil
+}
+
+// verifyResources checks that the reservations requested in r are in
+// effect: that all the requested CPUs and memory nodes are allowed by the
+// ancestor cgroups, and, with cgroup v1, that the container cgroup has the
+// requested real-time runtime and period, and that none of its ancestors
+// has a zero real-time runtime.
+func verifyResources(m cgroups.Manager, r *configs.Resources) error {
+	if r == nil {
+		return nil
+	}
+	if r.CpusetCpus != "" {
+		if err := verifyCpusetList("cpus", r.CpusetCpus, m.GetEffectiveCpus); err != nil {
+			return err
+		}
+	}
+	if r.CpusetMems != "" {
+		if err := verifyCpusetList("memory nodes", r.CpusetMems, m.GetEffectiveMems); err != nil {
+			return err
+		}
+	}
+	if r.CpuRtRuntime <= 0 || cgroups.IsCgroup2UnifiedMode() {
+		return nil
+	}
+	dir := m.ControllerPath("cpu")
+	if dir == "" {
+		return nil
+	}
+	levels, err := cgroups.GetRtHeadroom(dir)
+	if err != nil {
+		return fmt.Errorf("unable to verify the real-time budget: %w", err)
+	}
+	if have := levels[0].RtBandwidth; have.Runtime != r.CpuRtRuntime || (r.CpuRtPeriod != 0 && have.Period != r.CpuRtPeriod) {
+		return fmt.Errorf("real-time budget not in effect: requested runtime %d, period %d, have runtime %d, period %d",
+			r.CpuRtRuntime, r.CpuRtPeriod, have.Runtime, have.Period)
+	}
+	for _, level := range levels[1:] {
+		if level.Runtime == 0 {
+			return fmt.Errorf("real-time budget not in effect: ancestor cgroup %s has no real-time runtime", level.Path)
+		}
+	}
+	return nil
+}
+
+func verifyCpusetList(what, requested string, getEffective func() (string, error)) error {
+	effective, err := getEffective()
+	if err != nil {
+		return fmt.Errorf("unable to verify the effective %s: %w", what, err)
+	}
+	missing, err := missingFromList(requested, effective)
+	if err != nil {
+		return fmt.Errorf("unable to verify the effective %s: %w", what, err)
+	}
+	if missing != "" {
+		return fmt.Errorf("requested %s %s are not allowed by the parent cgroups (the effective %s are %q)",
+			what, missing, what, effective)
+	}
+	return nil
+}
+
+// frozenUntilStart returns whether the container was created with
+// configs.Config.StartFrozen, and not started yet, in which case its cgroup
+// is frozen while it is in the created state.
+func (c *Container) frozenUntilStart() bool {
+	if !c.config.StartFrozen {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(c.stateDir, execFifoFilename))
+	return err == nil
+}
@@ -0,0 +1,71 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestMissingFromList(t *testing.T) {
+	for _, tc := range []struct {
+		requested, effective, missing string
+	}{
+		{"0-3", "0-3", ""},
+		{"1,3", "0-7", ""},
+		{"0-3", "0,2", "1,3"},
+		{"4-7", "0-3", "4-7"},
+	} {
+		missing, err := missingFromList(tc.requested, tc.effective)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if missing != tc.missing {
+			t.Errorf("%s not in %s: expected %q, got %q", tc.requested, tc.effective, tc.missing, missing)
+		}
+	}
+	if _, err := missingFromList("0-", "0-3"); err == nil {
+		t.Error("expected an error for an invalid list, got nil")
+	}
+}
+
+func TestVerifyResourcesRt(t *testing.T) {
+	if cgroups.IsCgroup2UnifiedMode() {
+		t.Skip("cgroup v1 only")
+	}
+	cgroups.TestMode = true
+	defer func() { cgroups.TestMode = false }()
+	root := t.TempDir()
+	dir := filepath.Join(root, "ct")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(dir string, runtime string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "cpu.rt_runtime_us"), []byte(runtime), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cpu.rt_period_us"), []byte("1000000"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(root, "950000")
+	write(dir, "10000")
+	m := &mockCgroupManager{paths: map[string]string{"cpu": dir}}
+
+	r := &configs.Resources{CpuRtRuntime: 10000, CpuRtPeriod: 1000000}
+	if err := verifyResources(m, r); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	r.CpuRtRuntime = 20000
+	if err := verifyResources(m, r); err == nil {
+		t.Error("expected an error for a runtime not in effect, got nil")
+	}
+	r.CpuRtRuntime = 10000
+	write(root, "0")
+	if err := verifyResources(m, r); err == nil {
+		t.Error("expected an error for an ancestor without a runtime, got nil")
+	}
+}
@@ -1,12 +1,17 @@
 package libcontainer
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
 
@@ -47,23 +52,77 @@ func destroy(c *Container) error {
 		// Likely to fail when c.config.RootlessCgroups is true
 		_ = signalAllProcesses(c.cgroupManager, unix.SIGKILL)
 	}
+	// Make sure all the processes are gone before removing the cgroup, as
+	// otherwise the removal fails with EBUSY.
+	if err := waitForProcessesExit(c); err != nil {
+		return err
+	}
 	if err := c.cgroupManager.Destroy(); err != nil {
 		return fmt.Errorf("unable to remove container's cgroup: %w", err)
 	}
+	if err := releaseNetworkInterfaces(c.config); err != nil {
+		return fmt.Errorf("unable to release container's network interfaces: %w", err)
+	}
 	if c.intelRdtManager != nil {
 		if err := c.intelRdtManager.Destroy(); err != nil {
 			return fmt.Errorf("unable to remove container's IntelRDT group: %w", err)
 		}
 	}
+	if c.config.ExclusiveCPUs > 0 {
+		if err := releaseExclusiveCPUs(filepath.Dir(c.stateDir), c.id); err != nil {
+			return fmt.Errorf("unable to release container's exclusive cpus: %w", err)
+		}
+	}
 	if err := os.RemoveAll(c.stateDir); err != nil {
 		return fmt.Errorf("unable to remove container state dir: %w", err)
 	}
+	if err := c.store.remove(c.id); err != nil {
+		return fmt.Errorf("unable to remove container state: %w", err)
+	}
 	c.initProcess = nil
 	err := runPoststopHooks(c)
 	c.state = &stoppedState{c: c}
 	return err
 }
 
+// waitForProcessesExit waits for the container cgroup to become empty,
+// re-sending SIGKILL to any processes left, for up to the configured
+// DestroyTimeout. On timeout, it returns an error listing the processes
+// still running.
+func waitForProcessesExit(c *Container) error {
+	timeout := c.config.DestroyTimeout
+	if timeout <= 0 {
+		timeout = configs.DefaultDestroyTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	delay := time.Millisecond
+	for {
+		pids, err := c.cgroupManager.GetAllPids()
+		if err != nil {
+			// Likely the cgroup is already gone, or can't be
+			// read (rootless); let Destroy deal with it.
+			logrus.Debugf("unable to get container pids: %v", err)
+			return nil
+		}
+		if len(pids) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			procs := make([]string, 0, len(pids))
+			for _, pid := range pids {
+				comm, _ := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/comm")
+				procs = append(procs, fmt.Sprintf("%d (%s)", pid, bytes.TrimSpace(comm)))
+			}
+			return fmt.Errorf("container processes still running %s after SIGKILL: %s", timeout, strings.Join(procs, ", "))
+		}
+		_ = signalAllProcesses(c.cgroupManager, unix.SIGKILL)
+		time.Sleep(delay)
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
+
 func runPoststopHooks(c *Container) error {
 	hooks := c.config.Hooks
 	if hooks == nil {
@@ -0,0 +1,122 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// stateLockFilename is the name of the file, in the container state
+// directory, which is locked while the container state is being modified.
+const stateLockFilename = "state.lock"
+
+// The time to wait for the state lock (variables for testing purposes).
+var (
+	stateLockTimeout = 10 * time.Second
+	stateLockRetry   = 10 * time.Millisecond
+)
+
+// lockStateDir takes an exclusive advisory lock of the state directory dir,
+// so that concurrent runc invocations do not modify the container state at
+// the same time. It returns a function to release the lock.
+//
+// The lock is released by the kernel once its holder exits. The pid of the
+// holder is written to the lock file, so that if a lock is still held after
+// its holder is gone (i.e. the lock file descriptor was leaked to another
+// process), the lock file is removed and a new one is used.
+func lockStateDir(dir string) (func(), error) {
+	path := filepath.Join(dir, stateLockFilename)
+	deadline := time.Now().Add(stateLockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+		if err == nil {
+			if sameFile(f, path) {
+				if err := f.Truncate(0); err == nil {
+					_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+				}
+				return func() {
+					_ = f.Truncate(0)
+					_ = unix.Flock(int(f.Fd()), unix.LOCK_UN)
+					f.Close()
+				}, nil
+			}
+			// The stale lock file was removed while we were taking it.
+			f.Close()
+			continue
+		}
+		if !errors.Is(err, unix.EWOULDBLOCK) {
+			f.Close()
+			return nil, &os.PathError{Op: "flock", Path: path, Err: err}
+		}
+		holder := lockHolder(f)
+		f.Close()
+		if time.Now().After(deadline) {
+			if holder > 0 && !processAlive(holder) {
+				logrus.Warnf("removing stale state lock %s held by gone process %d", path, holder)
+				if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return nil, err
+				}
+				deadline = time.Now().Add(stateLockTimeout)
+				continue
+			}
+			return nil, fmt.Errorf("timed out waiting for state lock %s (held by pid %d)", path, holder)
+		}
+		time.Sleep(stateLockRetry)
+	}
+}
+
+// sameFile tells whether f is still the file at path.
+func sameFile(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi, pi)
+}
+
+// lockHolder returns the pid written to the lock file, or 0.
+func lockHolder(f *os.File) int {
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 32))
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
+
+func processAlive(pid int) bool {
+	return !errors.Is(unix.Kill(pid, 0), unix.ESRCH)
+}
+
+// lockState takes the state lock of the container, unless it is already
+// held by this container. It must be called with c.m held.
+func (c *Container) lockState() (func(), error) {
+	if c.stateLocked {
+		return func() {}, nil
+	}
+	unlock, err := lockStateDir(c.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	c.stateLocked = true
+	return func() {
+		c.stateLocked = false
+		unlock()
+	}, nil
+}
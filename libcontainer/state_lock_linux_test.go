@@ -0,0 +1,60 @@
+package libcontainer
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLockStateDir(t *testing.T) {
+	defer func(timeout time.Duration) { stateLockTimeout = timeout }(stateLockTimeout)
+	stateLockTimeout = 100 * time.Millisecond
+
+	dir := t.TempDir()
+	unlock, err := lockStateDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lock is held by a live process (us).
+	if _, err := lockStateDir(dir); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	unlock()
+
+	unlock, err = lockStateDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestLockStateDirStale(t *testing.T) {
+	defer func(timeout time.Duration) { stateLockTimeout = timeout }(stateLockTimeout)
+	stateLockTimeout = 100 * time.Millisecond
+
+	dir := t.TempDir()
+	unlock, err := lockStateDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	// Pretend the lock is held by a process which is gone.
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skip(err)
+	}
+	path := filepath.Join(dir, stateLockFilename)
+	if err := os.WriteFile(path, []byte(strconv.Itoa(cmd.Process.Pid)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock2, err := lockStateDir(dir)
+	if err != nil {
+		t.Fatalf("expected the stale lock to be recovered, got %v", err)
+	}
+	unlock2()
+}
@@ -0,0 +1,411 @@
+package libcontainer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// The state backends, i.e. the ways the container states are stored.
+const (
+	// StateBackendFiles stores the state of each container in the
+	// state.json file in the container state directory. This is the
+	// default.
+	StateBackendFiles = "files"
+	// StateBackendLog stores the states of all containers in a single
+	// append-only log file in the root directory, which is compacted from
+	// time to time. This results in much less inode churn on nodes running
+	// lots of short-lived containers.
+	StateBackendLog = "log"
+	// StateBackendMemory keeps the container states in the memory of the
+	// current process only, so that they are not written anywhere. The
+	// containers can not be used by other processes (see ErrInMemory), so
+	// this is only suitable when a container is run and waited for by a
+	// single process, e.g. on an immutable appliance. Unlike the other
+	// backends, it is not remembered for the root directory.
+	StateBackendMemory = "memory"
+)
+
+// memoryStateFilename is the name of the file, in the container state
+// directory, telling that the container state is held in memory, and by
+// which process.
+const memoryStateFilename = "memory-state"
+
+// stateLogFilename is the name of the state log file, relative to the root
+// directory. Its presence means the log backend is used.
+const stateLogFilename = "state.log"
+
+// stateLogCompactSize is the size of the state log above which it is
+// compacted (a variable for testing purposes).
+var stateLogCompactSize int64 = 1 << 20
+
+// stateStore is where the container states are saved.
+type stateStore interface {
+	// load returns the state of the container, or ErrNotExist.
+	load(id string) (*State, error)
+	save(id string, s *State) error
+	remove(id string) error
+}
+
+// memoryStateStores are the memory state stores of the root directories
+// using StateBackendMemory in this process.
+var memoryStateStores sync.Map // map[string]*memoryStateStore
+
+// newStateStore returns the state store used by the root directory.
+func newStateStore(root string) stateStore {
+	if store, ok := memoryStateStores.Load(root); ok {
+		return store.(*memoryStateStore)
+	}
+	path := filepath.Join(root, stateLogFilename)
+	if _, err := os.Stat(path); err == nil {
+		return &logStateStore{path: path}
+	}
+	return &fileStateStore{root: root}
+}
+
+// InitStateBackend sets up the root directory to use the given state
+// backend. The states of the existing containers are moved to the log when
+// switching to StateBackendLog. Switching back to StateBackendFiles is not
+// supported while there are containers in the log.
+func InitStateBackend(root, backend string) error {
+	path := filepath.Join(root, stateLogFilename)
+	switch backend {
+	case StateBackendMemory:
+		memoryStateStores.LoadOrStore(root, &memoryStateStore{root: root, states: make(map[string][]byte)})
+		return nil
+	case StateBackendFiles:
+		store, ok := newStateStore(root).(*logStateStore)
+		if !ok {
+			return nil
+		}
+		f, unlock, err := store.open(unix.LOCK_SH)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		states, _, err := readStateLog(f)
+		if err != nil {
+			return err
+		}
+		if len(states) != 0 {
+			return fmt.Errorf("root %s uses the %q state backend and has containers", root, StateBackendLog)
+		}
+		return os.Remove(path)
+	case StateBackendLog:
+		if err := os.MkdirAll(root, 0o700); err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return nil
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+		if err != nil {
+			return err
+		}
+		f.Close()
+		store := &logStateStore{path: path}
+		// Move the existing states to the log.
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return err
+		}
+		files := &fileStateStore{root: root}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			s, err := files.load(e.Name())
+			if err != nil {
+				continue
+			}
+			if err := store.save(e.Name(), s); err != nil {
+				return err
+			}
+			if err := files.remove(e.Name()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown state backend %q", backend)
+}
+
+// fileStateStore keeps the state of each container in its own state.json.
+type fileStateStore struct {
+	root string
+}
+
+func (s *fileStateStore) load(id string) (*State, error) {
+	stateDir, err := securejoin.SecureJoin(s.root, id)
+	if err != nil {
+		return nil, err
+	}
+	return loadState(stateDir)
+}
+
+func (s *fileStateStore) save(id string, state *State) (retErr error) {
+	stateDir, err := securejoin.SecureJoin(s.root, id)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(stateDir, "state-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if retErr != nil {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+		}
+	}()
+
+	err = utils.WriteJSON(tmpFile, state)
+	if err != nil {
+		return err
+	}
+	err = tmpFile.Close()
+	if err != nil {
+		return err
+	}
+
+	stateFilePath := filepath.Join(stateDir, stateFilename)
+	return os.Rename(tmpFile.Name(), stateFilePath)
+}
+
+func (s *fileStateStore) remove(id string) error {
+	stateDir, err := securejoin.SecureJoin(s.root, id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(stateDir, stateFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// logStateStore keeps the states of all containers in a log file, each
+// record of which is the JSON of the new state of a container, or of a null
+// state if the container was removed. The last record of a container wins.
+type logStateStore struct {
+	path string
+}
+
+// stateLogRecord is a record of the state log.
+type stateLogRecord struct {
+	ID    string          `json:"id"`
+	State json.RawMessage `json:"state"`
+}
+
+// open opens and locks the state log. Since the log is replaced when it is
+// compacted, it is reopened until the locked file is the current one.
+func (s *logStateStore) open(how int) (*os.File, func(), error) {
+	for {
+		f, err := os.OpenFile(s.path, os.O_RDWR|os.O_APPEND|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := unix.Flock(int(f.Fd()), how); err != nil {
+			f.Close()
+			return nil, nil, &os.PathError{Op: "flock", Path: s.path, Err: err}
+		}
+		if sameFile(f, s.path) {
+			return f, func() { f.Close() }, nil
+		}
+		f.Close()
+	}
+}
+
+// readStateLog reads the state log, returning the current states by the
+// container id, and whether the log is well-formed (i.e. there is no
+// partially written record at the end).
+func readStateLog(f *os.File) (map[string]json.RawMessage, bool, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	states := make(map[string]json.RawMessage)
+	dec := json.NewDecoder(f)
+	for {
+		var r stateLogRecord
+		if err := dec.Decode(&r); err != nil {
+			if err == io.EOF { //nolint:errorlint // dec.Decode returns io.EOF as is.
+				return states, true, nil
+			}
+			logrus.Warnf("ignoring the rest of state log %s: %v", f.Name(), err)
+			return states, false, nil
+		}
+		if len(r.State) == 0 || bytes.Equal(r.State, []byte("null")) {
+			delete(states, r.ID)
+		} else {
+			states[r.ID] = r.State
+		}
+	}
+}
+
+func (s *logStateStore) load(id string) (*State, error) {
+	f, unlock, err := s.open(unix.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	states, _, err := readStateLog(f)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := states[id]
+	if !ok {
+		return nil, ErrNotExist
+	}
+	var state *State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *logStateStore) save(id string, state *State) error {
+	return s.append(id, state)
+}
+
+func (s *logStateStore) remove(id string) error {
+	return s.append(id, nil)
+}
+
+func (s *logStateStore) append(id string, state *State) error {
+	f, unlock, err := s.open(unix.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	record, err := json.Marshal(stateLogRecord{ID: id, State: data})
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() > stateLogCompactSize {
+		return s.compact(f, id, data)
+	}
+	// Do not append after a partially written record.
+	if _, ok, err := readStateLog(f); err != nil {
+		return err
+	} else if !ok {
+		return s.compact(f, id, data)
+	}
+	_, err = f.Write(append(record, '\n'))
+	return err
+}
+
+// compact replaces the state log (locked as f) with a new one, containing
+// only the current states, and the new state of the given container.
+func (s *logStateStore) compact(f *os.File, id string, state json.RawMessage) (retErr error) {
+	states, _, err := readStateLog(f)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(state, []byte("null")) {
+		delete(states, id)
+	} else {
+		states[id] = state
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), stateLogFilename+"-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+		}
+	}()
+	enc := json.NewEncoder(tmpFile)
+	for cid, data := range states {
+		if err := enc.Encode(stateLogRecord{ID: cid, State: data}); err != nil {
+			return err
+		}
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), s.path)
+}
+
+// memoryStateStore keeps the container states in memory. A file in the
+// state directory of each container tells the other processes that its
+// state is held in memory (see checkMemoryState).
+type memoryStateStore struct {
+	root   string
+	mu     sync.Mutex
+	states map[string][]byte // The JSON-encoded states.
+}
+
+func (s *memoryStateStore) load(id string) (*State, error) {
+	s.mu.Lock()
+	data, ok := s.states[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrNotExist
+	}
+	var state *State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *memoryStateStore) save(id string, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.states[id]; !ok {
+		stateDir, err := securejoin.SecureJoin(s.root, id)
+		if err != nil {
+			return err
+		}
+		pid := strconv.Itoa(os.Getpid())
+		if err := os.WriteFile(filepath.Join(stateDir, memoryStateFilename), []byte(pid), 0o600); err != nil {
+			return err
+		}
+	}
+	s.states[id] = data
+	return nil
+}
+
+func (s *memoryStateStore) remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, id)
+	return nil
+}
+
+// checkMemoryState returns ErrInMemory if the state of the container with
+// the given state directory is held in memory by another process.
+func checkMemoryState(stateDir string) error {
+	data, err := os.ReadFile(filepath.Join(stateDir, memoryStateFilename))
+	if err != nil {
+		return nil
+	}
+	return fmt.Errorf("%w (runc pid %s)", ErrInMemory, data)
+}
@@ -0,0 +1,141 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestLogStateStore(t *testing.T) {
+	defer func(size int64) { stateLogCompactSize = size }(stateLogCompactSize)
+	stateLogCompactSize = 1024
+
+	root := t.TempDir()
+	if err := InitStateBackend(root, StateBackendLog); err != nil {
+		t.Fatal(err)
+	}
+	store, ok := newStateStore(root).(*logStateStore)
+	if !ok {
+		t.Fatalf("expected log state store, got %T", newStateStore(root))
+	}
+	for i := 0; i < 100; i++ {
+		s := &State{BaseState: BaseState{ID: "a", InitProcessPid: i}}
+		if err := store.save("a", s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.save("b", &State{BaseState: BaseState{ID: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := store.load("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.InitProcessPid != 99 {
+		t.Errorf("expected the last saved state, got pid %d", s.InitProcessPid)
+	}
+	// The log must have been compacted.
+	fi, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() > 2*stateLogCompactSize {
+		t.Errorf("state log was not compacted (size %d)", fi.Size())
+	}
+
+	if err := store.remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.load("a"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+	if _, err := store.load("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A partially written record must not break the log.
+	f, err := os.OpenFile(store.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"id":"c","state":{"id":`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := store.save("d", &State{BaseState: BaseState{ID: "d"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"b", "d"} {
+		if _, err := store.load(id); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+
+	// Can not switch back while there are containers.
+	if err := InitStateBackend(root, StateBackendFiles); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestInitStateBackendMigrate(t *testing.T) {
+	root := t.TempDir()
+	files := &fileStateStore{root: root}
+	for i := 0; i < 3; i++ {
+		id := "c" + strconv.Itoa(i)
+		if err := os.Mkdir(filepath.Join(root, id), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := files.save(id, &State{BaseState: BaseState{ID: id, InitProcessPid: i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := InitStateBackend(root, StateBackendLog); err != nil {
+		t.Fatal(err)
+	}
+	store := newStateStore(root)
+	for i := 0; i < 3; i++ {
+		id := "c" + strconv.Itoa(i)
+		s, err := store.load(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.InitProcessPid != i {
+			t.Errorf("%s: expected pid %d, got %d", id, i, s.InitProcessPid)
+		}
+		if _, err := os.Stat(filepath.Join(root, id, stateFilename)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: expected %s to be removed, got %v", id, stateFilename, err)
+		}
+	}
+}
+
+func TestMemoryStateStore(t *testing.T) {
+	root := t.TempDir()
+	if err := InitStateBackend(root, StateBackendMemory); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "c1"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	store := newStateStore(root)
+	if err := store.save("c1", &State{BaseState: BaseState{ID: "c1", InitProcessPid: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := store.load("c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.InitProcessPid != 1 {
+		t.Errorf("expected pid 1, got %d", s.InitProcessPid)
+	}
+	if _, err := os.Stat(filepath.Join(root, "c1", stateFilename)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no %s, got %v", stateFilename, err)
+	}
+
+	// Another process only sees that the state is held in memory.
+	memoryStateStores.Delete(root)
+	if _, err := Load(root, "c1"); !errors.Is(err, ErrInMemory) {
+		t.Errorf("expected ErrInMemory, got %v", err)
+	}
+}
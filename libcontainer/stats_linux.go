@@ -10,4 +10,8 @@ type Stats struct {
 	Interfaces    []*types.NetworkInterface
 	CgroupStats   *cgroups.Stats
 	IntelRdtStats *intelrdt.Stats
+	// ExecCgroupStats are the cgroup stats of the processes executed in
+	// the container, if accounted separately (see
+	// configs.Cgroup.ExecAccounting). They are included in CgroupStats.
+	ExecCgroupStats *cgroups.Stats
 }
@@ -0,0 +1,354 @@
+package libcontainer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/cgroups/fs"
+	"github.com/opencontainers/runc/libcontainer/cgroups/manager"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// setupSubCgroups creates the runtime and workload sub-cgroups of the
+// container cgroup, and moves the container init (pid) to the workload one.
+func setupSubCgroups(m cgroups.Manager, r *configs.Resources, pid int) error {
+	subs := []string{configs.RuntimeSubCgroup, configs.WorkloadSubCgroup}
+	if cgroups.IsCgroup2UnifiedMode() {
+		dir := m.ControllerPath("")
+		for _, sub := range subs {
+			if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+				return err
+			}
+		}
+		if err := cgroups.WriteCgroupProcRetry(filepath.Join(dir, configs.WorkloadSubCgroup), pid, r.GetAttachRetry()); err != nil {
+			return err
+		}
+		// Now that the container cgroup has no processes of its own, the
+		// controllers can be enabled for the sub-cgroups.
+		return enableSubtreeControllers(dir)
+	}
+
+	for ctrl, dir := range m.GetPaths() {
+		if !splitController(ctrl) {
+			continue
+		}
+		for _, sub := range subs {
+			if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+				return err
+			}
+			if ctrl == "cpuset" {
+				// The cpuset of a new cgroup v1 is empty.
+				if err := copyCpuset(dir, filepath.Join(dir, sub)); err != nil {
+					return err
+				}
+			}
+		}
+		if ctrl == "cpu" && r != nil {
+			// Set the real-time budget before moving in the process, in
+			// case it is already using a real-time policy.
+			if err := (&fs.CpuGroup{}).SetRtSched(filepath.Join(dir, configs.WorkloadSubCgroup), r); err != nil {
+				return err
+			}
+		}
+	}
+	for ctrl, dir := range m.GetPaths() {
+		if !splitController(ctrl) {
+			continue
+		}
+		if err := cgroups.WriteCgroupProcRetry(filepath.Join(dir, configs.WorkloadSubCgroup), pid, r.GetAttachRetry()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitController tells whether the sub-cgroups are used for the cgroup v1
+// controller. The devices controller is excluded, as the device rules of a
+// cgroup v1 can't be changed once it has children, and so is the unified
+// hierarchy of the hybrid mode ("" key), which has no controllers.
+func splitController(ctrl string) bool {
+	return ctrl != "devices" && ctrl != ""
+}
+
+// runtimeSubCgroupPaths returns the sub-cgroup paths for processes executed
+// in a container using the sub-cgroups, given its cgroup paths.
+func runtimeSubCgroupPaths(paths map[string]string) map[string]string {
+	if cgroups.IsCgroup2UnifiedMode() {
+		return map[string]string{"": configs.RuntimeSubCgroup}
+	}
+	sub := make(map[string]string, len(paths))
+	for ctrl := range paths {
+		if splitController(ctrl) {
+			sub[ctrl] = configs.RuntimeSubCgroup
+		}
+	}
+	return sub
+}
+
+// enableSubtreeControllers enables all the controllers available in the
+// cgroup v2 dir for its sub-cgroups.
+func enableSubtreeControllers(dir string) error {
+	content, err := cgroups.ReadFile(dir, "cgroup.controllers")
+	if err != nil {
+		return err
+	}
+	ctrls := strings.Fields(content)
+	if len(ctrls) == 0 {
+		return nil
+	}
+	return cgroups.WriteFile(dir, "cgroup.subtree_control", "+"+strings.Join(ctrls, " +"))
+}
+
+func copyCpuset(src, dst string) error {
+	for _, file := range []string{"cpuset.cpus", "cpuset.mems"} {
+		val, err := cgroups.ReadFile(src, file)
+		if err != nil {
+			return err
+		}
+		if err := cgroups.WriteFile(dst, file, strings.TrimSpace(val)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rtSubCgroup returns the name of the cgroup v1 sub-cgroup which is given
+// the real-time budget of the container, or an empty string if there is
+// none.
+func rtSubCgroup(c *configs.Cgroup) string {
+	if cgroups.IsCgroup2UnifiedMode() {
+		return ""
+	}
+	if c.SplitSubCgroups {
+		return configs.WorkloadSubCgroup
+	}
+	return c.RtSubCgroup
+}
+
+// lowerSubCgroupRtRuntime lowers the real-time runtime of the sub-cgroup
+// sub, if needed, so that the container cgroup can be set to r (the
+// runtime of a cgroup v1 can't be lower than that of its children).
+func lowerSubCgroupRtRuntime(m cgroups.Manager, sub string, r *configs.Resources) error {
+	dir := subCgroupCpuPath(m, sub, r)
+	if dir == "" || r.CpuRtRuntime == 0 {
+		return nil
+	}
+	cur, err := cgroups.ReadFile(dir, "cpu.rt_runtime_us")
+	if err != nil {
+		return err
+	}
+	curRt, err := strconv.ParseInt(strings.TrimSpace(cur), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid cpu.rt_runtime_us in %s: %w", dir, err)
+	}
+	if r.CpuRtRuntime < 0 || r.CpuRtRuntime >= curRt {
+		return nil
+	}
+	return cgroups.WriteFile(dir, "cpu.rt_runtime_us", strconv.FormatInt(r.CpuRtRuntime, 10))
+}
+
+// setSubCgroupRtSched gives the real-time budget of the container cgroup to
+// its sub-cgroup sub.
+func setSubCgroupRtSched(m cgroups.Manager, sub string, r *configs.Resources) error {
+	dir := subCgroupCpuPath(m, sub, r)
+	if dir == "" {
+		return nil
+	}
+	return (&fs.CpuGroup{}).SetRtSched(dir, r)
+}
+
+// subCgroupCpuPath returns the cgroup v1 cpu controller path of the
+// sub-cgroup sub, or an empty string if there is no real-time budget to
+// set.
+func subCgroupCpuPath(m cgroups.Manager, sub string, r *configs.Resources) string {
+	if sub == "" || r == nil || (r.CpuRtRuntime == 0 && r.CpuRtPeriod == 0) {
+		return ""
+	}
+	dir := m.ControllerPath("cpu")
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, sub)
+}
+
+// rtSubCgroupControllers are the cgroup v1 controllers the real-time
+// sub-cgroup is created for. The cpuacct one is included as it is usually
+// mounted together with cpu, so a process moved to the cpu sub-cgroup would
+// be moved back when joining the cpuacct cgroup of the container.
+var rtSubCgroupControllers = []string{"cpu", "cpuacct"}
+
+// setupRtSubCgroup creates the real-time sub-cgroup of the container
+// cgroup, gives it the real-time budget, and moves the container init
+// (pid) to it if its command (args[0]) is one of the real-time ones.
+func setupRtSubCgroup(m cgroups.Manager, c *configs.Cgroup, pid int, args []string) error {
+	paths := m.GetPaths()
+	for _, ctrl := range rtSubCgroupControllers {
+		if dir, ok := paths[ctrl]; ok {
+			if err := os.MkdirAll(filepath.Join(dir, c.RtSubCgroup), 0o755); err != nil {
+				return err
+			}
+		}
+	}
+	if err := setSubCgroupRtSched(m, c.RtSubCgroup, c.Resources); err != nil {
+		return err
+	}
+	if !isRtCommand(c, args) {
+		return nil
+	}
+	for _, ctrl := range rtSubCgroupControllers {
+		if dir, ok := paths[ctrl]; ok {
+			if err := cgroups.WriteCgroupProcRetry(filepath.Join(dir, c.RtSubCgroup), pid, c.Resources.GetAttachRetry()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isRtCommand tells whether the process with the given args is to be run in
+// the real-time sub-cgroup.
+func isRtCommand(c *configs.Cgroup, args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	cmd := filepath.Base(args[0])
+	for _, rt := range c.RtSubCgroupCommands {
+		if rt == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+// rtSubCgroupPaths returns the sub-cgroup paths for processes executed in
+// the real-time sub-cgroup of a container, given its cgroup paths.
+func rtSubCgroupPaths(paths map[string]string, sub string) map[string]string {
+	subs := make(map[string]string, len(rtSubCgroupControllers))
+	for _, ctrl := range rtSubCgroupControllers {
+		if _, ok := paths[ctrl]; ok {
+			subs[ctrl] = sub
+		}
+	}
+	return subs
+}
+
+// setSubCgroupCpusets sets the cpusets of the cgroup v1 sub-cgroups to the
+// ones in r, before the container cgroup itself is set to r. A cgroup v1
+// cpuset must contain those of its children, so the container cgroup is
+// first widened to the union of its current and new cpusets, which lets
+// the sub-cgroups move to the new ones; setting the container cgroup then
+// narrows it to r.
+func setSubCgroupCpusets(m cgroups.Manager, r *configs.Resources) error {
+	if cgroups.IsCgroup2UnifiedMode() || r == nil || (r.CpusetCpus == "" && r.CpusetMems == "") {
+		return nil
+	}
+	dir := m.ControllerPath("cpuset")
+	if dir == "" {
+		return nil
+	}
+	for _, f := range []struct{ file, val string }{
+		{"cpuset.cpus", r.CpusetCpus},
+		{"cpuset.mems", r.CpusetMems},
+	} {
+		if f.val == "" {
+			continue
+		}
+		cur, err := cgroups.ReadFile(dir, f.file)
+		if err != nil {
+			return err
+		}
+		union, err := cpuListUnion(strings.TrimSpace(cur), f.val)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", f.file, err)
+		}
+		if err := cgroups.WriteFile(dir, f.file, union); err != nil {
+			return err
+		}
+		for _, sub := range []string{configs.RuntimeSubCgroup, configs.WorkloadSubCgroup} {
+			if err := cgroups.WriteFile(filepath.Join(dir, sub), f.file, f.val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cpuListUnion returns the union of the a and b lists, in the cpuset list
+// format (e.g. "0-3,6").
+func cpuListUnion(a, b string) (string, error) {
+	list := b
+	if a != "" {
+		list = a + "," + b
+	}
+	ns, err := cgroups.ParseCPUList(list)
+	if err != nil {
+		return "", err
+	}
+	return cgroups.FormatCPUList(ns), nil
+}
+
+// execAccountingControllers are the cgroup v1 controllers the exec
+// sub-cgroup is used for, i.e. those accounting for resource usage.
+var execAccountingControllers = []string{"cpu", "cpuacct", "memory", "pids", "blkio"}
+
+// execSubCgroup returns the name of the sub-cgroup accounting for the
+// processes executed in the container, or an empty string if there is none.
+func execSubCgroup(c *configs.Cgroup) string {
+	if !c.ExecAccounting {
+		return ""
+	}
+	if c.SplitSubCgroups {
+		return configs.RuntimeSubCgroup
+	}
+	return configs.ExecSubCgroup
+}
+
+// execSubCgroupPaths returns the sub-cgroup paths for processes executed in
+// the exec sub-cgroup sub of a container, given its cgroup paths.
+func execSubCgroupPaths(paths map[string]string, sub string) map[string]string {
+	if cgroups.IsCgroup2UnifiedMode() {
+		return map[string]string{"": sub}
+	}
+	subs := make(map[string]string, len(execAccountingControllers))
+	for _, ctrl := range execAccountingControllers {
+		if _, ok := paths[ctrl]; ok {
+			subs[ctrl] = sub
+		}
+	}
+	return subs
+}
+
+// execSubCgroupDirs returns the absolute paths of the exec sub-cgroup sub of
+// the container cgroup, in the format of cgroups.Manager.GetPaths.
+func execSubCgroupDirs(m cgroups.Manager, sub string) map[string]string {
+	paths := m.GetPaths()
+	dirs := execSubCgroupPaths(paths, sub)
+	for ctrl, sub := range dirs {
+		dirs[ctrl] = filepath.Join(paths[ctrl], sub)
+	}
+	return dirs
+}
+
+// setupExecSubCgroup creates the exec sub-cgroup of the container cgroup.
+// No limits are set for it, so it is only used for accounting.
+func setupExecSubCgroup(m cgroups.Manager) error {
+	for _, dir := range execSubCgroupDirs(m, configs.ExecSubCgroup) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execSubCgroupStats returns the stats of the exec sub-cgroup sub.
+func execSubCgroupStats(m cgroups.Manager, sub string) (*cgroups.Stats, error) {
+	sm, err := manager.NewWithPaths(&configs.Cgroup{Resources: &configs.Resources{}}, execSubCgroupDirs(m, sub))
+	if err != nil {
+		return nil, err
+	}
+	return sm.GetStats()
+}
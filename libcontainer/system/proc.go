@@ -1,6 +1,7 @@
 package system
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -60,6 +61,9 @@ type Stat_t struct {
 	// State is the state of the process.
 	State State
 
+	// PPid is the PID of the parent of the process.
+	PPid int
+
 	// StartTime is the number of clock ticks after system boot (since
 	// Linux 2.6).
 	StartTime uint64
@@ -83,6 +87,7 @@ func parseStat(data string) (stat Stat_t, err error) {
 	//  * field 2: process name. It is the only field enclosed into
 	//    parenthesis, as it can contain spaces (and parenthesis) inside.
 	//  * field 3: process state, a single character (%c)
+	//  * field 4: parent process PID (%d)
 	//  * field 22: process start time, a long unsigned integer (%llu).
 
 	// 1. Look for the first '(' and the last ')' first, what's in between is Name.
@@ -106,7 +111,14 @@ func parseStat(data string) (stat Stat_t, err error) {
 	data = data[last+2:]
 	stat.State = State(data[0])
 
-	// 3. StartTime is field 22, data is at field 3 now, so we need to skip 19 spaces.
+	// 3. PPid is right after State.
+	ppid, _, _ := strings.Cut(data[2:], " ")
+	stat.PPid, err = strconv.Atoi(ppid)
+	if err != nil {
+		return stat, fmt.Errorf("invalid stat data (bad ppid): %w", err)
+	}
+
+	// 4. StartTime is field 22, data is at field 3 now, so we need to skip 19 spaces.
 	skipSpaces := 22 - 3
 	for first = 0; skipSpaces > 0 && first < len(data); first++ {
 		if data[first] == ' ' {
@@ -125,3 +137,36 @@ func parseStat(data string) (stat Stat_t, err error) {
 
 	return stat, nil
 }
+
+// NSpid returns the PIDs of the specified process in the PID namespaces it
+// is a member of, from the outermost to the innermost one (the last one
+// being the PID as seen from inside a container).
+func NSpid(pid int) ([]int, error) {
+	bytes, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "status"))
+	if err != nil {
+		return nil, err
+	}
+	return parseNSpid(string(bytes))
+}
+
+func parseNSpid(data string) ([]int, error) {
+	for _, line := range strings.Split(data, "\n") {
+		val, ok := strings.CutPrefix(line, "NSpid:")
+		if !ok {
+			continue
+		}
+		var pids []int
+		for _, f := range strings.Fields(val) {
+			pid, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid NSpid %q: %w", val, err)
+			}
+			pids = append(pids, pid)
+		}
+		if len(pids) > 0 {
+			return pids, nil
+		}
+	}
+	// NSpid is only available since Linux 4.1.
+	return nil, errors.New("no NSpid in status")
+}
@@ -0,0 +1,63 @@
+package libcontainer
+
+import "time"
+
+// The phases of the container bootstrap, in order, as seen from runc. They
+// are timed from the runc create (or run) process, except for
+// [PhaseExec], which is timed by runc start.
+const (
+	// PhaseSpawn is starting runc init.
+	PhaseSpawn = "spawn"
+	// PhaseCgroupApply is creating the container cgroups (and sub-cgroups)
+	// and adding runc init to them, including the real-time budget.
+	PhaseCgroupApply = "cgroup-apply"
+	// PhaseNsexec is nsexec setting up the namespaces, from the bootstrap
+	// data being sent to it to runc getting the PID of the final (stage 2)
+	// child, which runs the rest of runc init.
+	PhaseNsexec = "nsexec"
+	// PhaseNsexecExit is waiting for the parent (stage 0) runc init process
+	// to exit, leaving the final child.
+	PhaseNsexecExit = "nsexec-exit"
+	// PhaseInitSetup is runc init setting up the container (such as its
+	// root filesystem), up to requesting the hooks to be run.
+	PhaseInitSetup = "init-setup"
+	// PhaseCgroupSet is setting the container cgroup resources.
+	PhaseCgroupSet = "cgroup-set"
+	// PhaseHooks is running the prestart and createRuntime hooks.
+	PhaseHooks = "hooks"
+	// PhaseInitFinish is runc init finishing the container setup, up to
+	// being ready to be started.
+	PhaseInitFinish = "init-finish"
+	// PhaseExec is runc start releasing runc init to execute the container
+	// process, from the start request to runc init being past the exec fifo.
+	PhaseExec = "exec"
+)
+
+// BootstrapPhase is the timing of a phase of the container bootstrap.
+type BootstrapPhase struct {
+	// Name is one of the Phase constants.
+	Name string `json:"name"`
+	// Start is when the phase started.
+	Start time.Time `json:"start"`
+	// Duration is how long the phase took.
+	Duration time.Duration `json:"duration"`
+}
+
+// BootstrapTimings returns the timings of the phases of the container
+// bootstrap, in order, for the latest container init. They are only
+// recorded by this runc version, and are missing if runc was interrupted.
+func (c *Container) BootstrapTimings() []BootstrapPhase {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return append([]BootstrapPhase(nil), c.bootstrapTimings...)
+}
+
+// timePhase records that the bootstrap phase name started at start and has
+// just ended.
+func (c *Container) timePhase(name string, start time.Time) {
+	c.bootstrapTimings = append(c.bootstrapTimings, BootstrapPhase{
+		Name:     name,
+		Start:    start,
+		Duration: time.Since(start),
+	})
+}
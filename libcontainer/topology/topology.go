@@ -0,0 +1,313 @@
+// Package topology discovers the CPU topology of the host (cores and their
+// hardware threads, caches, NUMA nodes, and isolated CPUs) from sysfs, so
+// that CPUs can be assigned to containers without sharing cores or caches
+// with other workloads.
+package topology
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+const sysfsRoot = "/sys/devices/system"
+
+// CPUList is a list of CPU (or NUMA node) numbers. It is marshaled in the
+// cpuset list format, e.g. "0-3,6".
+type CPUList []int
+
+func (l CPUList) String() string {
+	return cgroups.FormatCPUList(l)
+}
+
+func (l CPUList) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *CPUList) UnmarshalText(text []byte) error {
+	list, err := cgroups.ParseCPUList(string(text))
+	if err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Topology is the CPU topology of the host. Only the online CPUs are
+// considered.
+type Topology struct {
+	// Online are the online CPUs.
+	Online CPUList `json:"online"`
+	// Isolated are the CPUs isolated from the scheduler (isolcpus=).
+	Isolated CPUList `json:"isolated"`
+	// NohzFull are the CPUs in adaptive-tick mode (nohz_full=).
+	NohzFull CPUList `json:"nohzFull"`
+	Cores    []Core  `json:"cores"`
+	Caches   []Cache `json:"caches"`
+	Nodes    []Node  `json:"nodes"`
+}
+
+// Core is a physical CPU core.
+type Core struct {
+	Package int `json:"package"`
+	ID      int `json:"id"`
+	// CPUs are the hardware threads (SMT siblings) of the core.
+	CPUs CPUList `json:"cpus"`
+}
+
+// Cache is a CPU cache.
+type Cache struct {
+	Level int `json:"level"`
+	// Type is either "Data", "Instruction", or "Unified".
+	Type string `json:"type"`
+	// Size is the size of the cache, in bytes.
+	Size int64 `json:"size"`
+	// CPUs are the CPUs sharing the cache.
+	CPUs CPUList `json:"cpus"`
+}
+
+// Node is a NUMA node.
+type Node struct {
+	ID   int     `json:"id"`
+	CPUs CPUList `json:"cpus"`
+}
+
+// Discover returns the CPU topology of the host.
+func Discover() (*Topology, error) {
+	return discover(sysfsRoot)
+}
+
+func discover(root string) (*Topology, error) {
+	cpuDir := filepath.Join(root, "cpu")
+	online, err := readList(cpuDir, "online")
+	if err != nil {
+		return nil, err
+	}
+	t := &Topology{Online: online}
+	// These files only exist in recent kernels, or if configured.
+	if t.Isolated, err = readList(cpuDir, "isolated"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if t.NohzFull, err = readList(cpuDir, "nohz_full"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	cores := make(map[string]struct{})
+	caches := make(map[string]struct{})
+	for _, cpu := range online {
+		dir := filepath.Join(cpuDir, "cpu"+strconv.Itoa(cpu))
+		core, err := readCore(filepath.Join(dir, "topology"))
+		if err != nil {
+			return nil, fmt.Errorf("cpu%d: %w", cpu, err)
+		}
+		if key := core.CPUs.String(); !hasKey(cores, key) {
+			t.Cores = append(t.Cores, *core)
+		}
+		cpuCaches, err := readCaches(filepath.Join(dir, "cache"))
+		if err != nil {
+			return nil, fmt.Errorf("cpu%d: %w", cpu, err)
+		}
+		for _, c := range cpuCaches {
+			if key := fmt.Sprintf("%d %s %s", c.Level, c.Type, c.CPUs); !hasKey(caches, key) {
+				t.Caches = append(t.Caches, c)
+			}
+		}
+	}
+
+	if t.Nodes, err = readNodes(filepath.Join(root, "node")); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// hasKey tells whether key is in set, and adds it.
+func hasKey(set map[string]struct{}, key string) bool {
+	if _, ok := set[key]; ok {
+		return true
+	}
+	set[key] = struct{}{}
+	return false
+}
+
+func readList(dir, file string) (CPUList, error) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return nil, err
+	}
+	list, err := cgroups.ParseCPUList(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", dir, file, err)
+	}
+	return list, nil
+}
+
+func readInt(dir, file string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+func readCore(dir string) (*Core, error) {
+	var (
+		c   Core
+		err error
+	)
+	if c.Package, err = readInt(dir, "physical_package_id"); err != nil {
+		return nil, err
+	}
+	if c.ID, err = readInt(dir, "core_id"); err != nil {
+		return nil, err
+	}
+	if c.CPUs, err = readList(dir, "thread_siblings_list"); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func readCaches(dir string) ([]Cache, error) {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// No cache information (e.g. in some virtual machines).
+			err = nil
+		}
+		return nil, err
+	}
+	var caches []Cache
+	for _, ent := range ents {
+		if !strings.HasPrefix(ent.Name(), "index") {
+			continue
+		}
+		idx := filepath.Join(dir, ent.Name())
+		var c Cache
+		if c.Level, err = readInt(idx, "level"); err != nil {
+			return nil, err
+		}
+		typ, err := os.ReadFile(filepath.Join(idx, "type"))
+		if err != nil {
+			return nil, err
+		}
+		c.Type = strings.TrimSpace(string(typ))
+		size, err := os.ReadFile(filepath.Join(idx, "size"))
+		if err != nil {
+			return nil, err
+		}
+		if c.Size, err = parseSize(strings.TrimSpace(string(size))); err != nil {
+			return nil, fmt.Errorf("%s/size: %w", idx, err)
+		}
+		if c.CPUs, err = readList(idx, "shared_cpu_list"); err != nil {
+			return nil, err
+		}
+		caches = append(caches, c)
+	}
+	return caches, nil
+}
+
+// parseSize parses a cache size, such as "32K".
+func parseSize(s string) (int64, error) {
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		mult = 1 << 30
+	}
+	if mult != 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * mult, nil
+}
+
+func readNodes(dir string) ([]Node, error) {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// A kernel without NUMA support.
+			err = nil
+		}
+		return nil, err
+	}
+	var nodes []Node
+	for _, ent := range ents {
+		id, err := strconv.Atoi(strings.TrimPrefix(ent.Name(), "node"))
+		if err != nil || !strings.HasPrefix(ent.Name(), "node") {
+			continue
+		}
+		cpus, err := readList(filepath.Join(dir, ent.Name()), "cpulist")
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, Node{ID: id, CPUs: cpus})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// Siblings returns the hardware threads of the core of cpu, including cpu
+// itself, or nil if cpu is not online.
+func (t *Topology) Siblings(cpu int) CPUList {
+	for _, c := range t.Cores {
+		for _, n := range c.CPUs {
+			if n == cpu {
+				return c.CPUs
+			}
+		}
+	}
+	return nil
+}
+
+// ForeignSiblings returns the hardware threads which are not in cpus but share
+// a core with one of them. A real-time workload using cpus can be disturbed
+// by the ones running on these.
+func (t *Topology) ForeignSiblings(cpus []int) CPUList {
+	in := make(map[int]struct{}, len(cpus))
+	for _, cpu := range cpus {
+		in[cpu] = struct{}{}
+	}
+	var foreign CPUList
+	for _, c := range t.Cores {
+		var used bool
+		for _, n := range c.CPUs {
+			if _, ok := in[n]; ok {
+				used = true
+				break
+			}
+		}
+		if !used {
+			continue
+		}
+		for _, n := range c.CPUs {
+			if _, ok := in[n]; !ok {
+				foreign = append(foreign, n)
+			}
+		}
+	}
+	sort.Ints(foreign)
+	return foreign
+}
+
+// Node returns the NUMA node of cpu, or -1 if unknown.
+func (t *Topology) Node(cpu int) int {
+	for _, node := range t.Nodes {
+		for _, n := range node.CPUs {
+			if n == cpu {
+				return node.ID
+			}
+		}
+	}
+	return -1
+}
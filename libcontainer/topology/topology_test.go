@@ -0,0 +1,116 @@
+package topology
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// writeSysfs creates a fake sysfs tree with 2 cores of 2 threads (0,2 and
+// 1,3), each core in its own NUMA node with its own L2 cache, and a shared
+// L3 cache.
+func writeSysfs(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cpu/online", "0-3")
+	write("cpu/isolated", "2-3")
+	for cpu := 0; cpu < 4; cpu++ {
+		dir := "cpu/cpu" + strconv.Itoa(cpu)
+		core := cpu % 2
+		siblings := strconv.Itoa(core) + "," + strconv.Itoa(core+2)
+		write(dir+"/topology/physical_package_id", "0")
+		write(dir+"/topology/core_id", strconv.Itoa(core))
+		write(dir+"/topology/thread_siblings_list", siblings)
+		write(dir+"/cache/index0/level", "2")
+		write(dir+"/cache/index0/type", "Unified")
+		write(dir+"/cache/index0/size", "1024K")
+		write(dir+"/cache/index0/shared_cpu_list", siblings)
+		write(dir+"/cache/index1/level", "3")
+		write(dir+"/cache/index1/type", "Unified")
+		write(dir+"/cache/index1/size", "16M")
+		write(dir+"/cache/index1/shared_cpu_list", "0-3")
+	}
+	write("node/node0/cpulist", "0,2")
+	write("node/node1/cpulist", "1,3")
+	write("node/online", "0-1")
+	return root
+}
+
+func TestDiscover(t *testing.T) {
+	topo, err := discover(writeSysfs(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Topology{
+		Online:   CPUList{0, 1, 2, 3},
+		Isolated: CPUList{2, 3},
+		Cores: []Core{
+			{Package: 0, ID: 0, CPUs: CPUList{0, 2}},
+			{Package: 0, ID: 1, CPUs: CPUList{1, 3}},
+		},
+		Caches: []Cache{
+			{Level: 2, Type: "Unified", Size: 1 << 20, CPUs: CPUList{0, 2}},
+			{Level: 3, Type: "Unified", Size: 16 << 20, CPUs: CPUList{0, 1, 2, 3}},
+			{Level: 2, Type: "Unified", Size: 1 << 20, CPUs: CPUList{1, 3}},
+		},
+		Nodes: []Node{
+			{ID: 0, CPUs: CPUList{0, 2}},
+			{ID: 1, CPUs: CPUList{1, 3}},
+		},
+	}
+	if !reflect.DeepEqual(topo, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, topo)
+	}
+
+	if s := topo.Siblings(3); !reflect.DeepEqual(s, CPUList{1, 3}) {
+		t.Errorf("expected siblings of 3 to be 1,3, got %v", s)
+	}
+	if s := topo.ForeignSiblings([]int{0, 1, 2}); !reflect.DeepEqual(s, CPUList{3}) {
+		t.Errorf("expected foreign siblings of 0-2 to be 3, got %v", s)
+	}
+	if s := topo.ForeignSiblings([]int{0, 2}); s != nil {
+		t.Errorf("expected no foreign siblings of 0,2, got %v", s)
+	}
+	if n := topo.Node(3); n != 1 {
+		t.Errorf("expected cpu 3 to be in node 1, got %d", n)
+	}
+
+	data, err := json.Marshal(topo.Cores[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"package":0,"id":0,"cpus":"0,2"}` {
+		t.Errorf("unexpected json: %s", data)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"48K":  48 << 10,
+		"2M":   2 << 20,
+		"1G":   1 << 30,
+		"4096": 4096,
+	} {
+		n, err := parseSize(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if n != expected {
+			t.Errorf("%s: expected %d, got %d", s, expected, n)
+		}
+	}
+	if _, err := parseSize("K"); err == nil {
+		t.Error("expected an error for an invalid size")
+	}
+}
@@ -0,0 +1,253 @@
+package libcontainer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/moby/sys/user"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+const (
+	// DefaultUsernsSize is the default size of an automatically allocated
+	// user namespace id range.
+	DefaultUsernsSize = 65536
+
+	// DefaultUsernsUser is the name of the /etc/subuid and /etc/subgid
+	// entries from which the id ranges are allocated when runc is run as
+	// root. Otherwise, the entries of the current user are used.
+	DefaultUsernsUser = "containers"
+
+	// usernsAllocFile is the file, relative to the runc root directory,
+	// which holds the id ranges allocated to containers.
+	usernsAllocFile = "userns-alloc.json"
+)
+
+// AutoUsernsOpts are the options for automatic user namespace id range
+// allocation.
+type AutoUsernsOpts struct {
+	// Size is the number of uids and gids to allocate. If 0,
+	// DefaultUsernsSize is used.
+	Size int64
+	// User is the name of the subuid and subgid entries to allocate from.
+	// If empty, DefaultUsernsUser is used for root, and the name of the
+	// current user otherwise.
+	User string
+	// SubUIDFile and SubGIDFile are the paths to the files with the id
+	// ranges, in subuid(5) format. If empty, /etc/subuid and /etc/subgid
+	// are used.
+	SubUIDFile, SubGIDFile string
+}
+
+// usernsAlloc is an id range allocation record.
+type usernsAlloc struct {
+	UID  int64 `json:"uid"`
+	GID  int64 `json:"gid"`
+	Size int64 `json:"size"`
+}
+
+// AllocateUserns allocates free uid and gid ranges for the container with
+// the given id, and returns the corresponding user namespace mappings.
+//
+// The allocations are recorded in a file under the root directory, which is
+// locked until the returned unlock function is called. The caller must create
+// the container (see [Create]) before calling unlock, as the allocations of
+// containers which do not exist are considered stale and are reclaimed.
+func AllocateUserns(root, id string, opts *AutoUsernsOpts) (uidMap, gidMap []configs.IDMap, unlock func(), _ error) {
+	size := opts.Size
+	if size == 0 {
+		size = DefaultUsernsSize
+	}
+	if size < 0 {
+		return nil, nil, nil, fmt.Errorf("invalid userns size %d", size)
+	}
+	name := opts.User
+	if name == "" {
+		name = DefaultUsernsUser
+		if os.Geteuid() != 0 {
+			u, err := user.CurrentUser()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			name = u.Name
+		}
+	}
+	subUIDFile, subGIDFile := opts.SubUIDFile, opts.SubGIDFile
+	if subUIDFile == "" {
+		subUIDFile = "/etc/subuid"
+	}
+	if subGIDFile == "" {
+		subGIDFile = "/etc/subgid"
+	}
+	uidPool, err := subIDRanges(subUIDFile, name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gidPool, err := subIDRanges(subGIDFile, name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	f, err := openAllocFile(root, usernsAllocFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	uid, gid, err := allocateIDRanges(f, root, id, size, uidPool, gidPool)
+	if err != nil {
+		f.Close()
+		return nil, nil, nil, fmt.Errorf("unable to allocate %d ids for %q: %w", size, name, err)
+	}
+	uidMap = []configs.IDMap{{ContainerID: 0, HostID: uid, Size: size}}
+	gidMap = []configs.IDMap{{ContainerID: 0, HostID: gid, Size: size}}
+	return uidMap, gidMap, func() { f.Close() }, nil
+}
+
+// allocateIDRanges allocates uid and gid ranges for the container id, and
+// records them in f, which must be locked by the caller.
+func allocateIDRanges(f *os.File, root, id string, size int64, uidPool, gidPool []user.SubID) (uid, gid int64, _ error) {
+	if exists, err := containerExists(root, id); err != nil {
+		return 0, 0, err
+	} else if exists {
+		return 0, 0, ErrExist
+	}
+	allocs := map[string]usernsAlloc{}
+	if err := readAllocFile(f, &allocs); err != nil {
+		return 0, 0, err
+	}
+	var usedUIDs, usedGIDs []user.SubID
+	for cid, a := range allocs {
+		exists, err := containerExists(root, cid)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !exists {
+			// Reclaim the allocations of the containers which are gone.
+			delete(allocs, cid)
+			continue
+		}
+		usedUIDs = append(usedUIDs, user.SubID{SubID: a.UID, Count: a.Size})
+		usedGIDs = append(usedGIDs, user.SubID{SubID: a.GID, Count: a.Size})
+	}
+	var err error
+	if uid, err = findFreeIDRange(uidPool, usedUIDs, size); err != nil {
+		return 0, 0, fmt.Errorf("uids: %w", err)
+	}
+	if gid, err = findFreeIDRange(gidPool, usedGIDs, size); err != nil {
+		return 0, 0, fmt.Errorf("gids: %w", err)
+	}
+	allocs[id] = usernsAlloc{UID: uid, GID: gid, Size: size}
+	if err := writeAllocFile(f, allocs); err != nil {
+		return 0, 0, err
+	}
+	return uid, gid, nil
+}
+
+// openAllocFile opens and locks the allocation record file with the given
+// name under the root directory.
+func openAllocFile(root, name string) (*os.File, error) {
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(root, name), os.O_RDWR|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+	return f, nil
+}
+
+// readAllocFile reads the allocation records from f into allocs.
+func readAllocFile(f *os.File, allocs any) error {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, allocs); err != nil {
+			return fmt.Errorf("invalid %s: %w", f.Name(), err)
+		}
+	}
+	return nil
+}
+
+// writeAllocFile replaces the allocation records in f with allocs.
+func writeAllocFile(f *os.File, allocs any) error {
+	data, err := json.Marshal(allocs)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(data, 0)
+	return err
+}
+
+// containerExists returns whether the container with the given id has a
+// state directory under root.
+func containerExists(root, id string) (bool, error) {
+	stateDir, err := securejoin.SecureJoin(root, id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(stateDir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// subIDRanges returns the ranges from a subuid(5) formatted file which
+// belong to the user with the given name (or uid).
+func subIDRanges(path, name string) ([]user.SubID, error) {
+	uid := ""
+	if u, err := user.LookupUser(name); err == nil {
+		uid = strconv.Itoa(u.Uid)
+	}
+	ranges, err := user.ParseSubIDFileFilter(path, func(s user.SubID) bool {
+		return s.Name == name || (uid != "" && s.Name == uid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no ranges for %q found in %s", name, path)
+	}
+	return ranges, nil
+}
+
+// findFreeIDRange returns the start of the first range of the given size
+// which is within one of the pool ranges and does not overlap with any of
+// the used ranges.
+func findFreeIDRange(pool, used []user.SubID, size int64) (int64, error) {
+	sort.Slice(used, func(i, j int) bool { return used[i].SubID < used[j].SubID })
+	for _, r := range pool {
+		start := r.SubID
+		for _, u := range used {
+			if u.SubID >= start+size {
+				break
+			}
+			if u.SubID+u.Count > start {
+				start = u.SubID + u.Count
+			}
+		}
+		if start+size <= r.SubID+r.Count {
+			return start, nil
+		}
+	}
+	return 0, errors.New("no free range available")
+}
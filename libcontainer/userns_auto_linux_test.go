@@ -0,0 +1,121 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moby/sys/user"
+)
+
+func TestFindFreeIDRange(t *testing.T) {
+	pool := []user.SubID{
+		{SubID: 100000, Count: 200000},
+		{SubID: 500000, Count: 65536},
+	}
+	for _, tc := range []struct {
+		name  string
+		used  []user.SubID
+		size  int64
+		start int64
+		isErr bool
+	}{
+		{name: "empty", size: 65536, start: 100000},
+		{
+			name:  "after used",
+			used:  []user.SubID{{SubID: 100000, Count: 65536}},
+			size:  65536,
+			start: 165536,
+		},
+		{
+			name:  "hole",
+			used:  []user.SubID{{SubID: 165536, Count: 65536}, {SubID: 100000, Count: 1000}},
+			size:  1000,
+			start: 101000,
+		},
+		{
+			name:  "next pool range",
+			used:  []user.SubID{{SubID: 120000, Count: 150000}},
+			size:  65536,
+			start: 500000,
+		},
+		{
+			name:  "exhausted",
+			used:  []user.SubID{{SubID: 100000, Count: 200000}, {SubID: 500000, Count: 1}},
+			size:  65536,
+			isErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			start, err := findFreeIDRange(pool, tc.used, tc.size)
+			if tc.isErr {
+				if err == nil {
+					t.Fatalf("expected error, got range at %d", start)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if start != tc.start {
+				t.Fatalf("expected %d, got %d", tc.start, start)
+			}
+		})
+	}
+}
+
+func TestAllocateUserns(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	subuid := filepath.Join(dir, "subuid")
+	subgid := filepath.Join(dir, "subgid")
+	if err := os.WriteFile(subuid, []byte("other:1000:1000\ntest:100000:3000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(subgid, []byte("test:200000:3000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := &AutoUsernsOpts{Size: 1000, User: "test", SubUIDFile: subuid, SubGIDFile: subgid}
+
+	allocate := func(id string) (int64, int64, error) {
+		uidMap, gidMap, unlock, err := AllocateUserns(root, id, opts)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer unlock()
+		// Pretend the container is created.
+		if err := os.Mkdir(filepath.Join(root, id), 0o711); err != nil {
+			t.Fatal(err)
+		}
+		return uidMap[0].HostID, gidMap[0].HostID, nil
+	}
+
+	for i, id := range []string{"a", "b", "c"} {
+		uid, gid, err := allocate(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uid != 100000+int64(i)*1000 || gid != 200000+int64(i)*1000 {
+			t.Fatalf("%s: unexpected range uid %d, gid %d", id, uid, gid)
+		}
+	}
+	if _, _, err := allocate("d"); err == nil {
+		t.Fatal("expected an error, pool is exhausted")
+	}
+	if _, _, err := allocate("a"); !errors.Is(err, ErrExist) {
+		t.Fatalf("expected ErrExist, got %v", err)
+	}
+
+	// Once a container is gone, its range is reclaimed.
+	if err := os.Remove(filepath.Join(root, "b")); err != nil {
+		t.Fatal(err)
+	}
+	uid, gid, err := allocate("d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid != 101000 || gid != 201000 {
+		t.Fatalf("unexpected range uid %d, gid %d", uid, gid)
+	}
+}
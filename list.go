@@ -38,6 +38,17 @@ type containerState struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// The owner of the state directory (the owner of the container).
 	Owner string `json:"owner"`
+	// EffectiveCpus and EffectiveMems are the CPUs and memory nodes the
+	// container can actually use (only set by runc state, for a running
+	// container with the cpuset controller available).
+	EffectiveCpus string `json:"effectiveCpus,omitempty"`
+	EffectiveMems string `json:"effectiveMems,omitempty"`
+	// CPUPaused tells whether the container was paused with
+	// runc pause --cpu-only.
+	CPUPaused bool `json:"cpuPaused,omitempty"`
+	// Timings are the timings of the phases of the container bootstrap
+	// (only set by runc state --timings).
+	Timings []libcontainer.BootstrapPhase `json:"timings,omitempty"`
 }
 
 var listCommand = cli.Command{
@@ -171,6 +182,7 @@ func getContainers(context *cli.Context) ([]containerState, error) {
 			Created:        state.BaseState.Created,
 			Annotations:    annotations,
 			Owner:          owner.Name,
+			CPUPaused:      state.CPUPaused != nil,
 		})
 	}
 	return s, nil
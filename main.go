@@ -11,7 +11,9 @@ import (
 	"strings"
 
 	//nolint:revive // Enable cgroup manager to manage devices
+	"github.com/opencontainers/runc/libcontainer"
 	_ "github.com/opencontainers/runc/libcontainer/cgroups/devices"
+	_ "github.com/opencontainers/runc/libcontainer/cgroups/netrate"
 	"github.com/opencontainers/runc/libcontainer/seccomp"
 	"github.com/opencontainers/runtime-spec/specs-go"
 
@@ -101,6 +103,10 @@ func main() {
 			Value: root,
 			Usage: "root directory for storage of container state (this should be located in tmpfs)",
 		},
+		cli.StringFlag{
+			Name:  "state-backend",
+			Usage: "how to store the container state under root ('files' (default), 'log', or 'memory'); 'files' and 'log' are remembered for the root directory",
+		},
 		cli.StringFlag{
 			Name:   "criu",
 			Usage:  "(obsoleted; do not use)",
@@ -118,14 +124,19 @@ func main() {
 	}
 	app.Commands = []cli.Command{
 		checkpointCommand,
+		checkIsolationCommand,
+		cloneCommand,
+		cpuRescanCommand,
 		createCommand,
 		deleteCommand,
 		eventsCommand,
 		execCommand,
+		inspectCgroupsCommand,
 		killCommand,
 		listCommand,
 		pauseCommand,
 		psCommand,
+		restartCommand,
 		restoreCommand,
 		resumeCommand,
 		runCommand,
@@ -152,6 +163,15 @@ func main() {
 		if err := reviseRootDir(context); err != nil {
 			return err
 		}
+		if backend := context.String("state-backend"); backend == libcontainer.StateBackendMemory {
+			if err := setupMemoryStateBackend(context); err != nil {
+				return err
+			}
+		} else if backend != "" {
+			if err := libcontainer.InitStateBackend(context.String("root"), backend); err != nil {
+				return err
+			}
+		}
 		// TODO: remove this in runc 1.3.0.
 		if context.IsSet("criu") {
 			fmt.Fprintln(os.Stderr, "WARNING: --criu ignored (criu binary from $PATH is used); do not use")
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
@@ -14,11 +16,29 @@ Where "<container-id>" is the name for the instance of the container to be
 paused. `,
 	Description: `The pause command suspends all processes in the instance of the container.
 
+With --cpu-only, the processes are not frozen, but the CPU bandwidth of the
+container is lowered to the minimum the kernel allows, so that they are barely
+scheduled but can still handle signals. The container status stays running.
+
 Use runc list to identify instances of containers and their current status.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "cpu-only",
+			Usage: "lower the container CPU quota to the minimum instead of freezing it",
+		},
+		cli.BoolFlag{
+			Name:  "rt",
+			Usage: "with --cpu-only, also set the container real-time runtime to 0 (cgroup v1 only)",
+		},
+	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
 			return err
 		}
+		cpuOnly := context.Bool("cpu-only")
+		if context.Bool("rt") && !cpuOnly {
+			return errors.New("--rt requires --cpu-only")
+		}
 		rootlessCg, err := shouldUseRootlessCgroupManager(context)
 		if err != nil {
 			return err
@@ -30,6 +50,9 @@ Use runc list to identify instances of containers and their current status.`,
 		if err != nil {
 			return err
 		}
+		if cpuOnly {
+			return container.PauseCPU(context.Bool("rt"))
+		}
 		return container.Pause()
 	},
 }
@@ -42,6 +65,7 @@ var resumeCommand = cli.Command{
 Where "<container-id>" is the name for the instance of the container to be
 resumed.`,
 	Description: `The resume command resumes all processes in the instance of the container.
+For a container paused with --cpu-only, its CPU settings are restored.
 
 Use runc list to identify instances of containers and their current status.`,
 	Action: func(context *cli.Context) error {
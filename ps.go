@@ -8,7 +8,9 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/opencontainers/runc/libcontainer/system"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
@@ -23,6 +25,10 @@ var psCommand = cli.Command{
 			Value: "table",
 			Usage: `select one of: ` + formatOptions,
 		},
+		cli.BoolFlag{
+			Name:  "zombies, z",
+			Usage: "only show the zombie processes, and their parents (ps options are ignored)",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, minArgs); err != nil {
@@ -46,6 +52,10 @@ var psCommand = cli.Command{
 			return err
 		}
 
+		if context.Bool("zombies") {
+			return printZombies(context.String("format"), pids)
+		}
+
 		switch context.String("format") {
 		case "table":
 		case "json":
@@ -75,6 +85,17 @@ var psCommand = cli.Command{
 			return err
 		}
 
+		// Zombies are no longer in the cgroup, so add them, and point
+		// them out, as they are a common reason for a container not to
+		// go away (e.g. when their parent is frozen, and can't reap them).
+		zombies, err := findZombies(pids)
+		if err != nil {
+			return err
+		}
+		for _, z := range zombies {
+			pids = append(pids, z.Pid)
+		}
+
 		fmt.Println(lines[0])
 		for _, line := range lines[1:] {
 			if len(line) == 0 {
@@ -93,11 +114,113 @@ var psCommand = cli.Command{
 				}
 			}
 		}
+
+		if len(zombies) > 0 {
+			logrus.Warnf("%d zombie process(es) in the container, see runc ps --zombies", len(zombies))
+		}
 		return nil
 	},
 	SkipArgReorder: true,
 }
 
+// zombie is a zombie (defunct) process of a container.
+type zombie struct {
+	// Pid is the host PID of the zombie.
+	Pid int `json:"pid"`
+	// NSPid is the PID of the zombie inside the container.
+	NSPid int `json:"nspid"`
+	// PPid is the host PID of the parent of the zombie, which is
+	// expected to reap it.
+	PPid int `json:"ppid"`
+	// NSPPid is the PID of the parent inside the container, or 0 if the
+	// parent is not in the container.
+	NSPPid int `json:"nsppid,omitempty"`
+	// Parent is the command name of the parent.
+	Parent string `json:"parent"`
+}
+
+// findZombies returns the zombie processes of a container, given the
+// processes in its cgroup. As zombies are no longer listed in their cgroup,
+// this looks for the zombies either in pids, or whose parent is in pids.
+func findZombies(pids []int) ([]zombie, error) {
+	in := make(map[int]struct{}, len(pids))
+	for _, pid := range pids {
+		in[pid] = struct{}{}
+	}
+	ents, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var zombies []zombie
+	for _, ent := range ents {
+		pid, err := strconv.Atoi(ent.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := system.Stat(pid)
+		if err != nil || stat.State != system.Zombie {
+			// An error is most probably due to the process being
+			// reaped in the meantime.
+			continue
+		}
+		_, zombieIn := in[pid]
+		_, parentIn := in[stat.PPid]
+		if !zombieIn && !parentIn {
+			continue
+		}
+		z := zombie{Pid: pid, NSPid: nsPid(pid), PPid: stat.PPid}
+		if parent, err := system.Stat(stat.PPid); err == nil {
+			z.Parent = parent.Name
+		}
+		if parentIn {
+			z.NSPPid = nsPid(stat.PPid)
+		}
+		zombies = append(zombies, z)
+	}
+	return zombies, nil
+}
+
+// nsPid returns the PID of pid in its innermost PID namespace, or 0 if
+// unknown.
+func nsPid(pid int) int {
+	nspids, err := system.NSpid(pid)
+	if err != nil {
+		return 0
+	}
+	return nspids[len(nspids)-1]
+}
+
+func printZombies(format string, pids []int) error {
+	zombies, err := findZombies(pids)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "table":
+	case "json":
+		if zombies == nil {
+			zombies = []zombie{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(zombies)
+	default:
+		return errors.New("invalid format option")
+	}
+
+	orDash := func(pid int) string {
+		if pid == 0 {
+			return "-"
+		}
+		return strconv.Itoa(pid)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 8, 1, 3, ' ', 0)
+	fmt.Fprint(w, "PID\tNSPID\tPPID\tNSPPID\tPARENT\n")
+	for _, z := range zombies {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n",
+			z.Pid, orDash(z.NSPid), z.PPid, orDash(z.NSPPid), z.Parent)
+	}
+	return w.Flush()
+}
+
 func getPidIndex(title string) (int, error) {
 	titles := strings.Fields(title)
 
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/runc/libcontainer/utils"
+	"github.com/urfave/cli"
+)
+
+var restartCommand = cli.Command{
+	Name:  "restart",
+	Usage: "run a stopped container again",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container to be
+restarted.`,
+	Description: `The restart command runs the process defined in the bundle's specification
+file again in a stopped container which has not been deleted, e.g. one run
+with "runc run --keep". The container's state, cgroups and CPU placement are
+reused, so the container is not set up from scratch.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "console-socket",
+			Value: "",
+			Usage: "path to an AF_UNIX socket which will receive a file descriptor referencing the master end of the console's pseudoterminal",
+		},
+		cli.StringFlag{
+			Name:  "pidfd-socket",
+			Usage: "path to an AF_UNIX socket which will receive a file descriptor referencing the init process",
+		},
+		cli.BoolFlag{
+			Name:  "detach, d",
+			Usage: "detach from the container's process",
+		},
+		cli.StringFlag{
+			Name:  "pid-file",
+			Value: "",
+			Usage: "specify the file to write the process id to",
+		},
+		cli.BoolFlag{
+			Name:  "no-subreaper",
+			Usage: "disable the use of the subreaper used to reap reparented processes",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		status, err := restartContainer(context)
+		if err == nil {
+			os.Exit(status)
+		}
+		return fmt.Errorf("runc restart failed: %w", err)
+	},
+}
+
+func restartContainer(context *cli.Context) (int, error) {
+	if err := revisePidFile(context); err != nil {
+		return -1, err
+	}
+	container, err := getContainer(context)
+	if err != nil {
+		return -1, err
+	}
+	state, err := container.State()
+	if err != nil {
+		return -1, err
+	}
+	bundle, ok := utils.SearchLabels(state.Config.Labels, "bundle")
+	if !ok {
+		return -1, errors.New("bundle not found in labels")
+	}
+	if err := os.Chdir(bundle); err != nil {
+		return -1, err
+	}
+	spec, err := loadSpec(filepath.Join(bundle, specConfig))
+	if err != nil {
+		return -1, err
+	}
+
+	r := &runner{
+		enableSubreaper: !context.Bool("no-subreaper"),
+		// The container is kept when it exits, so it can be restarted
+		// again.
+		shouldDestroy: false,
+		container:     container,
+		consoleSocket: context.String("console-socket"),
+		pidfdSocket:   context.String("pidfd-socket"),
+		detach:        context.Bool("detach"),
+		pidFile:       context.String("pid-file"),
+		action:        CT_ACT_RESTART,
+		init:          true,
+	}
+	return r.run(spec.Process)
+}
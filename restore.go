@@ -37,6 +37,10 @@ using the runc checkpoint command.`,
 			Name:  "tcp-established",
 			Usage: "allow open tcp connections",
 		},
+		cli.BoolFlag{
+			Name:  "tcp-close",
+			Usage: "restore open tcp connections as closed",
+		},
 		cli.BoolFlag{
 			Name:  "ext-unix-sk",
 			Usage: "allow external unix sockets",
@@ -98,6 +102,11 @@ using the runc checkpoint command.`,
 			Value: "",
 			Usage: "Specify an LSM mount context to be used during restore.",
 		},
+		cli.StringFlag{
+			Name:  "cgroup-root",
+			Value: "",
+			Usage: "restore the container cgroup under this parent cgroup (or, with systemd, slice) instead of the one in the spec",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
@@ -2,10 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/utils"
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
@@ -17,6 +19,16 @@ var stateCommand = cli.Command{
 Where "<container-id>" is your name for the instance of the container.`,
 	Description: `The state command outputs current state information for the
 instance of a container.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "repair",
+			Usage: "reconcile the saved container state with its processes and cgroups before showing it",
+		},
+		cli.BoolFlag{
+			Name:  "timings",
+			Usage: "include the timings of the phases of the container bootstrap",
+		},
+	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
 			return err
@@ -25,6 +37,15 @@ instance of a container.`,
 		if err != nil {
 			return err
 		}
+		if context.Bool("repair") {
+			fixed, err := container.Repair()
+			for _, f := range fixed {
+				logrus.Warnf("repaired: %s", f)
+			}
+			if err != nil {
+				return fmt.Errorf("unable to repair container %s: %w", container.ID(), err)
+			}
+		}
 		containerStatus, err := container.Status()
 		if err != nil {
 			return err
@@ -47,6 +68,17 @@ instance of a container.`,
 			Rootfs:         state.BaseState.Config.Rootfs,
 			Created:        state.BaseState.Created,
 			Annotations:    annotations,
+			CPUPaused:      state.CPUPaused != nil,
+		}
+		if containerStatus != libcontainer.Stopped {
+			cpus, mems, err := container.EffectiveCpuset()
+			if err != nil {
+				logrus.Debugf("unable to get the effective cpuset: %v", err)
+			}
+			cs.EffectiveCpus, cs.EffectiveMems = cpus, mems
+		}
+		if context.Bool("timings") {
+			cs.Timings = state.BootstrapTimings
 		}
 		data, err := json.MarshalIndent(cs, "", "  ")
 		if err != nil {
@@ -525,3 +525,180 @@ convert_hugetlb_size() {
 	# Cleanup.
 	rmdir "$FREEZER_DIR"
 }
+
+@test "runc run (exclusive cpu placement)" {
+	requires root cgroups_cpuset
+
+	set_cgroups_path
+	update_config '  .annotations += {"org.opencontainers.runc.cpu.exclusive-placement": "1"}
+			| del(.linux.resources.cpu.cpus)'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_placement
+	[ "$status" -eq 0 ]
+
+	# The selected cpu is recorded, and set as the container cpuset.
+	cpu=$(jq -r 'to_entries[] | select(.value.container == "test_placement") | .key' "$ROOT/state/cpu-placement.json")
+	[ -n "$cpu" ]
+	runc state test_placement
+	[[ "$output" == *"\"effectiveCpus\": \"$cpu\""* ]]
+
+	runc delete -f test_placement
+	[ "$status" -eq 0 ]
+	[ "$(jq -r 'length' "$ROOT/state/cpu-placement.json")" -eq 0 ]
+}
+
+@test "runc run (runtime/workload sub-cgroups)" {
+	requires root
+
+	set_cgroups_path
+	update_config '.annotations += {"org.opencontainers.runc.cgroups.split": "true"}'
+
+	runc run --pid-file pid.txt -d --console-socket "$CONSOLE_SOCKET" test_cgroups_split
+	[ "$status" -eq 0 ]
+
+	# The container init is in the workload sub-cgroup.
+	pid=$(cat pid.txt)
+	grep -E ':/.*runc-cgroups-integration-test.*/workload$' /proc/"$pid"/cgroup
+
+	# Processes executed in the container are in the runtime sub-cgroup.
+	runc exec test_cgroups_split cat /proc/self/cgroup
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"/runtime"* ]]
+	[[ "$output" != *"/workload"* ]]
+}
+
+@test "runc update cpuset (runtime/workload sub-cgroups)" {
+	requires root cgroups_v1 smp cgroups_cpuset
+
+	set_cgroups_path
+	update_config '  .annotations += {"org.opencontainers.runc.cgroups.split": "true"}
+			| .linux.resources.cpu |= {"cpus": "0"}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_cgroups_split
+	[ "$status" -eq 0 ]
+
+	# Moving to other CPUs requires the sub-cgroups to be moved first.
+	runc update --cpuset-cpus 1 test_cgroups_split
+	[ "$status" -eq 0 ]
+	check_cgroup_value "cpuset.cpus" 1
+	[ "$(cat "${CGROUP_CPUSET_BASE_PATH}${REL_CGROUPS_PATH}/workload/cpuset.cpus")" = "1" ]
+	[ "$(cat "${CGROUP_CPUSET_BASE_PATH}${REL_CGROUPS_PATH}/runtime/cpuset.cpus")" = "1" ]
+}
+
+@test "runc run (real-time sub-cgroup)" {
+	requires root cgroups_v1 cgroups_rt
+
+	set_cgroups_path
+	update_config '  .annotations += {"org.opencontainers.runc.cgroups.rt-sub-cgroup": "rt",
+				"org.opencontainers.runc.cgroups.rt-sub-cgroup.commands": "cat"}
+			| .linux.resources.cpu |= {"realtimePeriod": 1000000, "realtimeRuntime": 200000}'
+
+	runc run --pid-file pid.txt -d --console-socket "$CONSOLE_SOCKET" test_cgroups_rt
+	[ "$status" -eq 0 ]
+
+	# The real-time budget is given to the sub-cgroup.
+	[ "$(cat "${CGROUP_CPU_BASE_PATH}${REL_CGROUPS_PATH}/rt/cpu.rt_runtime_us")" = "200000" ]
+
+	# The container init is not a real-time command.
+	pid=$(cat pid.txt)
+	run ! grep -E ':cpu[,:].*/rt$' /proc/"$pid"/cgroup
+
+	# Real-time commands are executed in the sub-cgroup by default.
+	runc exec test_cgroups_rt cat /proc/self/cgroup
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"cpu"*"/rt"* ]]
+
+	# As are the processes executed with --sub-cgroup.
+	runc exec --sub-cgroup test_cgroups_rt sh -c 'cat /proc/self/cgroup'
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"cpu"*"/rt"* ]]
+
+	runc exec test_cgroups_rt sh -c 'cat /proc/self/cgroup'
+	[ "$status" -eq 0 ]
+	[[ "$output" != *"/rt"* ]]
+}
+
+@test "runc exec (exec accounting)" {
+	requires root
+
+	set_cgroups_path
+	update_config '.annotations += {"org.opencontainers.runc.cgroups.exec-accounting": "true"}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_cgroups_exec
+	[ "$status" -eq 0 ]
+
+	# Executed processes are run in the exec sub-cgroup.
+	runc exec test_cgroups_exec cat /proc/self/cgroup
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"/exec"* ]]
+
+	runc exec -d test_cgroups_exec sleep 100
+	[ "$status" -eq 0 ]
+
+	# And accounted separately.
+	runc events --stats test_cgroups_exec
+	[ "$status" -eq 0 ]
+	[ "$(jq '.data.exec.cpu.usage.total > 0' <<<"$output")" = "true" ]
+}
+
+@test "runc run (frozen parent cgroup)" {
+	requires root no_systemd
+
+	set_cgroups_path "pod_${RANDOM}"
+	if [ -v CGROUP_V2 ]; then
+		echo 1 >"/sys/fs/cgroup$REL_PARENT_PATH/cgroup.freeze"
+	else
+		echo FROZEN >"/sys/fs/cgroup/freezer$REL_PARENT_PATH/freezer.state"
+	fi
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_cgroups_frozen
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"ancestor cgroup "*"$REL_PARENT_PATH is frozen"* ]]
+
+	runc run -d --thaw-frozen-ancestors --console-socket "$CONSOLE_SOCKET" test_cgroups_frozen
+	[ "$status" -eq 0 ]
+	testcontainer test_cgroups_frozen running
+}
+
+@test "runc create --start-frozen" {
+	requires cgroups_freezer
+	[ $EUID -ne 0 ] && requires rootless_cgroup
+
+	set_cgroups_path
+	update_config '.linux.resources.cpu.cpus = "0"'
+
+	runc create --start-frozen --console-socket "$CONSOLE_SOCKET" test_cgroups_frozen
+	[ "$status" -eq 0 ]
+	testcontainer test_cgroups_frozen created
+	if [ -v CGROUP_V1 ]; then
+		[ "$(cat "${CGROUP_FREEZER_BASE_PATH}${REL_CGROUPS_PATH}/freezer.state")" = "FROZEN" ]
+	else
+		[ "$(cat "${CGROUP_V2_PATH}/cgroup.freeze")" = "1" ]
+	fi
+
+	runc start test_cgroups_frozen
+	[ "$status" -eq 0 ]
+	testcontainer test_cgroups_frozen running
+}
+
+@test "runc run (delegate cgroup annotation)" {
+	requires root
+
+	# chown test temp dir to allow host user to read it
+	chown 100000 "$ROOT"
+	# chown rootfs to allow host user to mkdir mount points
+	chown 100000 "$ROOT"/bundle/rootfs
+
+	set_cgroups_path
+	update_config '   .linux.namespaces += [{"type": "user"}]
+			| .linux.uidMappings += [{"hostID": 100000, "containerID": 0, "size": 65536}]
+			| .linux.gidMappings += [{"hostID": 100000, "containerID": 0, "size": 65536}]
+			| .annotations += {"org.opencontainers.runc.cgroups.delegate": "true"}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_cgroup_delegate
+	[ "$status" -eq 0 ]
+
+	run stat -c %u "$(get_cgroup_path pids)"
+	[ "$status" -eq 0 ]
+	[ "$output" = "100000" ]
+}
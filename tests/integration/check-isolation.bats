@@ -0,0 +1,48 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc check-isolation (bundle, json)" {
+	update_config '.linux.resources.cpu.cpus = "0"'
+
+	runc check-isolation -f json .
+	# The result depends on the host configuration.
+	[[ "$output" == *'"cpus":"0"'* ]]
+	[[ "$output" == *'"name":"isolated"'* ]]
+	[[ "$output" == *'"name":"nohz_full"'* ]]
+	[[ "$output" == *'"name":"siblings"'* ]]
+	[[ "$output" == *'"name":"exclusive","status":"pass"'* ]]
+	[[ "$output" == *'"name":"rt-budget","status":"skip"'* ]]
+}
+
+@test "runc check-isolation (no cpuset)" {
+	runc check-isolation .
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"isolated"*"fail"*"no cpuset cpus requested"* ]]
+	[[ "$output" == *"isolation check failed"* ]]
+}
+
+@test "runc check-isolation (container)" {
+	requires root
+
+	update_config '.linux.resources.cpu.cpus = "0"'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc check-isolation -f json test_busybox
+	[[ "$output" == *'"cpus":"0"'* ]]
+}
+
+@test "runc check-isolation (non-existent)" {
+	runc check-isolation no_such_container
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"neither a container nor a bundle"* ]]
+}
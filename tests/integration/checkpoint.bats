@@ -151,6 +151,20 @@ function simple_cr() {
 	simple_cr
 }
 
+@test "checkpoint --feature-check" {
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox running
+
+	runc checkpoint --feature-check --pre-dump --image-path ./image-dir test_busybox
+	[ "$status" -eq 0 ]
+	[[ "${lines[0]}" == "criu version: "* ]]
+
+	# Nothing is checkpointed.
+	[ ! -e ./image-dir ]
+	testcontainer test_busybox running
+}
+
 @test "checkpoint --pre-dump (bad --parent-path)" {
 	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
 	[ "$status" -eq 0 ]
@@ -441,3 +455,56 @@ function simple_cr() {
 	pid=$(cat "pid")
 	grep -q "${REL_CGROUPS_PATH}$" "/proc/$pid/cgroup"
 }
+
+@test "checkpoint then restore into a different parent cgroup (--cgroup-root)" {
+	requires no_systemd
+
+	set_cgroups_path pod1
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox running
+
+	runc checkpoint --work-path ./work-dir test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox checkpointed
+
+	# The spec still has the pod1 path.
+	runc restore -d --cgroup-root /runc-cgroups-integration-test/pod2 --pid-file pid \
+		--work-path ./work-dir --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox running
+
+	local pid
+	pid=$(cat "pid")
+	grep -q "/runc-cgroups-integration-test/pod2/${REL_CGROUPS_PATH##*/}$" "/proc/$pid/cgroup"
+	run ! grep -q "/pod1/" "/proc/$pid/cgroup"
+}
+
+@test "clone a running container" {
+	update_config '.process.args = ["sleep", "1000"] | .process.terminal = false'
+	runc run -d test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox running
+
+	runc clone -d --hostname clone --pid-file pid --work-path ./work-dir test_busybox test_clone
+	[ "$status" -eq 0 ]
+	# The cloned container is left running.
+	testcontainer test_busybox running
+	testcontainer test_clone running
+
+	runc exec test_clone hostname
+	[ "$status" -eq 0 ]
+	[ "$output" = "clone" ]
+
+	# The clone has its own cgroup.
+	local pid
+	pid=$(cat "pid")
+	run ! grep -q "${REL_CGROUPS_PATH}$" "/proc/$pid/cgroup"
+
+	runc clone test_busybox test_clone
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"already exists"* ]]
+
+	runc delete -f test_clone
+	[ "$status" -eq 0 ]
+}
@@ -29,17 +29,22 @@ function test_events() {
 	# Spawn two subshels:
 	# 1. Event logger that sends stats events to events.log.
 	(__runc events ${interval:+ --interval "$interval"} test_busybox >events.log) &
-	# 2. Waits for an event that includes test_busybox then kills the
+	# 2. Waits for a stats event that includes test_busybox then kills the
 	#    test_busybox container which causes the event logger to exit.
 	(
-		retry 10 "$retry_every" grep -q test_busybox events.log
+		retry 10 "$retry_every" grep -q '"type":"stats","id":"test_busybox"' events.log
 		__runc delete -f test_busybox
 	) &
 	wait # for both subshells to finish
 
 	[ -e events.log ]
 
+	# The bootstrap timings come first.
 	output=$(head -1 events.log)
+	[[ "$output" == [\{]"\"type\""[:]"\"bootstrap\""[,]"\"id\""[:]"\"test_busybox\""[,]* ]]
+	[[ "$output" == *'"name":"nsexec"'* ]]
+
+	output=$(sed -n 2p events.log)
 	[[ "$output" == [\{]"\"type\""[:]"\"stats\""[,]"\"id\""[:]"\"test_busybox\""[,]* ]]
 	[[ "$output" == *"data"* ]]
 }
@@ -58,6 +63,38 @@ function test_events() {
 	[ "$status" -eq 0 ]
 	[[ "${lines[0]}" == [\{]"\"type\""[:]"\"stats\""[,]"\"id\""[:]"\"test_busybox\""[,]* ]]
 	[[ "${lines[0]}" == *"data"* ]]
+	[ "$(jq '.schemaVersion' <<<"${lines[0]}")" -ge 2 ]
+	[ "$(jq '.monotonic' <<<"${lines[0]}")" -gt 0 ]
+	[[ "$(jq -r '.timestamp' <<<"${lines[0]}")" == 20* ]]
+}
+
+@test "events --stats with annotations" {
+	# XXX: currently cgroups require root containers.
+	requires root
+	init_cgroup_paths
+
+	update_config '.annotations += {"io.example.pod": "pod1"}'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc events --stats test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.annotations["io.example.pod"]' <<<"${lines[0]}")" = "pod1" ]
+}
+
+@test "events --stats --rates" {
+	# XXX: currently cgroups require root containers.
+	requires root
+	init_cgroup_paths
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc events --stats --rates --interval 200ms test_busybox
+	[ "$status" -eq 0 ]
+	[ "${#lines[@]}" -eq 1 ]
+	jq -e '.data.rates.interval >= 200000000' <<<"${lines[0]}"
+	jq -e '.data.rates.cpu.usage >= 0' <<<"${lines[0]}"
 }
 
 @test "events --stats with psi data" {
@@ -128,3 +165,23 @@ function test_events() {
 
 	grep -q '{"type":"oom","id":"test_busybox"}' events.log
 }
+
+@test "events --fork-rate-limit" {
+	requires root
+	init_cgroup_paths
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	(__runc events --fork-rate-limit 10 test_busybox >events.log) &
+	(
+		sleep 1
+		# A fork storm, way above 10 tasks per second.
+		__runc exec -d test_busybox sh -c 'for i in $(seq 100); do sleep 10 & done; wait'
+		retry 10 1 grep -q fork-throttle events.log
+		__runc delete -f test_busybox
+	) &
+	wait # wait for the above sub shells to finish
+
+	grep -q '"type":"fork-throttle","id":"test_busybox"' events.log
+}
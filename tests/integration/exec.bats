@@ -115,6 +115,17 @@ function teardown() {
 	[[ ${output} == *"RUNC_EXEC_TEST=true"* ]]
 }
 
+@test "runc exec --env-file --env-fd" {
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	echo "RUNC_EXEC_FILE=true" >env.list
+	runc exec --env-file env.list --env-fd 5 test_busybox env 5< <(echo RUNC_EXEC_FD=true)
+	[ "$status" -eq 0 ]
+	[[ ${output} == *"RUNC_EXEC_FILE=true"* ]]
+	[[ ${output} == *"RUNC_EXEC_FD=true"* ]]
+}
+
 @test "runc exec --user" {
 	# --user can't work in rootless containers that don't have idmap.
 	[ $EUID -ne 0 ] && requires rootless_idmap
@@ -340,3 +351,17 @@ EOF
 	[ ${#lines[@]} -eq 1 ]
 	[[ ${lines[0]} = *"exec /run.sh: no such file or directory"* ]]
 }
+
+@test "runc exec --timer-slack" {
+	update_config '.annotations += {"org.opencontainers.runc.timer-slack": "1us"}'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc exec test_busybox cat /proc/self/timerslack_ns
+	[ "$status" -eq 0 ]
+	[ "$output" = "1000" ]
+
+	runc exec --timer-slack 2us test_busybox cat /proc/self/timerslack_ns
+	[ "$status" -eq 0 ]
+	[ "$output" = "2000" ]
+}
@@ -0,0 +1,12 @@
+#!/usr/bin/env bats
+
+load helpers
+
+@test "runc features --topology" {
+	runc features --topology
+	[ "$status" -eq 0 ]
+
+	# CPU 0 is always online, and belongs to a core.
+	[ "$(jq -r '.online' <<<"$output" | cut -d, -f1 | cut -d- -f1)" = "0" ]
+	jq -e '.cores | map(.cpus) | length > 0' <<<"$output"
+}
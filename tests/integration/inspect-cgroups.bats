@@ -0,0 +1,30 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	requires root
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc inspect-cgroups" {
+	set_cgroups_path
+	update_config '.linux.resources.pids.limit = 42'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc inspect-cgroups test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.id' <<<"$output")" = "test_busybox" ]
+	[ "$(jq -r '.cgroups[].files["pids.max"] | select(. != null)' <<<"$output")" = "42" ]
+	[ "$(jq '.ancestors' <<<"$output")" = "null" ]
+
+	runc inspect-cgroups --ancestors test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq '.ancestors | length' <<<"$output")" -gt 0 ]
+}
@@ -92,7 +92,7 @@ test_host_pidns_kill() {
 	[ "$status" -ne 0 ]
 	[[ "$output" == *"container not running"* ]]
 
-	# Check that -a (now obsoleted) makes kill return no error for a stopped container.
+	# Check that -a makes kill return no error for a stopped container.
 	runc kill -a test_busybox 0
 	[ "$status" -eq 0 ]
 
@@ -157,3 +157,60 @@ test_host_pidns_kill() {
 	runc delete --force target_ctr
 	[ "$status" -eq 0 ]
 }
+
+@test "kill --all" {
+	requires cgroups_freezer
+	set_cgroups_path
+	if [ $EUID -ne 0 ]; then
+		requires rootless_cgroup
+	fi
+
+	update_config '.process.args = ["sleep", "infinity"]'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	init_pid="$(__runc state test_busybox | jq .pid)"
+	for _ in 1 2 3; do
+		__runc exec -d test_busybox sleep 1h
+	done
+	cgpath=$(get_cgroup_path "pids")
+	mapfile -t pids < <(grep -vx "$init_pid" "$cgpath"/cgroup.procs)
+	[ ${#pids[@]} -eq 3 ]
+
+	# A paused container stays paused after a non-KILL signal.
+	runc pause test_busybox
+	[ "$status" -eq 0 ]
+	runc kill --all test_busybox TERM
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox paused
+
+	# Once resumed, all processes get the pending SIGTERM. The container's
+	# init ignores it, as it has no signal handler, but the rest are gone.
+	runc resume test_busybox
+	[ "$status" -eq 0 ]
+	wait_pids_gone 10 0.2 "${pids[@]}"
+	kill -0 "$init_pid"
+
+	runc kill --all test_busybox KILL
+	[ "$status" -eq 0 ]
+	wait_for_container 10 1 test_busybox stopped
+}
+
+@test "kill with stop signal and prestop hook" {
+	update_config '.process.args = ["sh", "-c", "trap \"exit 0\" USR1; while true; do sleep 0.1; done"]'
+	update_config '.annotations += {
+		"org.opencontainers.runc.stop-signal": "SIGUSR1",
+		"org.opencontainers.runc.hooks.prestop": ([{"path": "/bin/sh", "args": ["sh", "-c", "cat > '"$PWD"'/prestop.json"]}] | tojson)
+	}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc kill test_busybox
+	[ "$status" -eq 0 ]
+	wait_for_container 10 1 test_busybox stopped
+
+	# The hook got the state of the running container.
+	[ "$(jq -r .status prestop.json)" = "running" ]
+	[ "$(jq -r .id prestop.json)" = "test_busybox" ]
+}
@@ -145,6 +145,33 @@ function test_mount_order() {
 	[[ "${lines[0]}" == *'drwxrwxrwx'* ]]
 }
 
+@test "runc run [overlay-auto]" {
+	requires root
+
+	lower="$(mktemp -d "$BATS_RUN_TMPDIR/lower.XXXXXX")"
+	echo "lower" >"$lower/file"
+	update_config '	  .mounts += [{
+					source: "overlay",
+					destination: "/data",
+					type: "overlay",
+					options: ["lowerdir='"$lower"'", "overlay-auto"]
+				}]
+			| .process.args |= ["sh", "-c", "cat /data/file && echo upper > /data/file && sleep infinity"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	wait_for_container 10 1 test_busybox running
+
+	# The changes go to the upper directory under the state dir.
+	upper="$(find "$ROOT/state/test_busybox/overlay" -maxdepth 2 -name upper)"
+	retry 10 0.5 grep -qx upper "$upper/file"
+	[ "$(cat "$lower/file")" = "lower" ]
+
+	runc delete -f test_busybox
+	[ "$status" -eq 0 ]
+	[ ! -e "$upper" ]
+}
+
 @test "runc run [bind mount]" {
 	update_config '	  .mounts += [{
 					source: ".",
@@ -75,3 +75,46 @@ function teardown() {
 	runc state test_busybox
 	[ "$status" -ne 0 ]
 }
+
+@test "runc pause --cpu-only and resume" {
+	requires root cgroups_cpu
+
+	set_cgroups_path
+	update_config '.linux.resources.cpu |= {"quota": 50000, "period": 100000}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc pause --cpu-only test_busybox
+	[ "$status" -eq 0 ]
+
+	# The container is not frozen, but barely gets any CPU time.
+	testcontainer test_busybox running
+	runc state test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *'"cpuPaused": true'* ]]
+	if [ -v CGROUP_V2 ]; then
+		check_cgroup_value "cpu.max" "1000 1000000"
+	else
+		check_cgroup_value "cpu.cfs_quota_us" 1000
+		check_cgroup_value "cpu.cfs_period_us" 1000000
+	fi
+
+	# The CPU settings would be lost on resume.
+	runc update --cpu-quota 60000 test_busybox
+	[ "$status" -ne 0 ]
+
+	runc resume test_busybox
+	[ "$status" -eq 0 ]
+	if [ -v CGROUP_V2 ]; then
+		check_cgroup_value "cpu.max" "50000 100000"
+	else
+		check_cgroup_value "cpu.cfs_quota_us" 50000
+		check_cgroup_value "cpu.cfs_period_us" 100000
+	fi
+	runc state test_busybox
+	[[ "$output" != *"cpuPaused"* ]]
+
+	runc resume test_busybox
+	[ "$status" -ne 0 ]
+}
@@ -62,3 +62,28 @@ function teardown() {
 	[ "$status" -eq 0 ]
 	[[ "$output" == *"x86_64"* ]]
 }
+
+@test "runc run personality with flags" {
+	update_config '
+      .process.args = ["/bin/cat", "/proc/self/personality"]
+			| .linux.personality = {
+                "domain": "LINUX",
+                "flags": ["ADDR_NO_RANDOMIZE"]
+			}'
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"00040000"* ]]
+}
+
+@test "runc run personality with invalid flag" {
+	update_config '
+      .linux.personality = {
+                "domain": "LINUX",
+                "flags": ["NO_SUCH_FLAG"]
+      }'
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"invalid personality flag"* ]]
+}
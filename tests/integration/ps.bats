@@ -40,6 +40,28 @@ function teardown() {
 	[[ "$output" =~ [0-9]+ ]]
 }
 
+@test "ps --zombies" {
+	# The shell exits without reaping sleep 0, which stays a zombie child
+	# of sleep 100.
+	runc exec -d test_busybox sh -c 'sleep 0 & exec sleep 100'
+	[ "$status" -eq 0 ]
+	sleep 1
+
+	runc ps test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"<defunct>"* ]]
+	[[ "$output" == *"1 zombie process(es)"* ]]
+
+	runc ps --zombies test_busybox
+	[ "$status" -eq 0 ]
+	[[ "${lines[0]}" =~ PID\ +NSPID\ +PPID\ +NSPPID\ +PARENT ]]
+	[[ "${lines[1]}" == *" sleep" ]]
+
+	runc ps -z -f json test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.[0].parent' <<<"$output")" = "sleep" ]
+}
+
 @test "ps after the container stopped" {
 	runc ps test_busybox
 	[ "$status" -eq 0 ]
@@ -58,6 +58,37 @@ function teardown() {
 	[ "$status" -ne 0 ]
 }
 
+@test "runc restart" {
+	requires no_systemd
+	[ $EUID -ne 0 ] && requires rootless_cgroup
+
+	set_cgroups_path
+
+	runc run --keep test_run_keep
+	[ "$status" -eq 0 ]
+	testcontainer test_run_keep stopped
+
+	# A stopped container can be run again, in the same cgroup.
+	runc restart test_run_keep
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"Hello World"* ]]
+	testcontainer test_run_keep stopped
+	check_cgroup_value "pids.max" "max"
+
+	update_config '.process.args = ["sleep", "infinity"]'
+	runc restart -d --console-socket "$CONSOLE_SOCKET" test_run_keep
+	[ "$status" -eq 0 ]
+	testcontainer test_run_keep running
+
+	# A running container can't be restarted.
+	runc restart -d --console-socket "$CONSOLE_SOCKET" test_run_keep
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"container not stopped"* ]]
+
+	runc delete -f test_run_keep
+	[ "$status" -eq 0 ]
+}
+
 @test "runc run [hostname domainname]" {
 	update_config ' .process.args |= ["sh"]
 			| .hostname = "myhostname"
@@ -169,6 +200,26 @@ function teardown() {
 	[[ "$output" = *"runc-dmz: using /proc/self/exe clone"* ]]
 }
 
+@test "runc run [exe-protection=bind-ro]" {
+	requires root
+	if stat -f -c %T "$RUNC" | grep -q overlay; then
+		skip "runc binary is on overlayfs"
+	fi
+	update_config '.annotations += {"org.opencontainers.runc.exe-protection": "bind-ro"}'
+	runc --debug run test_hello
+	[ "$status" -eq 0 ]
+	[[ "$output" = *"Hello World"* ]]
+	[[ "$output" = *"runc-dmz: using read-only bind mount of /proc/self/exe"* ]]
+}
+
+@test "runc run [exe-protection=off]" {
+	update_config '.annotations += {"org.opencontainers.runc.exe-protection": "off"}'
+	runc --debug run test_hello
+	[ "$status" -eq 0 ]
+	[[ "$output" = *"Hello World"* ]]
+	[[ "$output" = *"runc binary protection is disabled"* ]]
+}
+
 @test "runc run [joining existing container namespaces]" {
 	requires timens
 
@@ -275,3 +326,33 @@ EOF
 	[ ${#lines[@]} -eq 1 ]
 	[[ ${lines[0]} = "exec /run.sh: no such file or directory" ]]
 }
+
+@test "runc run --listen-fd" {
+	update_config '.process.args = ["sh", "-c", "echo $LISTEN_FDS $LISTEN_PID $LISTEN_FDNAMES; ls /proc/self/fd/3 /proc/self/fd/4"]'
+
+	runc run --listen-fd http=5 --listen-fd admin=6 test_listen_fd 5</dev/null 6</dev/null
+	[ "$status" -eq 0 ]
+	[[ "${lines[0]}" == "2 1 http:admin" ]]
+
+	runc run --listen-fd bad:name=5 test_listen_fd 5</dev/null
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"invalid --listen-fd name"* ]]
+
+	runc run --listen-fd http=2 test_listen_fd
+	[ "$status" -ne 0 ]
+}
+
+@test "runc run --env-file --env-fd" {
+	update_config '.process.args = ["sh", "-c", "echo $A $B $C"]
+		| .process.env += ["A=config"]'
+	printf '# secrets\nA=file\nB=file\n' >env.list
+
+	runc run --env-file env.list --env-fd 5 test_env_file 5< <(echo B=fd; echo C=fd)
+	[ "$status" -eq 0 ]
+	[[ "${lines[0]}" == "file fd fd" ]]
+
+	echo "NOVALUE" >bad.list
+	runc run --env-file bad.list test_env_file
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"env file "*"bad.list: line 1: expected KEY=VALUE"* ]]
+}
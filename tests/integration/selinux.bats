@@ -56,3 +56,26 @@ function teardown() {
 	runc run tst
 	[ "$status" -eq 0 ]
 }
+
+@test "runc run (automatic mcs level)" {
+	update_config '	  .annotations += {"org.opencontainers.runc.selinux.mcs.auto": "true"}
+			| .process.args = ["/bin/sh", "-c", "cat /proc/self/attr/current; sleep 100"]'
+	runc run -d --console-socket "$CONSOLE_SOCKET" tst1
+	[ "$status" -eq 0 ]
+	runc exec tst1 cat /proc/1/attr/current
+	[ "$status" -eq 0 ]
+	[[ "$output" == "system_u:system_r:container_t:s0:c"*",c"* ]]
+	local label1="$output"
+
+	update_config '.process.args = ["sleep", "100"]'
+	runc run -d --console-socket "$CONSOLE_SOCKET" tst2
+	[ "$status" -eq 0 ]
+	runc exec tst2 cat /proc/1/attr/current
+	[ "$status" -eq 0 ]
+	[[ "$output" == "system_u:system_r:container_t:s0:c"*",c"* ]]
+	# Each container gets its own level.
+	[ "$output" != "$label1" ]
+
+	runc delete -f tst1
+	runc delete -f tst2
+}
@@ -33,6 +33,27 @@ function teardown() {
 	[ "$status" -ne 0 ]
 }
 
+@test "state --timings" {
+	runc create --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc state test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq 'has("timings")' <<<"$output")" = "false" ]
+
+	runc state --timings test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '[.timings[].name] | join(" ")' <<<"$output")" = "spawn cgroup-apply nsexec nsexec-exit init-setup cgroup-set hooks init-finish" ]
+
+	runc start test_busybox
+	[ "$status" -eq 0 ]
+
+	runc state --timings test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.timings[-1].name' <<<"$output")" = "exec" ]
+	[ "$(jq '.timings[-1].duration > 0' <<<"$output")" = "true" ]
+}
+
 @test "state (pause + resume)" {
 	# XXX: pause and resume require cgroups.
 	requires root
@@ -61,3 +82,34 @@ function teardown() {
 	# test state of busybox is back to running
 	testcontainer test_busybox running
 }
+
+@test "state --repair [init gone]" {
+	# XXX: currently cgroups require root containers.
+	requires root
+	init_cgroup_paths
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox running
+
+	# Nothing to repair.
+	runc state --repair test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" != *"repaired"* ]]
+
+	cgpath=$(get_cgroup_path "pids")
+	init_pid=$(__runc state test_busybox | jq '.pid')
+	kill -9 "$init_pid"
+	wait_pids_gone 10 0.2 "$init_pid"
+
+	runc state --repair test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"repaired: init process $init_pid is gone"* ]]
+	[[ "$output" == *"repaired: removed the leftover cgroup"* ]]
+	[ ! -d "$cgpath" ]
+	testcontainer test_busybox stopped
+	[ "$(__runc state test_busybox | jq '.pid')" -eq 0 ]
+
+	runc delete test_busybox
+	[ "$status" -eq 0 ]
+}
@@ -775,6 +775,19 @@ EOF
 	[ "$status" -eq 0 ]
 	check_cgroup_value "cpu.rt_period_us" 100000
 	check_cgroup_value "cpu.rt_runtime_us" 20000
+
+	# The runtime in percent of the period is kept when the period changes.
+	runc update test_update_rt --cpu-rt-percent 30
+	[ "$status" -eq 0 ]
+	check_cgroup_value "cpu.rt_runtime_us" 30000
+
+	runc update test_update_rt --cpu-rt-period 200000
+	[ "$status" -eq 0 ]
+	check_cgroup_value "cpu.rt_period_us" 200000
+	check_cgroup_value "cpu.rt_runtime_us" 60000
+
+	runc update test_update_rt --cpu-rt-percent 30 --cpu-rt-runtime 1000
+	[ "$status" -ne 0 ]
 }
 
 @test "update devices [minimal transition rules]" {
@@ -890,3 +903,20 @@ EOF
 	runc update test_update --memory 1024
 	wait_for_container 10 1 test_update stopped
 }
+
+@test "update --dry-run" {
+	[ $EUID -ne 0 ] && requires rootless_cgroup
+	requires cgroups_pids
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_update
+	[ "$status" -eq 0 ]
+
+	# The pids limit is 20 (see setup), so only it changes.
+	runc update --dry-run --pids-limit 10 --memory 33554432 test_update
+	[ "$status" -eq 0 ]
+	[[ "$output" == *'pids.max: "20" -> "10"'* ]]
+	[[ "$output" != *"memory"* ]]
+
+	# Nothing is changed.
+	check_cgroup_value "pids.max" 20
+}
@@ -1,15 +1,57 @@
 package types
 
 import (
+	"time"
+
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
 )
 
+// EventSchemaVersion is the version of the Event (and Stats) format. It is
+// incremented whenever fields are added or their meaning is changed, so
+// consumers can tell what to expect. Fields are never removed or renamed.
+const EventSchemaVersion = 10
+
 // Event struct for encoding the event data to json.
 type Event struct {
-	Type string      `json:"type"`
-	ID   string      `json:"id"`
-	Data interface{} `json:"data,omitempty"`
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// SchemaVersion is EventSchemaVersion. Events without it (or with 0)
+	// were produced by a runc version predating the versioning.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// Timestamp is the time the event was generated (with nanosecond
+	// precision).
+	Timestamp time.Time `json:"timestamp"`
+	// Monotonic is the time the event was generated, in nanoseconds,
+	// according to CLOCK_MONOTONIC. Unlike Timestamp, it is not affected
+	// by system time changes, so it can be used to calculate rates.
+	Monotonic int64 `json:"monotonic,omitempty"`
+	// Annotations are the user defined annotations of the container, which
+	// can be used to label the events.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Data        interface{}       `json:"data,omitempty"`
+}
+
+// ForkThrottle is the data of a "fork-throttle" event, sent whenever the
+// container is frozen by runc events --fork-rate-limit.
+type ForkThrottle struct {
+	// Rate is the growth rate of the number of tasks which triggered the
+	// freeze, in tasks per second.
+	Rate float64 `json:"rate"`
+	// Tasks is the number of tasks in the container.
+	Tasks uint64 `json:"tasks"`
+	// Frozen is how long the container was frozen for, in nanoseconds.
+	Frozen uint64 `json:"frozen"`
+}
+
+// BootstrapPhase is an entry of the data of a "bootstrap" event, sent once
+// by runc events, which has the timings of the phases of the container
+// bootstrap, in order.
+type BootstrapPhase struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	// Duration is how long the phase took, in nanoseconds.
+	Duration uint64 `json:"duration"`
 }
 
 // stats is the runc specific stats structure for stability when encoding and decoding stats.
@@ -22,6 +64,34 @@ type Stats struct {
 	Hugetlb           map[string]Hugetlb  `json:"hugetlb"`
 	IntelRdt          IntelRdt            `json:"intel_rdt"`
 	NetworkInterfaces []*NetworkInterface `json:"network_interfaces"`
+	// Rates is only set by runc events --rates.
+	Rates *Rates `json:"rates,omitempty"`
+	// Exec are the stats of the processes executed in the container, if
+	// accounted separately (see the exec-accounting annotation). They are
+	// included in the above stats.
+	Exec *Stats `json:"exec,omitempty"`
+}
+
+// Rates are the rates of change of the stats counters, calculated from two
+// consecutive samples.
+type Rates struct {
+	// Interval is the time between the samples, in nanoseconds.
+	Interval uint64   `json:"interval"`
+	CPU      CpuRates `json:"cpu"`
+}
+
+type CpuRates struct {
+	// Units: percent of a single CPU (i.e. can be more than 100).
+	Usage  float64 `json:"usage"`
+	Kernel float64 `json:"kernel"`
+	User   float64 `json:"user"`
+	// Units: periods per second.
+	ThrottledPeriods float64 `json:"throttledPeriods"`
+	// Units: nanoseconds per second.
+	ThrottledTime float64 `json:"throttledTime"`
+	// Realtime is the CPU time consumed by the container's real-time
+	// (SCHED_FIFO and SCHED_RR) threads. Units: nanoseconds per second.
+	Realtime float64 `json:"realtime"`
 }
 
 type PSIData = cgroups.PSIData
@@ -64,6 +134,12 @@ type Throttling struct {
 	ThrottledTime    uint64 `json:"throttledTime,omitempty"`
 }
 
+type Burst struct {
+	BurstsPeriods uint64 `json:"burstsPeriods,omitempty"`
+	// Units: nanoseconds.
+	BurstTime uint64 `json:"burstTime,omitempty"`
+}
+
 type CpuUsage struct {
 	// Units: nanoseconds.
 	Total        uint64   `json:"total,omitempty"`
@@ -74,10 +150,37 @@ type CpuUsage struct {
 	User         uint64   `json:"user"`
 }
 
+// CpuRealtime is the real-time bandwidth of the container's cgroup.
+type CpuRealtime struct {
+	// Units: microseconds. A runtime of -1 means no limit.
+	Runtime int64  `json:"runtime"`
+	Period  uint64 `json:"period"`
+}
+
+// CpuRealtimeHeadroom is the real-time bandwidth of a cgroup which is not
+// allocated to its children.
+type CpuRealtimeHeadroom struct {
+	Path string `json:"path"`
+	// Units: microseconds. A runtime of -1 means no limit.
+	Runtime int64  `json:"runtime"`
+	Period  uint64 `json:"period"`
+	// Allocated is the runtime of the children, scaled to Period.
+	Allocated int64 `json:"allocated"`
+	// Free is Runtime minus Allocated, or -1 if there is no limit.
+	Free int64 `json:"free"`
+}
+
 type Cpu struct {
-	Usage      CpuUsage   `json:"usage,omitempty"`
-	Throttling Throttling `json:"throttling,omitempty"`
-	PSI        *PSIStats  `json:"psi,omitempty"`
+	Usage      CpuUsage     `json:"usage,omitempty"`
+	Throttling Throttling   `json:"throttling,omitempty"`
+	Burst      Burst        `json:"burst,omitempty"`
+	PSI        *PSIStats    `json:"psi,omitempty"`
+	Realtime   *CpuRealtime `json:"realtime,omitempty"`
+	// RealtimeHeadroom is the real-time bandwidth left unallocated in the
+	// container's cgroup and in each of its ancestors, innermost first.
+	RealtimeHeadroom []CpuRealtimeHeadroom `json:"realtime_headroom,omitempty"`
+	// Idle is 1 if the container's cgroup is SCHED_IDLE, 0 if not.
+	Idle *int64 `json:"idle,omitempty"`
 }
 
 type CPUSet struct {
@@ -92,6 +195,8 @@ type CPUSet struct {
 	MemoryPressure        uint64   `json:"memory_pressure"`
 	SchedLoadBalance      uint64   `json:"sched_load_balance"`
 	SchedRelaxDomainLevel int64    `json:"sched_relax_domain_level"`
+	EffectiveCPUs         []uint16 `json:"effective_cpus,omitempty"`
+	EffectiveMems         []uint16 `json:"effective_mems,omitempty"`
 }
 
 type MemoryEntry struct {
@@ -109,6 +214,9 @@ type Memory struct {
 	KernelTCP MemoryEntry       `json:"kernelTCP,omitempty"`
 	Raw       map[string]uint64 `json:"raw,omitempty"`
 	PSI       *PSIStats         `json:"psi,omitempty"`
+	// NUMA is the usage of memory by NUMA node, in bytes, by
+	// memory.numa_stat item (cgroup v2 only).
+	NUMA map[string]map[uint8]uint64 `json:"numa,omitempty"`
 }
 
 type L3CacheInfo struct {
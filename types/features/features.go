@@ -19,6 +19,12 @@ const (
 	// Third party implementations such as crun and runsc MAY use this annotation.
 	AnnotationRuncCheckpointEnabled = "org.opencontainers.runc.checkpoint.enabled"
 
+	// AnnotationRuncResourcePolicies is the comma-separated list of the cgroup
+	// resource policies compiled in runc, which can be selected with the
+	// org.opencontainers.runc.cgroups.resource-policy annotation of the
+	// container config, e.g., "equal-split".
+	AnnotationRuncResourcePolicies = "org.opencontainers.runc.cgroups.resource-policies"
+
 	// AnnotationLibseccompVersion is the version of libseccomp, e.g., "2.5.1".
 	// Note that the runtime MAY support seccomp even when this annotation is not present.
 	AnnotationLibseccompVersion = "io.github.seccomp.libseccomp.version"
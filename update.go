@@ -90,6 +90,10 @@ other options are ignored.
 			Name:  "cpu-rt-runtime",
 			Usage: "CPU realtime hardcap limit (in usecs). Allowed cpu time in a given period",
 		},
+		cli.StringFlag{
+			Name:  "cpu-rt-percent",
+			Usage: "CPU realtime hardcap limit, in percent of the realtime period (e.g. 30 for 30% of each CPU), kept when the period is changed",
+		},
 		cli.StringFlag{
 			Name:  "cpuset-cpus",
 			Usage: "CPU(s) to use",
@@ -116,6 +120,10 @@ other options are ignored.
 			Name:  "cpu-idle",
 			Usage: "set cgroup SCHED_IDLE or not, 0: default behavior, 1: SCHED_IDLE",
 		},
+		cli.StringFlag{
+			Name:  "cpu-latency-nice",
+			Usage: "set cgroup latency nice value, from -20 (most latency sensitive) to 19",
+		},
 		cli.StringFlag{
 			Name:  "memory-reservation",
 			Usage: "Memory reservation or soft_limit (in bytes)",
@@ -136,6 +144,10 @@ other options are ignored.
 			Name:  "mem-bw-schema",
 			Usage: "The string of Intel RDT/MBA memory bandwidth schema",
 		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "show the cgroup file changes the update would make, without making them",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
@@ -158,6 +170,10 @@ other options are ignored.
 
 		config := container.Config()
 
+		var (
+			latencyNice *int64
+			rtPercent   float64
+		)
 		if in := context.String("resources"); in != "" {
 			var (
 				f   *os.File
@@ -194,6 +210,21 @@ other options are ignored.
 				}
 				r.CPU.Idle = i64Ptr(idle)
 			}
+			if val := context.String("cpu-rt-percent"); val != "" {
+				if context.IsSet("cpu-rt-runtime") {
+					return errors.New("--cpu-rt-percent can't be used with --cpu-rt-runtime")
+				}
+				if rtPercent, err = strconv.ParseFloat(val, 64); err != nil || rtPercent <= 0 {
+					return fmt.Errorf("invalid value for cpu-rt-percent: %s", val)
+				}
+			}
+			if val := context.String("cpu-latency-nice"); val != "" {
+				nice, err := strconv.ParseInt(val, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid value for cpu-latency-nice: %w", err)
+				}
+				latencyNice = i64Ptr(nice)
+			}
 
 			for _, pair := range []struct {
 				opt  string
@@ -321,6 +352,20 @@ other options are ignored.
 		}
 		if r.CPU.RealtimeRuntime != nil {
 			config.Cgroups.Resources.CpuRtRuntime = *r.CPU.RealtimeRuntime
+			config.Cgroups.Resources.CpuRtPercent = 0
+		}
+		if rtPercent != 0 {
+			config.Cgroups.Resources.CpuRtPercent = rtPercent
+		}
+		if config.Cgroups.Resources.CpuRtPercent != 0 {
+			// Also rescales the runtime to a new period.
+			state, err := container.State()
+			if err != nil {
+				return err
+			}
+			if err := cgroups.ResolveRtPercent(config.Cgroups.Resources, state.CgroupPaths["cpu"]); err != nil {
+				return err
+			}
 		}
 		config.Cgroups.Resources.CpusetCpus = r.CPU.Cpus
 		config.Cgroups.Resources.CpusetMems = r.CPU.Mems
@@ -328,6 +373,9 @@ other options are ignored.
 			config.Cgroups.Resources.Memory = *r.Memory.Limit
 		}
 		config.Cgroups.Resources.CPUIdle = r.CPU.Idle
+		if latencyNice != nil {
+			config.Cgroups.Resources.CpuLatencyNice = latencyNice
+		}
 		if r.Memory.Reservation != nil {
 			config.Cgroups.Resources.MemoryReservation = *r.Memory.Reservation
 		}
@@ -340,6 +388,17 @@ other options are ignored.
 		config.Cgroups.Resources.PidsLimit = r.Pids.Limit
 		config.Cgroups.Resources.Unified = r.Unified
 
+		if context.Bool("dry-run") {
+			changes, err := container.Diff(config)
+			if err != nil {
+				return err
+			}
+			for _, c := range changes {
+				fmt.Printf("%s: %q -> %q\n", c.Path, c.Old, c.New)
+			}
+			return nil
+		}
+
 		// Update Intel RDT
 		l3CacheSchema := context.String("l3-cache-schema")
 		memBwSchema := context.String("mem-bw-schema")
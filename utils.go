@@ -97,6 +97,26 @@ func revisePidFile(context *cli.Context) error {
 	return context.Set("pid-file", pidFile)
 }
 
+// getEnvSources returns the --env-file paths, converted to absolute paths
+// so that the files can be read after chdir to the bundle, and the
+// --env-fd file descriptors.
+func getEnvSources(context *cli.Context) (files []string, fds []int, _ error) {
+	for _, path := range context.StringSlice("env-file") {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, path)
+	}
+	for _, fd := range context.IntSlice("env-fd") {
+		if fd < 3 {
+			return nil, nil, fmt.Errorf("invalid --env-fd %d: must be greater than 2", fd)
+		}
+		fds = append(fds, fd)
+	}
+	return files, fds, nil
+}
+
 // reviseRootDir ensures that the --root option argument,
 // if specified, is converted to an absolute and cleaned path,
 // and that this path is sane.
@@ -3,10 +3,12 @@ package main
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/coreos/go-systemd/v22/activation"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -171,29 +173,201 @@ func createContainer(context *cli.Context, id string, spec *specs.Spec) (*libcon
 	if err != nil {
 		return nil, err
 	}
+	root := context.GlobalString("root")
+	if val, ok := spec.Annotations[usernsAutoAnnotation]; ok {
+		unlock, err := setupAutoUserns(root, id, spec, val)
+		if err != nil {
+			return nil, err
+		}
+		// Keep the allocation locked until the container is created.
+		defer unlock()
+	}
+	if val, ok := spec.Annotations[selinuxMCSAutoAnnotation]; ok {
+		unlock, err := setupAutoMCS(root, id, spec, val)
+		if err != nil {
+			return nil, err
+		}
+		// Keep the allocation locked until the container is created.
+		defer unlock()
+	}
 	config, err := specconv.CreateLibcontainerConfig(&specconv.CreateOpts{
-		CgroupName:       id,
-		UseSystemdCgroup: context.GlobalBool("systemd-cgroup"),
-		NoPivotRoot:      context.Bool("no-pivot"),
-		NoNewKeyring:     context.Bool("no-new-keyring"),
-		Spec:             spec,
-		RootlessEUID:     os.Geteuid() != 0,
-		RootlessCgroups:  rootlessCg,
+		CgroupName:          id,
+		UseSystemdCgroup:    context.GlobalBool("systemd-cgroup"),
+		NoPivotRoot:         context.Bool("no-pivot"),
+		NoNewKeyring:        context.Bool("no-new-keyring"),
+		AdjustRtBandwidth:   context.Bool("adjust-rt-bandwidth"),
+		ThawFrozenAncestors: context.Bool("thaw-frozen-ancestors"),
+		StartFrozen:         context.Bool("start-frozen"),
+		CgroupRoot:          context.String("cgroup-root"),
+		Spec:                spec,
+		RootlessEUID:        os.Geteuid() != 0,
+		RootlessCgroups:     rootlessCg,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	root := context.GlobalString("root")
 	return libcontainer.Create(root, id, config)
 }
 
+// usernsAutoAnnotation enables automatic allocation of the container user
+// namespace uid and gid ranges. Its value is either empty, or "true", or a
+// comma-separated list of size=N, user=NAME, subuid=PATH, and subgid=PATH
+// options (see [libcontainer.AutoUsernsOpts]).
+const usernsAutoAnnotation = "org.opencontainers.runc.userns.auto"
+
+// setupAutoUserns allocates the user namespace id ranges for the container,
+// and sets the spec uid and gid mappings accordingly. The returned unlock
+// function must be called once the container is created.
+func setupAutoUserns(root, id string, spec *specs.Spec, val string) (unlock func(), _ error) {
+	opts, err := parseAutoUsernsOpts(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", usernsAutoAnnotation, err)
+	}
+	if opts == nil {
+		return func() {}, nil
+	}
+	var hasUserns bool
+	if spec.Linux != nil {
+		for _, ns := range spec.Linux.Namespaces {
+			if ns.Type != specs.UserNamespace {
+				continue
+			}
+			if ns.Path != "" {
+				return nil, fmt.Errorf("%s can not be used when joining an existing user namespace", usernsAutoAnnotation)
+			}
+			hasUserns = true
+		}
+	}
+	if !hasUserns {
+		return nil, fmt.Errorf("%s requires a new user namespace", usernsAutoAnnotation)
+	}
+	if len(spec.Linux.UIDMappings) != 0 || len(spec.Linux.GIDMappings) != 0 {
+		return nil, fmt.Errorf("%s can not be used together with uid or gid mappings", usernsAutoAnnotation)
+	}
+
+	uidMap, gidMap, unlock, err := libcontainer.AllocateUserns(root, id, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range uidMap {
+		spec.Linux.UIDMappings = append(spec.Linux.UIDMappings, specs.LinuxIDMapping{
+			ContainerID: uint32(m.ContainerID), HostID: uint32(m.HostID), Size: uint32(m.Size),
+		})
+	}
+	for _, m := range gidMap {
+		spec.Linux.GIDMappings = append(spec.Linux.GIDMappings, specs.LinuxIDMapping{
+			ContainerID: uint32(m.ContainerID), HostID: uint32(m.HostID), Size: uint32(m.Size),
+		})
+	}
+	logrus.Debugf("allocated user namespace mappings: uid %+v, gid %+v", uidMap, gidMap)
+	return unlock, nil
+}
+
+// parseAutoUsernsOpts parses the value of usernsAutoAnnotation. It returns
+// nil if automatic allocation is disabled (i.e. the value is "false").
+func parseAutoUsernsOpts(val string) (*libcontainer.AutoUsernsOpts, error) {
+	opts := &libcontainer.AutoUsernsOpts{}
+	switch val {
+	case "", "true":
+		return opts, nil
+	case "false":
+		return nil, nil
+	}
+	for _, o := range strings.Split(val, ",") {
+		key, v, ok := strings.Cut(o, "=")
+		if !ok || v == "" {
+			return nil, fmt.Errorf("invalid option %q", o)
+		}
+		switch key {
+		case "size":
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || size <= 0 || size > math.MaxUint32 {
+				return nil, fmt.Errorf("invalid size %q", v)
+			}
+			opts.Size = size
+		case "user":
+			opts.User = v
+		case "subuid":
+			opts.SubUIDFile = v
+		case "subgid":
+			opts.SubGIDFile = v
+		default:
+			return nil, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return opts, nil
+}
+
+// selinuxMCSAutoAnnotation enables automatic allocation of a unique SELinux
+// MCS level for the container, which is set in its process and mount labels.
+// Its value is either empty, or "true", or categories=N (see
+// [libcontainer.AutoMCSOpts]).
+const selinuxMCSAutoAnnotation = "org.opencontainers.runc.selinux.mcs.auto"
+
+// setupAutoMCS allocates an MCS level for the container, and sets the spec
+// process and mount labels accordingly. The returned unlock function must be
+// called once the container is created.
+func setupAutoMCS(root, id string, spec *specs.Spec, val string) (unlock func(), _ error) {
+	opts, err := parseAutoMCSOpts(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", selinuxMCSAutoAnnotation, err)
+	}
+	if opts == nil {
+		return func() {}, nil
+	}
+	if !selinux.GetEnabled() {
+		logrus.Warnf("%s is ignored, as selinux is disabled or not supported", selinuxMCSAutoAnnotation)
+		return func() {}, nil
+	}
+	if spec.Process == nil || spec.Linux == nil {
+		return nil, fmt.Errorf("%s requires the process and linux sections", selinuxMCSAutoAnnotation)
+	}
+	for _, l := range []string{spec.Process.SelinuxLabel, spec.Linux.MountLabel} {
+		if strings.Count(l, ":") > 2 {
+			return nil, fmt.Errorf("%s can not be used together with the label level in %q", selinuxMCSAutoAnnotation, l)
+		}
+	}
+
+	processLabel, mountLabel, unlock, err := libcontainer.AllocateMCS(root, id, spec.Process.SelinuxLabel, spec.Linux.MountLabel, opts)
+	if err != nil {
+		return nil, err
+	}
+	spec.Process.SelinuxLabel = processLabel
+	spec.Linux.MountLabel = mountLabel
+	logrus.Debugf("allocated selinux labels: process %q, mount %q", processLabel, mountLabel)
+	return unlock, nil
+}
+
+// parseAutoMCSOpts parses the value of selinuxMCSAutoAnnotation. It returns
+// nil if automatic allocation is disabled (i.e. the value is "false").
+func parseAutoMCSOpts(val string) (*libcontainer.AutoMCSOpts, error) {
+	opts := &libcontainer.AutoMCSOpts{}
+	switch val {
+	case "", "true":
+		return opts, nil
+	case "false":
+		return nil, nil
+	}
+	key, v, ok := strings.Cut(val, "=")
+	if !ok || key != "categories" {
+		return nil, fmt.Errorf("invalid option %q", val)
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 2 {
+		return nil, fmt.Errorf("invalid number of categories %q", v)
+	}
+	opts.Categories = n
+	return opts, nil
+}
+
 type runner struct {
 	init            bool
 	enableSubreaper bool
 	shouldDestroy   bool
 	detach          bool
 	listenFDs       []*os.File
+	namedFDs        []*os.File
 	preserveFDs     int
 	pidFile         string
 	consoleSocket   string
@@ -203,6 +377,10 @@ type runner struct {
 	notifySocket    *notifySocket
 	criuOpts        *libcontainer.CriuOpts
 	subCgroupPaths  map[string]string
+	rtSubCgroup     bool
+	timerSlack      uint64
+	envFiles        []string
+	envFds          []int
 }
 
 func (r *runner) run(config *specs.Process) (int, error) {
@@ -223,11 +401,21 @@ func (r *runner) run(config *specs.Process) (int, error) {
 	// Populate the fields that come from runner.
 	process.Init = r.init
 	process.SubCgroupPaths = r.subCgroupPaths
-	if len(r.listenFDs) > 0 {
-		process.Env = append(process.Env, "LISTEN_FDS="+strconv.Itoa(len(r.listenFDs)), "LISTEN_PID=1")
+	process.RtSubCgroup = r.rtSubCgroup
+	process.TimerSlack = r.timerSlack
+	process.EnvFiles = r.envFiles
+	process.EnvFds = r.envFds
+	// The preserved fds follow the ones passed via LISTEN_FDS in runc's fd
+	// table, regardless of the named ones (which are specified explicitly).
+	baseFd := 3 + len(r.listenFDs)
+	if len(r.listenFDs)+len(r.namedFDs) > 0 {
 		process.ExtraFiles = append(process.ExtraFiles, r.listenFDs...)
+		process.ExtraFiles = append(process.ExtraFiles, r.namedFDs...)
+		process.Env = append(process.Env, "LISTEN_FDS="+strconv.Itoa(len(process.ExtraFiles)), "LISTEN_PID=1")
+		if names := listenFDNames(process.ExtraFiles, len(r.namedFDs) > 0); names != "" {
+			process.Env = append(process.Env, "LISTEN_FDNAMES="+names)
+		}
 	}
-	baseFd := 3 + len(process.ExtraFiles)
 	procSelfFd, closer := utils.ProcThreadSelf("fd/")
 	defer closer()
 	for i := baseFd; i < baseFd+r.preserveFDs; i++ {
@@ -271,6 +459,8 @@ func (r *runner) run(config *specs.Process) (int, error) {
 		err = r.container.Restore(process, r.criuOpts)
 	case CT_ACT_RUN:
 		err = r.container.Run(process)
+	case CT_ACT_RESTART:
+		err = r.container.Restart(process)
 	default:
 		panic("Unknown action")
 	}
@@ -351,21 +541,75 @@ const (
 	CT_ACT_CREATE CtAct = iota + 1
 	CT_ACT_RUN
 	CT_ACT_RESTORE
+	CT_ACT_RESTART
 )
 
+// memoryStateRoot is the private temporary root directory used with the
+// memory state backend, if any (see setupMemoryStateBackend).
+var memoryStateRoot string
+
+// setupMemoryStateBackend checks that the command can be used with the
+// memory state backend, with which the containers are only known to the
+// runc process which runs them, and sets it up. Unless --root is set, a
+// private temporary root directory is used, which is removed once the
+// container is gone.
+func setupMemoryStateBackend(context *cli.Context) error {
+	switch cmd := context.Args().First(); cmd {
+	case "run":
+	case "", "help", "h", "spec", "features":
+		return nil
+	default:
+		return fmt.Errorf("runc %s can not be used with the %q state backend, as the containers are only known to the runc run process", cmd, libcontainer.StateBackendMemory)
+	}
+	if !context.IsSet("root") {
+		root, err := os.MkdirTemp("", "runc-")
+		if err != nil {
+			return err
+		}
+		if err := context.GlobalSet("root", root); err != nil {
+			os.Remove(root)
+			return err
+		}
+		memoryStateRoot = root
+	}
+	return libcontainer.InitStateBackend(context.GlobalString("root"), libcontainer.StateBackendMemory)
+}
+
 func startContainer(context *cli.Context, action CtAct, criuOpts *libcontainer.CriuOpts) (int, error) {
+	if context.GlobalString("state-backend") == libcontainer.StateBackendMemory {
+		if memoryStateRoot != "" {
+			defer os.RemoveAll(memoryStateRoot)
+		}
+		// The container would outlive its state.
+		if context.Bool("detach") || context.Bool("keep") {
+			return -1, fmt.Errorf("--detach and --keep can not be used with the %q state backend", libcontainer.StateBackendMemory)
+		}
+	}
 	if err := revisePidFile(context); err != nil {
 		return -1, err
 	}
+	envFiles, envFds, err := getEnvSources(context)
+	if err != nil {
+		return -1, err
+	}
 	spec, err := setupSpec(context)
 	if err != nil {
 		return -1, err
 	}
+	if criuOpts != nil {
+		if err := setCriuAnnotationDefaults(context, criuOpts, spec.Annotations); err != nil {
+			return -1, err
+		}
+	}
 
 	id := context.Args().First()
 	if id == "" {
 		return -1, errEmptyID
 	}
+	namedFDs, err := parseNamedFDs(context.StringSlice("listen-fd"))
+	if err != nil {
+		return -1, err
+	}
 
 	notifySocket := newNotifySocket(context, os.Getenv("NOTIFY_SOCKET"), id)
 	if notifySocket != nil {
@@ -399,6 +643,7 @@ func startContainer(context *cli.Context, action CtAct, criuOpts *libcontainer.C
 		shouldDestroy:   !context.Bool("keep"),
 		container:       container,
 		listenFDs:       listenFDs,
+		namedFDs:        namedFDs,
 		notifySocket:    notifySocket,
 		consoleSocket:   context.String("console-socket"),
 		pidfdSocket:     context.String("pidfd-socket"),
@@ -408,10 +653,62 @@ func startContainer(context *cli.Context, action CtAct, criuOpts *libcontainer.C
 		action:          action,
 		criuOpts:        criuOpts,
 		init:            true,
+		envFiles:        envFiles,
+		envFds:          envFds,
 	}
 	return r.run(spec.Process)
 }
 
+// parseNamedFDs parses the --listen-fd NAME=FD arguments, returning the
+// files for the given fds, named NAME.
+func parseNamedFDs(args []string) ([]*os.File, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	procSelfFd, closer := utils.ProcThreadSelf("fd/")
+	defer closer()
+	files := make([]*os.File, 0, len(args))
+	for _, arg := range args {
+		name, fdStr, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --listen-fd %q: must be NAME=FD", arg)
+		}
+		// The names are passed as a colon-separated list,
+		// limited to the same characters as systemd's FileDescriptorName=.
+		if len(name) > 255 || strings.ContainsFunc(name, func(r rune) bool {
+			return r == ':' || r < 0x20 || r > 0x7e
+		}) {
+			return nil, fmt.Errorf("invalid --listen-fd name %q", name)
+		}
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil || fd < 3 {
+			return nil, fmt.Errorf("invalid --listen-fd %q: FD must be a number greater than 2", arg)
+		}
+		if _, err := os.Stat(filepath.Join(procSelfFd, fdStr)); err != nil {
+			return nil, fmt.Errorf("unable to stat listen-fd %s: %w", arg, err)
+		}
+		files = append(files, os.NewFile(uintptr(fd), name))
+	}
+	return files, nil
+}
+
+// listenFDNames returns the value of LISTEN_FDNAMES for the files passed to
+// the container, or an empty string if no names are known. Files inherited
+// without a name get systemd's default name of "unknown".
+func listenFDNames(files []*os.File, named bool) string {
+	if !named && os.Getenv("LISTEN_FDNAMES") == "" {
+		return ""
+	}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name()
+		if strings.HasPrefix(names[i], "LISTEN_FD_") {
+			names[i] = "unknown"
+		}
+	}
+	return strings.Join(names, ":")
+}
+
 func setupPidfdSocket(process *libcontainer.Process, sockpath string) (_clean func(), _ error) {
 	linux530 := kernelversion.KernelVersion{Kernel: 5, Major: 3}
 	ok, err := kernelversion.GreaterEqualThan(linux530)